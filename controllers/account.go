@@ -42,6 +42,9 @@ type Response struct {
 	Name   string      `json:"name"`
 	Data   interface{} `json:"data"`
 	Data2  interface{} `json:"data2"`
+
+	// Impersonation marks the account signed in by an admin impersonating the user
+	Impersonation *object.Impersonation `json:"impersonation,omitempty"`
 }
 
 type Captcha struct {
@@ -79,7 +82,25 @@ func (c *ApiController) Signup() {
 	}
 
 	application := object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
-	if !application.EnableSignUp {
+
+	// an invitation allows to sign up even if the application doesn't, with its email and phone locked
+	var invitation *object.Invitation
+	if authForm.InvitationCode != "" {
+		var msg string
+		invitation, msg = object.CheckInvitationCode(application, authForm.InvitationCode, c.GetAcceptLanguage())
+		if msg != "" {
+			c.ResponseError(msg)
+			return
+		}
+
+		if invitation.Email != "" {
+			authForm.Email = invitation.Email
+		}
+		if invitation.Phone != "" {
+			authForm.Phone = invitation.Phone
+			authForm.CountryCode = invitation.CountryCode
+		}
+	} else if !application.EnableSignUp {
 		c.ResponseError(c.T("account:The application does not allow to sign up new account"))
 		return
 	}
@@ -91,7 +112,11 @@ func (c *ApiController) Signup() {
 		return
 	}
 
-	if application.IsSignupItemVisible("Email") && application.GetSignupItemRule("Email") != "No verification" && authForm.Email != "" {
+	// the invitation link or code has been sent to its email or phone, which needs no more verification
+	isEmailInvited := invitation != nil && invitation.Email != ""
+	isPhoneInvited := invitation != nil && invitation.Phone != ""
+
+	if application.IsSignupItemVisible("Email") && application.GetSignupItemRule("Email") != "No verification" && authForm.Email != "" && !isEmailInvited {
 		checkResult := object.CheckVerificationCode(authForm.Email, authForm.EmailCode, c.GetAcceptLanguage())
 		if checkResult.Code != object.VerificationSuccess {
 			c.ResponseError(checkResult.Msg)
@@ -100,7 +125,7 @@ func (c *ApiController) Signup() {
 	}
 
 	var checkPhone string
-	if application.IsSignupItemVisible("Phone") && application.GetSignupItemRule("Phone") != "No verification" && authForm.Phone != "" {
+	if application.IsSignupItemVisible("Phone") && application.GetSignupItemRule("Phone") != "No verification" && authForm.Phone != "" && !isPhoneInvited {
 		checkPhone, _ = util.GetE164Number(authForm.Phone, authForm.CountryCode)
 		checkResult := object.CheckVerificationCode(checkPhone, authForm.PhoneCode, c.GetAcceptLanguage())
 		if checkResult.Code != object.VerificationSuccess {
@@ -173,12 +198,42 @@ func (c *ApiController) Signup() {
 		}
 	}
 
+	for _, attribute := range organization.UserAttributes {
+		accountItem := object.GetAccountItemByName(attribute.Name, organization)
+		if accountItem != nil && accountItem.ModifyRule == "Admin" {
+			continue
+		}
+		if value, ok := authForm.Properties[attribute.Name]; ok {
+			user.Properties[attribute.Name] = value
+		}
+	}
+
+	if msg := object.CheckUserAttributes(organization, user, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	if invitation != nil {
+		user.Groups = object.GetInvitationGroups(invitation)
+		if !object.ReserveInvitation(invitation) {
+			c.ResponseError(c.T("invitation:The invitation has reached its usage limit"))
+			return
+		}
+	}
+
 	affected := object.AddUser(user)
 	if !affected {
+		if invitation != nil {
+			object.ReleaseInvitation(invitation)
+		}
 		c.ResponseError(c.T("account:Failed to add user"), util.StructToJson(user))
 		return
 	}
 
+	if invitation != nil {
+		object.AddInvitationUser(invitation, user)
+	}
+
 	object.AddUserToOriginalDatabase(user)
 
 	if application.HasPromptPage() {
@@ -313,6 +368,7 @@ func (c *ApiController) GetAccount() {
 		Data:   object.GetMaskedUser(user),
 		Data2:  organization,
 	}
+	resp.Impersonation = c.getSessionImpersonation(user.GetId())
 	c.Data["json"] = resp
 	c.ServeJSON()
 }
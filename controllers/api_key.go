@@ -0,0 +1,185 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/casdoor/casdoor/object"
+)
+
+// GetApiKeys
+// @Title GetApiKeys
+// @Tag API Key API
+// @Description get the API keys of the service accounts of an organization, allowed for the admins
+// @Param   owner     query    string  true        "The owner of the API keys"
+// @Param   user      query    string  false       "The name of the service account"
+// @Success 200 {array} object.ApiKey The Response object
+// @router /get-api-keys [get]
+func (c *ApiController) GetApiKeys() {
+	owner := c.Input().Get("owner")
+	user := c.Input().Get("user")
+	if !c.RequireOrganizationAdmin(owner) {
+		return
+	}
+
+	c.ResponseOk(object.GetApiKeys(owner, user))
+}
+
+// GetApiKey
+// @Title GetApiKey
+// @Tag API Key API
+// @Description get an API key, allowed for the admins
+// @Param   id     query    string  true        "The id ( owner/name ) of the API key"
+// @Success 200 {object} object.ApiKey The Response object
+// @router /get-api-key [get]
+func (c *ApiController) GetApiKey() {
+	id := c.Input().Get("id")
+
+	apiKey := object.GetApiKey(id)
+	if apiKey != nil && !c.RequireOrganizationAdmin(apiKey.Owner) {
+		return
+	}
+
+	c.ResponseOk(apiKey)
+}
+
+// AddApiKey
+// @Title AddApiKey
+// @Tag API Key API
+// @Description add an API key for a service account, the key is only returned in this response, allowed for the admins
+// @Param   body    body   object.ApiKey  true        "The details of the API key"
+// @Success 200 {object} object.ApiKey The Response object
+// @router /add-api-key [post]
+func (c *ApiController) AddApiKey() {
+	var apiKey object.ApiKey
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &apiKey)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if !c.RequireOrganizationAdmin(apiKey.Owner) {
+		return
+	}
+
+	res, err := object.AddApiKey(&apiKey, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(res)
+}
+
+// UpdateApiKey
+// @Title UpdateApiKey
+// @Tag API Key API
+// @Description update the display name and the expire time of an API key, allowed for the admins
+// @Param   id     query    string  true        "The id ( owner/name ) of the API key"
+// @Param   body    body   object.ApiKey  true        "The details of the API key"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-api-key [post]
+func (c *ApiController) UpdateApiKey() {
+	id := c.Input().Get("id")
+
+	var apiKey object.ApiKey
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &apiKey)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	// the API key is updated by the id in the query, so it's the one whose owner is checked
+	oldApiKey := object.GetApiKey(id)
+	if oldApiKey == nil {
+		c.ResponseError(fmt.Sprintf(c.T("apiKey:The API key: %s does not exist"), id))
+		return
+	}
+	if !c.RequireOrganizationAdmin(oldApiKey.Owner) {
+		return
+	}
+
+	affected, err := object.UpdateApiKey(id, &apiKey, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
+	c.ServeJSON()
+}
+
+// RotateApiKey
+// @Title RotateApiKey
+// @Tag API Key API
+// @Description replace the key of an API key, the new key is only returned in this response, allowed for the admins
+// @Param   body    body   object.ApiKey  true        "The API key to rotate"
+// @Success 200 {object} object.ApiKey The Response object
+// @router /rotate-api-key [post]
+func (c *ApiController) RotateApiKey() {
+	var form object.ApiKey
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	apiKey := object.GetApiKey(form.GetId())
+	if apiKey == nil {
+		c.ResponseError(fmt.Sprintf(c.T("apiKey:The API key: %s does not exist"), form.GetId()))
+		return
+	}
+	if !c.RequireOrganizationAdmin(apiKey.Owner) {
+		return
+	}
+
+	res, err := object.RotateApiKey(apiKey)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(res)
+}
+
+// DeleteApiKey
+// @Title DeleteApiKey
+// @Tag API Key API
+// @Description delete an API key, allowed for the admins
+// @Param   body    body   object.ApiKey  true        "The API key to delete"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-api-key [post]
+func (c *ApiController) DeleteApiKey() {
+	var form object.ApiKey
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	apiKey := object.GetApiKey(form.GetId())
+	if apiKey == nil {
+		c.ResponseError(fmt.Sprintf(c.T("apiKey:The API key: %s does not exist"), form.GetId()))
+		return
+	}
+	if !c.RequireOrganizationAdmin(apiKey.Owner) {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteApiKey(apiKey))
+	c.ServeJSON()
+}
@@ -59,6 +59,11 @@ func tokenToResponse(token *object.Token) *Response {
 func (c *ApiController) HandleLoggedIn(application *object.Application, user *object.User, form *form.AuthForm) (resp *Response) {
 	userId := user.GetId()
 
+	if user.IsServiceAccount() {
+		c.ResponseError(c.T("check:Service accounts can only authenticate with API keys"))
+		return
+	}
+
 	allowed, err := object.CheckAccessPermission(userId, application)
 	if err != nil {
 		c.ResponseError(err.Error(), nil)
@@ -93,7 +98,7 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 			c.ResponseError(c.T("auth:Challenge method should be S256"))
 			return
 		}
-		code := object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, c.Ctx.Request.Host, c.GetAcceptLanguage())
+		code := object.GetOAuthCode(userId, c.getSessionImpersonation(userId), clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, c.Ctx.Request.Host, c.GetAcceptLanguage())
 		resp = codeToResponse(code)
 
 		if application.EnableSigninSession || application.HasPromptPage() {
@@ -105,7 +110,7 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 			resp = &Response{Status: "error", Msg: fmt.Sprintf("error: grant_type: %s is not supported in this application", form.Type), Data: ""}
 		} else {
 			scope := c.Input().Get("scope")
-			token, _ := object.GetTokenByUser(application, user, scope, c.Ctx.Request.Host)
+			token, _ := object.GetTokenByUser(application, user, c.getSessionImpersonation(userId), scope, c.Ctx.Request.Host)
 			resp = tokenToResponse(token)
 		}
 	} else if form.Type == ResponseTypeSaml { // saml flow
@@ -247,6 +252,7 @@ func (c *ApiController) Login() {
 		var user *object.User
 		var msg string
 
+		clientIp := util.GetIPFromRequest(c.Ctx.Request)
 		if authForm.Password == "" {
 			if user = object.GetUserByFields(authForm.Organization, authForm.Username); user == nil {
 				c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), util.GetId(authForm.Organization, authForm.Username)))
@@ -265,7 +271,8 @@ func (c *ApiController) Login() {
 			}
 
 			// check result through Email or Phone
-			checkResult := object.CheckSigninCode(user, checkDest, authForm.Code, c.GetAcceptLanguage())
+			attempt := &object.SigninAttempt{Application: object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application)), ClientIp: clientIp}
+			checkResult := object.CheckSigninCode(user, checkDest, authForm.Code, c.GetAcceptLanguage(), attempt)
 			if len(checkResult) != 0 {
 				c.ResponseError(fmt.Sprintf("%s - %s", verificationCodeType, checkResult))
 				return
@@ -284,7 +291,7 @@ func (c *ApiController) Login() {
 				return
 			}
 			var enableCaptcha bool
-			if enableCaptcha = object.CheckToEnableCaptcha(application, authForm.Organization, authForm.Username); enableCaptcha {
+			if enableCaptcha = object.CheckToEnableCaptcha(application, authForm.Organization, authForm.Username, clientIp); enableCaptcha {
 				isHuman, err := captcha.VerifyCaptchaByCaptchaType(authForm.CaptchaType, authForm.CaptchaToken, authForm.ClientSecret)
 				if err != nil {
 					c.ResponseError(err.Error())
@@ -298,11 +305,16 @@ func (c *ApiController) Login() {
 			}
 
 			password := authForm.Password
-			user, msg = object.CheckUserPassword(authForm.Organization, authForm.Username, password, c.GetAcceptLanguage(), enableCaptcha)
+			attempt := &object.SigninAttempt{Application: application, ClientIp: clientIp}
+			user, msg = object.CheckUserPassword(authForm.Organization, authForm.Username, password, c.GetAcceptLanguage(), attempt, enableCaptcha)
 		}
 
 		if msg != "" {
 			resp = &Response{Status: "error", Msg: msg}
+		} else if authForm.Password != "" && object.IsPasswordExpired(user) {
+			// the user isn't signed in, but may change the expired password and then sign in with the new one
+			c.SetSession(object.PasswordChangeSessionUserId, user.GetId())
+			resp = &Response{Status: object.RequiredPasswordChange, Msg: object.CheckPasswordExpired(user, c.GetAcceptLanguage()), Data: user.GetId()}
 		} else {
 			application := object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
 			if application == nil {
@@ -453,7 +465,7 @@ func (c *ApiController) Login() {
 					}
 
 					properties := map[string]string{}
-					properties["no"] = strconv.Itoa(object.GetUserCount(application.Organization, "", "") + 2)
+					properties["no"] = strconv.Itoa(object.GetUserCount(application.Organization, "", "", nil) + 2)
 					initScore, err := organization.GetInitScore()
 					if err != nil {
 						c.ResponseError(fmt.Errorf(c.T("account:Get init score failed, error: %w"), err).Error())
@@ -672,8 +684,10 @@ func (c *ApiController) GetCaptchaStatus() {
 	userId := c.Input().Get("user_id")
 	user := object.GetUserByFields(organization, userId)
 	var captchaEnabled bool
-	if user != nil && user.SigninWrongTimes >= object.SigninWrongTimesLimit {
-		captchaEnabled = true
+	if user != nil {
+		application := object.GetApplication(fmt.Sprintf("admin/%s", c.Input().Get("application")))
+		attempt := &object.SigninAttempt{Application: application, ClientIp: util.GetIPFromRequest(c.Ctx.Request)}
+		captchaEnabled = object.IsSigninCaptchaNeeded(user, attempt)
 	}
 	c.ResponseOk(captchaEnabled)
 }
@@ -83,6 +83,11 @@ func (c *ApiController) getCurrentUser() *object.User {
 
 // GetSessionUsername ...
 func (c *ApiController) GetSessionUsername() string {
+	// the service account authenticated by its API key is signed in for the request only
+	if user, ok := c.Ctx.Input.GetData(object.ApiKeyUsername).(string); ok && user != "" {
+		return user
+	}
+
 	// check if user session expired
 	sessionData := c.GetSessionData()
 
@@ -98,7 +103,7 @@ func (c *ApiController) GetSessionUsername() string {
 		return ""
 	}
 
-	return user.(string)
+	return c.checkSessionImpersonation(user.(string))
 }
 
 func (c *ApiController) GetSessionApplication() *object.Application {
@@ -111,6 +116,7 @@ func (c *ApiController) GetSessionApplication() *object.Application {
 }
 
 func (c *ApiController) ClearUserSession() {
+	c.endSessionImpersonation()
 	c.SetSessionUsername("")
 	c.SetSessionData(nil)
 }
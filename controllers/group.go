@@ -0,0 +1,204 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetGroups
+// @Title GetGroups
+// @Tag Group API
+// @Description get groups
+// @Param   owner     query    string  true        "The owner of groups"
+// @Success 200 {array} object.Group The Response object
+// @router /get-groups [get]
+func (c *ApiController) GetGroups() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetGroups(owner)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetGroupCount(owner, field, value)))
+		groups := object.GetPaginationGroups(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		c.ResponseOk(groups, paginator.Nums())
+	}
+}
+
+// GetGroup
+// @Title GetGroup
+// @Tag Group API
+// @Description get group
+// @Param   id     query    string  true        "The id ( owner/name ) of the group"
+// @Success 200 {object} object.Group The Response object
+// @router /get-group [get]
+func (c *ApiController) GetGroup() {
+	id := c.Input().Get("id")
+
+	c.Data["json"] = object.GetGroup(id)
+	c.ServeJSON()
+}
+
+// UpdateGroup
+// @Title UpdateGroup
+// @Tag Group API
+// @Description update group
+// @Param   id     query    string  true        "The id ( owner/name ) of the group"
+// @Param   body    body   object.Group  true        "The details of the group"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-group [post]
+func (c *ApiController) UpdateGroup() {
+	id := c.Input().Get("id")
+
+	var group object.Group
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &group)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	_, name := util.GetOwnerAndNameFromId(id)
+	if msg := object.CheckGroup(name, &group, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateGroup(id, &group))
+	c.ServeJSON()
+}
+
+// AddGroup
+// @Title AddGroup
+// @Tag Group API
+// @Description add group
+// @Param   body    body   object.Group  true        "The details of the group"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-group [post]
+func (c *ApiController) AddGroup() {
+	var group object.Group
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &group)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if msg := object.CheckGroup(group.Name, &group, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddGroup(&group))
+	c.ServeJSON()
+}
+
+// DeleteGroup
+// @Title DeleteGroup
+// @Tag Group API
+// @Description delete group
+// @Param   body    body   object.Group  true        "The details of the group"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-group [post]
+func (c *ApiController) DeleteGroup() {
+	var group object.Group
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &group)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteGroup(&group))
+	c.ServeJSON()
+}
+
+// checkGroupAdmin checks that the current user is an admin of the organization of the group,
+// or a group admin of the group or one of its ancestors
+func (c *ApiController) checkGroupAdmin(groupId string) bool {
+	group := object.GetGroup(groupId)
+	if group == nil {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return false
+	}
+
+	if c.IsGlobalAdmin() {
+		return true
+	}
+
+	user := c.getCurrentUser()
+	if user != nil && (user.IsAdmin && user.Owner == group.Owner || object.IsGroupAdmin(groupId, user.GetId())) {
+		return true
+	}
+
+	c.ResponseError(c.T("auth:Unauthorized operation"))
+	return false
+}
+
+// AddGroupUser
+// @Title AddGroupUser
+// @Tag Group API
+// @Description add a user to the group, allowed for the group admins
+// @Param   id     formData    string  true        "The id ( owner/name ) of the group"
+// @Param   userId formData    string  true        "The id ( owner/name ) of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-group-user [post]
+func (c *ApiController) AddGroupUser() {
+	id := c.Ctx.Request.Form.Get("id")
+	userId := c.Ctx.Request.Form.Get("userId")
+	if id == "" || userId == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	if !c.checkGroupAdmin(id) {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddGroupUser(id, userId))
+	c.ServeJSON()
+}
+
+// RemoveGroupUser
+// @Title RemoveGroupUser
+// @Tag Group API
+// @Description remove a user from the group, allowed for the group admins
+// @Param   id     formData    string  true        "The id ( owner/name ) of the group"
+// @Param   userId formData    string  true        "The id ( owner/name ) of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /remove-group-user [post]
+func (c *ApiController) RemoveGroupUser() {
+	id := c.Ctx.Request.Form.Get("id")
+	userId := c.Ctx.Request.Form.Get("userId")
+	if id == "" || userId == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	if !c.checkGroupAdmin(id) {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RemoveGroupUser(id, userId))
+	c.ServeJSON()
+}
@@ -0,0 +1,212 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// getSessionImpersonation returns the active impersonation of the user signed in by the session, if any
+func (c *ApiController) getSessionImpersonation(userId string) *object.Impersonation {
+	impersonationId, ok := c.GetSession(object.ImpersonationSessionId).(string)
+	if !ok {
+		return nil
+	}
+
+	impersonation := object.GetImpersonation(impersonationId)
+	if impersonation == nil || !impersonation.IsActive() || impersonation.GetUserId() != userId {
+		return nil
+	}
+	return impersonation
+}
+
+func (c *ApiController) clearSessionImpersonation() {
+	c.DelSession(object.ImpersonationSessionId)
+	c.DelSession(object.ImpersonatorSessionUserId)
+}
+
+// checkSessionImpersonation signs the admin back in when the impersonation of the session user has ended or expired
+func (c *ApiController) checkSessionImpersonation(username string) string {
+	impersonationId, ok := c.GetSession(object.ImpersonationSessionId).(string)
+	if !ok {
+		return username
+	}
+
+	impersonation := object.GetImpersonation(impersonationId)
+	if impersonation != nil && impersonation.IsActive() && impersonation.GetUserId() == username {
+		return username
+	}
+
+	impersonator, _ := c.GetSession(object.ImpersonatorSessionUserId).(string)
+	c.clearSessionImpersonation()
+	if impersonation == nil || impersonation.GetUserId() != username {
+		return username
+	}
+
+	c.SetSessionUsername(impersonator)
+	return impersonator
+}
+
+// endSessionImpersonation ends the impersonation of the session when the impersonated user signs out
+func (c *ApiController) endSessionImpersonation() {
+	impersonationId, ok := c.GetSession(object.ImpersonationSessionId).(string)
+	if !ok {
+		return
+	}
+
+	impersonator, _ := c.GetSession(object.ImpersonatorSessionUserId).(string)
+	if impersonation := object.GetImpersonation(impersonationId); impersonation != nil {
+		object.EndImpersonation(impersonation, impersonator, util.GetIPFromRequest(c.Ctx.Request))
+	}
+	c.clearSessionImpersonation()
+}
+
+// StartImpersonation
+// @Title StartImpersonation
+// @Tag Impersonation API
+// @Description sign in as the user on behalf of the admin, until the impersonation is stopped or expires
+// @Param   reason  query    string  false       "The reason of the impersonation"
+// @Param   minutes query    string  false       "The duration of the impersonation in minutes, limited by the policy of the organization"
+// @Param   body    body   object.User  true        "The user to impersonate"
+// @Success 200 {object} object.Impersonation The Response object
+// @router /start-impersonation [post]
+func (c *ApiController) StartImpersonation() {
+	impersonator, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	if _, ok = c.GetSession(object.ImpersonationSessionId).(string); ok {
+		c.ResponseError(c.T("impersonation:Please stop the current impersonation first"))
+		return
+	}
+
+	var form object.User
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	user := object.GetUser(form.GetId())
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), form.GetId()))
+		return
+	}
+
+	minutes := 0
+	if value := c.Input().Get("minutes"); value != "" {
+		minutes, err = strconv.Atoi(value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+	}
+
+	reason := c.Input().Get("reason")
+	impersonation, err := object.StartImpersonation(impersonator, user, reason, minutes, util.GetIPFromRequest(c.Ctx.Request), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.SetSession(object.ImpersonationSessionId, impersonation.GetId())
+	c.SetSession(object.ImpersonatorSessionUserId, impersonator.GetId())
+	c.SetSessionUsername(user.GetId())
+	util.LogInfo(c.Ctx, "API: [%s] started impersonating [%s]", impersonator.GetId(), user.GetId())
+
+	c.ResponseOk(impersonation)
+}
+
+// StopImpersonation
+// @Title StopImpersonation
+// @Tag Impersonation API
+// @Description stop the impersonation of the session and sign the admin back in
+// @Success 200 {object} controllers.Response The Response object
+// @router /stop-impersonation [post]
+func (c *ApiController) StopImpersonation() {
+	username := c.GetSessionUsername()
+	impersonation := c.getSessionImpersonation(username)
+	if impersonation == nil {
+		c.ResponseError(c.T("impersonation:You are not impersonating any user"))
+		return
+	}
+
+	impersonator := impersonation.Impersonator
+	object.EndImpersonation(impersonation, impersonator, util.GetIPFromRequest(c.Ctx.Request))
+	c.clearSessionImpersonation()
+	c.SetSessionUsername(impersonator)
+	util.LogInfo(c.Ctx, "API: [%s] stopped impersonating [%s]", impersonator, username)
+
+	c.ResponseOk(impersonator)
+}
+
+// EndImpersonation
+// @Title EndImpersonation
+// @Tag Impersonation API
+// @Description terminate an impersonation, revoking the tokens minted during it
+// @Param   body    body   object.Impersonation  true        "The impersonation to terminate"
+// @Success 200 {object} controllers.Response The Response object
+// @router /end-impersonation [post]
+func (c *ApiController) EndImpersonation() {
+	var form object.Impersonation
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	impersonation := object.GetImpersonation(form.GetId())
+	if impersonation == nil {
+		c.ResponseError(fmt.Sprintf(c.T("impersonation:The impersonation: %s does not exist"), form.GetId()))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.EndImpersonation(impersonation, c.GetSessionUsername(), util.GetIPFromRequest(c.Ctx.Request)))
+	c.ServeJSON()
+}
+
+// GetImpersonations
+// @Title GetImpersonations
+// @Tag Impersonation API
+// @Description get the impersonations of the users of an organization
+// @Param   owner     query    string  true        "The owner of the impersonations"
+// @Param   user      query    string  false       "The name of the impersonated user"
+// @Success 200 {array} object.Impersonation The Response object
+// @router /get-impersonations [get]
+func (c *ApiController) GetImpersonations() {
+	owner := c.Input().Get("owner")
+	user := c.Input().Get("user")
+
+	c.ResponseOk(object.GetImpersonations(owner, user))
+}
+
+// GetImpersonation
+// @Title GetImpersonation
+// @Tag Impersonation API
+// @Description get an impersonation
+// @Param   id     query    string  true        "The id ( owner/name ) of the impersonation"
+// @Success 200 {object} object.Impersonation The Response object
+// @router /get-impersonation [get]
+func (c *ApiController) GetImpersonation() {
+	id := c.Input().Get("id")
+
+	c.ResponseOk(object.GetImpersonation(id))
+}
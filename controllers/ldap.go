@@ -22,19 +22,16 @@ import (
 )
 
 type LdapResp struct {
-	// Groups []LdapRespGroup `json:"groups"`
-	Users      []object.LdapUser `json:"users"`
-	ExistUuids []string          `json:"existUuids"`
+	Groups     []object.LdapGroup `json:"groups"`
+	Users      []object.LdapUser  `json:"users"`
+	ExistUuids []string           `json:"existUuids"`
 }
 
-//type LdapRespGroup struct {
-//	GroupId   string
-//	GroupName string
-//}
-
 type LdapSyncResp struct {
-	Exist  []object.LdapUser `json:"exist"`
-	Failed []object.LdapUser `json:"failed"`
+	Exist        []object.LdapUser `json:"exist"`
+	Failed       []object.LdapUser `json:"failed"`
+	AddedRoles   int               `json:"addedRoles"`
+	UpdatedRoles int               `json:"updatedRoles"`
 }
 
 // GetLdapUsers
@@ -53,18 +50,14 @@ func (c *ApiController) GetLdapUsers() {
 		return
 	}
 
-	//groupsMap, err := conn.GetLdapGroups(ldapServer.BaseDn)
-	//if err != nil {
-	//  c.ResponseError(err.Error())
-	//	return
-	//}
-
-	//for _, group := range groupsMap {
-	//	resp.Groups = append(resp.Groups, LdapRespGroup{
-	//		GroupId:   group.GidNumber,
-	//		GroupName: group.Cn,
-	//	})
-	//}
+	groups := []object.LdapGroup{}
+	if ldapServer.EnableGroupSync {
+		groups, err = conn.GetLdapGroups(ldapServer)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+	}
 
 	users, err := conn.GetLdapUsers(ldapServer)
 	if err != nil {
@@ -79,6 +72,7 @@ func (c *ApiController) GetLdapUsers() {
 	existUuids := object.GetExistUuids(ldapServer.Owner, uuids)
 
 	resp := LdapResp{
+		Groups:     groups,
 		Users:      object.AutoAdjustLdapUser(users),
 		ExistUuids: existUuids,
 	}
@@ -92,7 +86,12 @@ func (c *ApiController) GetLdapUsers() {
 func (c *ApiController) GetLdaps() {
 	owner := c.Input().Get("owner")
 
-	c.ResponseOk(object.GetLdaps(owner))
+	ldaps := object.GetLdaps(owner)
+	for _, ldap := range ldaps {
+		ldap.SetScheduleInfo()
+	}
+
+	c.ResponseOk(ldaps)
 }
 
 // GetLdap
@@ -108,7 +107,12 @@ func (c *ApiController) GetLdap() {
 	}
 
 	_, name := util.GetOwnerAndNameFromId(id)
-	c.ResponseOk(object.GetLdap(name))
+	ldap := object.GetLdap(name)
+	if ldap != nil {
+		ldap.SetScheduleInfo()
+	}
+
+	c.ResponseOk(ldap)
 }
 
 // AddLdap
@@ -207,9 +211,59 @@ func (c *ApiController) SyncLdapUsers() {
 	object.UpdateLdapSyncTime(ldapId)
 
 	exist, failed, _ := object.SyncLdapUsers(owner, users, ldapId)
-
-	c.ResponseOk(&LdapSyncResp{
+	resp := &LdapSyncResp{
 		Exist:  exist,
 		Failed: failed,
-	})
+	}
+
+	// the memberships of all the LDAP users are synced, not only of the selected ones
+	ldap := object.GetLdap(ldapId)
+	if ldap != nil && ldap.EnableGroupSync {
+		conn, err := ldap.GetLdapConn()
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		ldapUsers, err := conn.GetLdapUsers(ldap)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		groups, err := conn.GetLdapGroups(ldap)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		resp.AddedRoles, resp.UpdatedRoles = object.SyncLdapGroups(ldap, groups, ldapUsers)
+	}
+
+	c.ResponseOk(resp)
+}
+
+// RunLdapSync
+// @Tag Account API
+// @Title RunLdapSync
+// @Description run a sync of the LDAP server with its auto sync settings, the report is returned
+// @Param   id     query    string  true        "The id of the LDAP server"
+// @Success 200 {object} object.LdapSyncReport The Response object
+// @router /run-ldap-sync [post]
+func (c *ApiController) RunLdapSync() {
+	id := c.Input().Get("id")
+
+	ldap := object.GetLdap(id)
+	if ldap == nil {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	report, err := object.RunLdapSync(ldap)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(report)
 }
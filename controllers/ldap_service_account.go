@@ -0,0 +1,128 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetLdapServiceAccounts
+// @Title GetLdapServiceAccounts
+// @Tag LDAP Service Account API
+// @Description get LDAP service accounts
+// @Param   owner     query    string  true        "The owner of LDAP service accounts"
+// @Success 200 {array} object.LdapServiceAccount The Response object
+// @router /get-ldap-service-accounts [get]
+func (c *ApiController) GetLdapServiceAccounts() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetMaskedLdapServiceAccounts(object.GetLdapServiceAccounts(owner))
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetLdapServiceAccountCount(owner, field, value)))
+		accounts := object.GetMaskedLdapServiceAccounts(object.GetPaginationLdapServiceAccounts(owner, paginator.Offset(), limit, field, value, sortField, sortOrder))
+		c.ResponseOk(accounts, paginator.Nums())
+	}
+}
+
+// GetLdapServiceAccount
+// @Title GetLdapServiceAccount
+// @Tag LDAP Service Account API
+// @Description get LDAP service account
+// @Param   id     query    string  true        "The id ( owner/name ) of the LDAP service account"
+// @Success 200 {object} object.LdapServiceAccount The Response object
+// @router /get-ldap-service-account [get]
+func (c *ApiController) GetLdapServiceAccount() {
+	id := c.Input().Get("id")
+
+	c.Data["json"] = object.GetMaskedLdapServiceAccount(object.GetLdapServiceAccount(id))
+	c.ServeJSON()
+}
+
+// UpdateLdapServiceAccount
+// @Title UpdateLdapServiceAccount
+// @Tag LDAP Service Account API
+// @Description update LDAP service account
+// @Param   id     query    string  true        "The id ( owner/name ) of the LDAP service account"
+// @Param   body    body   object.LdapServiceAccount  true        "The details of the LDAP service account"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-ldap-service-account [post]
+func (c *ApiController) UpdateLdapServiceAccount() {
+	id := c.Input().Get("id")
+
+	var account object.LdapServiceAccount
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &account)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateLdapServiceAccount(id, &account))
+	c.ServeJSON()
+}
+
+// AddLdapServiceAccount
+// @Title AddLdapServiceAccount
+// @Tag LDAP Service Account API
+// @Description add LDAP service account
+// @Param   body    body   object.LdapServiceAccount  true        "The details of the LDAP service account"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-ldap-service-account [post]
+func (c *ApiController) AddLdapServiceAccount() {
+	var account object.LdapServiceAccount
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &account)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if util.IsStringsEmpty(account.Owner, account.Name, account.Password) {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddLdapServiceAccount(&account))
+	c.ServeJSON()
+}
+
+// DeleteLdapServiceAccount
+// @Title DeleteLdapServiceAccount
+// @Tag LDAP Service Account API
+// @Description delete LDAP service account
+// @Param   body    body   object.LdapServiceAccount  true        "The details of the LDAP service account"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-ldap-service-account [post]
+func (c *ApiController) DeleteLdapServiceAccount() {
+	var account object.LdapServiceAccount
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &account)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteLdapServiceAccount(&account))
+	c.ServeJSON()
+}
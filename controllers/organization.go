@@ -63,6 +63,25 @@ func (c *ApiController) GetOrganization() {
 	c.ServeJSON()
 }
 
+// GetPasswordMigration ...
+// @Title GetPasswordMigration
+// @Tag Organization API
+// @Description get the progress of rehashing the passwords of the organization with its current password type
+// @Param   id     query    string  true        "organization id"
+// @Success 200 {object} object.PasswordMigration The Response object
+// @router /get-password-migration [get]
+func (c *ApiController) GetPasswordMigration() {
+	id := c.Input().Get("id")
+
+	organization := object.GetOrganization(id)
+	if organization == nil {
+		c.ResponseError(c.T("check:Organization does not exist"))
+		return
+	}
+
+	c.ResponseOk(object.GetPasswordMigration(organization))
+}
+
 // UpdateOrganization ...
 // @Title UpdateOrganization
 // @Tag Organization API
@@ -81,6 +100,11 @@ func (c *ApiController) UpdateOrganization() {
 		return
 	}
 
+	if msg := object.CheckUserAttributeSchema(&organization, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
 	c.Data["json"] = wrapActionResponse(object.UpdateOrganization(id, &organization))
 	c.ServeJSON()
 }
@@ -106,6 +130,11 @@ func (c *ApiController) AddOrganization() {
 		return
 	}
 
+	if msg := object.CheckUserAttributeSchema(&organization, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
 	c.Data["json"] = wrapActionResponse(object.AddOrganization(&organization))
 	c.ServeJSON()
 }
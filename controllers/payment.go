@@ -27,6 +27,7 @@ import (
 // @Tag Payment API
 // @Description get payments
 // @Param   owner     query    string  true        "The owner of payments"
+// @Param   filter    query    string  false       "The filter expression of the paginated listing, e.g. state = \"Paid\" AND price BETWEEN 10 AND 100"
 // @Success 200 {array} object.Payment The Response object
 // @router /get-payments [get]
 func (c *ApiController) GetPayments() {
@@ -41,9 +42,14 @@ func (c *ApiController) GetPayments() {
 		c.Data["json"] = object.GetPayments(owner)
 		c.ServeJSON()
 	} else {
+		filter, ok := c.GetFilter(object.PaymentFilterFields)
+		if !ok {
+			return
+		}
+
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetPaymentCount(owner, field, value)))
-		payments := object.GetPaginationPayments(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetPaymentCount(owner, field, value, filter)))
+		payments := object.GetPaginationPayments(owner, paginator.Offset(), limit, field, value, sortField, sortOrder, filter)
 		c.ResponseOk(payments, paginator.Nums())
 	}
 }
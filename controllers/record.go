@@ -28,6 +28,7 @@ import (
 // @Description get all records
 // @Param   pageSize     query    string  true        "The size of each page"
 // @Param   p     query    string  true        "The number of the page"
+// @Param   filter    query    string  false       "The filter expression of the paginated listing, e.g. action IN (\"login\", \"logout\") AND createdTime >= \"now-7d\""
 // @Success 200 {object} object.Record The Response object
 // @router /get-records [get]
 func (c *ApiController) GetRecords() {
@@ -46,10 +47,15 @@ func (c *ApiController) GetRecords() {
 		c.Data["json"] = object.GetRecords()
 		c.ServeJSON()
 	} else {
+		filter, ok := c.GetFilter(object.RecordFilterFields)
+		if !ok {
+			return
+		}
+
 		limit := util.ParseInt(limit)
 		filterRecord := &object.Record{Organization: organization}
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetRecordCount(field, value, filterRecord)))
-		records := object.GetPaginationRecords(paginator.Offset(), limit, field, value, sortField, sortOrder, filterRecord)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetRecordCount(field, value, filterRecord, filter)))
+		records := object.GetPaginationRecords(paginator.Offset(), limit, field, value, sortField, sortOrder, filterRecord, filter)
 		c.ResponseOk(records, paginator.Nums())
 	}
 }
@@ -0,0 +1,100 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/scim"
+	"github.com/casdoor/casdoor/util"
+)
+
+const scimContentType = "application/scim+json"
+
+// HandleScim
+// @Title HandleScim
+// @Tag SCIM API
+// @Description the SCIM 2.0 endpoints of the organization: Users, Groups, Bulk, ServiceProviderConfig, Schemas and ResourceTypes. They are authenticated by a SCIM token of the organization, except the discovery endpoints.
+// @Param   organization     path    string  true        "The name of the organization"
+// @Success 200 {object} scim.ListResponse The Response object
+// @router /scim/v2/:organization/* [get,post,put,patch,delete]
+func (c *RootController) HandleScim() {
+	organization := c.Ctx.Input.Param(":organization")
+	path := c.Ctx.Input.Param(":splat")
+
+	if object.GetOrganization(util.GetId("admin", organization)) == nil {
+		c.sendScimResponse(&scim.Response{
+			Status: http.StatusNotFound,
+			Body:   scim.NewError(http.StatusNotFound, "", "the organization: %s doesn't exist", organization),
+		})
+		return
+	}
+
+	if !scim.IsDiscoveryPath(path) {
+		bearerToken := ""
+		if tokens := strings.Split(c.Ctx.Request.Header.Get("Authorization"), " "); len(tokens) == 2 && tokens[0] == "Bearer" {
+			bearerToken = tokens[1]
+		}
+
+		if !object.CheckScimToken(organization, bearerToken) {
+			c.Ctx.Output.Header("WWW-Authenticate", "Bearer")
+			c.sendScimResponse(&scim.Response{
+				Status: http.StatusUnauthorized,
+				Body:   scim.NewError(http.StatusUnauthorized, "", "the SCIM token is invalid"),
+			})
+			return
+		}
+	}
+
+	server := scim.NewServer(organization, object.GetScimBaseUrl(c.Ctx.Request.Host, organization))
+	response := server.Handle(&scim.Request{
+		Method:      c.Ctx.Request.Method,
+		Path:        path,
+		Query:       c.Ctx.Request.URL.Query(),
+		Body:        c.Ctx.Input.RequestBody,
+		IfMatch:     c.Ctx.Request.Header.Get("If-Match"),
+		IfNoneMatch: c.Ctx.Request.Header.Get("If-None-Match"),
+	})
+	c.sendScimResponse(response)
+}
+
+func (c *RootController) sendScimResponse(response *scim.Response) {
+	if response.ETag != "" {
+		c.Ctx.Output.Header("ETag", response.ETag)
+	}
+	if response.Location != "" {
+		c.Ctx.Output.Header("Location", response.Location)
+	}
+
+	if response.Body == nil {
+		c.Ctx.Output.SetStatus(response.Status)
+		return
+	}
+
+	body, err := json.Marshal(response.Body)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Ctx.Output.Header("Content-Type", scimContentType)
+	c.Ctx.Output.SetStatus(response.Status)
+	err = c.Ctx.Output.Body(body)
+	if err != nil {
+		panic(err)
+	}
+}
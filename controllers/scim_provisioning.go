@@ -0,0 +1,73 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetScimProvisionings
+// @Title GetScimProvisionings
+// @Tag SCIM Provisioning API
+// @Description get the provisioning statuses of the users and roles pushed to the SCIM server of an application
+// @Param   application     query    string  true        "The name of the application"
+// @Success 200 {array} object.ScimProvisioning The Response object
+// @router /get-scim-provisionings [get]
+func (c *ApiController) GetScimProvisionings() {
+	application := c.Input().Get("application")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetScimProvisionings(application)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetScimProvisioningCount(application, field, value)))
+		provisionings := object.GetPaginationScimProvisionings(application, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		c.ResponseOk(provisionings, paginator.Nums())
+	}
+}
+
+// RetryScimProvisioning
+// @Title RetryScimProvisioning
+// @Tag SCIM Provisioning API
+// @Description push the user or the role to the SCIM server of the application again
+// @Param   body    body   object.ScimProvisioning  true        "The application, type and objectId of the provisioning"
+// @Success 200 {object} controllers.Response The Response object
+// @router /retry-scim-provisioning [post]
+func (c *ApiController) RetryScimProvisioning() {
+	var provisioning object.ScimProvisioning
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &provisioning)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if util.IsStringsEmpty(provisioning.Application, provisioning.Type, provisioning.ObjectId) {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RetryScimProvisioning(&provisioning))
+	c.ServeJSON()
+}
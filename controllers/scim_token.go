@@ -0,0 +1,171 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetScimTokens
+// @Title GetScimTokens
+// @Tag SCIM Token API
+// @Description get SCIM tokens, allowed for the admins
+// @Param   owner     query    string  true        "The owner of SCIM tokens"
+// @Success 200 {array} object.ScimToken The Response object
+// @router /get-scim-tokens [get]
+func (c *ApiController) GetScimTokens() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	if !c.RequireOrganizationAdmin(owner) {
+		return
+	}
+
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetScimTokens(owner)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetScimTokenCount(owner, field, value)))
+		tokens := object.GetPaginationScimTokens(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		c.ResponseOk(tokens, paginator.Nums())
+	}
+}
+
+// GetScimToken
+// @Title GetScimToken
+// @Tag SCIM Token API
+// @Description get SCIM token, allowed for the admins
+// @Param   id     query    string  true        "The id ( owner/name ) of the SCIM token"
+// @Success 200 {object} object.ScimToken The Response object
+// @router /get-scim-token [get]
+func (c *ApiController) GetScimToken() {
+	id := c.Input().Get("id")
+
+	token := object.GetScimToken(id)
+	if token != nil && !c.RequireOrganizationAdmin(token.Owner) {
+		return
+	}
+
+	c.Data["json"] = token
+	c.ServeJSON()
+}
+
+// UpdateScimToken
+// @Title UpdateScimToken
+// @Tag SCIM Token API
+// @Description update SCIM token, allowed for the admins
+// @Param   id     query    string  true        "The id ( owner/name ) of the SCIM token"
+// @Param   body    body   object.ScimToken  true        "The details of the SCIM token"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-scim-token [post]
+func (c *ApiController) UpdateScimToken() {
+	id := c.Input().Get("id")
+
+	var token object.ScimToken
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &token)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	oldToken := object.GetScimToken(id)
+	if oldToken == nil {
+		c.ResponseError(fmt.Sprintf(c.T("scim:The SCIM token: %s does not exist"), id))
+		return
+	}
+	if !c.RequireOrganizationAdmin(oldToken.Owner) {
+		return
+	}
+	if msg := object.CheckScimTokenExpireTime(token.ExpireTime, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateScimToken(id, &token))
+	c.ServeJSON()
+}
+
+// AddScimToken
+// @Title AddScimToken
+// @Tag SCIM Token API
+// @Description add SCIM token, the token is returned only in this response, allowed for the admins
+// @Param   body    body   object.ScimToken  true        "The details of the SCIM token"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-scim-token [post]
+func (c *ApiController) AddScimToken() {
+	var token object.ScimToken
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &token)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if util.IsStringsEmpty(token.Owner, token.Name) {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+	if !c.RequireOrganizationAdmin(token.Owner) {
+		return
+	}
+	if msg := object.CheckScimTokenExpireTime(token.ExpireTime, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	// the token is only known when it is added, it is returned in data2 and can't be read again
+	resp := wrapActionResponse(object.AddScimToken(&token))
+	resp.Data2 = token
+
+	c.Data["json"] = resp
+	c.ServeJSON()
+}
+
+// DeleteScimToken
+// @Title DeleteScimToken
+// @Tag SCIM Token API
+// @Description delete SCIM token, allowed for the admins
+// @Param   body    body   object.ScimToken  true        "The details of the SCIM token"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-scim-token [post]
+func (c *ApiController) DeleteScimToken() {
+	var form object.ScimToken
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	token := object.GetScimToken(form.GetId())
+	if token == nil {
+		c.ResponseError(fmt.Sprintf(c.T("scim:The SCIM token: %s does not exist"), form.GetId()))
+		return
+	}
+	if !c.RequireOrganizationAdmin(token.Owner) {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteScimToken(token))
+	c.ServeJSON()
+}
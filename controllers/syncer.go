@@ -39,12 +39,21 @@ func (c *ApiController) GetSyncers() {
 	sortOrder := c.Input().Get("sortOrder")
 	organization := c.Input().Get("organization")
 	if limit == "" || page == "" {
-		c.Data["json"] = object.GetOrganizationSyncers(owner, organization)
+		syncers := object.GetOrganizationSyncers(owner, organization)
+		for _, syncer := range syncers {
+			syncer.SetScheduleInfo()
+		}
+
+		c.Data["json"] = syncers
 		c.ServeJSON()
 	} else {
 		limit := util.ParseInt(limit)
 		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetSyncerCount(owner, organization, field, value)))
 		syncers := object.GetPaginationSyncers(owner, organization, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		for _, syncer := range syncers {
+			syncer.SetScheduleInfo()
+		}
+
 		c.ResponseOk(syncers, paginator.Nums())
 	}
 }
@@ -59,7 +68,12 @@ func (c *ApiController) GetSyncers() {
 func (c *ApiController) GetSyncer() {
 	id := c.Input().Get("id")
 
-	c.Data["json"] = object.GetSyncer(id)
+	syncer := object.GetSyncer(id)
+	if syncer != nil {
+		syncer.SetScheduleInfo()
+	}
+
+	c.Data["json"] = syncer
 	c.ServeJSON()
 }
 
@@ -81,6 +95,12 @@ func (c *ApiController) UpdateSyncer() {
 		return
 	}
 
+	_, err = syncer.GetSchedule()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
 	c.Data["json"] = wrapActionResponse(object.UpdateSyncer(id, &syncer))
 	c.ServeJSON()
 }
@@ -100,6 +120,12 @@ func (c *ApiController) AddSyncer() {
 		return
 	}
 
+	_, err = syncer.GetSchedule()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
 	c.Data["json"] = wrapActionResponse(object.AddSyncer(&syncer))
 	c.ServeJSON()
 }
@@ -126,15 +152,87 @@ func (c *ApiController) DeleteSyncer() {
 // RunSyncer
 // @Title RunSyncer
 // @Tag Syncer API
-// @Description run syncer
-// @Param   body    body   object.Syncer  true        "The details of the syncer"
-// @Success 200 {object} controllers.Response The Response object
+// @Description run syncer, a dry run returns the changes without writing them
+// @Param   id     query    string  true        "The id ( owner/name ) of the syncer"
+// @Param   dryRun query    string  false       "Whether it is a dry run"
+// @Success 200 {object} object.SyncerRun The Response object
 // @router /run-syncer [get]
 func (c *ApiController) RunSyncer() {
 	id := c.Input().Get("id")
+	isDryRun := c.Input().Get("dryRun") == "true" || c.Input().Get("dryRun") == "1"
+
 	syncer := object.GetSyncer(id)
+	if syncer == nil {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	run, err := object.RunSyncer(syncer, isDryRun)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(run)
+}
 
-	object.RunSyncer(syncer)
+// GetSyncerRuns
+// @Title GetSyncerRuns
+// @Tag Syncer API
+// @Description get the runs of a syncer, without the user diffs
+// @Param   owner     query    string  true        "The owner of the syncer"
+// @Param   syncer    query    string  true        "The name of the syncer"
+// @Success 200 {array} object.SyncerRun The Response object
+// @router /get-syncer-runs [get]
+func (c *ApiController) GetSyncerRuns() {
+	owner := c.Input().Get("owner")
+	syncer := c.Input().Get("syncer")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetSyncerRuns(owner, syncer)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetSyncerRunCount(owner, syncer, field, value)))
+		runs := object.GetPaginationSyncerRuns(owner, syncer, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		c.ResponseOk(runs, paginator.Nums())
+	}
+}
 
-	c.ResponseOk()
+// GetSyncerRun
+// @Title GetSyncerRun
+// @Tag Syncer API
+// @Description get a syncer run with its user diffs
+// @Param   id     query    string  true        "The id ( owner/name ) of the syncer run"
+// @Success 200 {object} object.SyncerRun The Response object
+// @router /get-syncer-run [get]
+func (c *ApiController) GetSyncerRun() {
+	id := c.Input().Get("id")
+
+	c.Data["json"] = object.GetSyncerRun(id)
+	c.ServeJSON()
+}
+
+// DeleteSyncerRun
+// @Title DeleteSyncerRun
+// @Tag Syncer API
+// @Description delete syncer run
+// @Param   body    body   object.SyncerRun  true        "The details of the syncer run"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-syncer-run [post]
+func (c *ApiController) DeleteSyncerRun() {
+	var run object.SyncerRun
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &run)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteSyncerRun(&run))
+	c.ServeJSON()
 }
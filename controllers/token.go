@@ -29,6 +29,7 @@ import (
 // @Param   owner     query    string  true        "The owner of tokens"
 // @Param   pageSize     query    string  true        "The size of each page"
 // @Param   p     query    string  true        "The number of the page"
+// @Param   filter    query    string  false       "The filter expression of the paginated listing, e.g. application = \"app-built-in\" AND expiresIn > 0"
 // @Success 200 {array} object.Token The Response object
 // @router /get-tokens [get]
 func (c *ApiController) GetTokens() {
@@ -44,9 +45,14 @@ func (c *ApiController) GetTokens() {
 		c.Data["json"] = object.GetTokens(owner, organization)
 		c.ServeJSON()
 	} else {
+		filter, ok := c.GetFilter(object.TokenFilterFields)
+		if !ok {
+			return
+		}
+
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetTokenCount(owner, organization, field, value)))
-		tokens := object.GetPaginationTokens(owner, organization, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetTokenCount(owner, organization, field, value, filter)))
+		tokens := object.GetPaginationTokens(owner, organization, paginator.Offset(), limit, field, value, sortField, sortOrder, filter)
 		c.ResponseOk(tokens, paginator.Nums())
 	}
 }
@@ -171,8 +177,9 @@ func (c *ApiController) GetOAuthToken() {
 		}
 	}
 	host := c.Ctx.Request.Host
+	clientIp := util.GetIPFromRequest(c.Ctx.Request)
 
-	c.Data["json"] = object.GetOAuthToken(grantType, clientId, clientSecret, code, verifier, scope, username, password, host, refreshToken, tag, avatar, c.GetAcceptLanguage())
+	c.Data["json"] = object.GetOAuthToken(grantType, clientId, clientSecret, code, verifier, scope, username, password, host, clientIp, refreshToken, tag, avatar, c.GetAcceptLanguage())
 	c.SetTokenErrorHttpStatus()
 	c.ServeJSON()
 }
@@ -27,3 +27,10 @@ type TokenRequest struct {
 	Avatar       string `json:"avatar"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+type DataErasureRequest struct {
+	Owner  string `json:"owner"`
+	Name   string `json:"name"`
+	Mode   string `json:"mode"`
+	Reason string `json:"reason"`
+}
@@ -28,6 +28,7 @@ import (
 // @Title GetGlobalUsers
 // @Tag User API
 // @Description get global users
+// @Param   filter    query    string  false       "The filter expression of the paginated listing, e.g. createdTime >= \"2023-01-01\" AND emailVerified = false"
 // @Success 200 {array} object.User The Response object
 // @router /get-global-users [get]
 func (c *ApiController) GetGlobalUsers() {
@@ -41,9 +42,14 @@ func (c *ApiController) GetGlobalUsers() {
 		c.Data["json"] = object.GetMaskedUsers(object.GetGlobalUsers())
 		c.ServeJSON()
 	} else {
+		filter, ok := c.GetFilter(object.UserFilterFields)
+		if !ok {
+			return
+		}
+
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetGlobalUserCount(field, value)))
-		users := object.GetPaginationGlobalUsers(paginator.Offset(), limit, field, value, sortField, sortOrder)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetGlobalUserCount(field, value, filter)))
+		users := object.GetPaginationGlobalUsers(paginator.Offset(), limit, field, value, sortField, sortOrder, filter)
 		users = object.GetMaskedUsers(users)
 		c.ResponseOk(users, paginator.Nums())
 	}
@@ -54,6 +60,9 @@ func (c *ApiController) GetGlobalUsers() {
 // @Tag User API
 // @Description
 // @Param   owner     query    string  true        "The owner of users"
+// @Param   group     query    string  false       "The id ( owner/name ) of the group the users belong to"
+// @Param   includeSubGroups query string false    "Whether to include the users of the subgroups, true or false"
+// @Param   filter    query    string  false       "The filter expression of the paginated listing, e.g. role = \"org/role\" AND lastSigninTime IS NULL"
 // @Success 200 {array} object.User The Response object
 // @router /get-users [get]
 func (c *ApiController) GetUsers() {
@@ -64,13 +73,40 @@ func (c *ApiController) GetUsers() {
 	value := c.Input().Get("value")
 	sortField := c.Input().Get("sortField")
 	sortOrder := c.Input().Get("sortOrder")
+	group := c.Input().Get("group")
+	includeSubGroups := c.Input().Get("includeSubGroups") == "true"
+	if group != "" {
+		if limit == "" || page == "" {
+			c.Data["json"] = c.OrganizationFilter(object.GetMaskedUsers(object.GetGroupUsers(group, includeSubGroups)))
+			c.ServeJSON()
+		} else {
+			groupOwner, _ := util.GetOwnerAndNameFromId(group)
+			filter, ok := c.GetUserFilter(groupOwner)
+			if !ok {
+				return
+			}
+
+			limit := util.ParseInt(limit)
+			paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetGroupUserCount(group, includeSubGroups, field, value, filter)))
+			users := object.GetPaginationGroupUsers(group, includeSubGroups, paginator.Offset(), limit, field, value, sortField, sortOrder, filter)
+			users = object.GetMaskedUsers(users)
+			c.ResponseOk(c.OrganizationFilter(users), paginator.Nums())
+		}
+		return
+	}
+
 	if limit == "" || page == "" {
 		c.Data["json"] = c.OrganizationFilter(object.GetMaskedUsers(object.GetUsers(owner)))
 		c.ServeJSON()
 	} else {
+		filter, ok := c.GetUserFilter(c.Input().Get("owner"))
+		if !ok {
+			return
+		}
+
 		limit := util.ParseInt(limit)
-		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetUserCount(owner, field, value)))
-		users := object.GetPaginationUsers(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetUserCount(owner, field, value, filter)))
+		users := object.GetPaginationUsers(owner, paginator.Offset(), limit, field, value, sortField, sortOrder, filter)
 		users = object.GetMaskedUsers(users)
 		c.ResponseOk(c.OrganizationFilter(users), paginator.Nums())
 	}
@@ -128,6 +164,10 @@ func (c *ApiController) GetUser() {
 
 	object.ExtendUserWithRolesAndPermissions(user)
 
+	if user != nil {
+		object.MaskUserAttributes(user, c.GetSessionUsername() == user.GetId(), c.IsAdmin())
+	}
+
 	c.Data["json"] = object.GetMaskedUser(user)
 	c.ServeJSON()
 }
@@ -180,6 +220,11 @@ func (c *ApiController) UpdateUser() {
 		return
 	}
 
+	if msg := object.CheckUserAttributes(object.GetOrganizationByUser(oldUser), &user, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
 	columns := []string{}
 	if columnsStr != "" {
 		columns = strings.Split(columnsStr, ",")
@@ -209,7 +254,7 @@ func (c *ApiController) AddUser() {
 		return
 	}
 
-	count := object.GetUserCount("", "", "")
+	count := object.GetUserCount("", "", "", nil)
 	if err := checkQuotaForUser(count); err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -221,6 +266,19 @@ func (c *ApiController) AddUser() {
 		return
 	}
 
+	organization := object.GetOrganizationByUser(&user)
+	if msg := object.CheckUserAttributes(organization, &user, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	if user.Password != "" {
+		if msg := object.CheckPasswordPolicy(organization, user.Name, user.Email, user.Password, c.GetAcceptLanguage()); msg != "" {
+			c.ResponseError(msg)
+			return
+		}
+	}
+
 	c.Data["json"] = wrapActionResponse(object.AddUser(&user))
 	c.ServeJSON()
 }
@@ -245,10 +303,182 @@ func (c *ApiController) DeleteUser() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.DeleteUser(&user))
+	// the user is soft-deleted if the organization enables it, and a soft-deleted user is purged
+	if oldUser := object.GetUser(user.GetId()); oldUser != nil {
+		organization := object.GetOrganizationByUser(oldUser)
+		if organization != nil && organization.EnableSoftDeletion && !oldUser.IsDeleted {
+			c.Data["json"] = wrapActionResponse(object.SoftDeleteUser(oldUser))
+			c.ServeJSON()
+			return
+		}
+		if oldUser.IsDeleted {
+			affected, err := object.PurgeUser(oldUser, c.GetAcceptLanguage())
+			if err != nil {
+				c.ResponseError(err.Error())
+				return
+			}
+
+			c.Data["json"] = wrapActionResponse(affected)
+			c.ServeJSON()
+			return
+		}
+	}
+
+	affected, err := object.DeleteUser(&user, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
+	c.ServeJSON()
+}
+
+// GetDeletedUsers
+// @Title GetDeletedUsers
+// @Tag User API
+// @Description get the soft-deleted users of the organization
+// @Param   owner     query    string  true        "The owner of users"
+// @Success 200 {array} object.User The Response object
+// @router /get-deleted-users [get]
+func (c *ApiController) GetDeletedUsers() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetMaskedUsers(object.GetDeletedUsers(owner))
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetDeletedUserCount(owner, field, value)))
+		users := object.GetPaginationDeletedUsers(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		c.ResponseOk(object.GetMaskedUsers(users), paginator.Nums())
+	}
+}
+
+// RestoreUser
+// @Title RestoreUser
+// @Tag User API
+// @Description restore the soft-deleted user with its released username, email and phone
+// @Param   body    body   object.User  true        "The details of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /restore-user [post]
+func (c *ApiController) RestoreUser() {
+	var form object.User
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	user := object.GetUser(form.GetId())
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), form.GetId()))
+		return
+	}
+
+	err = object.RestoreUser(user, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(user.GetId())
+}
+
+// GetSigninLockouts
+// @Title GetSigninLockouts
+// @Tag User API
+// @Description get the failed sign-in counters and the lockouts of the user
+// @Param   id     query    string  true        "The id ( owner/name ) of the user"
+// @Success 200 {array} object.SigninLockout The Response object
+// @router /get-signin-lockouts [get]
+func (c *ApiController) GetSigninLockouts() {
+	id := c.Input().Get("id")
+
+	c.ResponseOk(object.GetSigninLockouts(id))
+}
+
+// UnlockUser
+// @Title UnlockUser
+// @Tag User API
+// @Description unlock the user locked out by the failed sign-ins
+// @Param   body    body   object.User  true        "The details of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /unlock-user [post]
+func (c *ApiController) UnlockUser() {
+	var form object.User
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	user := object.GetUser(form.GetId())
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), form.GetId()))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UnlockUser(user, util.GetIPFromRequest(c.Ctx.Request)))
 	c.ServeJSON()
 }
 
+// MergeUsers
+// @Title MergeUsers
+// @Tag User API
+// @Description merge a duplicate user into the surviving user, keeping the name of the merged user as an alias
+// @Param   mergedId    query    string  true        "The id ( owner/name ) of the duplicate user to merge"
+// @Param   body    body   object.User  true        "The surviving user"
+// @Success 200 {object} object.UserMerge The Response object
+// @router /merge-users [post]
+func (c *ApiController) MergeUsers() {
+	var form object.User
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	user := object.GetUser(form.GetId())
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), form.GetId()))
+		return
+	}
+
+	mergedId := c.Input().Get("mergedId")
+	mergedUser := object.GetUser(mergedId)
+	if mergedUser == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), mergedId))
+		return
+	}
+
+	userMerge, err := object.MergeUser(user, mergedUser, c.GetSessionUsername(), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(userMerge)
+}
+
+// GetUserMerges
+// @Title GetUserMerges
+// @Tag User API
+// @Description get the audit records of the merged users of an organization
+// @Param   owner     query    string  true        "The owner of the users"
+// @Success 200 {array} object.UserMerge The Response object
+// @router /get-user-merges [get]
+func (c *ApiController) GetUserMerges() {
+	owner := c.Input().Get("owner")
+
+	c.ResponseOk(object.GetUserMerges(owner))
+}
+
 // GetEmailAndPhone
 // @Title GetEmailAndPhone
 // @Tag User API
@@ -307,20 +537,16 @@ func (c *ApiController) SetPassword() {
 	//	return
 	//}
 
-	if strings.Contains(newPassword, " ") {
-		c.ResponseError(c.T("user:New password cannot contain blank space."))
-		return
-	}
-	if len(newPassword) <= 5 {
-		c.ResponseError(c.T("user:New password must have at least 6 characters"))
-		return
-	}
-
 	userId := util.GetId(userOwner, userName)
 
 	requestUserId := c.GetSessionUsername()
+	isPasswordChange := false
 	if requestUserId == "" && code == "" {
-		return
+		// the user who has signed in with an expired password may only change it with the old one
+		isPasswordChange = oldPassword != "" && c.GetSession(object.PasswordChangeSessionUserId) == userId
+		if !isPasswordChange {
+			return
+		}
 	} else if code == "" {
 		hasPermission, err := object.CheckUserPermission(requestUserId, userId, true, c.GetAcceptLanguage())
 		if !hasPermission {
@@ -336,9 +562,18 @@ func (c *ApiController) SetPassword() {
 	}
 
 	targetUser := object.GetUser(userId)
+	if targetUser == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), userId))
+		return
+	}
+
+	if msg := object.CheckNewPassword(targetUser, newPassword, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
 
 	if oldPassword != "" {
-		msg := object.CheckPassword(targetUser, oldPassword, c.GetAcceptLanguage())
+		msg := object.CheckPassword(targetUser, oldPassword, c.GetAcceptLanguage(), &object.SigninAttempt{ClientIp: util.GetIPFromRequest(c.Ctx.Request)})
 		if msg != "" {
 			c.ResponseError(msg)
 			return
@@ -347,6 +582,9 @@ func (c *ApiController) SetPassword() {
 
 	targetUser.Password = newPassword
 	object.SetUserField(targetUser, "password", targetUser.Password)
+	if isPasswordChange {
+		c.DelSession(object.PasswordChangeSessionUserId)
+	}
 	c.ResponseOk()
 }
 
@@ -362,7 +600,7 @@ func (c *ApiController) CheckUserPassword() {
 		return
 	}
 
-	_, msg := object.CheckUserPassword(user.Owner, user.Name, user.Password, c.GetAcceptLanguage())
+	_, msg := object.CheckUserPassword(user.Owner, user.Name, user.Password, c.GetAcceptLanguage(), &object.SigninAttempt{ClientIp: util.GetIPFromRequest(c.Ctx.Request)})
 	if msg == "" {
 		c.ResponseOk()
 	} else {
@@ -402,7 +640,7 @@ func (c *ApiController) GetUserCount() {
 
 	count := 0
 	if isOnline == "" {
-		count = object.GetUserCount(owner, "", "")
+		count = object.GetUserCount(owner, "", "", nil)
 	} else {
 		count = object.GetOnlineUserCount(owner, util.ParseInt(isOnline))
 	}
@@ -0,0 +1,147 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// ExportUserData
+// @Title ExportUserData
+// @Tag User API
+// @Description download the zip archive of all the data related to the user, allowed for the user and the admins
+// @Param   id     query    string  true        "The id ( owner/name ) of the user"
+// @Success 200 {file} file The zip archive
+// @router /export-user-data [get]
+func (c *ApiController) ExportUserData() {
+	id := c.Input().Get("id")
+	if id == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	hasPermission, err := object.CheckUserPermission(c.GetSessionUsername(), id, true, c.GetAcceptLanguage())
+	if !hasPermission {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	user := object.GetUser(id)
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), id))
+		return
+	}
+
+	data, err := object.ExportUserData(user, c.GetSessionUsername())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "application/zip")
+	c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_%s_data.zip", user.Owner, user.Name))
+	err = c.Ctx.Output.Body(data)
+	if err != nil {
+		c.ResponseError(err.Error())
+	}
+}
+
+// EraseUserData
+// @Title EraseUserData
+// @Tag User API
+// @Description anonymize or delete all the data related to the user, allowed for the admins, the request is recorded as a data request
+// @Param   body    body   controllers.DataErasureRequest  true        "The user, the mode (Anonymize or Delete) and the reason"
+// @Success 200 {object} controllers.Response The Response object
+// @router /erase-user-data [post]
+func (c *ApiController) EraseUserData() {
+	var request DataErasureRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &request)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if !c.RequireOrganizationAdmin(request.Owner) {
+		return
+	}
+
+	user := object.GetUser(util.GetId(request.Owner, request.Name))
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), util.GetId(request.Owner, request.Name)))
+		return
+	}
+
+	dataRequest, err := object.EraseUserData(user, request.Mode, c.GetSessionUsername(), request.Reason, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error(), dataRequest)
+		return
+	}
+
+	c.ResponseOk(dataRequest)
+}
+
+// GetDataRequests
+// @Title GetDataRequests
+// @Tag User API
+// @Description get the data export and erasure requests of the organization, allowed for the admins
+// @Param   owner     query    string  true        "The owner of data requests"
+// @Success 200 {array} object.DataRequest The Response object
+// @router /get-data-requests [get]
+func (c *ApiController) GetDataRequests() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	if !c.RequireOrganizationAdmin(owner) {
+		return
+	}
+
+	if limit == "" || page == "" {
+		c.Data["json"] = object.GetDataRequests(owner)
+		c.ServeJSON()
+	} else {
+		limit := util.ParseInt(limit)
+		paginator := pagination.SetPaginator(c.Ctx, limit, int64(object.GetDataRequestCount(owner, field, value)))
+		dataRequests := object.GetPaginationDataRequests(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		c.ResponseOk(dataRequests, paginator.Nums())
+	}
+}
+
+// GetDataRequest
+// @Title GetDataRequest
+// @Tag User API
+// @Description get data request, allowed for the admins
+// @Param   id     query    string  true        "The id ( owner/name ) of the data request"
+// @Success 200 {object} object.DataRequest The Response object
+// @router /get-data-request [get]
+func (c *ApiController) GetDataRequest() {
+	id := c.Input().Get("id")
+
+	dataRequest := object.GetDataRequest(id)
+	if dataRequest != nil && !c.RequireOrganizationAdmin(dataRequest.Owner) {
+		return
+	}
+
+	c.Data["json"] = dataRequest
+	c.ServeJSON()
+}
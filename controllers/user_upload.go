@@ -15,6 +15,7 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -57,10 +58,104 @@ func (c *ApiController) UploadUsers() {
 		return
 	}
 
-	affected := object.UploadUsers(owner, fileId)
-	if affected {
-		c.ResponseOk()
-	} else {
-		c.ResponseError(c.T("user_upload:Failed to import users"))
+	report, err := object.UploadUsers(owner, fileId, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(report)
+}
+
+// ImportUsers
+// @Title ImportUsers
+// @Tag User API
+// @Description import the users of an organization from a CSV, XLSX or JSON file
+// @Param   owner     query    string  true        "The owner of the users"
+// @Param   format    query    string  false       "The file format: csv, xlsx or json, guessed from the file name by default"
+// @Param   mode      query    string  false       "The import mode: create (default), upsert or update"
+// @Param   dryRun    query    string  false       "Whether to only check the rows without importing them"
+// @Param   mapping   query    string  false       "The JSON object mapping the column headers to the user fields"
+// @Param   file      formData file    true        "The file to import"
+// @Success 200 {object} object.UserImportReport The Response object
+// @router /import-users [post]
+func (c *ApiController) ImportUsers() {
+	owner := c.Input().Get("owner")
+
+	file, header, err := c.Ctx.Request.FormFile("file")
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	options := &object.UserImportOptions{
+		Format:   object.GetUserImportFormat(c.Input().Get("format"), header.Filename),
+		Mode:     c.Input().Get("mode"),
+		IsDryRun: c.Input().Get("dryRun") == "true" || c.Input().Get("dryRun") == "1",
+	}
+	if mapping := c.Input().Get("mapping"); mapping != "" {
+		err = json.Unmarshal([]byte(mapping), &options.Mapping)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+	}
+
+	report, err := object.ImportUsers(owner, data, options, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(report)
+}
+
+// ExportUsers
+// @Title ExportUsers
+// @Tag User API
+// @Description export the users of an organization to a CSV, XLSX or JSON file
+// @Param   owner          query    string  true        "The owner of the users"
+// @Param   format         query    string  false       "The file format: csv (default), xlsx or json"
+// @Param   field          query    string  false       "The field to filter the users by"
+// @Param   value          query    string  false       "The value to filter the users by"
+// @Param   withPasswords  query    string  false       "Whether to export the hashed passwords with their password types"
+// @Success 200 {file} file The exported file
+// @router /export-users [get]
+func (c *ApiController) ExportUsers() {
+	owner := c.Input().Get("owner")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	withPasswords := c.Input().Get("withPasswords") == "true" || c.Input().Get("withPasswords") == "1"
+
+	if object.GetOrganization(util.GetId("admin", owner)) == nil {
+		c.ResponseError(c.T("check:Organization does not exist"))
+		return
+	}
+
+	format := object.GetUserImportFormat(c.Input().Get("format"), "users.csv")
+	if format == "" {
+		c.ResponseError(c.T("user_upload:The file format is not supported"))
+		return
+	}
+
+	contentTypes := map[string]string{
+		"csv":  "text/csv; charset=utf-8",
+		"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"json": "application/json; charset=utf-8",
+	}
+	c.Ctx.Output.Header("Content-Type", contentTypes[format])
+	c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_users.%s", owner, format))
+
+	err := object.ExportUsers(c.Ctx.ResponseWriter, owner, format, field, value, withPasswords)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
 	}
 }
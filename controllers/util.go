@@ -114,6 +114,21 @@ func (c *ApiController) RequireAdmin() (string, bool) {
 	return user.Owner, true
 }
 
+// RequireOrganizationAdmin checks that the current user is a global admin or an admin of the organization, the
+// handlers whose objects the authz filter would also let their own users through use it
+func (c *ApiController) RequireOrganizationAdmin(owner string) bool {
+	if c.IsGlobalAdmin() {
+		return true
+	}
+
+	user := c.getCurrentUser()
+	if user == nil || !user.IsAdmin || user.Owner != owner {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return false
+	}
+	return true
+}
+
 // IsMaskedEnabled ...
 func (c *ApiController) IsMaskedEnabled() (bool, bool) {
 	isMaskEnabled := true
@@ -135,6 +150,32 @@ func (c *ApiController) IsMaskedEnabled() (bool, bool) {
 	return true, isMaskEnabled
 }
 
+// GetFilter parses the "filter" expression of the list APIs against the fields allowed for the listed objects
+func (c *ApiController) GetFilter(fields map[string]string) (*object.Filter, bool) {
+	filter, err := object.ParseFilter(c.Input().Get("filter"), fields)
+	if err != nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:Invalid filter: %s"), err.Error()))
+		return nil, false
+	}
+
+	return filter, true
+}
+
+// GetUserFilter parses the filter of the users listed in the organization, whose roles and groups must be in the
+// organization as well unless the current user is a global admin
+func (c *ApiController) GetUserFilter(owner string) (*object.Filter, bool) {
+	filter, ok := c.GetFilter(object.UserFilterFields)
+	if !ok || filter == nil || c.IsGlobalAdmin() {
+		return filter, ok
+	}
+
+	if err := filter.CheckOwner(owner); err != nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:Invalid filter: %s"), err.Error()))
+		return nil, false
+	}
+	return filter, true
+}
+
 func (c *ApiController) GetProviderFromContext(category string) (*object.Provider, *object.User, bool) {
 	providerName := c.Input().Get("provider")
 	if providerName != "" {
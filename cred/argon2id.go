@@ -16,15 +16,26 @@ package cred
 
 import "github.com/alexedwards/argon2id"
 
-type Argon2idCredManager struct{}
+type Argon2idCredManager struct {
+	params argon2id.Params
+}
 
-func NewArgon2idCredManager() *Argon2idCredManager {
-	cm := &Argon2idCredManager{}
+func NewArgon2idCredManager(options *CredOptions) *Argon2idCredManager {
+	cm := &Argon2idCredManager{params: *argon2id.DefaultParams}
+	if options.Argon2idMemory > 0 {
+		cm.params.Memory = options.Argon2idMemory
+	}
+	if options.Argon2idIterations > 0 {
+		cm.params.Iterations = options.Argon2idIterations
+	}
+	if options.Argon2idParallelism > 0 {
+		cm.params.Parallelism = options.Argon2idParallelism
+	}
 	return cm
 }
 
 func (cm *Argon2idCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
-	hash, err := argon2id.CreateHash(password, argon2id.DefaultParams)
+	hash, err := argon2id.CreateHash(password, &cm.params)
 	if err != nil {
 		return ""
 	}
@@ -35,3 +46,11 @@ func (cm *Argon2idCredManager) IsPasswordCorrect(plainPwd string, hashedPwd stri
 	match, _ := argon2id.ComparePasswordAndHash(plainPwd, hashedPwd)
 	return match
 }
+
+func (cm *Argon2idCredManager) NeedsRehash(passwordHash string) bool {
+	params, _, _, err := argon2id.DecodeHash(passwordHash)
+	if err != nil {
+		return false
+	}
+	return params.Memory != cm.params.Memory || params.Iterations != cm.params.Iterations || params.Parallelism != cm.params.Parallelism
+}
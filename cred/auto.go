@@ -0,0 +1,88 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import "strings"
+
+// the prefixes of the hashes describing their password types, e.g. the Modular Crypt Format ones
+var hashPrefixes = []struct {
+	prefix       string
+	passwordType string
+}{
+	{"$2a$", "bcrypt"},
+	{"$2b$", "bcrypt"},
+	{"$2y$", "bcrypt"},
+	{"$argon2id$", "argon2id"},
+	{"$scrypt$", "scrypt"},
+	{djangoPrefix + "$", "django"},
+	{"$P$", "phpass"},
+	{"$H$", "phpass"},
+	{md5CryptPrefix, "md5-crypt"},
+	{sha256CryptPrefix, "sha256-crypt"},
+	{sha512CryptPrefix, "sha512-crypt"},
+}
+
+// AutoCredManager verifies each hash with the password type the hash describes, so that the users imported
+// from different systems can sign in within one organization, and hashes the new passwords with argon2id.
+// The hashes without a self-describing prefix are prefixed by their password types in braces,
+// e.g. "{md5-salt}<hash>" or "{pbkdf2-salt}<hash>".
+type AutoCredManager struct {
+	options *CredOptions
+}
+
+func NewAutoCredManager(options *CredOptions) *AutoCredManager {
+	cm := &AutoCredManager{options: options}
+	return cm
+}
+
+// GetPasswordTypeFromHash returns the password type described by the hash and the hash without the braces prefix,
+// or an empty password type if the hash doesn't describe it
+func GetPasswordTypeFromHash(hash string) (string, string) {
+	if strings.HasPrefix(hash, "{") {
+		if i := strings.Index(hash, "}"); i > 1 {
+			return hash[1:i], hash[i+1:]
+		}
+	}
+
+	for _, item := range hashPrefixes {
+		if strings.HasPrefix(hash, item.prefix) {
+			return item.passwordType, hash
+		}
+	}
+	return "", hash
+}
+
+func (cm *AutoCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	return NewArgon2idCredManager(cm.options).GetHashedPassword(password, userSalt, organizationSalt)
+}
+
+func (cm *AutoCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	passwordType, hash := GetPasswordTypeFromHash(hashedPwd)
+	if passwordType == "" || passwordType == "auto" {
+		return false
+	}
+
+	credManager := GetCredManagerWithOptions(passwordType, cm.options)
+	return credManager != nil && credManager.IsPasswordCorrect(plainPwd, hash, userSalt, organizationSalt)
+}
+
+// NeedsRehash returns true for the hashes of other password types than argon2id, to upgrade them at the next sign-in
+func (cm *AutoCredManager) NeedsRehash(passwordHash string) bool {
+	passwordType, hash := GetPasswordTypeFromHash(passwordHash)
+	if passwordType != "argon2id" {
+		return true
+	}
+	return NewArgon2idCredManager(cm.options).NeedsRehash(hash)
+}
@@ -2,15 +2,20 @@ package cred
 
 import "golang.org/x/crypto/bcrypt"
 
-type BcryptCredManager struct{}
+type BcryptCredManager struct {
+	cost int
+}
 
-func NewBcryptCredManager() *BcryptCredManager {
-	cm := &BcryptCredManager{}
+func NewBcryptCredManager(options *CredOptions) *BcryptCredManager {
+	cm := &BcryptCredManager{cost: bcrypt.DefaultCost}
+	if options.BcryptCost >= bcrypt.MinCost && options.BcryptCost <= bcrypt.MaxCost {
+		cm.cost = options.BcryptCost
+	}
 	return cm
 }
 
 func (cm *BcryptCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), cm.cost)
 	if err != nil {
 		return ""
 	}
@@ -21,3 +26,8 @@ func (cm *BcryptCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPwd), []byte(plainPwd))
 	return err == nil
 }
+
+func (cm *BcryptCredManager) NeedsRehash(passwordHash string) bool {
+	cost, err := bcrypt.Cost([]byte(passwordHash))
+	return err == nil && cost != cm.cost
+}
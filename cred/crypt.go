@@ -0,0 +1,288 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// the alphabet of the crypt(3) hashes, which is also used by PHPass
+const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const (
+	md5CryptPrefix    = "$1$"
+	sha256CryptPrefix = "$5$"
+	sha512CryptPrefix = "$6$"
+
+	shaCryptDefaultRounds = 5000
+	shaCryptMinRounds     = 1000
+	shaCryptMaxRounds     = 999999999
+)
+
+// the orders in which the bytes of the digests are encoded, by groups of 3 bytes
+var (
+	md5CryptOrder = [][]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}, {-1, -1, 11}}
+
+	sha256CryptOrder = [][]int{
+		{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14},
+		{15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8}, {9, 19, 29}, {-1, 31, 30},
+	}
+
+	sha512CryptOrder = [][]int{
+		{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4}, {47, 5, 26}, {6, 27, 48},
+		{28, 49, 7}, {50, 8, 29}, {9, 30, 51}, {31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13},
+		{56, 14, 35}, {15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19}, {62, 20, 41},
+		{-1, -1, 63},
+	}
+)
+
+// CryptCredManager verifies the MD5-crypt, SHA256-crypt and SHA512-crypt hashes of crypt(3), e.g. from /etc/shadow,
+// and hashes the new passwords with its own variant
+type CryptCredManager struct {
+	prefix string
+}
+
+func NewCryptCredManager(prefix string) *CryptCredManager {
+	cm := &CryptCredManager{prefix: prefix}
+	return cm
+}
+
+func getRandomCryptSalt(length int) string {
+	b := make([]byte, length)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	for i := range b {
+		b[i] = itoa64[int(b[i])%len(itoa64)]
+	}
+	return string(b)
+}
+
+// encodeCrypt64 encodes the bytes in the order of crypt(3), -1 stands for a zero byte,
+// and the last group is encoded by the characters its bytes need
+func encodeCrypt64(digest []byte, order [][]int) string {
+	var sb strings.Builder
+	for i, group := range order {
+		value, bits := 0, 0
+		for _, index := range group {
+			value <<= 8
+			if index >= 0 {
+				value |= int(digest[index])
+				bits += 8
+			}
+		}
+
+		n := 4
+		if i == len(order)-1 {
+			n = (bits + 5) / 6
+		}
+		for j := 0; j < n; j++ {
+			sb.WriteByte(itoa64[value&0x3f])
+			value >>= 6
+		}
+	}
+	return sb.String()
+}
+
+func getMd5Crypt(password []byte, salt []byte) string {
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+
+	alternate := md5.Sum(append(append(append([]byte{}, password...), salt...), password...))
+
+	h := md5.New()
+	h.Write(password)
+	h.Write([]byte(md5CryptPrefix))
+	h.Write(salt)
+	for i := len(password); i > 0; i -= 16 {
+		if i > 16 {
+			h.Write(alternate[:])
+		} else {
+			h.Write(alternate[:i])
+		}
+	}
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(password[:1])
+		}
+	}
+	digest := h.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		h.Reset()
+		if i&1 == 1 {
+			h.Write(password)
+		} else {
+			h.Write(digest)
+		}
+		if i%3 != 0 {
+			h.Write(salt)
+		}
+		if i%7 != 0 {
+			h.Write(password)
+		}
+		if i&1 == 1 {
+			h.Write(digest)
+		} else {
+			h.Write(password)
+		}
+		digest = h.Sum(nil)
+	}
+
+	return fmt.Sprintf("%s%s$%s", md5CryptPrefix, salt, encodeCrypt64(digest, md5CryptOrder))
+}
+
+// repeatBytes returns the bytes repeated up to the length
+func repeatBytes(b []byte, length int) []byte {
+	res := make([]byte, 0, length)
+	for len(res) < length {
+		res = append(res, b...)
+	}
+	return res[:length]
+}
+
+func getShaCrypt(newHash func() hash.Hash, prefix string, password []byte, salt []byte, rounds int, hasRounds bool) string {
+	if len(salt) > 16 {
+		salt = salt[:16]
+	}
+
+	h := newHash()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(password)
+	alternate := h.Sum(nil)
+
+	h.Reset()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(repeatBytes(alternate, len(password)))
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			h.Write(alternate)
+		} else {
+			h.Write(password)
+		}
+	}
+	digest := h.Sum(nil)
+
+	h.Reset()
+	for i := 0; i < len(password); i++ {
+		h.Write(password)
+	}
+	p := repeatBytes(h.Sum(nil), len(password))
+
+	h.Reset()
+	for i := 0; i < 16+int(digest[0]); i++ {
+		h.Write(salt)
+	}
+	s := repeatBytes(h.Sum(nil), len(salt))
+
+	for i := 0; i < rounds; i++ {
+		h.Reset()
+		if i&1 == 1 {
+			h.Write(p)
+		} else {
+			h.Write(digest)
+		}
+		if i%3 != 0 {
+			h.Write(s)
+		}
+		if i%7 != 0 {
+			h.Write(p)
+		}
+		if i&1 == 1 {
+			h.Write(digest)
+		} else {
+			h.Write(p)
+		}
+		digest = h.Sum(nil)
+	}
+
+	order := sha256CryptOrder
+	if prefix == sha512CryptPrefix {
+		order = sha512CryptOrder
+	}
+
+	setting := prefix
+	if hasRounds {
+		setting += fmt.Sprintf("rounds=%d$", rounds)
+	}
+	return fmt.Sprintf("%s%s$%s", setting, salt, encodeCrypt64(digest, order))
+}
+
+// getCrypt returns the crypt(3) hash of the password with the setting of the hash, e.g. "$6$rounds=5000$salt"
+func getCrypt(password string, setting string) string {
+	var prefix string
+	for _, p := range []string{md5CryptPrefix, sha256CryptPrefix, sha512CryptPrefix} {
+		if strings.HasPrefix(setting, p) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return ""
+	}
+
+	tokens := strings.Split(setting[len(prefix):], "$")
+	if prefix == md5CryptPrefix {
+		return getMd5Crypt([]byte(password), []byte(tokens[0]))
+	}
+
+	rounds, hasRounds := shaCryptDefaultRounds, false
+	if strings.HasPrefix(tokens[0], "rounds=") {
+		value, err := strconv.Atoi(strings.TrimPrefix(tokens[0], "rounds="))
+		if err != nil || len(tokens) < 2 {
+			return ""
+		}
+
+		rounds, hasRounds = value, true
+		if rounds < shaCryptMinRounds {
+			rounds = shaCryptMinRounds
+		} else if rounds > shaCryptMaxRounds {
+			rounds = shaCryptMaxRounds
+		}
+		tokens = tokens[1:]
+	}
+
+	newHash := sha256.New
+	if prefix == sha512CryptPrefix {
+		newHash = sha512.New
+	}
+	return getShaCrypt(newHash, prefix, []byte(password), []byte(tokens[0]), rounds, hasRounds)
+}
+
+func (cm *CryptCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	if cm.prefix == md5CryptPrefix {
+		return getCrypt(password, md5CryptPrefix+getRandomCryptSalt(8))
+	}
+	return getCrypt(password, cm.prefix+getRandomCryptSalt(16))
+}
+
+func (cm *CryptCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	hash := getCrypt(plainPwd, hashedPwd)
+	return hash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(hashedPwd)) == 1
+}
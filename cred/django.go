@@ -0,0 +1,65 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	djangoPrefix            = "pbkdf2_sha256"
+	djangoDefaultIterations = 600000
+)
+
+// DjangoCredManager verifies the default password hashes of Django, e.g. "pbkdf2_sha256$600000$<salt>$<hash>"
+type DjangoCredManager struct{}
+
+func NewDjangoCredManager() *DjangoCredManager {
+	cm := &DjangoCredManager{}
+	return cm
+}
+
+func getDjangoHash(password string, salt string, iterations int) string {
+	key := pbkdf2.Key([]byte(password), []byte(salt), iterations, sha256.Size, sha256.New)
+	return fmt.Sprintf("%s$%d$%s$%s", djangoPrefix, iterations, salt, base64.StdEncoding.EncodeToString(key))
+}
+
+func (cm *DjangoCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	// the salts of Django are alphanumeric
+	salt := strings.NewReplacer(".", "a", "/", "b").Replace(getRandomCryptSalt(22))
+	return getDjangoHash(password, salt, djangoDefaultIterations)
+}
+
+func (cm *DjangoCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	tokens := strings.Split(hashedPwd, "$")
+	if len(tokens) != 4 || tokens[0] != djangoPrefix {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(tokens[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+
+	hash := getDjangoHash(plainPwd, tokens[2], iterations)
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashedPwd)) == 1
+}
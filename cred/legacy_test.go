@@ -0,0 +1,79 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import "testing"
+
+func TestLegacyHashes(t *testing.T) {
+	cases := []struct {
+		passwordType string
+		password     string
+		hash         string
+	}{
+		{"md5-crypt", "Hello world!", "$1$saltsalt$le8lFSqqnPaRFOlmAZpvH1"},
+		{"sha256-crypt", "Hello world!", "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5"},
+		{"sha256-crypt", "Hello world!", "$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA"},
+		{"sha512-crypt", "Hello world!", "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"},
+		{"sha512-crypt", "Hello world!", "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v."},
+		{"django", "Hello world!", "pbkdf2_sha256$1000$seasalt$Ef3RYVahgD/fiuZm7bxL8BcLo5iWEoR4WjUZyeOSdgg="},
+		{"scrypt", "Hello world!", "$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$Ip7kiyUPAPfZxFE+Z/+Px71pSwQgr/65LvIHutJ3xVg"},
+		{"phpass", "test12345", "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0"},
+	}
+
+	for _, c := range cases {
+		for _, passwordType := range []string{c.passwordType, "auto"} {
+			cm := GetCredManager(passwordType)
+			if !cm.IsPasswordCorrect(c.password, c.hash, "", "") {
+				t.Errorf("%s: the password is incorrect for the hash: %s", passwordType, c.hash)
+			}
+			if cm.IsPasswordCorrect(c.password+"1", c.hash, "", "") {
+				t.Errorf("%s: a wrong password is correct for the hash: %s", passwordType, c.hash)
+			}
+		}
+	}
+}
+
+func TestLegacyHashedPasswords(t *testing.T) {
+	for _, passwordType := range []string{"md5-crypt", "sha256-crypt", "sha512-crypt", "django", "scrypt", "phpass"} {
+		cm := GetCredManager(passwordType)
+		hash := cm.GetHashedPassword("123456", "", "")
+		if !cm.IsPasswordCorrect("123456", hash, "", "") {
+			t.Errorf("%s: the password is incorrect for its hash: %s", passwordType, hash)
+		}
+		if hashPasswordType, _ := GetPasswordTypeFromHash(hash); hashPasswordType != passwordType {
+			t.Errorf("%s: got the password type: %s from the hash: %s", passwordType, hashPasswordType, hash)
+		}
+	}
+}
+
+func TestAutoCredManager(t *testing.T) {
+	cm := GetCredManager("auto")
+	hash := cm.GetHashedPassword("123456", "", "")
+	if !cm.IsPasswordCorrect("123456", hash, "", "") || cm.(RehashChecker).NeedsRehash(hash) {
+		t.Errorf("the password is incorrect for its hash: %s", hash)
+	}
+
+	saltHash := "{md5-salt}" + GetCredManager("md5-salt").GetHashedPassword("123456", "salt", "")
+	if !cm.IsPasswordCorrect("123456", saltHash, "salt", "") {
+		t.Errorf("the password is incorrect for the hash: %s", saltHash)
+	}
+	if !cm.(RehashChecker).NeedsRehash(saltHash) {
+		t.Errorf("the hash doesn't need rehash: %s", saltHash)
+	}
+
+	if cm.IsPasswordCorrect("123456", "123456", "", "") {
+		t.Errorf("the password is correct for a hash without a password type")
+	}
+}
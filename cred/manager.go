@@ -19,7 +19,30 @@ type CredManager interface {
 	IsPasswordCorrect(password string, passwordHash string, userSalt string, organizationSalt string) bool
 }
 
+// RehashChecker is implemented by the cred managers with work factors, to tell whether a hash
+// has been made with other work factors than the configured ones
+type RehashChecker interface {
+	NeedsRehash(passwordHash string) bool
+}
+
+// CredOptions are the work factors of the cred managers, the zero values keep the defaults
+type CredOptions struct {
+	BcryptCost          int    `json:"bcryptCost"`
+	Argon2idMemory      uint32 `json:"argon2idMemory"`
+	Argon2idIterations  uint32 `json:"argon2idIterations"`
+	Argon2idParallelism uint8  `json:"argon2idParallelism"`
+	Pbkdf2Iterations    int    `json:"pbkdf2Iterations"`
+}
+
 func GetCredManager(passwordType string) CredManager {
+	return GetCredManagerWithOptions(passwordType, nil)
+}
+
+func GetCredManagerWithOptions(passwordType string, options *CredOptions) CredManager {
+	if options == nil {
+		options = &CredOptions{}
+	}
+
 	if passwordType == "plain" {
 		return NewPlainCredManager()
 	} else if passwordType == "salt" {
@@ -27,11 +50,25 @@ func GetCredManager(passwordType string) CredManager {
 	} else if passwordType == "md5-salt" {
 		return NewMd5UserSaltCredManager()
 	} else if passwordType == "bcrypt" {
-		return NewBcryptCredManager()
+		return NewBcryptCredManager(options)
 	} else if passwordType == "pbkdf2-salt" {
-		return NewPbkdf2SaltCredManager()
+		return NewPbkdf2SaltCredManager(options)
 	} else if passwordType == "argon2id" {
-		return NewArgon2idCredManager()
+		return NewArgon2idCredManager(options)
+	} else if passwordType == "scrypt" {
+		return NewScryptCredManager()
+	} else if passwordType == "django" {
+		return NewDjangoCredManager()
+	} else if passwordType == "phpass" {
+		return NewPhpassCredManager()
+	} else if passwordType == "md5-crypt" {
+		return NewCryptCredManager(md5CryptPrefix)
+	} else if passwordType == "sha256-crypt" {
+		return NewCryptCredManager(sha256CryptPrefix)
+	} else if passwordType == "sha512-crypt" {
+		return NewCryptCredManager(sha512CryptPrefix)
+	} else if passwordType == "auto" {
+		return NewAutoCredManager(options)
 	}
 	return nil
 }
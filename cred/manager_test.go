@@ -0,0 +1,50 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import "testing"
+
+func TestNeedsRehash(t *testing.T) {
+	options := &CredOptions{BcryptCost: 5, Argon2idMemory: 1024, Argon2idIterations: 1, Argon2idParallelism: 1, Pbkdf2Iterations: 1000}
+	otherOptions := &CredOptions{BcryptCost: 4, Argon2idMemory: 2048, Argon2idIterations: 1, Argon2idParallelism: 1}
+
+	for _, passwordType := range []string{"bcrypt", "argon2id", "pbkdf2-salt"} {
+		cm := GetCredManagerWithOptions(passwordType, options)
+		hash := cm.GetHashedPassword("123456", "c2FsdA==", "")
+		if !cm.IsPasswordCorrect("123456", hash, "c2FsdA==", "") {
+			t.Errorf("%s: the password is incorrect for its hash: %s", passwordType, hash)
+		}
+		if cm.(RehashChecker).NeedsRehash(hash) {
+			t.Errorf("%s: the hash needs rehash with the same options: %s", passwordType, hash)
+		}
+
+		otherCm := GetCredManagerWithOptions(passwordType, otherOptions)
+		if !otherCm.IsPasswordCorrect("123456", hash, "c2FsdA==", "") {
+			t.Errorf("%s: the password is incorrect with other options: %s", passwordType, hash)
+		}
+		if !otherCm.(RehashChecker).NeedsRehash(hash) {
+			t.Errorf("%s: the hash doesn't need rehash with other options: %s", passwordType, hash)
+		}
+	}
+}
+
+func TestPbkdf2DefaultIterations(t *testing.T) {
+	// the hashes with the default iterations stay compatible with Keycloak
+	cm := GetCredManager("pbkdf2-salt")
+	hash := cm.GetHashedPassword("123456", "c2FsdA==", "")
+	if iterations, key := parsePbkdf2Hash(hash); iterations != defaultPbkdf2Iterations || key != hash {
+		t.Errorf("got iterations: %d, key: %s, expected the plain key: %s", iterations, key, hash)
+	}
+}
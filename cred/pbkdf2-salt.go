@@ -17,24 +17,64 @@ package cred
 import (
 	"crypto/sha256"
 	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"golang.org/x/crypto/pbkdf2"
 )
 
-type Pbkdf2SaltCredManager struct{}
+// the iterations of Keycloak, whose hashes don't carry the iterations
+const defaultPbkdf2Iterations = 27500
 
-func NewPbkdf2SaltCredManager() *Pbkdf2SaltCredManager {
-	cm := &Pbkdf2SaltCredManager{}
+type Pbkdf2SaltCredManager struct {
+	iterations int
+}
+
+func NewPbkdf2SaltCredManager(options *CredOptions) *Pbkdf2SaltCredManager {
+	cm := &Pbkdf2SaltCredManager{iterations: defaultPbkdf2Iterations}
+	if options.Pbkdf2Iterations > 0 {
+		cm.iterations = options.Pbkdf2Iterations
+	}
 	return cm
 }
 
-func (cm *Pbkdf2SaltCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+// parsePbkdf2Hash returns the iterations and the key of the hash, the hashes with other iterations than
+// the default ones are prefixed by "<iterations>$"
+func parsePbkdf2Hash(hashedPwd string) (int, string) {
+	tokens := strings.SplitN(hashedPwd, "$", 2)
+	if len(tokens) != 2 {
+		return defaultPbkdf2Iterations, hashedPwd
+	}
+
+	iterations, err := strconv.Atoi(tokens[0])
+	if err != nil {
+		return defaultPbkdf2Iterations, hashedPwd
+	}
+	return iterations, tokens[1]
+}
+
+func getPbkdf2Key(password string, userSalt string, iterations int) string {
 	// https://www.keycloak.org/docs/latest/server_admin/index.html#password-database-compromised
 	decodedSalt, _ := base64.StdEncoding.DecodeString(userSalt)
-	res := pbkdf2.Key([]byte(password), decodedSalt, 27500, 64, sha256.New)
+	res := pbkdf2.Key([]byte(password), decodedSalt, iterations, 64, sha256.New)
 	return base64.StdEncoding.EncodeToString(res)
 }
 
+func (cm *Pbkdf2SaltCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	key := getPbkdf2Key(password, userSalt, cm.iterations)
+	if cm.iterations == defaultPbkdf2Iterations {
+		return key
+	}
+	return fmt.Sprintf("%d$%s", cm.iterations, key)
+}
+
 func (cm *Pbkdf2SaltCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
-	return hashedPwd == cm.GetHashedPassword(plainPwd, userSalt, organizationSalt)
+	iterations, key := parsePbkdf2Hash(hashedPwd)
+	return key == getPbkdf2Key(plainPwd, userSalt, iterations)
+}
+
+func (cm *Pbkdf2SaltCredManager) NeedsRehash(passwordHash string) bool {
+	iterations, _ := parsePbkdf2Hash(passwordHash)
+	return iterations != cm.iterations
 }
@@ -0,0 +1,82 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"strings"
+)
+
+// the cost of WordPress, 2^13 iterations
+const phpassDefaultCost = 13
+
+// PhpassCredManager verifies the portable hashes of PHPass, which are used by WordPress ("$P$") and phpBB ("$H$")
+type PhpassCredManager struct{}
+
+func NewPhpassCredManager() *PhpassCredManager {
+	cm := &PhpassCredManager{}
+	return cm
+}
+
+func encodePhpass64(digest []byte) string {
+	var sb strings.Builder
+	for i := 0; i < len(digest); i += 3 {
+		value := int(digest[i])
+		n := 2
+		if i+1 < len(digest) {
+			value |= int(digest[i+1]) << 8
+			n = 3
+		}
+		if i+2 < len(digest) {
+			value |= int(digest[i+2]) << 16
+			n = 4
+		}
+
+		for j := 0; j < n; j++ {
+			sb.WriteByte(itoa64[value&0x3f])
+			value >>= 6
+		}
+	}
+	return sb.String()
+}
+
+// getPhpass returns the portable PHPass hash of the password with the setting of the hash, e.g. "$P$B12345678"
+func getPhpass(password string, setting string) string {
+	if len(setting) < 12 || (setting[:3] != "$P$" && setting[:3] != "$H$") {
+		return ""
+	}
+
+	cost := strings.IndexByte(itoa64, setting[3])
+	if cost < 7 || cost > 30 {
+		return ""
+	}
+
+	salt := setting[4:12]
+	digest := md5.Sum([]byte(salt + password))
+	for i := 0; i < 1<<cost; i++ {
+		digest = md5.Sum(append(digest[:], password...))
+	}
+	return setting[:12] + encodePhpass64(digest[:])
+}
+
+func (cm *PhpassCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	return getPhpass(password, "$P$"+string(itoa64[phpassDefaultCost])+getRandomCryptSalt(8))
+}
+
+func (cm *PhpassCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	hash := getPhpass(plainPwd, hashedPwd)
+	return hash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(hashedPwd)) == 1
+}
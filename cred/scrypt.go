@@ -0,0 +1,87 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// the recommended parameters of golang.org/x/crypto/scrypt, N = 2^15
+const (
+	scryptDefaultLogN = 15
+	scryptDefaultR    = 8
+	scryptDefaultP    = 1
+	scryptKeyLength   = 32
+)
+
+// ScryptCredManager verifies the scrypt hashes in the format of passlib, e.g. "$scrypt$ln=16,r=8,p=1$<salt>$<hash>"
+// with the salt and hash in base64 without padding
+type ScryptCredManager struct{}
+
+func NewScryptCredManager() *ScryptCredManager {
+	cm := &ScryptCredManager{}
+	return cm
+}
+
+func getScryptHash(password string, salt []byte, logN int, r int, p int, keyLength int) string {
+	key, err := scrypt.Key([]byte(password), salt, 1<<logN, r, p, keyLength)
+	if err != nil {
+		return ""
+	}
+
+	encoding := base64.RawStdEncoding
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", logN, r, p, encoding.EncodeToString(salt), encoding.EncodeToString(key))
+}
+
+func (cm *ScryptCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		panic(err)
+	}
+
+	return getScryptHash(password, salt, scryptDefaultLogN, scryptDefaultR, scryptDefaultP, scryptKeyLength)
+}
+
+func (cm *ScryptCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	tokens := strings.Split(hashedPwd, "$")
+	if len(tokens) != 5 || tokens[0] != "" || tokens[1] != "scrypt" {
+		return false
+	}
+
+	var logN, r, p int
+	_, err := fmt.Sscanf(tokens[2], "ln=%d,r=%d,p=%d", &logN, &r, &p)
+	if err != nil || logN <= 0 || logN >= 32 {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(tokens[3])
+	if err != nil {
+		return false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(tokens[4])
+	if err != nil || len(key) == 0 {
+		return false
+	}
+
+	hash := getScryptHash(plainPwd, salt, logN, r, p, len(key))
+	return hash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(hashedPwd)) == 1
+}
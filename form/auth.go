@@ -29,6 +29,10 @@ type AuthForm struct {
 	IdCard       string `json:"idCard"`
 	Region       string `json:"region"`
 
+	Properties map[string]string `json:"properties"`
+
+	InvitationCode string `json:"invitationCode"`
+
 	Application string `json:"application"`
 	ClientId    string `json:"clientId"`
 	Provider    string `json:"provider"`
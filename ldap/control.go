@@ -0,0 +1,113 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"fmt"
+	"strconv"
+
+	ldap "github.com/forestmgy/ldapserver"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/lor00x/goldap/message"
+)
+
+type pagedResultsControl struct {
+	Size       int
+	Offset     int
+	IsCritical bool
+}
+
+// getPagedResultsControl returns the RFC 2696 paged results control of the request, or nil if not present.
+// The cookie handed out to clients is the offset of the next page.
+func getPagedResultsControl(m *ldap.Message) (*pagedResultsControl, error) {
+	controls := m.Controls()
+	if controls == nil {
+		return nil, nil
+	}
+
+	for _, control := range *controls {
+		if string(control.ControlType()) != goldap.ControlTypePaging {
+			continue
+		}
+
+		res := &pagedResultsControl{IsCritical: bool(control.Criticality())}
+		if control.ControlValue() == nil {
+			return nil, fmt.Errorf("paged results control has no value")
+		}
+
+		packet, err := ber.DecodePacketErr([]byte(*control.ControlValue()))
+		if err != nil {
+			return nil, err
+		}
+		if len(packet.Children) != 2 {
+			return nil, fmt.Errorf("invalid paged results control value")
+		}
+
+		size, ok := packet.Children[0].Value.(int64)
+		if !ok {
+			return nil, fmt.Errorf("invalid paged results size")
+		}
+		res.Size = int(size)
+
+		cookie := packet.Children[1].Data.String()
+		if cookie != "" {
+			res.Offset, err = strconv.Atoi(cookie)
+			if err != nil || res.Offset < 0 {
+				return nil, fmt.Errorf("invalid paged results cookie: %s", cookie)
+			}
+		}
+
+		return res, nil
+	}
+
+	return nil, nil
+}
+
+// writeMessage writes a response to the client connection directly, the ResponseWriter of the
+// server library can't attach response controls. Each message is sent with a single write so
+// it doesn't interleave with the responses of other requests.
+func writeMessage(m *ldap.Message, po message.ProtocolOp, controls ...goldap.Control) error {
+	msg := message.NewLDAPMessageWithProtocolOp(po)
+	msg.SetMessageID(m.MessageID().Int())
+	bytes, err := msg.Write()
+	if err != nil {
+		return err
+	}
+
+	packet, err := ber.DecodePacketErr(bytes.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if len(controls) > 0 {
+		controlsPacket := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
+		for _, control := range controls {
+			controlsPacket.AppendChild(control.Encode())
+		}
+		packet.AppendChild(controlsPacket)
+	}
+
+	_, err = m.Client.GetConn().Write(packet.Bytes())
+	return err
+}
+
+func newPagedResultsResponseControl(nextOffset int) goldap.Control {
+	control := goldap.NewControlPaging(0)
+	if nextOffset > 0 {
+		control.SetCookie([]byte(strconv.Itoa(nextOffset)))
+	}
+	return control
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lor00x/goldap/message"
+)
+
+// Entry is a directory entry published by the LDAP server, attribute names are
+// kept in their canonical case and looked up case-insensitively.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+func NewEntry(dn string) *Entry {
+	return &Entry{
+		DN:         dn,
+		Attributes: map[string][]string{},
+	}
+}
+
+func (e *Entry) AddAttribute(name string, values ...string) {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		e.Attributes[name] = append(e.Attributes[name], value)
+	}
+}
+
+func (e *Entry) GetAttributeValues(name string) []string {
+	name = getAttributeType(name)
+	for attributeName, values := range e.Attributes {
+		if strings.EqualFold(attributeName, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// getAttributeType strips the options from an attribute description, e.g. "cn;lang-en" -> "cn"
+func getAttributeType(description string) string {
+	if i := strings.Index(description, ";"); i != -1 {
+		return description[:i]
+	}
+	return description
+}
+
+// MatchFilter evaluates an RFC 4515 search filter against the entry. Undefined
+// results (such as extensible matches) are treated as false.
+func (e *Entry) MatchFilter(filter message.Filter) bool {
+	switch f := filter.(type) {
+	case message.FilterAnd:
+		for _, child := range f {
+			if !e.MatchFilter(child) {
+				return false
+			}
+		}
+		return true
+	case message.FilterOr:
+		for _, child := range f {
+			if e.MatchFilter(child) {
+				return true
+			}
+		}
+		return false
+	case message.FilterNot:
+		return !e.MatchFilter(f.Filter)
+	case message.FilterPresent:
+		if strings.EqualFold(string(f), "objectClass") {
+			return true
+		}
+		return len(e.GetAttributeValues(string(f))) > 0
+	case message.FilterEqualityMatch:
+		assertion := string(f.AssertionValue())
+		for _, value := range e.GetAttributeValues(string(f.AttributeDesc())) {
+			if strings.EqualFold(value, assertion) {
+				return true
+			}
+		}
+		return false
+	case message.FilterApproxMatch:
+		assertion := normalizeApproxValue(string(f.AssertionValue()))
+		for _, value := range e.GetAttributeValues(string(f.AttributeDesc())) {
+			if normalizeApproxValue(value) == assertion {
+				return true
+			}
+		}
+		return false
+	case message.FilterGreaterOrEqual:
+		assertion := string(f.AssertionValue())
+		for _, value := range e.GetAttributeValues(string(f.AttributeDesc())) {
+			if compareValues(value, assertion) >= 0 {
+				return true
+			}
+		}
+		return false
+	case message.FilterLessOrEqual:
+		assertion := string(f.AssertionValue())
+		for _, value := range e.GetAttributeValues(string(f.AttributeDesc())) {
+			if compareValues(value, assertion) <= 0 {
+				return true
+			}
+		}
+		return false
+	case message.FilterSubstrings:
+		for _, value := range e.GetAttributeValues(string(f.Type_())) {
+			if matchSubstrings(value, f.Substrings()) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func normalizeApproxValue(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), ""))
+}
+
+// compareValues compares two attribute values numerically when both are integers,
+// otherwise case-insensitively. Generalized times compare correctly as strings.
+func compareValues(a string, b string) int {
+	intA, errA := strconv.ParseInt(a, 10, 64)
+	intB, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case intA < intB:
+			return -1
+		case intA > intB:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func matchSubstrings(value string, substrings []message.Substring) bool {
+	value = strings.ToLower(value)
+	for i, substring := range substrings {
+		switch s := substring.(type) {
+		case message.SubstringInitial:
+			prefix := strings.ToLower(string(s))
+			if i != 0 || !strings.HasPrefix(value, prefix) {
+				return false
+			}
+			value = value[len(prefix):]
+		case message.SubstringAny:
+			part := strings.ToLower(string(s))
+			index := strings.Index(value, part)
+			if index == -1 {
+				return false
+			}
+			value = value[index+len(part):]
+		case message.SubstringFinal:
+			if !strings.HasSuffix(value, strings.ToLower(string(s))) {
+				return false
+			}
+		}
+	}
+	return true
+}
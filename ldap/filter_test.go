@@ -0,0 +1,104 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/lor00x/goldap/message"
+)
+
+// compileFilter encodes a filter string into a search request and decodes it with the server's LDAP library
+func compileFilter(t *testing.T, filter string) message.Filter {
+	filterPacket, err := goldap.CompileFilter(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchRequest, nil, "Search Request")
+	request.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "ou=casbin,dc=example,dc=com", "Base DN"))
+	request.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, uint64(goldap.ScopeWholeSubtree), "Scope"))
+	request.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, uint64(goldap.NeverDerefAliases), "Deref Aliases"))
+	request.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, uint64(0), "Size Limit"))
+	request.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, uint64(0), "Time Limit"))
+	request.AppendChild(ber.NewBoolean(ber.ClassUniversal, ber.TypePrimitive, ber.TagBoolean, false, "Types Only"))
+	request.AppendChild(filterPacket)
+	request.AppendChild(ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes"))
+
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Request")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(1), "MessageID"))
+	packet.AppendChild(request)
+
+	msg, err := message.ReadLDAPMessage(message.NewBytes(0, packet.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := msg.ProtocolOp().(message.SearchRequest)
+	return r.Filter()
+}
+
+func TestMatchFilter(t *testing.T) {
+	entry := NewEntry("cn=alice,ou=casbin,dc=example,dc=com")
+	entry.AddAttribute("objectClass", "top", "person", "inetOrgPerson")
+	entry.AddAttribute("cn", "alice", "staff")
+	entry.AddAttribute("uid", "alice")
+	entry.AddAttribute("mail", "Alice@Example.com")
+	entry.AddAttribute("displayName", "Alice  Smith")
+	entry.AddAttribute("uidNumber", "1005")
+	entry.AddAttribute("createTimestamp", "20230102150405Z")
+
+	scenarios := []struct {
+		filter   string
+		expected bool
+	}{
+		{"(objectClass=*)", true},
+		{"(objectclass=inetOrgPerson)", true},
+		{"(cn=alice)", true},
+		{"(CN=ALICE)", true},
+		{"(cn=staff)", true},
+		{"(cn=bob)", false},
+		{"(mail=alice@example.com)", true},
+		{"(telephoneNumber=*)", false},
+		{"(mail=*@example.com)", true},
+		{"(mail=ali*)", true},
+		{"(mail=*ce@*.com)", true},
+		{"(mail=*example)", false},
+		{"(displayName~=alicesmith)", true},
+		{"(uidNumber>=1000)", true},
+		{"(uidNumber>=200)", true},
+		{"(uidNumber<=999)", false},
+		{"(createTimestamp>=20230101000000Z)", true},
+		{"(createTimestamp<=20221231000000Z)", false},
+		{"(&(objectClass=person)(uid=alice))", true},
+		{"(&(objectClass=person)(uid=bob))", false},
+		{"(|(uid=bob)(mail=alice@example.com))", true},
+		{"(|(uid=bob)(uid=carol))", false},
+		{"(!(uid=bob))", true},
+		{"(&(objectClass=person)(!(|(uid=bob)(cn=alice))))", false},
+		{"(&(|(objectClass=inetOrgPerson)(objectClass=posixAccount))(|(uid=alice)(mail=alice)))", true},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.filter, func(t *testing.T) {
+			actual := entry.MatchFilter(compileFilter(t, scenario.filter))
+			if actual != scenario.expected {
+				t.Errorf("MatchFilter(%s) = %v, expected %v", scenario.filter, actual, scenario.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// roles are published as groups under "ou=groups,ou=<organization>,<suffix>"
+const groupsOu = "groups"
+
+// RoleMembership resolves the members of roles, a role in Role.Roles is a member of the role
+// containing it, so the users of nested roles are members of all their ancestor roles
+type RoleMembership struct {
+	roles     map[string]*object.Role
+	members   map[string][]string
+	userRoles map[string][]*object.Role
+}
+
+func NewRoleMembership(roles []*object.Role) *RoleMembership {
+	rm := &RoleMembership{
+		roles:     map[string]*object.Role{},
+		members:   map[string][]string{},
+		userRoles: map[string][]*object.Role{},
+	}
+
+	for _, role := range roles {
+		if role.IsEnabled {
+			rm.roles[role.GetId()] = role
+		}
+	}
+
+	for _, role := range rm.GetRoles() {
+		roleId := role.GetId()
+		rm.members[roleId] = rm.getEffectiveUsers(roleId, map[string]bool{})
+		for _, userId := range rm.members[roleId] {
+			rm.userRoles[userId] = append(rm.userRoles[userId], role)
+		}
+	}
+	return rm
+}
+
+func (rm *RoleMembership) getEffectiveUsers(roleId string, visited map[string]bool) []string {
+	role, ok := rm.roles[roleId]
+	if !ok || visited[roleId] {
+		return nil
+	}
+	visited[roleId] = true
+
+	users := append([]string{}, role.Users...)
+	for _, subRoleId := range role.Roles {
+		users = append(users, rm.getEffectiveUsers(subRoleId, visited)...)
+	}
+	return util.UniqueStrings(users)
+}
+
+// GetUserRoles returns the roles the user is a direct or nested member of
+func (rm *RoleMembership) GetUserRoles(userId string) []*object.Role {
+	return rm.userRoles[userId]
+}
+
+func (rm *RoleMembership) GetRoles() []*object.Role {
+	roles := []*object.Role{}
+	for _, role := range rm.roles {
+		roles = append(roles, role)
+	}
+
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].GetId() < roles[j].GetId()
+	})
+	return roles
+}
+
+func getUserDN(userId string, suffix string) string {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(userId)
+	return joinDN(fmt.Sprintf("cn=%s,ou=%s", name, owner), suffix)
+}
+
+func getGroupsDN(owner string, suffix string) string {
+	return joinDN(fmt.Sprintf("ou=%s,ou=%s", groupsOu, owner), suffix)
+}
+
+func getRoleDN(roleId string, suffix string) string {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(roleId)
+	return joinDN(fmt.Sprintf("cn=%s,ou=%s,ou=%s", name, groupsOu, owner), suffix)
+}
+
+func joinDN(rdns string, suffix string) string {
+	if suffix == "" {
+		return rdns
+	}
+	return fmt.Sprintf("%s,%s", rdns, suffix)
+}
+
+func getGroupsEntry(owner string, suffix string) *Entry {
+	entry := NewEntry(getGroupsDN(owner, suffix))
+	entry.AddAttribute("objectClass", "top", "organizationalUnit")
+	entry.AddAttribute("ou", groupsOu)
+	return entry
+}
+
+func getRoleEntry(role *object.Role, rm *RoleMembership, gidNumber string, suffix string) *Entry {
+	entry := NewEntry(getRoleDN(role.GetId(), suffix))
+	entry.AddAttribute("objectClass", "top", "groupOfNames", "posixGroup")
+	entry.AddAttribute("cn", role.Name)
+	entry.AddAttribute("description", role.DisplayName)
+	entry.AddAttribute("gidNumber", gidNumber)
+	entry.AddAttribute("entryUUID", role.GetId())
+	entry.AddAttribute("createTimestamp", getGeneralizedTime(role.CreatedTime))
+
+	// groupOfNames lists direct members, nested roles included, while posixGroup has no
+	// nesting so memberUid lists all the effective users
+	for _, userId := range role.Users {
+		entry.AddAttribute("member", getUserDN(userId, suffix))
+	}
+	for _, subRoleId := range role.Roles {
+		entry.AddAttribute("member", getRoleDN(subRoleId, suffix))
+	}
+	for _, userId := range rm.members[role.GetId()] {
+		_, name := util.GetOwnerAndNameFromIdNoCheck(userId)
+		entry.AddAttribute("memberUid", name)
+	}
+	return entry
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/casdoor/casdoor/object"
+)
+
+func TestRoleMembership(t *testing.T) {
+	roles := []*object.Role{
+		{Owner: "casbin", Name: "staff", IsEnabled: true, Users: []string{"casbin/alice"}, Roles: []string{"casbin/admins", "casbin/devs"}},
+		{Owner: "casbin", Name: "admins", IsEnabled: true, Users: []string{"casbin/bob"}},
+		{Owner: "casbin", Name: "devs", IsEnabled: true, Users: []string{"casbin/carol"}, Roles: []string{"casbin/staff"}},
+		{Owner: "casbin", Name: "disabled", IsEnabled: false, Users: []string{"casbin/alice"}},
+	}
+	rm := NewRoleMembership(roles)
+
+	getRoleIds := func(userId string) []string {
+		res := []string{}
+		for _, role := range rm.GetUserRoles(userId) {
+			res = append(res, role.GetId())
+		}
+		return res
+	}
+
+	scenarios := []struct {
+		userId   string
+		expected []string
+	}{
+		{"casbin/alice", []string{"casbin/devs", "casbin/staff"}},
+		{"casbin/bob", []string{"casbin/admins", "casbin/devs", "casbin/staff"}},
+		{"casbin/carol", []string{"casbin/devs", "casbin/staff"}},
+		{"casbin/dave", []string{}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.userId, func(t *testing.T) {
+			actual := getRoleIds(scenario.userId)
+			if !reflect.DeepEqual(actual, scenario.expected) {
+				t.Errorf("GetUserRoles(%s) = %v, expected %v", scenario.userId, actual, scenario.expected)
+			}
+		})
+	}
+
+	entry := getRoleEntry(roles[1], rm, "10001", "dc=example,dc=com")
+	if entry.DN != "cn=admins,ou=groups,ou=casbin,dc=example,dc=com" {
+		t.Errorf("unexpected role DN: %s", entry.DN)
+	}
+	if !reflect.DeepEqual(entry.GetAttributeValues("member"), []string{"cn=bob,ou=casbin,dc=example,dc=com"}) {
+		t.Errorf("unexpected members: %v", entry.GetAttributeValues("member"))
+	}
+}
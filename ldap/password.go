@@ -0,0 +1,151 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	ldap "github.com/forestmgy/ldapserver"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/lor00x/goldap/message"
+)
+
+type passwordModifyRequest struct {
+	UserIdentity string
+	OldPassword  string
+	NewPassword  string
+}
+
+// parsePasswordModifyRequest decodes the value of an RFC 3062 password modify request:
+//
+//	PasswdModifyRequestValue ::= SEQUENCE {
+//	  userIdentity [0] OCTET STRING OPTIONAL
+//	  oldPasswd    [1] OCTET STRING OPTIONAL
+//	  newPasswd    [2] OCTET STRING OPTIONAL }
+func parsePasswordModifyRequest(value *message.OCTETSTRING) (*passwordModifyRequest, error) {
+	res := &passwordModifyRequest{}
+	if value == nil {
+		return res, nil
+	}
+
+	packet, err := ber.DecodePacketErr([]byte(*value))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range packet.Children {
+		if child.ClassType != ber.ClassContext {
+			return nil, fmt.Errorf("invalid password modify request")
+		}
+
+		switch child.Tag {
+		case 0:
+			res.UserIdentity = child.Data.String()
+		case 1:
+			res.OldPassword = child.Data.String()
+		case 2:
+			res.NewPassword = child.Data.String()
+		default:
+			return nil, fmt.Errorf("invalid password modify request tag: %d", child.Tag)
+		}
+	}
+	return res, nil
+}
+
+// handlePasswordModify changes the password of the bound user, or of the user identified by its DN
+// for admins, e.g. with "ldappasswd". The new password goes through the same checks as the web.
+func handlePasswordModify(w ldap.ResponseWriter, m *ldap.Message) {
+	res := ldap.NewExtendedResponse(ldap.LDAPResultSuccess)
+	r := m.GetExtendedRequest()
+
+	if !m.Client.IsAuthenticated {
+		res.SetResultCode(ldap.LDAPResultUnwillingToPerform)
+		w.Write(res)
+		return
+	}
+
+	if _, ok := getBoundServiceAccount(m); ok {
+		res.SetResultCode(ldap.LDAPResultInsufficientAccessRights)
+		res.SetDiagnosticMessage("service accounts are read-only")
+		w.Write(res)
+		return
+	}
+
+	request, err := parsePasswordModifyRequest(r.RequestValue())
+	if err != nil {
+		log.Printf("handlePasswordModify() error: %s", err.Error())
+		res.SetResultCode(ldap.LDAPResultProtocolError)
+		w.Write(res)
+		return
+	}
+
+	if request.NewPassword == "" {
+		res.SetResultCode(ldap.LDAPResultUnwillingToPerform)
+		res.SetDiagnosticMessage("generating passwords is not supported, please provide the new password")
+		w.Write(res)
+		return
+	}
+
+	requestUserId := util.GetId(m.Client.OrgName, m.Client.UserName)
+	userId := requestUserId
+	if request.UserIdentity != "" {
+		name, org, msg := getNameAndOrgFromDN(request.UserIdentity)
+		if msg != "" {
+			res.SetResultCode(ldap.LDAPResultInvalidDNSyntax)
+			res.SetDiagnosticMessage(msg)
+			w.Write(res)
+			return
+		}
+		userId = util.GetId(org, name)
+	}
+
+	user := object.GetUser(userId)
+	if user == nil || user.IsDeleted {
+		res.SetResultCode(ldap.LDAPResultNoSuchObject)
+		w.Write(res)
+		return
+	}
+
+	hasPermission, err := object.CheckUserPermission(requestUserId, userId, true, "en")
+	if !hasPermission {
+		res.SetResultCode(ldap.LDAPResultInsufficientAccessRights)
+		res.SetDiagnosticMessage(err.Error())
+		w.Write(res)
+		return
+	}
+
+	if request.OldPassword != "" {
+		if msg := object.CheckPassword(user, request.OldPassword, "en", getSigninAttempt(m)); msg != "" {
+			res.SetResultCode(ldap.LDAPResultInvalidCredentials)
+			res.SetDiagnosticMessage(msg)
+			w.Write(res)
+			return
+		}
+	}
+
+	if msg := object.CheckNewPassword(user, request.NewPassword, "en"); msg != "" {
+		res.SetResultCode(ldap.LDAPResultConstraintViolation)
+		res.SetDiagnosticMessage(msg)
+		w.Write(res)
+		return
+	}
+
+	user.Password = request.NewPassword
+	object.SetUserField(user, "password", user.Password)
+	w.Write(res)
+}
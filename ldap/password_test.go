@@ -0,0 +1,60 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"reflect"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/lor00x/goldap/message"
+)
+
+func encodePasswordModifyRequest(request *passwordModifyRequest) *message.OCTETSTRING {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Password Modify Request")
+	for tag, value := range []string{request.UserIdentity, request.OldPassword, request.NewPassword} {
+		if value != "" {
+			packet.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, ber.Tag(tag), value, ""))
+		}
+	}
+
+	value := message.OCTETSTRING(packet.Bytes())
+	return &value
+}
+
+func TestParsePasswordModifyRequest(t *testing.T) {
+	scenarios := []*passwordModifyRequest{
+		{UserIdentity: "cn=alice,ou=casbin,dc=example,dc=com", OldPassword: "123456", NewPassword: "654321"},
+		{NewPassword: "654321"},
+		{},
+	}
+
+	for _, scenario := range scenarios {
+		actual, err := parsePasswordModifyRequest(encodePasswordModifyRequest(scenario))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual, scenario) {
+			t.Errorf("parsePasswordModifyRequest() = %v, expected %v", actual, scenario)
+		}
+	}
+
+	if _, _, ok := getServiceAccountNameAndOrgFromDN("cn=sssd,ou=service-accounts,ou=casbin,dc=example,dc=com"); !ok {
+		t.Errorf("service account DN not recognized")
+	}
+	if _, _, ok := getServiceAccountNameAndOrgFromDN("cn=alice,ou=casbin,dc=example,dc=com"); ok {
+		t.Errorf("user DN recognized as a service account")
+	}
+}
@@ -15,7 +15,6 @@
 package ldap
 
 import (
-	"fmt"
 	"log"
 
 	"github.com/casdoor/casdoor/conf"
@@ -30,6 +29,7 @@ func StartLdapServer() {
 
 	routes.Bind(handleBind)
 	routes.Search(handleSearch).Label(" SEARCH****")
+	routes.Extended(handlePasswordModify).RequestName(ldap.NoticeOfPasswordModify).Label(" PASSWORD MODIFY****")
 
 	server.Handle(routes)
 	err := server.ListenAndServe("0.0.0.0:" + conf.GetConfigString("ldapServerPort"))
@@ -43,6 +43,11 @@ func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 	res := ldap.NewBindResponse(ldap.LDAPResultSuccess)
 
 	if r.AuthenticationChoice() == "simple" {
+		if name, org, ok := getServiceAccountNameAndOrgFromDN(string(r.Name())); ok {
+			handleServiceAccountBind(w, m, name, org)
+			return
+		}
+
 		bindUsername, bindOrg, err := getNameAndOrgFromDN(string(r.Name()))
 		if err != "" {
 			log.Printf("Bind failed ,ErrMsg=%s", err)
@@ -53,7 +58,10 @@ func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 		}
 
 		bindPassword := string(r.AuthenticationSimple())
-		bindUser, err := object.CheckUserPassword(bindOrg, bindUsername, bindPassword, "en")
+		bindUser, err := object.CheckUserPassword(bindOrg, bindUsername, bindPassword, "en", getSigninAttempt(m))
+		if err == "" {
+			err = object.CheckPasswordExpired(bindUser, "en")
+		}
 		if err != "" {
 			log.Printf("Bind failed User=%s, Pass=%#v, ErrMsg=%s", string(r.Name()), r.Authentication(), err)
 			res.SetResultCode(ldap.LDAPResultInvalidCredentials)
@@ -62,6 +70,7 @@ func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 			return
 		}
 
+		m.Client.IsGlobalAdmin, m.Client.IsOrgAdmin = false, false
 		if bindOrg == "built-in" || bindUser.IsGlobalAdmin {
 			m.Client.IsGlobalAdmin, m.Client.IsOrgAdmin = true, true
 		} else if bindUser.IsAdmin {
@@ -78,16 +87,42 @@ func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 	w.Write(res)
 }
 
+func handleServiceAccountBind(w ldap.ResponseWriter, m *ldap.Message, name string, org string) {
+	r := m.GetBindRequest()
+	res := ldap.NewBindResponse(ldap.LDAPResultSuccess)
+
+	_, err := object.CheckLdapServiceAccountPassword(org, name, string(r.AuthenticationSimple()), "en")
+	if err != "" {
+		log.Printf("Bind failed ServiceAccount=%s, ErrMsg=%s", string(r.Name()), err)
+		res.SetResultCode(ldap.LDAPResultInvalidCredentials)
+		res.SetDiagnosticMessage("invalid credentials ErrMsg: " + err)
+		w.Write(res)
+		return
+	}
+
+	m.Client.IsGlobalAdmin, m.Client.IsOrgAdmin = false, false
+	m.Client.IsAuthenticated = true
+	m.Client.UserName = serviceAccountPrefix + name
+	m.Client.OrgName = org
+	w.Write(res)
+}
+
 func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 	res := ldap.NewSearchResultDoneResponse(ldap.LDAPResultSuccess)
-	if !m.Client.IsAuthenticated {
-		res.SetResultCode(ldap.LDAPResultUnwillingToPerform)
+	r := m.GetSearchRequest()
+
+	// the root DSE is readable without binding, clients use it to discover the supported controls
+	if string(r.BaseObject()) == "" && r.Scope() == message.SearchRequestScopeBaseObject {
+		rootDSE := getRootDSE()
+		if rootDSE.MatchFilter(r.Filter()) {
+			w.Write(getSearchResultEntry(rootDSE, r))
+		}
 		w.Write(res)
 		return
 	}
 
-	r := m.GetSearchRequest()
-	if r.FilterString() == "(objectClass=*)" {
+	if !m.Client.IsAuthenticated {
+		res.SetResultCode(ldap.LDAPResultUnwillingToPerform)
 		w.Write(res)
 		return
 	}
@@ -100,25 +135,64 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 	default:
 	}
 
-	users, code := GetFilteredUsers(m)
+	paging, err := getPagedResultsControl(m)
+	if err != nil {
+		log.Printf("handleSearch() error: %s", err.Error())
+		res.SetResultCode(ldap.LDAPResultProtocolError)
+		w.Write(res)
+		return
+	}
+
+	entries, code := GetFilteredEntries(m)
 	if code != ldap.LDAPResultSuccess {
 		res.SetResultCode(code)
 		w.Write(res)
 		return
 	}
 
-	for _, user := range users {
-		dn := fmt.Sprintf("cn=%s,%s", user.Name, string(r.BaseObject()))
-		e := ldap.NewSearchResultEntry(dn)
+	if paging != nil {
+		handlePagedSearch(m, entries, paging)
+		return
+	}
 
-		for _, attr := range r.Attributes() {
-			e.AddAttribute(message.AttributeDescription(attr), getAttribute(string(attr), user))
-			if string(attr) == "cn" {
-				e.AddAttribute(message.AttributeDescription(attr), getAttribute("title", user))
-			}
+	sizeLimit := r.SizeLimit().Int()
+	for i, entry := range entries {
+		if sizeLimit > 0 && i >= sizeLimit {
+			res.SetResultCode(ldap.LDAPResultSizeLimitExceeded)
+			break
 		}
-
-		w.Write(e)
+		w.Write(getSearchResultEntry(entry, r))
 	}
 	w.Write(res)
 }
+
+func handlePagedSearch(m *ldap.Message, entries []*Entry, paging *pagedResultsControl) {
+	r := m.GetSearchRequest()
+	res := ldap.NewSearchResultDoneResponse(ldap.LDAPResultSuccess)
+
+	// a page size of zero abandons the paged search
+	start, end := paging.Offset, paging.Offset+paging.Size
+	if start > len(entries) || paging.Size == 0 {
+		start = len(entries)
+	}
+	if end > len(entries) || paging.Size == 0 {
+		end = len(entries)
+	}
+
+	for _, entry := range entries[start:end] {
+		err := writeMessage(m, getSearchResultEntry(entry, r))
+		if err != nil {
+			log.Printf("handlePagedSearch() error: %s", err.Error())
+			return
+		}
+	}
+
+	nextOffset := 0
+	if end < len(entries) {
+		nextOffset = end
+	}
+	err := writeMessage(m, res, newPagedResultsResponseControl(nextOffset))
+	if err != nil {
+		log.Printf("handlePagedSearch() error: %s", err.Error())
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	ldap "github.com/forestmgy/ldapserver"
+)
+
+// service accounts bind as "cn=<name>,ou=service-accounts,ou=<organization>,<suffix>"
+const serviceAccountsOu = "service-accounts"
+
+// the client bound as a service account has this prefix in its user name, no user name can contain a "/"
+const serviceAccountPrefix = serviceAccountsOu + "/"
+
+func getServiceAccountNameAndOrgFromDN(DN string) (string, string, bool) {
+	components := parseDN(DN)
+	if len(components) < 3 || components[0].Attribute != "cn" ||
+		components[1].Attribute != "ou" || !strings.EqualFold(components[1].Value, serviceAccountsOu) ||
+		components[2].Attribute != "ou" {
+		return "", "", false
+	}
+	return components[0].Value, components[2].Value, true
+}
+
+// getBoundServiceAccount returns whether the client is bound as a service account, and the account if it's
+// still enabled. The account is loaded on every request so disabling it takes effect on open connections.
+func getBoundServiceAccount(m *ldap.Message) (*object.LdapServiceAccount, bool) {
+	if !strings.HasPrefix(m.Client.UserName, serviceAccountPrefix) {
+		return nil, false
+	}
+
+	name := strings.TrimPrefix(m.Client.UserName, serviceAccountPrefix)
+	account := object.GetLdapServiceAccount(util.GetId(m.Client.OrgName, name))
+	if account == nil || !account.IsEnabled {
+		return nil, true
+	}
+	return account, true
+}
+
+func canServiceAccountReadOrganization(account *object.LdapServiceAccount, org string) bool {
+	if len(account.Organizations) == 0 {
+		return org == account.Owner
+	}
+	if account.Owner != "built-in" && org != account.Owner {
+		return false
+	}
+	return account.Owner == "built-in" && util.InSlice(account.Organizations, "*") ||
+		util.InSlice(account.Organizations, org)
+}
+
+func canServiceAccountReadUser(account *object.LdapServiceAccount, rm *RoleMembership, user *object.User) bool {
+	if user.IsDeleted || !canServiceAccountReadOrganization(account, user.Owner) {
+		return false
+	}
+	if len(account.Roles) == 0 {
+		return true
+	}
+
+	for _, role := range rm.GetUserRoles(user.GetId()) {
+		if util.InSlice(account.Roles, role.GetId()) {
+			return true
+		}
+	}
+	return false
+}
+
+func getServiceAccountVisibleUsers(account *object.LdapServiceAccount, rm *RoleMembership, org string, name string) ([]*object.User, int) {
+	if account == nil || org != "*" && !canServiceAccountReadOrganization(account, org) {
+		return nil, ldap.LDAPResultInsufficientAccessRights
+	}
+
+	var users []*object.User
+	if name != "" {
+		user := object.GetUser(util.GetId(org, name))
+		if user == nil || !canServiceAccountReadUser(account, rm, user) {
+			return nil, ldap.LDAPResultNoSuchObject
+		}
+		return []*object.User{user}, ldap.LDAPResultSuccess
+	} else if org == "*" {
+		users = object.GetGlobalUsers()
+	} else {
+		users = object.GetUsers(org)
+	}
+
+	res := []*object.User{}
+	for _, user := range users {
+		if canServiceAccountReadUser(account, rm, user) {
+			res = append(res, user)
+		}
+	}
+	return res, ldap.LDAPResultSuccess
+}
+
+func getServiceAccountVisibleRoles(account *object.LdapServiceAccount, rm *RoleMembership, org string) ([]*object.Role, int) {
+	if account == nil || org != "*" && !canServiceAccountReadOrganization(account, org) {
+		return nil, ldap.LDAPResultInsufficientAccessRights
+	}
+
+	res := []*object.Role{}
+	for _, role := range rm.GetRoles() {
+		if !canServiceAccountReadOrganization(account, role.Owner) {
+			continue
+		}
+		if len(account.Roles) == 0 || util.InSlice(account.Roles, role.GetId()) {
+			res = append(res, role)
+		}
+	}
+	return res, ldap.LDAPResultSuccess
+}
@@ -0,0 +1,204 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+
+	ldap "github.com/forestmgy/ldapserver"
+)
+
+// groups are published as organizational units under "ou=units,ou=<organization>,<suffix>",
+// e.g. "ou=backend,ou=engineering,ou=units,ou=casbin,dc=example,dc=com" for "casbin/engineering/backend"
+const unitsOu = "units"
+
+func getUnitsDN(owner string, suffix string) string {
+	return joinDN(fmt.Sprintf("ou=%s,ou=%s", unitsOu, owner), suffix)
+}
+
+// getUnitDN returns the DN of the group path, e.g. "casbin/engineering/backend"
+func getUnitDN(groupPath string, suffix string) string {
+	tokens := strings.Split(groupPath, "/")
+	rdns := []string{}
+	for i := len(tokens) - 1; i >= 1; i-- {
+		rdns = append(rdns, fmt.Sprintf("ou=%s", tokens[i]))
+	}
+	rdns = append(rdns, fmt.Sprintf("ou=%s,ou=%s", unitsOu, tokens[0]))
+	return joinDN(strings.Join(rdns, ","), suffix)
+}
+
+// hasUnitsOu returns whether the leading "ou" components contain the units entry
+func hasUnitsOu(components []dnComponent) bool {
+	for i, component := range components {
+		if component.Attribute != "ou" {
+			return false
+		}
+		if strings.EqualFold(component.Value, unitsOu) && i+1 < len(components) && components[i+1].Attribute == "ou" {
+			return true
+		}
+	}
+	return false
+}
+
+// getUnitTree returns the enabled groups of the organization, an empty tree for all organizations
+func getUnitTree(org string) *object.GroupTree {
+	groups := []*object.Group{}
+	if org != "*" {
+		for _, group := range object.GetGroups(org) {
+			if group.IsEnabled {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return object.NewGroupTree(groups)
+}
+
+func getUnitsEntry(owner string, suffix string) *Entry {
+	entry := NewEntry(getUnitsDN(owner, suffix))
+	entry.AddAttribute("objectClass", "top", "organizationalUnit")
+	entry.AddAttribute("ou", unitsOu)
+	return entry
+}
+
+func getUnitEntry(group *object.Group, tree *object.GroupTree, suffix string) *Entry {
+	entry := NewEntry(getUnitDN(tree.GetPath(group.Name), suffix))
+	entry.AddAttribute("objectClass", "top", "organizationalUnit")
+	entry.AddAttribute("ou", group.Name)
+	entry.AddAttribute("description", group.DisplayName)
+	entry.AddAttribute("entryUUID", group.GetId())
+	entry.AddAttribute("createTimestamp", getGeneralizedTime(group.CreatedTime))
+	return entry
+}
+
+// getVisibleUnits returns the groups of the organization the bound client is allowed to read,
+// admins and service accounts can read all the groups while users can only read their own groups
+func getVisibleUnits(m *ldap.Message, tree *object.GroupTree, org string) ([]*object.Group, int) {
+	if account, ok := getBoundServiceAccount(m); ok {
+		if account == nil || !canServiceAccountReadOrganization(account, org) {
+			return nil, ldap.LDAPResultInsufficientAccessRights
+		}
+		return tree.GetGroups(), ldap.LDAPResultSuccess
+	} else if m.Client.IsGlobalAdmin || (m.Client.IsOrgAdmin && org == m.Client.OrgName) {
+		return tree.GetGroups(), ldap.LDAPResultSuccess
+	} else if org == m.Client.OrgName {
+		user := object.GetUser(util.GetId(m.Client.OrgName, m.Client.UserName))
+		if user == nil {
+			return []*object.Group{}, ldap.LDAPResultSuccess
+		}
+		return tree.GetUserGroups(user), ldap.LDAPResultSuccess
+	} else {
+		return nil, ldap.LDAPResultInsufficientAccessRights
+	}
+}
+
+// getUnitEntries returns the entries within the base and scope of a search below "ou=units",
+// the users of a group are listed as its children with their own DNs
+func getUnitEntries(m *ldap.Message, base *searchBase, isBaseInScope bool, isChildInScope bool, isSubtreeInScope bool) ([]*Entry, int) {
+	if base.Org == "*" || base.Name != "" {
+		return nil, ldap.LDAPResultNoSuchObject
+	}
+
+	tree := getUnitTree(base.Org)
+	groups, code := getVisibleUnits(m, tree, base.Org)
+	if code != ldap.LDAPResultSuccess {
+		return nil, code
+	}
+
+	visible := map[string]bool{}
+	for _, group := range groups {
+		visible[group.Name] = true
+	}
+
+	entries := []*Entry{}
+	var unit *object.Group
+	if len(base.UnitPath) == 0 {
+		if isBaseInScope {
+			entries = append(entries, getUnitsEntry(base.Org, base.Suffix))
+		}
+	} else {
+		unit = tree.GetGroupByPath(fmt.Sprintf("%s/%s", base.Org, strings.Join(base.UnitPath, "/")))
+		if unit == nil || !visible[unit.Name] {
+			return nil, ldap.LDAPResultNoSuchObject
+		}
+		if isBaseInScope {
+			entries = append(entries, getUnitEntry(unit, tree, base.Suffix))
+		}
+	}
+
+	if !isChildInScope {
+		return entries, ldap.LDAPResultSuccess
+	}
+
+	// the groups one level below the base, or all the groups below it for a subtree search
+	children := []*object.Group{}
+	if isSubtreeInScope {
+		if unit == nil {
+			children = groups
+		} else {
+			children = tree.GetDescendants(unit.Name)[1:]
+		}
+	} else {
+		parentName := ""
+		if unit != nil {
+			parentName = unit.Name
+		}
+		children = tree.GetChildren(parentName)
+	}
+	for _, child := range children {
+		if visible[child.Name] {
+			entries = append(entries, getUnitEntry(child, tree, base.Suffix))
+		}
+	}
+
+	if unit == nil {
+		return entries, ldap.LDAPResultSuccess
+	}
+
+	groupIds := []string{unit.GetId()}
+	if isSubtreeInScope {
+		groupIds = []string{}
+		for _, group := range tree.GetDescendants(unit.Name) {
+			groupIds = append(groupIds, group.GetId())
+		}
+	}
+
+	rm := NewRoleMembership(object.GetRoles(base.Org))
+	users, code := getVisibleUsers(m, rm, base.Org, "")
+	if code != ldap.LDAPResultSuccess {
+		return nil, code
+	}
+
+	_, isServiceAccount := getBoundServiceAccount(m)
+	withPassword := isAttributeRequested(m.GetSearchRequest(), "userPassword") && !isServiceAccount
+	unitUsers := []*object.User{}
+	for _, user := range users {
+		for _, groupId := range user.Groups {
+			if util.InSlice(groupIds, groupId) {
+				unitUsers = append(unitUsers, user)
+				break
+			}
+		}
+	}
+
+	uidNumbers := object.GetPosixIds(object.PosixIdTypeUser, getUserIds(unitUsers))
+	for _, user := range unitUsers {
+		entries = append(entries, getUserEntry(user, rm, tree, uidNumbers[user.GetId()], base.Suffix, withPassword))
+	}
+	return entries, ldap.LDAPResultSuccess
+}
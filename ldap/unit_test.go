@@ -0,0 +1,56 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"reflect"
+	"testing"
+
+	ldap "github.com/forestmgy/ldapserver"
+)
+
+func TestGetSearchBase(t *testing.T) {
+	scenarios := []struct {
+		baseDN   string
+		expected *searchBase
+	}{
+		{"ou=casbin,dc=example,dc=com", &searchBase{Org: "casbin", Suffix: "dc=example,dc=com"}},
+		{"cn=alice,ou=casbin,dc=example,dc=com", &searchBase{Name: "alice", Org: "casbin", Suffix: "dc=example,dc=com"}},
+		{"cn=admins,ou=groups,ou=casbin,dc=example,dc=com", &searchBase{Name: "admins", Org: "casbin", IsGroups: true, Suffix: "dc=example,dc=com"}},
+		{"ou=units,ou=casbin,dc=example,dc=com", &searchBase{Org: "casbin", IsUnits: true, Suffix: "dc=example,dc=com"}},
+		{
+			"ou=backend,ou=engineering,ou=units,ou=casbin,dc=example,dc=com",
+			&searchBase{Org: "casbin", IsUnits: true, UnitPath: []string{"engineering", "backend"}, Suffix: "dc=example,dc=com"},
+		},
+		{"ou=units,dc=example,dc=com", &searchBase{Org: "units", Suffix: "dc=example,dc=com"}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.baseDN, func(t *testing.T) {
+			actual, code := getSearchBase(scenario.baseDN)
+			if code != ldap.LDAPResultSuccess {
+				t.Fatalf("getSearchBase(%s) failed with code %d", scenario.baseDN, code)
+			}
+			if !reflect.DeepEqual(actual, scenario.expected) {
+				t.Errorf("getSearchBase(%s) = %+v, expected %+v", scenario.baseDN, actual, scenario.expected)
+			}
+		})
+	}
+
+	dn := getUnitDN("casbin/engineering/backend", "dc=example,dc=com")
+	if dn != "ou=backend,ou=engineering,ou=units,ou=casbin,dc=example,dc=com" {
+		t.Errorf("unexpected unit DN: %s", dn)
+	}
+}
@@ -17,15 +17,40 @@ package ldap
 import (
 	"fmt"
 	"log"
+	"net"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
+	goldap "github.com/go-ldap/ldap/v3"
 	"github.com/lor00x/goldap/message"
 
 	ldap "github.com/forestmgy/ldapserver"
 )
 
+type dnComponent struct {
+	Attribute string
+	Value     string
+}
+
+func parseDN(DN string) []dnComponent {
+	components := []dnComponent{}
+	for _, field := range strings.Split(DN, ",") {
+		tokens := strings.SplitN(field, "=", 2)
+		if len(tokens) != 2 {
+			continue
+		}
+
+		components = append(components, dnComponent{
+			Attribute: strings.ToLower(strings.TrimSpace(tokens[0])),
+			Value:     strings.TrimSpace(tokens[1]),
+		})
+	}
+	return components
+}
+
 func getNameAndOrgFromDN(DN string) (string, string, string) {
 	DNFields := strings.Split(DN, ",")
 	params := make(map[string]string, len(DNFields))
@@ -45,92 +70,374 @@ func getNameAndOrgFromDN(DN string) (string, string, string) {
 	return params["cn"], params["ou"], ""
 }
 
-func getNameAndOrgFromFilter(baseDN, filter string) (string, string, int) {
-	if !strings.Contains(baseDN, "ou=") {
-		return "", "", ldap.LDAPResultInvalidDNSyntax
+type searchBase struct {
+	// Name is the user or role the base DN points at, empty for the organization, groups or units entry
+	Name     string
+	Org      string
+	IsGroups bool
+	IsUnits  bool
+	// UnitPath is the group names from the top group down to the group the base DN points at
+	UnitPath []string
+	// Suffix is the part of the base DN after the organization, e.g. "dc=example,dc=com"
+	Suffix string
+}
+
+// getSearchBase parses a search base DN like "cn=alice,ou=org,dc=example,dc=com",
+// "cn=admins,ou=groups,ou=org,dc=example,dc=com" for the groups of an organization, or
+// "ou=backend,ou=engineering,ou=units,ou=org,dc=example,dc=com" for its organizational units
+func getSearchBase(baseDN string) (*searchBase, int) {
+	components := parseDN(baseDN)
+	rdns := strings.Split(baseDN, ",")
+
+	res := &searchBase{}
+	for i, component := range components {
+		if component.Attribute == "ou" {
+			hasParentOu := i+1 < len(components) && components[i+1].Attribute == "ou"
+			if hasParentOu && strings.EqualFold(component.Value, groupsOu) {
+				res.IsGroups = true
+				continue
+			}
+			if hasParentOu && strings.EqualFold(component.Value, unitsOu) {
+				res.IsUnits = true
+				continue
+			}
+			if hasParentOu && res.Name == "" && !res.IsGroups && !res.IsUnits && hasUnitsOu(components[i+1:]) {
+				res.UnitPath = append([]string{component.Value}, res.UnitPath...)
+				continue
+			}
+
+			res.Org = component.Value
+			res.Suffix = strings.TrimSpace(strings.Join(rdns[i+1:], ","))
+			return res, ldap.LDAPResultSuccess
+		}
+		if component.Attribute == "cn" || component.Attribute == "uid" {
+			res.Name = component.Value
+		}
 	}
 
-	name, org, _ := getNameAndOrgFromDN(fmt.Sprintf("cn=%s,", getUsername(filter)) + baseDN)
-	return name, org, ldap.LDAPResultSuccess
+	return nil, ldap.LDAPResultInvalidDNSyntax
 }
 
-func getUsername(filter string) string {
-	nameIndex := strings.Index(filter, "cn=")
-	if nameIndex == -1 {
-		nameIndex = strings.Index(filter, "uid=")
-		if nameIndex == -1 {
-			return "*"
-		} else {
-			nameIndex += 4
+// getVisibleUsers returns the users of the organization the bound client is allowed to read,
+// a user name narrows the result down to that single user
+func getVisibleUsers(m *ldap.Message, rm *RoleMembership, org string, name string) ([]*object.User, int) {
+	if account, ok := getBoundServiceAccount(m); ok {
+		return getServiceAccountVisibleUsers(account, rm, org, name)
+	}
+
+	if name != "" {
+		user := object.GetUser(util.GetId(org, name))
+		if user == nil || user.IsDeleted {
+			return nil, ldap.LDAPResultNoSuchObject
 		}
-	} else {
-		nameIndex += 3
+
+		requestUserId := util.GetId(m.Client.OrgName, m.Client.UserName)
+		hasPermission, err := object.CheckUserPermission(requestUserId, user.GetId(), true, "en")
+		if !hasPermission {
+			log.Printf("ErrMsg = %v", err.Error())
+			return nil, ldap.LDAPResultInsufficientAccessRights
+		}
+		return []*object.User{user}, ldap.LDAPResultSuccess
 	}
 
-	var name string
-	for i := nameIndex; filter[i] != ')'; i++ {
-		name = name + string(filter[i])
+	var users []*object.User
+	if m.Client.IsGlobalAdmin && org == "*" {
+		users = object.GetGlobalUsers()
+	} else if m.Client.IsGlobalAdmin || (m.Client.IsOrgAdmin && org == m.Client.OrgName) {
+		users = object.GetUsers(org)
+	} else if org == m.Client.OrgName {
+		user := object.GetUser(util.GetId(m.Client.OrgName, m.Client.UserName))
+		if user != nil {
+			users = append(users, user)
+		}
+	} else {
+		return nil, ldap.LDAPResultInsufficientAccessRights
 	}
-	return name
-}
 
-func stringInSlice(value string, list []string) bool {
-	for _, item := range list {
-		if item == value {
-			return true
+	res := []*object.User{}
+	for _, user := range users {
+		if !user.IsDeleted {
+			res = append(res, user)
 		}
 	}
-	return false
+	return res, ldap.LDAPResultSuccess
 }
 
-func GetFilteredUsers(m *ldap.Message) (filteredUsers []*object.User, code int) {
+// GetFilteredEntries returns the entries within the base and scope of the search request that match its filter.
+// An organization holds its users, the "ou=groups" entry, which holds the roles as groups, and the "ou=units"
+// entry, which holds the groups as organizational units.
+func GetFilteredEntries(m *ldap.Message) ([]*Entry, int) {
 	r := m.GetSearchRequest()
 
-	name, org, code := getNameAndOrgFromFilter(string(r.BaseObject()), r.FilterString())
+	base, code := getSearchBase(string(r.BaseObject()))
 	if code != ldap.LDAPResultSuccess {
 		return nil, code
 	}
 
-	if name == "*" && m.Client.IsOrgAdmin { // get all users from organization 'org'
-		if m.Client.IsGlobalAdmin && org == "*" {
-			filteredUsers = object.GetGlobalUsers()
-			return filteredUsers, ldap.LDAPResultSuccess
+	// whether the entries one and two levels below the base are in the scope
+	scope := int(r.Scope())
+	isBaseInScope := scope != message.SearchRequestSingleLevel
+	isChildInScope := scope != message.SearchRequestScopeBaseObject
+	isGrandchildInScope := scope == message.SearchRequestHomeSubtree
+
+	if base.IsUnits {
+		entries, code := getUnitEntries(m, base, isBaseInScope, isChildInScope, isGrandchildInScope)
+		if code != ldap.LDAPResultSuccess {
+			return nil, code
 		}
-		if m.Client.IsGlobalAdmin || org == m.Client.OrgName {
-			filteredUsers = object.GetUsers(org)
-			return filteredUsers, ldap.LDAPResultSuccess
-		} else {
-			return nil, ldap.LDAPResultInsufficientAccessRights
+		return filterEntries(entries, r), ldap.LDAPResultSuccess
+	}
+
+	entries := []*Entry{}
+	if base.Org != "*" && base.Name == "" && !base.IsGroups && isBaseInScope {
+		organization := object.GetOrganization(util.GetId("admin", base.Org))
+		if organization == nil {
+			return nil, ldap.LDAPResultNoSuchObject
+		}
+		entries = append(entries, getOrganizationEntry(organization, joinDN(fmt.Sprintf("ou=%s", base.Org), base.Suffix)))
+	}
+
+	if base.Org != "*" && base.Name == "" && (base.IsGroups && isBaseInScope || !base.IsGroups && isChildInScope) {
+		entries = append(entries, getGroupsEntry(base.Org, base.Suffix))
+	}
+
+	// the units entry is a sibling of the groups entry, the units below it are only listed by subtree searches
+	if base.Org != "*" && base.Name == "" && !base.IsGroups && isChildInScope {
+		unitEntries, code := getUnitEntries(m, &searchBase{Org: base.Org, Suffix: base.Suffix, IsUnits: true}, true, isGrandchildInScope, true)
+		if code != ldap.LDAPResultSuccess {
+			return nil, code
+		}
+		entries = append(entries, unitEntries...)
+	}
+
+	isUsersInScope := !base.IsGroups && (base.Name == "" && isChildInScope || base.Name != "" && isBaseInScope)
+	isRolesInScope := base.Name == "" && (base.IsGroups && isChildInScope || !base.IsGroups && isGrandchildInScope) ||
+		base.IsGroups && base.Name != "" && isBaseInScope
+
+	if isUsersInScope || isRolesInScope {
+		rm := NewRoleMembership(object.GetRoles(getRolesOwner(base.Org)))
+		tree := getUnitTree(base.Org)
+
+		if isUsersInScope {
+			users, code := getVisibleUsers(m, rm, base.Org, base.Name)
+			if code != ldap.LDAPResultSuccess {
+				return nil, code
+			}
+
+			// service accounts are read-only and never get the password hashes
+			_, isServiceAccount := getBoundServiceAccount(m)
+			withPassword := isAttributeRequested(r, "userPassword") && !isServiceAccount
+			uidNumbers := object.GetPosixIds(object.PosixIdTypeUser, getUserIds(users))
+			for _, user := range users {
+				entries = append(entries, getUserEntry(user, rm, tree, uidNumbers[user.GetId()], base.Suffix, withPassword))
+			}
+		}
+
+		if isRolesInScope {
+			roles, code := getVisibleRoles(m, rm, base.Org, base.Name)
+			if code != ldap.LDAPResultSuccess {
+				return nil, code
+			}
+
+			roleIds := []string{}
+			for _, role := range roles {
+				roleIds = append(roleIds, role.GetId())
+			}
+			gidNumbers := object.GetPosixIds(object.PosixIdTypeRole, roleIds)
+			for _, role := range roles {
+				entries = append(entries, getRoleEntry(role, rm, gidNumbers[role.GetId()], base.Suffix))
+			}
+		}
+	}
+
+	return filterEntries(entries, r), ldap.LDAPResultSuccess
+}
+
+func getUserIds(users []*object.User) []string {
+	userIds := []string{}
+	for _, user := range users {
+		userIds = append(userIds, user.GetId())
+	}
+	return userIds
+}
+
+func filterEntries(entries []*Entry, r message.SearchRequest) []*Entry {
+	filteredEntries := []*Entry{}
+	for _, entry := range entries {
+		if entry.MatchFilter(r.Filter()) {
+			filteredEntries = append(filteredEntries, entry)
 		}
+	}
+	return filteredEntries
+}
+
+func getRolesOwner(org string) string {
+	// an empty owner gets the roles of all organizations
+	if org == "*" {
+		return ""
+	}
+	return org
+}
+
+// getVisibleRoles returns the roles of the organization the bound client is allowed to read,
+// admins can read all the roles while users can only read the roles they are members of,
+// and service accounts the roles in their scope
+func getVisibleRoles(m *ldap.Message, rm *RoleMembership, org string, name string) ([]*object.Role, int) {
+	var roles []*object.Role
+	if account, ok := getBoundServiceAccount(m); ok {
+		var code int
+		roles, code = getServiceAccountVisibleRoles(account, rm, org)
+		if code != ldap.LDAPResultSuccess {
+			return nil, code
+		}
+	} else if m.Client.IsGlobalAdmin || (m.Client.IsOrgAdmin && org == m.Client.OrgName) {
+		roles = rm.GetRoles()
+	} else if org == m.Client.OrgName {
+		roles = rm.GetUserRoles(util.GetId(m.Client.OrgName, m.Client.UserName))
 	} else {
-		requestUserId := util.GetId(m.Client.OrgName, m.Client.UserName)
-		userId := util.GetId(org, name)
+		return nil, ldap.LDAPResultInsufficientAccessRights
+	}
 
-		hasPermission, err := object.CheckUserPermission(requestUserId, userId, true, "en")
-		if !hasPermission {
-			log.Printf("ErrMsg = %v", err.Error())
-			return nil, ldap.LDAPResultInsufficientAccessRights
+	if name == "" {
+		return roles, ldap.LDAPResultSuccess
+	}
+
+	for _, role := range roles {
+		if role.Owner == org && role.Name == name {
+			return []*object.Role{role}, ldap.LDAPResultSuccess
 		}
+	}
+	return nil, ldap.LDAPResultNoSuchObject
+}
 
-		user := object.GetUser(userId)
-		if user != nil {
-			filteredUsers = append(filteredUsers, user)
-			return filteredUsers, ldap.LDAPResultSuccess
+func isAttributeRequested(r message.SearchRequest, name string) bool {
+	for _, attribute := range r.Attributes() {
+		if strings.EqualFold(getAttributeType(string(attribute)), name) {
+			return true
 		}
+	}
+	return false
+}
 
-		organization := object.GetOrganization(util.GetId("admin", org))
-		if organization == nil {
-			return nil, ldap.LDAPResultNoSuchObject
+// getSearchResultEntry converts an entry to a search result, keeping only the requested attributes.
+// No requested attributes or "*" means all attributes except userPassword, "1.1" means none.
+func getSearchResultEntry(entry *Entry, r message.SearchRequest) message.SearchResultEntry {
+	e := ldap.NewSearchResultEntry(entry.DN)
+
+	addAttribute := func(name string, values []string) {
+		if r.TypesOnly() {
+			e.AddAttribute(message.AttributeDescription(name))
+			return
 		}
 
-		if !stringInSlice(name, organization.Tags) {
-			return nil, ldap.LDAPResultNoSuchObject
+		attributeValues := []message.AttributeValue{}
+		for _, value := range values {
+			attributeValues = append(attributeValues, message.AttributeValue(value))
+		}
+		e.AddAttribute(message.AttributeDescription(name), attributeValues...)
+	}
+
+	isAllAttributes := len(r.Attributes()) == 0
+	for _, attribute := range r.Attributes() {
+		if string(attribute) == "*" {
+			isAllAttributes = true
+		}
+	}
+
+	if isAllAttributes {
+		names := []string{}
+		for name := range entry.Attributes {
+			if name != "userPassword" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			addAttribute(name, entry.Attributes[name])
+		}
+	}
+
+	for _, attribute := range r.Attributes() {
+		name := string(attribute)
+		if name == "*" || name == "1.1" || isAllAttributes && !strings.EqualFold(name, "userPassword") {
+			continue
+		}
+
+		values := entry.GetAttributeValues(name)
+		if len(values) > 0 {
+			addAttribute(name, values)
 		}
+	}
+
+	return e
+}
 
-		users := object.GetUsersByTag(org, name)
-		filteredUsers = append(filteredUsers, users...)
-		return filteredUsers, ldap.LDAPResultSuccess
+func getRootDSE() *Entry {
+	entry := NewEntry("")
+	entry.AddAttribute("objectClass", "top")
+	entry.AddAttribute("vendorName", "Casdoor")
+	entry.AddAttribute("supportedLDAPVersion", "3")
+	entry.AddAttribute("supportedControl", goldap.ControlTypePaging)
+	entry.AddAttribute("supportedExtension", string(ldap.NoticeOfPasswordModify))
+	return entry
+}
+
+func getOrganizationEntry(organization *object.Organization, dn string) *Entry {
+	entry := NewEntry(dn)
+	entry.AddAttribute("objectClass", "top", "organizationalUnit")
+	entry.AddAttribute("ou", organization.Name)
+	entry.AddAttribute("description", organization.DisplayName)
+	return entry
+}
+
+// getUserEntry publishes the user as an inetOrgPerson and a posixAccount, the POSIX attributes
+// can be overridden with the user properties of the same names. The "ou" attribute lists the
+// organization and the groups the user is a direct or inherited member of.
+func getUserEntry(user *object.User, rm *RoleMembership, tree *object.GroupTree, uidNumber string, suffix string, withPassword bool) *Entry {
+	entry := NewEntry(getUserDN(user.GetId(), suffix))
+	entry.AddAttribute("objectClass", "top", "person", "organizationalPerson", "inetOrgPerson", "posixAccount")
+	entry.AddAttribute("cn", user.Name, user.Tag)
+	entry.AddAttribute("uid", user.Name)
+	entry.AddAttribute("sn", user.LastName)
+	entry.AddAttribute("givenName", user.FirstName)
+	entry.AddAttribute("displayName", user.DisplayName)
+	entry.AddAttribute("gecos", user.DisplayName)
+	entry.AddAttribute("mail", user.Email)
+	entry.AddAttribute("email", user.Email)
+	entry.AddAttribute("mobile", user.Phone)
+	entry.AddAttribute("title", user.Tag)
+	entry.AddAttribute("ou", user.Owner)
+	for _, group := range tree.GetUserGroups(user) {
+		entry.AddAttribute("ou", group.Name)
+	}
+	entry.AddAttribute("entryUUID", user.Id)
+	entry.AddAttribute("createTimestamp", getGeneralizedTime(user.CreatedTime))
+	entry.AddAttribute("modifyTimestamp", getGeneralizedTime(util.ReturnAnyNotEmpty(user.UpdatedTime, user.CreatedTime)))
+
+	uidNumber = util.ReturnAnyNotEmpty(user.Properties["uidNumber"], uidNumber)
+	entry.AddAttribute("uidNumber", uidNumber)
+	entry.AddAttribute("gidNumber", util.ReturnAnyNotEmpty(user.Properties["gidNumber"], uidNumber))
+	entry.AddAttribute("homeDirectory", util.ReturnAnyNotEmpty(user.Properties["homeDirectory"], fmt.Sprintf("/home/%s", user.Name)))
+	entry.AddAttribute("loginShell", util.ReturnAnyNotEmpty(user.Properties["loginShell"], "/bin/bash"))
+
+	for _, role := range rm.GetUserRoles(user.GetId()) {
+		entry.AddAttribute("memberOf", getRoleDN(role.GetId(), suffix))
 	}
+
+	if withPassword {
+		entry.AddAttribute("userPassword", getUserPasswordWithType(user))
+	}
+	return entry
+}
+
+// getGeneralizedTime converts an RFC 3339 time to the LDAP GeneralizedTime syntax, e.g. 20230102150405Z
+func getGeneralizedTime(rfc3339Time string) string {
+	t, err := time.Parse(time.RFC3339, rfc3339Time)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format("20060102150405Z")
 }
 
 // get user password with hash type prefix
@@ -148,29 +455,17 @@ func getUserPasswordWithType(user *object.User) string {
 		prefix = "md5"
 	} else if prefix == "pbkdf2-salt" {
 		prefix = "pbkdf2"
+	} else if strings.HasSuffix(prefix, "-crypt") {
+		prefix = "CRYPT"
 	}
 	return fmt.Sprintf("{%s}%s", prefix, user.Password)
 }
 
-func getAttribute(attributeName string, user *object.User) message.AttributeValue {
-	switch attributeName {
-	case "cn":
-		return message.AttributeValue(user.Name)
-	case "uid":
-		return message.AttributeValue(user.Name)
-	case "displayname":
-		return message.AttributeValue(user.DisplayName)
-	case "email":
-		return message.AttributeValue(user.Email)
-	case "mail":
-		return message.AttributeValue(user.Email)
-	case "mobile":
-		return message.AttributeValue(user.Phone)
-	case "title":
-		return message.AttributeValue(user.Tag)
-	case "userPassword":
-		return message.AttributeValue(getUserPasswordWithType(user))
-	default:
-		return ""
+// getSigninAttempt returns the client IP of the bind, which the lockout policy may count the failures per
+func getSigninAttempt(m *ldap.Message) *object.SigninAttempt {
+	clientIp := m.Client.Addr().String()
+	if host, _, err := net.SplitHostPort(clientIp); err == nil {
+		clientIp = host
 	}
+	return &object.SigninAttempt{ClientIp: clientIp}
 }
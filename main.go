@@ -46,6 +46,7 @@ func main() {
 	authz.InitAuthz()
 
 	util.SafeGoroutine(func() { object.RunSyncUsersJob() })
+	util.SafeGoroutine(func() { object.RunPurgeDeletedUsersJob() })
 
 	// beego.DelStaticPath("/static")
 	// beego.SetStaticPath("/static", "web/build/static")
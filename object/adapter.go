@@ -196,6 +196,61 @@ func (a *Adapter) createTable() {
 		panic(err)
 	}
 
+	err = a.Engine.Sync2(new(SyncerRun))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(Lease))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ScimToken))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ScimProvisioning))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(Group))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(Invitation))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(DataRequest))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(SigninLockout))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(Impersonation))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(UserMerge))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ApiKey))
+	if err != nil {
+		panic(err)
+	}
+
 	err = a.Engine.Sync2(new(Cert))
 	if err != nil {
 		panic(err)
@@ -226,6 +281,16 @@ func (a *Adapter) createTable() {
 		panic(err)
 	}
 
+	err = a.Engine.Sync2(new(LdapServiceAccount))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(PosixId))
+	if err != nil {
+		panic(err)
+	}
+
 	err = a.Engine.Sync2(new(PermissionRule))
 	if err != nil {
 		panic(err)
@@ -0,0 +1,290 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/thanhpk/randstr"
+	"github.com/xorm-io/core"
+)
+
+const (
+	// UserTypeServiceAccount is the type of the users for the machine clients, which authenticate the API with
+	// their API keys and can't sign in interactively
+	UserTypeServiceAccount = "service-account"
+
+	// ApiKeyPrefix starts the API keys, e.g. "cak_1f2e3d4c5b6a_<secret>", telling them from the access tokens
+	ApiKeyPrefix = "cak_"
+
+	// ApiKeyUsername is the request data of the service account authenticated by its API key, which is signed in
+	// for the request only and not in a session
+	ApiKeyUsername = "apiKeyUsername"
+
+	// apiKeyUsageInterval limits how often the last used time of an API key is written
+	apiKeyUsageInterval = time.Minute
+)
+
+// ApiKey is a named API key of a service account, only the hash of the key is stored and the key itself is
+// returned once when the API key is added or rotated
+type ApiKey struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	// User is the name of the service account in the owner
+	User string `xorm:"varchar(100) index" json:"user"`
+	// KeyId is the public part of the key to look it up, and KeyHash is the SHA-256 hash of the whole key
+	KeyId       string `xorm:"varchar(100) unique" json:"keyId"`
+	KeyHash     string `xorm:"varchar(100)" json:"-"`
+	ExpireTime  string `xorm:"varchar(100)" json:"expireTime"`
+	RotatedTime string `xorm:"varchar(100)" json:"rotatedTime"`
+
+	LastUsedTime string `xorm:"varchar(100)" json:"lastUsedTime"`
+	LastUsedIp   string `xorm:"varchar(100)" json:"lastUsedIp"`
+
+	// Key is only set in the responses of adding and rotating the API key
+	Key string `xorm:"-" json:"key,omitempty"`
+}
+
+func (apiKey *ApiKey) GetId() string {
+	return fmt.Sprintf("%s/%s", apiKey.Owner, apiKey.Name)
+}
+
+func (apiKey *ApiKey) GetUserId() string {
+	return util.GetId(apiKey.Owner, apiKey.User)
+}
+
+func (apiKey *ApiKey) isExpired(now time.Time) bool {
+	if apiKey.ExpireTime == "" {
+		return false
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, apiKey.ExpireTime)
+	return err != nil || !expireTime.After(now)
+}
+
+// generateKey sets a new key of the API key, and returns the key
+func (apiKey *ApiKey) generateKey() string {
+	apiKey.KeyId = randstr.Hex(6)
+	key := fmt.Sprintf("%s%s_%s", ApiKeyPrefix, apiKey.KeyId, randstr.Hex(20))
+	apiKey.KeyHash = util.GetSha256Hash(key)
+	return key
+}
+
+func (user *User) IsServiceAccount() bool {
+	return user.Type == UserTypeServiceAccount
+}
+
+// parseApiKey returns the key id of the API key, or "" if it isn't an API key
+func parseApiKey(key string) string {
+	if !strings.HasPrefix(key, ApiKeyPrefix) {
+		return ""
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(key, ApiKeyPrefix), "_")
+	if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+		return ""
+	}
+	return tokens[0]
+}
+
+func GetApiKeys(owner string, user string) []*ApiKey {
+	apiKeys := []*ApiKey{}
+	err := adapter.Engine.Desc("created_time").Find(&apiKeys, &ApiKey{Owner: owner, User: user})
+	if err != nil {
+		panic(err)
+	}
+
+	return apiKeys
+}
+
+func getApiKey(owner string, name string) *ApiKey {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	apiKey := ApiKey{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&apiKey)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &apiKey
+	}
+	return nil
+}
+
+func GetApiKey(id string) *ApiKey {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getApiKey(owner, name)
+}
+
+func getApiKeyByKeyId(keyId string) *ApiKey {
+	if keyId == "" {
+		return nil
+	}
+
+	apiKey := ApiKey{KeyId: keyId}
+	existed, err := adapter.Engine.Get(&apiKey)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &apiKey
+	}
+	return nil
+}
+
+func checkApiKeyExpireTime(expireTime string, lang string) error {
+	if expireTime == "" {
+		return nil
+	}
+
+	if _, err := time.Parse(time.RFC3339, expireTime); err != nil {
+		return fmt.Errorf(i18n.Translate(lang, "apiKey:The expire time: %s is invalid"), expireTime)
+	}
+	return nil
+}
+
+// AddApiKey adds the API key for the service account of the API key, and returns it with the key
+func AddApiKey(apiKey *ApiKey, lang string) (*ApiKey, error) {
+	user := getUser(apiKey.Owner, apiKey.User)
+	if user == nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), apiKey.GetUserId())
+	}
+	if !user.IsServiceAccount() {
+		return nil, errors.New(i18n.Translate(lang, "apiKey:Only service accounts can have API keys"))
+	}
+	if err := checkApiKeyExpireTime(apiKey.ExpireTime, lang); err != nil {
+		return nil, err
+	}
+
+	apiKey.Name = util.GenerateId()
+	apiKey.CreatedTime = util.GetCurrentTime()
+	apiKey.RotatedTime = ""
+	apiKey.LastUsedTime = ""
+	apiKey.LastUsedIp = ""
+	if apiKey.DisplayName == "" {
+		apiKey.DisplayName = apiKey.Name
+	}
+	key := apiKey.generateKey()
+
+	_, err := adapter.Engine.Insert(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey.Key = key
+	return apiKey, nil
+}
+
+// UpdateApiKey updates the display name and the expire time of the API key, the key itself is only changed
+// by rotating it
+func UpdateApiKey(id string, apiKey *ApiKey, lang string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if getApiKey(owner, name) == nil {
+		return false, nil
+	}
+	if err := checkApiKeyExpireTime(apiKey.ExpireTime, lang); err != nil {
+		return false, err
+	}
+
+	affected, err := adapter.Engine.ID(core.PK{owner, name}).Cols("display_name", "expire_time").Update(apiKey)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0, nil
+}
+
+// RotateApiKey replaces the key of the API key, the old key stops working at once
+func RotateApiKey(apiKey *ApiKey) (*ApiKey, error) {
+	key := apiKey.generateKey()
+	apiKey.RotatedTime = util.GetCurrentTime()
+	_, err := adapter.Engine.ID(core.PK{apiKey.Owner, apiKey.Name}).Cols("key_id", "key_hash", "rotated_time").Update(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey.Key = key
+	return apiKey, nil
+}
+
+func DeleteApiKey(apiKey *ApiKey) bool {
+	affected, err := adapter.Engine.ID(core.PK{apiKey.Owner, apiKey.Name}).Delete(&ApiKey{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func deleteUserApiKeys(user *User) int {
+	affected, err := adapter.Engine.Delete(&ApiKey{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(affected)
+}
+
+func updateApiKeyUsage(apiKey *ApiKey, clientIp string, now time.Time) {
+	lastUsedTime, err := time.Parse(time.RFC3339, apiKey.LastUsedTime)
+	if err == nil && now.Sub(lastUsedTime) < apiKeyUsageInterval && apiKey.LastUsedIp == clientIp {
+		return
+	}
+
+	apiKey.LastUsedTime = now.Format(time.RFC3339)
+	apiKey.LastUsedIp = clientIp
+	_, err = adapter.Engine.ID(core.PK{apiKey.Owner, apiKey.Name}).Cols("last_used_time", "last_used_ip").Update(apiKey)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CheckApiKey returns the service account authenticated by the API key, with the error message if the key is
+// invalid, expired, or its service account can't sign in
+func CheckApiKey(key string, clientIp string, lang string) (*User, string) {
+	apiKey := getApiKeyByKeyId(parseApiKey(key))
+	if apiKey == nil || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(util.GetSha256Hash(key))) != 1 {
+		return nil, i18n.Translate(lang, "apiKey:The API key is invalid")
+	}
+
+	now := time.Now()
+	if apiKey.isExpired(now) {
+		return nil, i18n.Translate(lang, "apiKey:The API key has expired")
+	}
+
+	user := getUser(apiKey.Owner, apiKey.User)
+	if user == nil || user.IsDeleted || !user.IsServiceAccount() {
+		return nil, i18n.Translate(lang, "apiKey:The API key is invalid")
+	}
+	if user.IsForbidden {
+		return nil, i18n.Translate(lang, "check:The user is forbidden to sign in, please contact the administrator")
+	}
+
+	util.SafeGoroutine(func() { updateApiKeyUsage(apiKey, clientIp, now) })
+	return user, ""
+}
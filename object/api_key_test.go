@@ -0,0 +1,85 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+func TestGenerateApiKey(t *testing.T) {
+	apiKey := &ApiKey{Owner: "org", Name: "key"}
+	key := apiKey.generateKey()
+
+	if !strings.HasPrefix(key, ApiKeyPrefix) {
+		t.Errorf("the key: %s doesn't start with the prefix: %s", key, ApiKeyPrefix)
+	}
+	if parseApiKey(key) != apiKey.KeyId {
+		t.Errorf("got the key id: %s, expected: %s", parseApiKey(key), apiKey.KeyId)
+	}
+	if apiKey.KeyHash != util.GetSha256Hash(key) || strings.Contains(apiKey.KeyHash, key) {
+		t.Errorf("the key isn't stored as its hash")
+	}
+
+	oldKeyId, oldKeyHash := apiKey.KeyId, apiKey.KeyHash
+	if newKey := apiKey.generateKey(); newKey == key || apiKey.KeyId == oldKeyId || apiKey.KeyHash == oldKeyHash {
+		t.Errorf("the rotated key is the same as the old key")
+	}
+}
+
+func TestParseApiKey(t *testing.T) {
+	scenarios := []struct {
+		key      string
+		expected string
+	}{
+		{"cak_1a2b3c_secret", "1a2b3c"},
+		{"cak__secret", ""},
+		{"cak_1a2b3c_", ""},
+		{"cak_1a2b3c", ""},
+		{"cak_1a_2b_3c", ""},
+		{"eyJhbGciOiJSUzI1NiJ9.e30.sig", ""},
+		{"", ""},
+	}
+
+	for _, scenario := range scenarios {
+		if keyId := parseApiKey(scenario.key); keyId != scenario.expected {
+			t.Errorf("got the key id: %s for the key: %s, expected: %s", keyId, scenario.key, scenario.expected)
+		}
+	}
+}
+
+func TestApiKeyIsExpired(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	scenarios := []struct {
+		expireTime string
+		expected   bool
+	}{
+		{"", false},
+		{"2023-06-15T13:00:00Z", false},
+		{"2023-06-15T12:00:00Z", true},
+		{"2023-06-15T19:00:00+08:00", true},
+		{"invalid", true},
+	}
+
+	for _, scenario := range scenarios {
+		apiKey := &ApiKey{ExpireTime: scenario.expireTime}
+		if isExpired := apiKey.isExpired(now); isExpired != scenario.expected {
+			t.Errorf("got expired: %v for the expire time: %s, expected: %v", isExpired, scenario.expireTime, scenario.expected)
+		}
+	}
+}
@@ -58,25 +58,30 @@ type Application struct {
 	GrantTypes          []string        `xorm:"varchar(1000)" json:"grantTypes"`
 	OrganizationObj     *Organization   `xorm:"-" json:"organizationObj"`
 
-	ClientId             string     `xorm:"varchar(100)" json:"clientId"`
-	ClientSecret         string     `xorm:"varchar(100)" json:"clientSecret"`
-	RedirectUris         []string   `xorm:"varchar(1000)" json:"redirectUris"`
-	TokenFormat          string     `xorm:"varchar(100)" json:"tokenFormat"`
-	ExpireInHours        int        `json:"expireInHours"`
-	RefreshExpireInHours int        `json:"refreshExpireInHours"`
-	SignupUrl            string     `xorm:"varchar(200)" json:"signupUrl"`
-	SigninUrl            string     `xorm:"varchar(200)" json:"signinUrl"`
-	ForgetUrl            string     `xorm:"varchar(200)" json:"forgetUrl"`
-	AffiliationUrl       string     `xorm:"varchar(100)" json:"affiliationUrl"`
-	TermsOfUse           string     `xorm:"varchar(100)" json:"termsOfUse"`
-	SignupHtml           string     `xorm:"mediumtext" json:"signupHtml"`
-	SigninHtml           string     `xorm:"mediumtext" json:"signinHtml"`
-	ThemeData            *ThemeData `xorm:"json" json:"themeData"`
-	FormCss              string     `xorm:"text" json:"formCss"`
-	FormCssMobile        string     `xorm:"text" json:"formCssMobile"`
-	FormOffset           int        `json:"formOffset"`
-	FormSideHtml         string     `xorm:"mediumtext" json:"formSideHtml"`
-	FormBackgroundUrl    string     `xorm:"varchar(200)" json:"formBackgroundUrl"`
+	ClientId             string         `xorm:"varchar(100)" json:"clientId"`
+	ClientSecret         string         `xorm:"varchar(100)" json:"clientSecret"`
+	RedirectUris         []string       `xorm:"varchar(1000)" json:"redirectUris"`
+	TokenFormat          string         `xorm:"varchar(100)" json:"tokenFormat"`
+	ExpireInHours        int            `json:"expireInHours"`
+	RefreshExpireInHours int            `json:"refreshExpireInHours"`
+	SignupUrl            string         `xorm:"varchar(200)" json:"signupUrl"`
+	SigninUrl            string         `xorm:"varchar(200)" json:"signinUrl"`
+	ForgetUrl            string         `xorm:"varchar(200)" json:"forgetUrl"`
+	AffiliationUrl       string         `xorm:"varchar(100)" json:"affiliationUrl"`
+	TermsOfUse           string         `xorm:"varchar(100)" json:"termsOfUse"`
+	SignupHtml           string         `xorm:"mediumtext" json:"signupHtml"`
+	SigninHtml           string         `xorm:"mediumtext" json:"signinHtml"`
+	ThemeData            *ThemeData     `xorm:"json" json:"themeData"`
+	LockoutPolicy        *LockoutPolicy `xorm:"json" json:"lockoutPolicy"`
+	FormCss              string         `xorm:"text" json:"formCss"`
+	FormCssMobile        string         `xorm:"text" json:"formCssMobile"`
+	FormOffset           int            `json:"formOffset"`
+	FormSideHtml         string         `xorm:"mediumtext" json:"formSideHtml"`
+	FormBackgroundUrl    string         `xorm:"varchar(200)" json:"formBackgroundUrl"`
+
+	EnableScimProvisioning bool   `json:"enableScimProvisioning"`
+	ScimUrl                string `xorm:"varchar(200)" json:"scimUrl"`
+	ScimToken              string `xorm:"varchar(200)" json:"scimToken"`
 }
 
 func GetApplicationCount(owner, field, value string) int {
@@ -262,6 +267,9 @@ func GetMaskedApplication(application *Application, userId string) *Application
 	if application.ClientSecret != "" {
 		application.ClientSecret = "***"
 	}
+	if application.ScimToken != "" {
+		application.ScimToken = "***"
+	}
 
 	if application.OrganizationObj != nil {
 		if application.OrganizationObj.MasterPassword != "" {
@@ -318,6 +326,9 @@ func UpdateApplication(id string, application *Application) bool {
 	if application.ClientSecret == "***" {
 		session.Omit("client_secret")
 	}
+	if application.ScimToken == "***" {
+		session.Omit("scim_token")
+	}
 	affected, err := session.Update(application)
 	if err != nil {
 		panic(err)
@@ -364,6 +375,8 @@ func DeleteApplication(application *Application) bool {
 		panic(err)
 	}
 
+	deleteScimProvisionings(application.Name)
+
 	return affected != 0
 }
 
@@ -454,6 +467,13 @@ func applicationChangeTrigger(oldName string, newName string) error {
 		return err
 	}
 
+	scimProvisioning := new(ScimProvisioning)
+	scimProvisioning.Application = newName
+	_, err = session.Where("application=?", oldName).Update(scimProvisioning)
+	if err != nil {
+		return err
+	}
+
 	var permissions []*Permission
 	err = adapter.Engine.Find(&permissions)
 	if err != nil {
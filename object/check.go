@@ -33,6 +33,7 @@ var (
 	reFieldWhiteList *regexp.Regexp
 )
 
+// the default lockout policy, for the organizations and applications without one
 const (
 	SigninWrongTimesLimit     = 5
 	LastSignWrongTimeDuration = time.Minute * 15
@@ -81,6 +82,10 @@ func CheckUserSignup(application *Application, organization *Organization, form
 		return i18n.Translate(lang, "check:Password must have at least 6 characters")
 	}
 
+	if msg := CheckPasswordPolicy(organization, form.Username, form.Email, form.Password, lang); msg != "" {
+		return msg
+	}
+
 	if application.IsSignupItemVisible("Email") {
 		if form.Email == "" {
 			if application.IsSignupItemRequired("Email") {
@@ -138,34 +143,37 @@ func CheckUserSignup(application *Application, organization *Organization, form
 	return ""
 }
 
-func checkSigninErrorTimes(user *User, lang string) string {
-	if user.SigninWrongTimes >= SigninWrongTimesLimit {
-		lastSignWrongTime, _ := time.Parse(time.RFC3339, user.LastSigninWrongTime)
-		passedTime := time.Now().UTC().Sub(lastSignWrongTime)
-		minutes := int(LastSignWrongTimeDuration.Minutes() - passedTime.Minutes())
-
-		// deny the login if the error times is greater than the limit and the last login time is less than the duration
-		if minutes > 0 {
-			return fmt.Sprintf(i18n.Translate(lang, "check:You have entered the wrong password or code too many times, please wait for %d minutes and try again"), minutes)
-		}
-
-		// reset the error times
-		user.SigninWrongTimes = 0
-
-		UpdateUser(user.GetId(), user, []string{"signin_wrong_times"}, user.IsGlobalAdmin)
+// CheckNewPassword checks the password a user or an admin is about to set for the user,
+// the web and LDAP password changes go through the same checks
+func CheckNewPassword(user *User, password string, lang string) string {
+	if strings.Contains(password, " ") {
+		return i18n.Translate(lang, "user:New password cannot contain blank space.")
+	}
+	if len(password) <= 5 {
+		return i18n.Translate(lang, "user:New password must have at least 6 characters")
 	}
 
+	organization := GetOrganizationByUser(user)
+	if organization == nil || organization.PasswordPolicy == nil {
+		return ""
+	}
+	if msg := organization.PasswordPolicy.checkPassword(password, user.Name, user.Email, lang); msg != "" {
+		return msg
+	}
+	if organization.PasswordPolicy.isPasswordReused(user, organization, password) {
+		return i18n.Translate(lang, "check:The password has been used recently, please choose another one")
+	}
 	return ""
 }
 
-func CheckPassword(user *User, password string, lang string, options ...bool) string {
+func CheckPassword(user *User, password string, lang string, attempt *SigninAttempt, options ...bool) string {
 	enableCaptcha := false
 	if len(options) > 0 {
 		enableCaptcha = options[0]
 	}
 	// check the login error times
 	if !enableCaptcha {
-		if msg := checkSigninErrorTimes(user, lang); msg != "" {
+		if msg := checkSigninLockout(user, attempt, lang); msg != "" {
 			return msg
 		}
 	}
@@ -183,17 +191,18 @@ func CheckPassword(user *User, password string, lang string, options ...bool) st
 	if credManager != nil {
 		if organization.MasterPassword != "" {
 			if credManager.IsPasswordCorrect(password, organization.MasterPassword, "", organization.PasswordSalt) {
-				resetUserSigninErrorTimes(user)
+				resetSigninFailures(user, attempt)
 				return ""
 			}
 		}
 
 		if credManager.IsPasswordCorrect(password, user.Password, user.PasswordSalt, organization.PasswordSalt) {
-			resetUserSigninErrorTimes(user)
+			resetSigninFailures(user, attempt)
+			rehashUserPassword(user, organization, password)
 			return ""
 		}
 
-		return recordSigninErrorInfo(user, lang, enableCaptcha)
+		return recordSigninFailure(user, attempt, lang, enableCaptcha)
 	} else {
 		return fmt.Sprintf(i18n.Translate(lang, "check:unsupported password type: %s"), organization.PasswordType)
 	}
@@ -242,7 +251,7 @@ func checkLdapUserPassword(user *User, password string, lang string) string {
 	return ""
 }
 
-func CheckUserPassword(organization string, username string, password string, lang string, options ...bool) (*User, string) {
+func CheckUserPassword(organization string, username string, password string, lang string, attempt *SigninAttempt, options ...bool) (*User, string) {
 	enableCaptcha := false
 	if len(options) > 0 {
 		enableCaptcha = options[0]
@@ -256,6 +265,10 @@ func CheckUserPassword(organization string, username string, password string, la
 		return nil, i18n.Translate(lang, "check:The user is forbidden to sign in, please contact the administrator")
 	}
 
+	if user.IsServiceAccount() {
+		return nil, i18n.Translate(lang, "check:Service accounts can only authenticate with API keys")
+	}
+
 	if user.Ldap != "" {
 		// ONLY for ldap users
 		if msg := checkLdapUserPassword(user, password, lang); msg != "" {
@@ -265,7 +278,7 @@ func CheckUserPassword(organization string, username string, password string, la
 			return nil, msg
 		}
 	} else {
-		if msg := CheckPassword(user, password, lang, enableCaptcha); msg != "" {
+		if msg := CheckPassword(user, password, lang, attempt, enableCaptcha); msg != "" {
 			return nil, msg
 		}
 	}
@@ -403,7 +416,7 @@ func CheckUpdateUser(oldUser, user *User, lang string) string {
 	return ""
 }
 
-func CheckToEnableCaptcha(application *Application, organization, username string) bool {
+func CheckToEnableCaptcha(application *Application, organization, username string, clientIp string) bool {
 	if len(application.Providers) == 0 {
 		return false
 	}
@@ -415,7 +428,7 @@ func CheckToEnableCaptcha(application *Application, organization, username strin
 		if providerItem.Provider.Category == "Captcha" {
 			if providerItem.Rule == "Dynamic" {
 				user := GetUserByFields(organization, username)
-				return user != nil && user.SigninWrongTimes >= SigninWrongTimesLimit
+				return user != nil && IsSigninCaptchaNeeded(user, &SigninAttempt{Application: application, ClientIp: clientIp})
 			}
 			return providerItem.Rule == "Always"
 		}
@@ -15,11 +15,7 @@
 package object
 
 import (
-	"fmt"
 	"regexp"
-	"time"
-
-	"github.com/casdoor/casdoor/i18n"
 )
 
 var reRealName *regexp.Regexp
@@ -35,39 +31,3 @@ func init() {
 func isValidRealName(s string) bool {
 	return reRealName.MatchString(s)
 }
-
-func resetUserSigninErrorTimes(user *User) {
-	// if the password is correct and wrong times is not zero, reset the error times
-	if user.SigninWrongTimes == 0 {
-		return
-	}
-	user.SigninWrongTimes = 0
-	UpdateUser(user.GetId(), user, []string{"signin_wrong_times", "last_signin_wrong_time"}, user.IsGlobalAdmin)
-}
-
-func recordSigninErrorInfo(user *User, lang string, options ...bool) string {
-	enableCaptcha := false
-	if len(options) > 0 {
-		enableCaptcha = options[0]
-	}
-	// increase failed login count
-	if user.SigninWrongTimes < SigninWrongTimesLimit {
-		user.SigninWrongTimes++
-	}
-
-	if user.SigninWrongTimes >= SigninWrongTimesLimit {
-		// record the latest failed login time
-		user.LastSigninWrongTime = time.Now().UTC().Format(time.RFC3339)
-	}
-
-	// update user
-	UpdateUser(user.GetId(), user, []string{"signin_wrong_times", "last_signin_wrong_time"}, user.IsGlobalAdmin)
-	leftChances := SigninWrongTimesLimit - user.SigninWrongTimes
-	if leftChances == 0 && enableCaptcha {
-		return fmt.Sprint(i18n.Translate(lang, "check:password or code is incorrect"))
-	} else if leftChances >= 0 {
-		return fmt.Sprintf(i18n.Translate(lang, "check:password or code is incorrect, you have %d remaining chances"), leftChances)
-	}
-	// don't show the chance error message if the user has no chance left
-	return fmt.Sprintf(i18n.Translate(lang, "check:You have entered the wrong password or code too many times, please wait for %d minutes and try again"), int(LastSignWrongTimeDuration.Minutes()))
-}
@@ -0,0 +1,716 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/xorm"
+)
+
+// The filter expressions of the list APIs, e.g.
+//
+//	role = "org/support" AND createdTime >= "2023-01-01" AND lastSigninTime IS NULL AND emailVerified = false
+//	(type IN ("normal-user", "paid-user") OR score > 100) AND NOT isForbidden = true
+//	createdTime BETWEEN "now-30d" AND "now"
+//
+// The fields are the JSON names of the whitelisted fields of the listed objects, the values are quoted strings,
+// numbers, true, false or null. The time fields are compared as the stored RFC 3339 strings, so "2023-01-31" is
+// before any time of that day, and also accept the relative times "now", "now-30d", "now+12h" and "now-15m".
+
+const (
+	filterTypeString = "string"
+	filterTypeInt    = "int"
+	filterTypeFloat  = "float"
+	filterTypeBool   = "bool"
+	filterTypeTime   = "time"
+	// the users in the role, or in the group
+	filterTypeRole  = "role"
+	filterTypeGroup = "group"
+
+	maxFilterLength = 4000
+	maxFilterDepth  = 20
+	maxFilterValues = 200
+)
+
+var (
+	UserFilterFields = map[string]string{
+		"owner": filterTypeString, "name": filterTypeString, "id": filterTypeString, "type": filterTypeString,
+		"createdTime": filterTypeTime, "updatedTime": filterTypeTime, "deletedTime": filterTypeTime,
+		"displayName": filterTypeString, "firstName": filterTypeString, "lastName": filterTypeString,
+		"email": filterTypeString, "emailVerified": filterTypeBool, "phone": filterTypeString, "countryCode": filterTypeString,
+		"region": filterTypeString, "location": filterTypeString, "affiliation": filterTypeString, "title": filterTypeString,
+		"tag": filterTypeString, "language": filterTypeString, "gender": filterTypeString, "birthday": filterTypeString,
+		"education": filterTypeString, "score": filterTypeInt, "karma": filterTypeInt, "ranking": filterTypeInt,
+		"isOnline": filterTypeBool, "isAdmin": filterTypeBool, "isForbidden": filterTypeBool, "isDeleted": filterTypeBool,
+		"signupApplication": filterTypeString, "passwordType": filterTypeString, "ldap": filterTypeString,
+		"createdIp": filterTypeString, "lastSigninTime": filterTypeTime, "lastSigninIp": filterTypeString,
+		"passwordChangedTime": filterTypeTime, "role": filterTypeRole, "group": filterTypeGroup,
+	}
+
+	RecordFilterFields = map[string]string{
+		"id": filterTypeInt, "owner": filterTypeString, "name": filterTypeString, "createdTime": filterTypeTime,
+		"organization": filterTypeString, "clientIp": filterTypeString, "user": filterTypeString,
+		"impersonator": filterTypeString, "method": filterTypeString, "requestUri": filterTypeString,
+		"action": filterTypeString, "isTriggered": filterTypeBool,
+	}
+
+	TokenFilterFields = map[string]string{
+		"owner": filterTypeString, "name": filterTypeString, "createdTime": filterTypeTime,
+		"application": filterTypeString, "organization": filterTypeString, "user": filterTypeString,
+		"expiresIn": filterTypeInt, "scope": filterTypeString, "tokenType": filterTypeString,
+		"codeIsUsed": filterTypeBool, "impersonation": filterTypeString,
+	}
+
+	PaymentFilterFields = map[string]string{
+		"owner": filterTypeString, "name": filterTypeString, "createdTime": filterTypeTime,
+		"displayName": filterTypeString, "provider": filterTypeString, "type": filterTypeString,
+		"organization": filterTypeString, "user": filterTypeString, "productName": filterTypeString,
+		"productDisplayName": filterTypeString, "tag": filterTypeString, "currency": filterTypeString,
+		"price": filterTypeFloat, "state": filterTypeString, "personName": filterTypeString,
+		"personEmail": filterTypeString, "personPhone": filterTypeString, "invoiceType": filterTypeString,
+	}
+
+	reRelativeTime = regexp.MustCompile(`^now(?:([+-])(\d+)([dhm]))?$`)
+)
+
+// Filter is a parsed filter expression, a tree of AND, OR and NOT over the comparisons of the fields
+type Filter struct {
+	op        string
+	children  []*Filter
+	field     string
+	fieldType string
+	values    []interface{}
+}
+
+type filterToken struct {
+	kind  string // "ident", "string", "number", "op", "(", ")", ","
+	text  string
+	start int
+}
+
+type filterParser struct {
+	tokens []*filterToken
+	pos    int
+	fields map[string]string
+	now    time.Time
+}
+
+func tokenizeFilter(text string) ([]*filterToken, error) {
+	tokens := []*filterToken{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i += 1
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, &filterToken{kind: string(r), text: string(r), start: i})
+			i += 1
+		case r == '"' || r == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j += 1
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, &filterToken{kind: "string", text: sb.String(), start: i})
+			i = j + 1
+		case strings.ContainsRune("=!<>", r):
+			j := i + 1
+			if j < len(runes) && (runes[j] == '=' || (r == '<' && runes[j] == '>')) {
+				j += 1
+			}
+			op := string(runes[i:j])
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected character: %c at position %d", r, i)
+			}
+			tokens = append(tokens, &filterToken{kind: "op", text: op, start: i})
+			i = j
+		case r == '-' || r == '.' || unicode.IsDigit(r):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j += 1
+			}
+			tokens = append(tokens, &filterToken{kind: "number", text: string(runes[i:j]), start: i})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j += 1
+			}
+			tokens = append(tokens, &filterToken{kind: "ident", text: string(runes[i:j]), start: i})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character: %c at position %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+func (p *filterParser) peek() *filterToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return p.tokens[p.pos]
+}
+
+// isKeyword returns whether the next token is the keyword, and consumes it if so
+func (p *filterParser) isKeyword(keyword string) bool {
+	token := p.peek()
+	if token != nil && token.kind == "ident" && strings.EqualFold(token.text, keyword) {
+		p.pos += 1
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(kind string) (*filterToken, error) {
+	token := p.peek()
+	if token == nil {
+		return nil, fmt.Errorf("unexpected end of the filter, expecting %s", kind)
+	}
+	if token.kind != kind {
+		return nil, fmt.Errorf("unexpected %q at position %d, expecting %s", token.text, token.start, kind)
+	}
+	p.pos += 1
+	return token, nil
+}
+
+func (p *filterParser) parseOr(depth int) (*Filter, error) {
+	if depth > maxFilterDepth {
+		return nil, fmt.Errorf("the filter is nested too deeply")
+	}
+
+	filter, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*Filter{filter}
+	for p.isKeyword("or") {
+		filter, err = p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, filter)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &Filter{op: "or", children: children}, nil
+}
+
+func (p *filterParser) parseAnd(depth int) (*Filter, error) {
+	filter, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*Filter{filter}
+	for p.isKeyword("and") {
+		filter, err = p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, filter)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &Filter{op: "and", children: children}, nil
+}
+
+func (p *filterParser) parseUnary(depth int) (*Filter, error) {
+	if p.isKeyword("not") {
+		filter, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Filter{op: "not", children: []*Filter{filter}}, nil
+	}
+
+	if token := p.peek(); token != nil && token.kind == "(" {
+		p.pos += 1
+		filter, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return filter, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (*Filter, error) {
+	token, err := p.expect("ident")
+	if err != nil {
+		return nil, err
+	}
+
+	fieldType, ok := p.fields[token.text]
+	if !ok {
+		return nil, fmt.Errorf("the field: %s is not allowed in the filter", token.text)
+	}
+	filter := &Filter{field: token.text, fieldType: fieldType}
+
+	switch {
+	case p.isKeyword("is"):
+		filter.op = "is null"
+		if p.isKeyword("not") {
+			filter.op = "is not null"
+		}
+		if !p.isKeyword("null") {
+			return nil, fmt.Errorf("expecting NULL after IS for the field: %s", filter.field)
+		}
+		if fieldType == filterTypeRole || fieldType == filterTypeGroup {
+			return nil, fmt.Errorf("the operator: %s is not supported for the field: %s", filter.op, filter.field)
+		}
+		return filter, nil
+	case p.isKeyword("not"):
+		if !p.isKeyword("in") {
+			return nil, fmt.Errorf("expecting IN after NOT for the field: %s", filter.field)
+		}
+		filter.op = "not in"
+	case p.isKeyword("in"):
+		filter.op = "in"
+	case p.isKeyword("between"):
+		filter.op = "between"
+	case p.isKeyword("like"):
+		filter.op = "like"
+	default:
+		opToken, err := p.expect("op")
+		if err != nil {
+			return nil, err
+		}
+		filter.op = opToken.text
+		if filter.op == "<>" {
+			filter.op = "!="
+		}
+	}
+
+	if err = checkFilterOperator(filter.op, fieldType); err != nil {
+		return nil, fmt.Errorf("%s for the field: %s", err.Error(), filter.field)
+	}
+
+	switch filter.op {
+	case "in", "not in":
+		if _, err = p.expect("("); err != nil {
+			return nil, err
+		}
+		for {
+			value, err := p.parseValue(filter)
+			if err != nil {
+				return nil, err
+			}
+			filter.values = append(filter.values, value)
+			if len(filter.values) > maxFilterValues {
+				return nil, fmt.Errorf("too many values for the field: %s", filter.field)
+			}
+
+			if token := p.peek(); token != nil && token.kind == "," {
+				p.pos += 1
+				continue
+			}
+			break
+		}
+		if _, err = p.expect(")"); err != nil {
+			return nil, err
+		}
+	case "between":
+		from, err := p.parseValue(filter)
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("and") {
+			return nil, fmt.Errorf("expecting AND in BETWEEN for the field: %s", filter.field)
+		}
+		to, err := p.parseValue(filter)
+		if err != nil {
+			return nil, err
+		}
+		filter.values = []interface{}{from, to}
+	default:
+		value, err := p.parseValue(filter)
+		if err != nil {
+			return nil, err
+		}
+		filter.values = []interface{}{value}
+	}
+
+	return filter, nil
+}
+
+func checkFilterOperator(op string, fieldType string) error {
+	switch fieldType {
+	case filterTypeRole, filterTypeGroup:
+		if !util.InSlice([]string{"=", "!=", "in", "not in"}, op) {
+			return fmt.Errorf("the operator: %s is not supported", op)
+		}
+	case filterTypeBool:
+		if !util.InSlice([]string{"=", "!="}, op) {
+			return fmt.Errorf("the operator: %s is not supported", op)
+		}
+	case filterTypeString:
+	default:
+		if op == "like" {
+			return fmt.Errorf("the operator: %s is not supported", op)
+		}
+	}
+
+	if !util.InSlice([]string{"=", "!=", "<", "<=", ">", ">=", "like", "in", "not in", "between"}, op) {
+		return fmt.Errorf("the operator: %s is not supported", op)
+	}
+	return nil
+}
+
+// parseValue parses the next value for the field of the filter by the field type
+func (p *filterParser) parseValue(filter *Filter) (interface{}, error) {
+	token := p.peek()
+	if token == nil {
+		return nil, fmt.Errorf("unexpected end of the filter, expecting a value for the field: %s", filter.field)
+	}
+	if token.kind != "string" && token.kind != "number" && token.kind != "ident" {
+		return nil, fmt.Errorf("unexpected %q at position %d, expecting a value", token.text, token.start)
+	}
+	p.pos += 1
+
+	text := token.text
+	if token.kind == "ident" {
+		text = strings.ToLower(text)
+		if text != "true" && text != "false" && text != "now" {
+			return nil, fmt.Errorf("unexpected %q at position %d, strings must be quoted", token.text, token.start)
+		}
+	}
+
+	switch filter.fieldType {
+	case filterTypeInt:
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("the value: %s of the field: %s is not an integer", text, filter.field)
+		}
+		return value, nil
+	case filterTypeFloat:
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("the value: %s of the field: %s is not a number", text, filter.field)
+		}
+		return value, nil
+	case filterTypeBool:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("the value: %s of the field: %s is not a boolean", text, filter.field)
+		}
+		return value, nil
+	case filterTypeTime:
+		return parseFilterTime(text, p.now, filter.field)
+	case filterTypeRole, filterTypeGroup:
+		if tokens := strings.Split(text, "/"); len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+			return nil, fmt.Errorf("the value: %s of the field: %s should be an id like \"owner/name\"", text, filter.field)
+		}
+		return text, nil
+	default:
+		if filter.op == "like" {
+			if strings.Contains(text, "*") {
+				return strings.ReplaceAll(text, "*", "%"), nil
+			}
+			return fmt.Sprintf("%%%s%%", text), nil
+		}
+		return text, nil
+	}
+}
+
+// parseFilterTime returns the time value as the stored RFC 3339 string, the dates are kept as is to compare as
+// the prefixes of the times
+func parseFilterTime(text string, now time.Time, field string) (string, error) {
+	if matches := reRelativeTime.FindStringSubmatch(text); matches != nil {
+		if matches[1] == "" {
+			return now.Format(time.RFC3339), nil
+		}
+
+		n, _ := strconv.Atoi(matches[2])
+		unit := map[string]time.Duration{"d": 24 * time.Hour, "h": time.Hour, "m": time.Minute}[matches[3]]
+		duration := time.Duration(n) * unit
+		if matches[1] == "-" {
+			duration = -duration
+		}
+		return now.Add(duration).Format(time.RFC3339), nil
+	}
+
+	if _, err := time.Parse("2006-01-02", text); err == nil {
+		return text, nil
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t.In(now.Location()).Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("the value: %s of the field: %s is not a date or time", text, field)
+}
+
+// ParseFilter parses the filter expression, the fields are validated against the whitelist of the listed objects
+func ParseFilter(text string, fields map[string]string) (*Filter, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	if len(text) > maxFilterLength {
+		return nil, fmt.Errorf("the filter is longer than %d characters", maxFilterLength)
+	}
+
+	tokens, err := tokenizeFilter(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens, fields: fields, now: time.Now()}
+	filter, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token != nil {
+		return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.start)
+	}
+	return filter, nil
+}
+
+func getFilterPlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// filterIdBatchSize is the max number of the ids in an IN list of the role conditions
+const filterIdBatchSize = 500
+
+// getRoleMembers returns the ids of the users and the groups having any of the roles, directly or through the
+// sub-roles of the roles, the groups pass the roles on to their subgroups
+func getRoleMembers(roleIds []string, roleMap map[string]*Role, tree *GroupTree) ([]string, []string) {
+	roles := map[string]bool{}
+	for len(roleIds) != 0 {
+		roleId := roleIds[0]
+		roleIds = roleIds[1:]
+		role := roleMap[roleId]
+		if role == nil || roles[roleId] {
+			continue
+		}
+
+		roles[roleId] = true
+		roleIds = append(roleIds, role.Roles...)
+	}
+
+	userIds := []string{}
+	for _, role := range roleMap {
+		if roles[role.GetId()] {
+			userIds = append(userIds, role.Users...)
+		}
+	}
+
+	groupIds := []string{}
+	for _, group := range tree.GetGroups() {
+		for _, roleId := range group.Roles {
+			if roles[roleId] {
+				for _, subGroup := range tree.GetDescendants(group.Name) {
+					groupIds = append(groupIds, subGroup.GetId())
+				}
+				break
+			}
+		}
+	}
+
+	userIds = util.UniqueStrings(userIds)
+	groupIds = util.UniqueStrings(groupIds)
+	sort.Strings(userIds)
+	sort.Strings(groupIds)
+	return userIds, groupIds
+}
+
+// getUserIdsCondition returns the condition of the users, listing the names of each organization in IN lists of
+// filterIdBatchSize names at most
+func getUserIdsCondition(userIds []string, quote func(string) string) (string, []interface{}) {
+	ownerNames := map[string][]string{}
+	owners := []string{}
+	for _, userId := range userIds {
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(userId)
+		if _, ok := ownerNames[owner]; !ok {
+			owners = append(owners, owner)
+		}
+		ownerNames[owner] = append(ownerNames[owner], name)
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+	for _, owner := range owners {
+		names := ownerNames[owner]
+		nameConditions := []string{}
+		args = append(args, owner)
+		for start := 0; start < len(names); start += filterIdBatchSize {
+			end := start + filterIdBatchSize
+			if end > len(names) {
+				end = len(names)
+			}
+
+			nameConditions = append(nameConditions, fmt.Sprintf("%s IN (%s)", quote("name"), getFilterPlaceholders(end-start)))
+			for _, name := range names[start:end] {
+				args = append(args, name)
+			}
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s = ? AND (%s))", quote("owner"), strings.Join(nameConditions, " OR ")))
+	}
+
+	if len(conditions) == 0 {
+		return "1 = 0", args
+	}
+	return fmt.Sprintf("(%s)", strings.Join(conditions, " OR ")), args
+}
+
+// getRoleUsersCondition returns the condition of the users having any of the roles, including the users of the
+// sub-roles and of the groups having the roles, like the permission enforcer
+func getRoleUsersCondition(roleIds []interface{}, quote func(string) string) (string, []interface{}) {
+	ownerRoleIds := map[string][]string{}
+	owners := []string{}
+	for _, roleId := range roleIds {
+		owner, _ := util.GetOwnerAndNameFromIdNoCheck(roleId.(string))
+		if _, ok := ownerRoleIds[owner]; !ok {
+			owners = append(owners, owner)
+		}
+		ownerRoleIds[owner] = append(ownerRoleIds[owner], roleId.(string))
+	}
+
+	userIds := []string{}
+	groupIds := []string{}
+	for _, owner := range owners {
+		roleMap := map[string]*Role{}
+		for _, role := range GetRoles(owner) {
+			roleMap[role.GetId()] = role
+		}
+
+		ownerUserIds, ownerGroupIds := getRoleMembers(ownerRoleIds[owner], roleMap, NewGroupTree(getEnabledGroups(owner)))
+		userIds = append(userIds, ownerUserIds...)
+		groupIds = append(groupIds, ownerGroupIds...)
+	}
+
+	userCondition, args := getUserIdsCondition(userIds, quote)
+	if len(groupIds) == 0 {
+		return userCondition, args
+	}
+
+	groupCondition, groupArgs := getGroupsCondition(groupIds, quote)
+	return fmt.Sprintf("(%s OR %s)", userCondition, groupCondition), append(args, groupArgs...)
+}
+
+// CheckOwner returns an error if a role or a group of the filter isn't in the organization of the listed users
+func (filter *Filter) CheckOwner(owner string) error {
+	for _, child := range filter.children {
+		if err := child.CheckOwner(owner); err != nil {
+			return err
+		}
+	}
+
+	if filter.fieldType == filterTypeRole || filter.fieldType == filterTypeGroup {
+		for _, value := range filter.values {
+			if valueOwner, _ := util.GetOwnerAndNameFromId(value.(string)); valueOwner != owner {
+				return fmt.Errorf("the value: %s of the field: %s should be in the organization: %s", value, filter.field, owner)
+			}
+		}
+	}
+	return nil
+}
+
+// toSql returns the SQL condition of the filter with its arguments, quoting the columns with the quote function
+func (filter *Filter) toSql(quote func(string) string) (string, []interface{}) {
+	switch filter.op {
+	case "and", "or":
+		conditions := []string{}
+		args := []interface{}{}
+		for _, child := range filter.children {
+			condition, childArgs := child.toSql(quote)
+			conditions = append(conditions, condition)
+			args = append(args, childArgs...)
+		}
+		return fmt.Sprintf("(%s)", strings.Join(conditions, fmt.Sprintf(" %s ", strings.ToUpper(filter.op)))), args
+	case "not":
+		condition, args := filter.children[0].toSql(quote)
+		return fmt.Sprintf("NOT (%s)", condition), args
+	}
+
+	isNegated := filter.op == "!=" || filter.op == "not in"
+	switch filter.fieldType {
+	case filterTypeRole:
+		condition, args := getRoleUsersCondition(filter.values, quote)
+		if isNegated {
+			condition = fmt.Sprintf("NOT %s", condition)
+		}
+		return condition, args
+	case filterTypeGroup:
+		groupIds := []string{}
+		for _, groupId := range filter.values {
+			groupIds = append(groupIds, groupId.(string))
+		}
+		condition, args := getGroupsCondition(groupIds, quote)
+		if isNegated {
+			condition = fmt.Sprintf("NOT %s", condition)
+		}
+		return condition, args
+	}
+
+	column := quote(util.SnakeString(filter.field))
+	isText := filter.fieldType == filterTypeString || filter.fieldType == filterTypeTime
+	switch filter.op {
+	case "is null":
+		if isText {
+			return fmt.Sprintf("(%s IS NULL OR %s = '')", column, column), nil
+		}
+		return fmt.Sprintf("%s IS NULL", column), nil
+	case "is not null":
+		if isText {
+			return fmt.Sprintf("(%s IS NOT NULL AND %s <> '')", column, column), nil
+		}
+		return fmt.Sprintf("%s IS NOT NULL", column), nil
+	case "in", "not in":
+		return fmt.Sprintf("%s %s (%s)", column, strings.ToUpper(filter.op), getFilterPlaceholders(len(filter.values))), filter.values
+	case "between":
+		return fmt.Sprintf("%s BETWEEN ? AND ?", column), filter.values
+	case "like":
+		return fmt.Sprintf("%s LIKE ?", column), filter.values
+	case "!=":
+		return fmt.Sprintf("%s <> ?", column), filter.values
+	default:
+		return fmt.Sprintf("%s %s ?", column, filter.op), filter.values
+	}
+}
+
+// applyFilter adds the condition of the filter to the session, if any
+func applyFilter(session *xorm.Session, filter *Filter) *xorm.Session {
+	if filter == nil {
+		return session
+	}
+
+	condition, args := filter.toSql(adapter.Engine.Quote)
+	return session.And(condition, args...)
+}
@@ -0,0 +1,212 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func quoteFilterColumn(column string) string {
+	return fmt.Sprintf("`%s`", column)
+}
+
+func TestParseFilter(t *testing.T) {
+	scenarios := []struct {
+		description string
+		input       string
+		sql         string
+		args        []interface{}
+	}{
+		{"empty", "  ", "", nil},
+		{"comparison", `emailVerified = false`, "`email_verified` = ?", []interface{}{false}},
+		{"not equal", `type <> "normal-user"`, "`type` <> ?", []interface{}{"normal-user"}},
+		{"and", `score > 10 AND createdTime >= "2023-01-01"`, "(`score` > ? AND `created_time` >= ?)", []interface{}{int64(10), "2023-01-01"}},
+		{"precedence", `isAdmin = true or score >= 1 and score < 5`, "(`is_admin` = ? OR (`score` >= ? AND `score` < ?))", []interface{}{true, int64(1), int64(5)}},
+		{"parentheses", `(isAdmin = true OR score >= 1) AND NOT isForbidden = true`, "((`is_admin` = ? OR `score` >= ?) AND NOT (`is_forbidden` = ?))", []interface{}{true, int64(1), true}},
+		{"in", `type IN ("a", 'b')`, "`type` IN (?, ?)", []interface{}{"a", "b"}},
+		{"not in", `type not in ("a")`, "`type` NOT IN (?)", []interface{}{"a"}},
+		{"is null", `lastSigninTime IS NULL`, "(`last_signin_time` IS NULL OR `last_signin_time` = '')", nil},
+		{"is not null", `score is not null`, "`score` IS NOT NULL", nil},
+		{"between", `createdTime BETWEEN "2023-01-01" AND "2023-02-01"`, "`created_time` BETWEEN ? AND ?", []interface{}{"2023-01-01", "2023-02-01"}},
+		{"like", `email LIKE "@example.com"`, "`email` LIKE ?", []interface{}{"%@example.com%"}},
+		{"like wildcard", `email LIKE "admin*"`, "`email` LIKE ?", []interface{}{"admin%"}},
+		{"escaped quote", `displayName = "a \"b\""`, "`display_name` = ?", []interface{}{`a "b"`}},
+		{"group", `group = "org/dev"`, "(`groups` like ? escape '!')", []interface{}{`%"org/dev"%`}},
+		{"not group", `group NOT IN ("org/a", "org/b")`, "NOT (`groups` like ? escape '!' or `groups` like ? escape '!')", []interface{}{`%"org/a"%`, `%"org/b"%`}},
+		{"group wildcards", `group = "org/a_b%!"`, "(`groups` like ? escape '!')", []interface{}{`%"org/a!_b!%!!"%`}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			filter, err := ParseFilter(scenario.input, UserFilterFields)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			if filter == nil {
+				if scenario.sql != "" {
+					t.Fatalf("expected the filter: %s", scenario.sql)
+				}
+				return
+			}
+
+			sql, args := filter.toSql(quoteFilterColumn)
+			if sql != scenario.sql {
+				t.Errorf("got sql: %s, expected: %s", sql, scenario.sql)
+			}
+			if !reflect.DeepEqual(args, scenario.args) {
+				t.Errorf("got args: %v, expected: %v", args, scenario.args)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	scenarios := []struct {
+		description string
+		input       string
+	}{
+		{"unknown field", `password = "x"`},
+		{"unquoted string", `type = normal`},
+		{"not an integer", `score > "a"`},
+		{"not a boolean", `isAdmin = "yes"`},
+		{"not a time", `createdTime > "yesterday"`},
+		{"like on number", `score LIKE "1"`},
+		{"order on bool", `isAdmin > true`},
+		{"role id", `role = "admin"`},
+		{"role path", `role = "org/a/b"`},
+		{"group path", `group IN ("org/a", "org/a/b")`},
+		{"group owner", `group = "/a"`},
+		{"role null", `role IS NULL`},
+		{"unterminated string", `name = "alice`},
+		{"missing parenthesis", `(name = "alice"`},
+		{"trailing tokens", `name = "alice" "bob"`},
+		{"missing value", `name =`},
+		{"between without and", `score BETWEEN 1 OR 2`},
+		{"unexpected character", `name = "a" ; drop`},
+		{"too deep", strings.Repeat("(", maxFilterDepth+2) + `name = "a"` + strings.Repeat(")", maxFilterDepth+2)},
+		{"too long", `name = "` + strings.Repeat("a", maxFilterLength) + `"`},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			if _, err := ParseFilter(scenario.input, UserFilterFields); err == nil {
+				t.Errorf("expected an error for the filter: %s", scenario.input)
+			}
+		})
+	}
+}
+
+func TestParseFilterTime(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	scenarios := []struct {
+		input    string
+		expected string
+	}{
+		{"now", "2023-06-15T12:00:00Z"},
+		{"now-7d", "2023-06-08T12:00:00Z"},
+		{"now+2h", "2023-06-15T14:00:00Z"},
+		{"now-30m", "2023-06-15T11:30:00Z"},
+		{"2023-01-01", "2023-01-01"},
+		{"2023-01-01T08:00:00+08:00", "2023-01-01T00:00:00Z"},
+	}
+
+	for _, scenario := range scenarios {
+		value, err := parseFilterTime(scenario.input, now, "createdTime")
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %s", scenario.input, err.Error())
+		}
+		if value != scenario.expected {
+			t.Errorf("got %s for %s, expected: %s", value, scenario.input, scenario.expected)
+		}
+	}
+}
+
+func TestFilterCheckOwner(t *testing.T) {
+	filter, err := ParseFilter(`role = "org/support" OR NOT group IN ("org/a", "org/b")`, UserFilterFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = filter.CheckOwner("org"); err != nil {
+		t.Errorf("the roles and groups of the organization should be accepted: %s", err)
+	}
+
+	filter, err = ParseFilter(`name = "alice" AND (group = "org/a" OR role = "other/support")`, UserFilterFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = filter.CheckOwner("org"); err == nil {
+		t.Errorf("the role of another organization should be rejected")
+	}
+}
+
+func TestGetRoleMembers(t *testing.T) {
+	roleMap := map[string]*Role{}
+	for _, role := range []*Role{
+		{Owner: "org", Name: "admin", Users: []string{"org/alice"}, Roles: []string{"org/support"}},
+		{Owner: "org", Name: "support", Users: []string{"org/bob"}, Roles: []string{"org/admin"}},
+		{Owner: "org", Name: "guest", Users: []string{"org/carol"}},
+	} {
+		roleMap[role.GetId()] = role
+	}
+	tree := NewGroupTree([]*Group{
+		{Owner: "org", Name: "ops", Roles: []string{"org/support"}},
+		{Owner: "org", Name: "oncall", ParentName: "ops"},
+		{Owner: "org", Name: "sales", Roles: []string{"org/guest"}},
+	})
+
+	userIds, groupIds := getRoleMembers([]string{"org/admin"}, roleMap, tree)
+	if expected := []string{"org/alice", "org/bob"}; !reflect.DeepEqual(userIds, expected) {
+		t.Errorf("got users: %v, expected: %v", userIds, expected)
+	}
+	if expected := []string{"org/oncall", "org/ops"}; !reflect.DeepEqual(groupIds, expected) {
+		t.Errorf("got groups: %v, expected: %v", groupIds, expected)
+	}
+
+	userIds, groupIds = getRoleMembers([]string{"org/guest", "org/unknown"}, roleMap, tree)
+	if expected := []string{"org/carol"}; !reflect.DeepEqual(userIds, expected) {
+		t.Errorf("got users: %v, expected: %v", userIds, expected)
+	}
+	if expected := []string{"org/sales"}; !reflect.DeepEqual(groupIds, expected) {
+		t.Errorf("got groups: %v, expected: %v", groupIds, expected)
+	}
+}
+
+func TestGetUserIdsCondition(t *testing.T) {
+	userIds := []string{}
+	for i := 0; i < filterIdBatchSize+1; i++ {
+		userIds = append(userIds, fmt.Sprintf("org/user%d", i))
+	}
+	userIds = append(userIds, "other/alice")
+
+	sql, args := getUserIdsCondition(userIds, quoteFilterColumn)
+	if count := strings.Count(sql, "IN ("); count != 3 {
+		t.Errorf("got %d IN lists in %s, expected: 3", count, sql)
+	}
+	if count := strings.Count(sql, "?"); count != len(args) || len(args) != len(userIds)+2 {
+		t.Errorf("got %d placeholders and %d args, expected: %d", count, len(args), len(userIds)+2)
+	}
+	if !strings.HasSuffix(sql, "(`owner` = ? AND (`name` IN (?))))") || args[len(args)-2] != "other" {
+		t.Errorf("unexpected condition of the other organization: %s", sql[len(sql)-60:])
+	}
+
+	if sql, _ = getUserIdsCondition([]string{}, quoteFilterColumn); sql != "1 = 0" {
+		t.Errorf("got %s for no users, expected: 1 = 0", sql)
+	}
+}
@@ -0,0 +1,607 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// Group is an organizational unit inside an organization. Groups form a tree through
+// ParentName, the users of a group are the users listing its id in User.Groups, and the
+// members of a group are also members of all its ancestor groups.
+type Group struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	// ParentName is the name of the parent group in the same organization, empty for a top group
+	ParentName string `xorm:"varchar(100) index" json:"parentName"`
+	// Admins are the users who can manage the members of the group and its subgroups
+	Admins []string `xorm:"mediumtext" json:"admins"`
+	// Roles are inherited by the members of the group and its subgroups
+	Roles     []string `xorm:"mediumtext" json:"roles"`
+	IsEnabled bool     `json:"isEnabled"`
+
+	Path string `xorm:"-" json:"path"`
+}
+
+func GetGroupCount(owner, field, value string) int {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	count, err := session.Count(&Group{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetGroups(owner string) []*Group {
+	groups := []*Group{}
+	err := adapter.Engine.Desc("created_time").Find(&groups, &Group{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return extendGroupsWithPath(groups)
+}
+
+func GetPaginationGroups(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Group {
+	groups := []*Group{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&groups)
+	if err != nil {
+		panic(err)
+	}
+
+	return extendGroupsWithPath(groups)
+}
+
+func getGroup(owner string, name string) *Group {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	group := Group{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&group)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		group.Path = NewGroupTree(getAllGroups(owner)).GetPath(name)
+		return &group
+	} else {
+		return nil
+	}
+}
+
+func GetGroup(id string) *Group {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getGroup(owner, name)
+}
+
+func getAllGroups(owner string) []*Group {
+	groups := []*Group{}
+	err := adapter.Engine.Find(&groups, &Group{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return groups
+}
+
+func getEnabledGroups(owner string) []*Group {
+	groups := []*Group{}
+	for _, group := range getAllGroups(owner) {
+		if group.IsEnabled {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+func extendGroupsWithPath(groups []*Group) []*Group {
+	trees := map[string]*GroupTree{}
+	for _, group := range groups {
+		tree, ok := trees[group.Owner]
+		if !ok {
+			tree = NewGroupTree(getAllGroups(group.Owner))
+			trees[group.Owner] = tree
+		}
+		group.Path = tree.GetPath(group.Name)
+	}
+	return groups
+}
+
+// CheckGroup verifies that the parent of the group exists and is not the group itself or one of its subgroups.
+func CheckGroup(oldName string, group *Group, lang string) string {
+	if group.ParentName == "" {
+		return ""
+	}
+
+	tree := NewGroupTree(getAllGroups(group.Owner))
+	if tree.groups[group.ParentName] == nil {
+		return fmt.Sprintf(i18n.Translate(lang, "group:The parent group: %s doesn't exist"), group.ParentName)
+	}
+
+	for _, descendant := range tree.GetDescendants(oldName) {
+		if descendant.Name == group.ParentName {
+			return i18n.Translate(lang, "group:A group cannot be its own parent or the parent of its ancestor")
+		}
+	}
+	if group.ParentName == group.Name {
+		return i18n.Translate(lang, "group:A group cannot be its own parent or the parent of its ancestor")
+	}
+	return ""
+}
+
+func UpdateGroup(id string, group *Group) bool {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if getGroup(owner, name) == nil {
+		return false
+	}
+
+	if name != group.Name {
+		err := groupChangeTrigger(owner, name, group.Name)
+		if err != nil {
+			return false
+		}
+	}
+
+	group.UpdatedTime = util.GetCurrentTime()
+	affected, err := adapter.Engine.ID(core.PK{owner, name}).AllCols().Update(group)
+	if err != nil {
+		panic(err)
+	}
+
+	if affected != 0 {
+		refreshGroupPermissions(owner)
+	}
+
+	return affected != 0
+}
+
+func AddGroup(group *Group) bool {
+	affected, err := adapter.Engine.Insert(group)
+	if err != nil {
+		panic(err)
+	}
+
+	if affected != 0 {
+		refreshGroupPermissions(group.Owner)
+	}
+
+	return affected != 0
+}
+
+// DeleteGroup deletes the group, its subgroups are moved up to its parent
+// and its users are removed from it.
+func DeleteGroup(group *Group) bool {
+	oldGroup := getGroup(group.Owner, group.Name)
+	if oldGroup == nil {
+		return false
+	}
+
+	affected, err := adapter.Engine.ID(core.PK{group.Owner, group.Name}).Delete(&Group{})
+	if err != nil {
+		panic(err)
+	}
+
+	if affected != 0 {
+		_, err = adapter.Engine.Where("owner = ? and parent_name = ?", group.Owner, group.Name).Cols("parent_name").Update(&Group{ParentName: oldGroup.ParentName})
+		if err != nil {
+			panic(err)
+		}
+
+		groupId := group.GetId()
+		for _, user := range getUsersByGroupIds(group.Owner, []string{groupId}) {
+			user.Groups = util.DeleteVal(user.Groups, groupId)
+			updateUserGroups(user)
+		}
+
+		refreshGroupPermissions(group.Owner)
+	}
+
+	return affected != 0
+}
+
+func (group *Group) GetId() string {
+	return fmt.Sprintf("%s/%s", group.Owner, group.Name)
+}
+
+// groupChangeTrigger renames the group in its subgroups, the group lists of its users and the permissions.
+func groupChangeTrigger(owner string, oldName string, newName string) error {
+	session := adapter.Engine.NewSession()
+	defer session.Close()
+
+	err := session.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = session.Where("owner = ? and parent_name = ?", owner, oldName).Cols("parent_name").Update(&Group{ParentName: newName})
+	if err != nil {
+		return err
+	}
+
+	oldId, newId := util.GetId(owner, oldName), util.GetId(owner, newName)
+	for _, user := range getUsersByGroupIds(owner, []string{oldId}) {
+		for i, groupId := range user.Groups {
+			if groupId == oldId {
+				user.Groups[i] = newId
+			}
+		}
+		_, err = session.ID(core.PK{user.Owner, user.Name}).Cols("groups").Update(user)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the paths of the group and its subgroups change, so do the permission subjects using them
+	tree := NewGroupTree(getAllGroups(owner))
+	segment := len(strings.Split(tree.GetPath(oldName), "/")) - 1
+	pathPrefixes := map[string]string{}
+	for _, descendant := range tree.GetDescendants(oldName) {
+		oldPath := tree.GetPath(descendant.Name)
+		pathPrefixes[oldPath] = renameGroupPath(oldPath, segment, newName)
+	}
+
+	var permissions []*Permission
+	err = adapter.Engine.Where("owner = ?", owner).Find(&permissions)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		isChanged := false
+		for i, path := range permission.Groups {
+			if newPath, ok := pathPrefixes[path]; ok {
+				permission.Groups[i] = newPath
+				isChanged = true
+			}
+		}
+		if isChanged {
+			_, err = session.ID(core.PK{permission.Owner, permission.Name}).Cols("groups").Update(permission)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return session.Commit()
+}
+
+// renameGroupPath replaces the segment of the renamed group in the path of the group or one of its subgroups
+func renameGroupPath(path string, segment int, newName string) string {
+	tokens := strings.Split(path, "/")
+	if segment > 0 && segment < len(tokens) {
+		tokens[segment] = newName
+	}
+	return strings.Join(tokens, "/")
+}
+
+// GroupTree resolves the paths, ancestors and descendants of the groups of an organization.
+// The path of a group is "<organization>/<top group>/.../<group>", so the path of a top group
+// is the same as its id.
+type GroupTree struct {
+	owner    string
+	groups   map[string]*Group
+	children map[string][]*Group
+}
+
+func NewGroupTree(groups []*Group) *GroupTree {
+	tree := &GroupTree{
+		groups:   map[string]*Group{},
+		children: map[string][]*Group{},
+	}
+
+	for _, group := range groups {
+		tree.owner = group.Owner
+		tree.groups[group.Name] = group
+	}
+
+	for _, group := range groups {
+		parentName := group.ParentName
+		if tree.groups[parentName] == nil {
+			parentName = ""
+		}
+		tree.children[parentName] = append(tree.children[parentName], group)
+	}
+
+	for _, children := range tree.children {
+		sort.Slice(children, func(i, j int) bool {
+			return children[i].Name < children[j].Name
+		})
+	}
+	return tree
+}
+
+// GetAncestors returns the group followed by its parent, grandparent and so on up to its top group
+func (tree *GroupTree) GetAncestors(name string) []*Group {
+	res := []*Group{}
+	visited := map[string]bool{}
+	for group := tree.groups[name]; group != nil && !visited[group.Name]; group = tree.groups[group.ParentName] {
+		visited[group.Name] = true
+		res = append(res, group)
+	}
+	return res
+}
+
+// GetDescendants returns the group and all its subgroups
+func (tree *GroupTree) GetDescendants(name string) []*Group {
+	group := tree.groups[name]
+	if group == nil {
+		return []*Group{}
+	}
+
+	res := []*Group{group}
+	visited := map[string]bool{name: true}
+	for i := 0; i < len(res); i++ {
+		for _, child := range tree.children[res[i].Name] {
+			if !visited[child.Name] {
+				visited[child.Name] = true
+				res = append(res, child)
+			}
+		}
+	}
+	return res
+}
+
+func (tree *GroupTree) GetChildren(name string) []*Group {
+	return tree.children[name]
+}
+
+func (tree *GroupTree) GetPath(name string) string {
+	ancestors := tree.GetAncestors(name)
+	if len(ancestors) == 0 {
+		return ""
+	}
+
+	names := []string{}
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		names = append(names, ancestors[i].Name)
+	}
+	return fmt.Sprintf("%s/%s", ancestors[0].Owner, strings.Join(names, "/"))
+}
+
+// GetGroupByPath returns the group of the path, the last name of the path is the group name
+func (tree *GroupTree) GetGroupByPath(path string) *Group {
+	name := path[strings.LastIndex(path, "/")+1:]
+	if tree.GetPath(name) != path {
+		return nil
+	}
+	return tree.groups[name]
+}
+
+func (tree *GroupTree) GetGroups() []*Group {
+	groups := []*Group{}
+	for _, group := range tree.groups {
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return tree.GetPath(groups[i].Name) < tree.GetPath(groups[j].Name)
+	})
+	return groups
+}
+
+// GetUserGroupPaths returns the paths of the groups the user is a direct member of
+func (tree *GroupTree) GetUserGroupPaths(user *User) []string {
+	res := []string{}
+	for _, groupId := range user.Groups {
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(groupId)
+		if owner != tree.owner || tree.groups[name] == nil {
+			continue
+		}
+		res = append(res, tree.GetPath(name))
+	}
+	return res
+}
+
+// GetUserGroups returns the groups the user is a direct or inherited member of
+func (tree *GroupTree) GetUserGroups(user *User) []*Group {
+	res := []*Group{}
+	visited := map[string]bool{}
+	for _, groupId := range user.Groups {
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(groupId)
+		if owner != tree.owner {
+			continue
+		}
+
+		for _, group := range tree.GetAncestors(name) {
+			if !visited[group.Name] {
+				visited[group.Name] = true
+				res = append(res, group)
+			}
+		}
+	}
+	return res
+}
+
+func getUsersByGroupIds(owner string, groupIds []string) []*User {
+	if len(groupIds) == 0 {
+		return []*User{}
+	}
+
+	users := []*User{}
+	session := adapter.Engine.Where("owner = ?", owner)
+	condition, args := getGroupsCondition(groupIds, adapter.Engine.Quote)
+	session = session.And(condition, args...)
+	err := session.Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	return users
+}
+
+// escapeLike escapes the LIKE wildcards of the value with "!", which is the escape character of the LIKE conditions
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+// getGroupsCondition returns the condition of the users in any of the groups, matching the JSON strings of the
+// group ids in the groups of the users, the columns are quoted with the quote function
+func getGroupsCondition(groupIds []string, quote func(string) string) (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+	for _, groupId := range groupIds {
+		conditions = append(conditions, fmt.Sprintf("%s like ? escape '!'", quote("groups")))
+		args = append(args, fmt.Sprintf("%%%s%%", escapeLike(util.StructToJson(groupId))))
+	}
+	if len(conditions) == 0 {
+		return "1 = 0", args
+	}
+	return fmt.Sprintf("(%s)", strings.Join(conditions, " or ")), args
+}
+
+func getSubGroupIds(groupId string, includeSubGroups bool) []string {
+	if !includeSubGroups {
+		return []string{groupId}
+	}
+
+	owner, name := util.GetOwnerAndNameFromId(groupId)
+	groupIds := []string{}
+	for _, group := range NewGroupTree(getAllGroups(owner)).GetDescendants(name) {
+		groupIds = append(groupIds, group.GetId())
+	}
+	return groupIds
+}
+
+// GetGroupUsers returns the users of the group, and of its subgroups when includeSubGroups is set
+func GetGroupUsers(groupId string, includeSubGroups bool) []*User {
+	owner, _ := util.GetOwnerAndNameFromId(groupId)
+	return getUsersByGroupIds(owner, getSubGroupIds(groupId, includeSubGroups))
+}
+
+func GetGroupUserCount(groupId string, includeSubGroups bool, field, value string, filter *Filter) int {
+	owner, _ := util.GetOwnerAndNameFromId(groupId)
+	condition, args := getGroupsCondition(getSubGroupIds(groupId, includeSubGroups), adapter.Engine.Quote)
+	session := applyFilter(GetSession(owner, -1, -1, field, value, "", ""), filter)
+	count, err := session.And(condition, args...).Count(&User{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetPaginationGroupUsers(groupId string, includeSubGroups bool, offset, limit int, field, value, sortField, sortOrder string, filter *Filter) []*User {
+	owner, _ := util.GetOwnerAndNameFromId(groupId)
+	users := []*User{}
+	condition, args := getGroupsCondition(getSubGroupIds(groupId, includeSubGroups), adapter.Engine.Quote)
+	session := applyFilter(GetSession(owner, offset, limit, field, value, sortField, sortOrder), filter)
+	err := session.And(condition, args...).Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	return users
+}
+
+func updateUserGroups(user *User) bool {
+	affected, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("groups").Update(user)
+	if err != nil {
+		panic(err)
+	}
+
+	if affected != 0 {
+		provisionUser(user)
+	}
+
+	return affected != 0
+}
+
+func AddGroupUser(groupId string, userId string) bool {
+	group := GetGroup(groupId)
+	user := GetUser(userId)
+	if group == nil || user == nil || group.Owner != user.Owner {
+		return false
+	}
+
+	if util.InSlice(user.Groups, groupId) {
+		return false
+	}
+
+	user.Groups = append(user.Groups, groupId)
+	affected := updateUserGroups(user)
+	if affected {
+		refreshGroupPermissions(user.Owner)
+	}
+	return affected
+}
+
+func RemoveGroupUser(groupId string, userId string) bool {
+	user := GetUser(userId)
+	if user == nil || !util.InSlice(user.Groups, groupId) {
+		return false
+	}
+
+	user.Groups = util.DeleteVal(user.Groups, groupId)
+	affected := updateUserGroups(user)
+	if affected {
+		refreshGroupPermissions(user.Owner)
+	}
+	return affected
+}
+
+// IsGroupAdmin returns whether the user is an admin of the group or one of its ancestors
+func IsGroupAdmin(groupId string, userId string) bool {
+	owner, name := util.GetOwnerAndNameFromId(groupId)
+	for _, group := range NewGroupTree(getAllGroups(owner)).GetAncestors(name) {
+		if util.InSlice(group.Admins, userId) {
+			return true
+		}
+	}
+	return false
+}
+
+// getGroupRolesByUser returns the roles the user inherits from its groups and their ancestors
+func getGroupRolesByUser(user *User) []*Role {
+	if len(user.Groups) == 0 {
+		return []*Role{}
+	}
+
+	roleIds := []string{}
+	for _, group := range NewGroupTree(getEnabledGroups(user.Owner)).GetUserGroups(user) {
+		roleIds = append(roleIds, group.Roles...)
+	}
+
+	roles := []*Role{}
+	for _, roleId := range util.UniqueStrings(roleIds) {
+		role := GetRole(roleId)
+		if role != nil {
+			role.Users = nil
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// GetUserGroupPaths returns the paths of the enabled groups the user is a direct member of
+func GetUserGroupPaths(user *User) []string {
+	if user == nil || len(user.Groups) == 0 {
+		return []string{}
+	}
+
+	return NewGroupTree(getEnabledGroups(user.Owner)).GetUserGroupPaths(user)
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupTree(t *testing.T) {
+	tree := NewGroupTree([]*Group{
+		{Owner: "casbin", Name: "engineering"},
+		{Owner: "casbin", Name: "backend", ParentName: "engineering"},
+		{Owner: "casbin", Name: "frontend", ParentName: "engineering"},
+		{Owner: "casbin", Name: "db", ParentName: "backend"},
+		{Owner: "casbin", Name: "sales"},
+		{Owner: "casbin", Name: "orphan", ParentName: "missing"},
+	})
+
+	getNames := func(groups []*Group) []string {
+		res := []string{}
+		for _, group := range groups {
+			res = append(res, group.Name)
+		}
+		return res
+	}
+
+	if path := tree.GetPath("db"); path != "casbin/engineering/backend/db" {
+		t.Errorf("GetPath(db) = %s", path)
+	}
+	if path := tree.GetPath("orphan"); path != "casbin/orphan" {
+		t.Errorf("GetPath(orphan) = %s", path)
+	}
+	if names := getNames(tree.GetAncestors("db")); !reflect.DeepEqual(names, []string{"db", "backend", "engineering"}) {
+		t.Errorf("GetAncestors(db) = %v", names)
+	}
+	if names := getNames(tree.GetDescendants("engineering")); !reflect.DeepEqual(names, []string{"engineering", "backend", "frontend", "db"}) {
+		t.Errorf("GetDescendants(engineering) = %v", names)
+	}
+	if names := getNames(tree.GetChildren("")); !reflect.DeepEqual(names, []string{"engineering", "orphan", "sales"}) {
+		t.Errorf("GetChildren() = %v", names)
+	}
+
+	if group := tree.GetGroupByPath("casbin/engineering/backend"); group == nil || group.Name != "backend" {
+		t.Errorf("GetGroupByPath(casbin/engineering/backend) = %v", group)
+	}
+	if group := tree.GetGroupByPath("casbin/sales/backend"); group != nil {
+		t.Errorf("GetGroupByPath(casbin/sales/backend) = %v, expected nil", group)
+	}
+
+	user := &User{Owner: "casbin", Name: "alice", Groups: []string{"casbin/db", "casbin/sales", "other/engineering"}}
+	if names := getNames(tree.GetUserGroups(user)); !reflect.DeepEqual(names, []string{"db", "backend", "engineering", "sales"}) {
+		t.Errorf("GetUserGroups() = %v", names)
+	}
+	if paths := tree.GetUserGroupPaths(user); !reflect.DeepEqual(paths, []string{"casbin/engineering/backend/db", "casbin/sales"}) {
+		t.Errorf("GetUserGroupPaths() = %v", paths)
+	}
+}
+
+func TestGroupTreeCycle(t *testing.T) {
+	tree := NewGroupTree([]*Group{
+		{Owner: "casbin", Name: "a", ParentName: "b"},
+		{Owner: "casbin", Name: "b", ParentName: "a"},
+	})
+
+	if len(tree.GetAncestors("a")) != 2 {
+		t.Errorf("GetAncestors(a) = %v", tree.GetAncestors("a"))
+	}
+	if len(tree.GetDescendants("a")) != 2 {
+		t.Errorf("GetDescendants(a) = %v", tree.GetDescendants("a"))
+	}
+}
+
+func TestRenameGroupPath(t *testing.T) {
+	scenarios := []struct {
+		path     string
+		segment  int
+		expected string
+	}{
+		{"casbin/eng", 1, "casbin/en2"},
+		{"casbin/eng/en", 2, "casbin/eng/en2"},
+		{"casbin/eng/en/backend", 2, "casbin/eng/en2/backend"},
+		{"casbin/en/en/en", 2, "casbin/en/en2/en"},
+	}
+
+	for _, scenario := range scenarios {
+		if path := renameGroupPath(scenario.path, scenario.segment, "en2"); path != scenario.expected {
+			t.Errorf("renameGroupPath(%s, %d) = %s, expected: %s", scenario.path, scenario.segment, path, scenario.expected)
+		}
+	}
+}
+
+func TestGetPoliciesGroupSubjects(t *testing.T) {
+	permission := &Permission{
+		Owner:     "casbin",
+		Name:      "permission",
+		Roles:     []string{"casbin/admin"},
+		Groups:    []string{"casbin/admin"},
+		Resources: []string{"app"},
+		Actions:   []string{"Read"},
+	}
+
+	subjects := []string{}
+	for _, policy := range getPolicies(permission) {
+		subjects = append(subjects, policy[0])
+	}
+	if !reflect.DeepEqual(subjects, []string{"casbin/admin", "group:casbin/admin"}) {
+		t.Errorf("got the subjects: %v, expected the role and the prefixed group", subjects)
+	}
+}
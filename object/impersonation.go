@@ -0,0 +1,329 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	// ImpersonationSessionId and ImpersonatorSessionUserId are the session keys of the impersonation
+	// and the real admin while the admin is signed in as the impersonated user
+	ImpersonationSessionId    = "ImpersonationId"
+	ImpersonatorSessionUserId = "ImpersonatorUserId"
+
+	ImpersonationStateActive  = "Active"
+	ImpersonationStateEnded   = "Ended"
+	ImpersonationStateExpired = "Expired"
+
+	defaultImpersonationMinutes = 30
+)
+
+// ImpersonationPolicy is the permission of the admins of an organization to impersonate its users,
+// the global admins can always impersonate the users
+type ImpersonationPolicy struct {
+	// Users and Roles are the admins, and the roles of the admins, permitted to impersonate the users
+	Users []string `json:"users"`
+	Roles []string `json:"roles"`
+	// MaxMinutes is the longest an impersonation lasts, 30 minutes by default
+	MaxMinutes int `json:"maxMinutes"`
+	// NotifyUser emails the impersonated user when an impersonation starts
+	NotifyUser bool `json:"notifyUser"`
+}
+
+type Impersonation struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	// Impersonator is the real admin, e.g. "built-in/admin", and User is the name of the impersonated user in the owner
+	Impersonator string `xorm:"varchar(100) index" json:"impersonator"`
+	User         string `xorm:"varchar(100) index" json:"user"`
+	Reason       string `xorm:"varchar(500)" json:"reason"`
+	ExpireTime   string `xorm:"varchar(100)" json:"expireTime"`
+	EndTime      string `xorm:"varchar(100)" json:"endTime"`
+	EndedBy      string `xorm:"varchar(100)" json:"endedBy"`
+
+	State string `xorm:"-" json:"state"`
+}
+
+// ActClaim is the "act" claim of the tokens minted while impersonating, identifying the real admin (RFC 8693)
+type ActClaim struct {
+	Sub  string `json:"sub"`
+	Name string `json:"name,omitempty"`
+}
+
+func (impersonation *Impersonation) GetId() string {
+	return fmt.Sprintf("%s/%s", impersonation.Owner, impersonation.Name)
+}
+
+func (impersonation *Impersonation) GetUserId() string {
+	return util.GetId(impersonation.Owner, impersonation.User)
+}
+
+func (impersonation *Impersonation) getState(now time.Time) string {
+	if impersonation.EndTime != "" {
+		return ImpersonationStateEnded
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, impersonation.ExpireTime)
+	if err != nil || !expireTime.After(now) {
+		return ImpersonationStateExpired
+	}
+	return ImpersonationStateActive
+}
+
+// clampExpireTime returns the expire time of a token minted while impersonating, which can't outlive the impersonation
+func (impersonation *Impersonation) clampExpireTime(expireTime time.Time) time.Time {
+	if impersonation == nil {
+		return expireTime
+	}
+
+	impersonationExpireTime, err := time.Parse(time.RFC3339, impersonation.ExpireTime)
+	if err != nil || impersonationExpireTime.Before(expireTime) {
+		return impersonationExpireTime
+	}
+	return expireTime
+}
+
+func (impersonation *Impersonation) IsActive() bool {
+	return impersonation.getState(time.Now()) == ImpersonationStateActive
+}
+
+func (impersonation *Impersonation) getActClaim() *ActClaim {
+	act := &ActClaim{Sub: impersonation.Impersonator, Name: impersonation.Impersonator}
+	if impersonator := GetUser(impersonation.Impersonator); impersonator != nil && impersonator.Id != "" {
+		act.Sub = impersonator.Id
+	}
+	return act
+}
+
+// isPermitted returns whether the admin with the roles is permitted to impersonate by the policy
+func (policy *ImpersonationPolicy) isPermitted(impersonatorId string, roleIds []string) bool {
+	if util.InSlice(policy.Users, impersonatorId) {
+		return true
+	}
+
+	for _, roleId := range roleIds {
+		if util.InSlice(policy.Roles, roleId) {
+			return true
+		}
+	}
+	return false
+}
+
+// getDuration returns the duration of the impersonation requested for the minutes, limited by the policy
+func (policy *ImpersonationPolicy) getDuration(minutes int) time.Duration {
+	maxMinutes := defaultImpersonationMinutes
+	if policy != nil && policy.MaxMinutes > 0 {
+		maxMinutes = policy.MaxMinutes
+	}
+
+	if minutes <= 0 || minutes > maxMinutes {
+		minutes = maxMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// CheckImpersonation returns the error message if the impersonator can't impersonate the user
+func CheckImpersonation(impersonator *User, user *User, lang string) string {
+	if impersonator == nil {
+		return i18n.Translate(lang, "auth:Unauthorized operation")
+	}
+
+	isGlobalAdmin := impersonator.Owner == "built-in" || impersonator.IsGlobalAdmin
+	if !isGlobalAdmin {
+		if !impersonator.IsAdmin || impersonator.Owner != user.Owner {
+			return i18n.Translate(lang, "auth:Unauthorized operation")
+		}
+
+		organization := getOrganization("admin", user.Owner)
+		if organization == nil || organization.ImpersonationPolicy == nil {
+			return i18n.Translate(lang, "impersonation:You don't have the permission to impersonate users")
+		}
+
+		roleIds := []string{}
+		for _, role := range GetRolesByUser(impersonator.GetId()) {
+			roleIds = append(roleIds, role.GetId())
+		}
+		if !organization.ImpersonationPolicy.isPermitted(impersonator.GetId(), roleIds) {
+			return i18n.Translate(lang, "impersonation:You don't have the permission to impersonate users")
+		}
+
+		if user.IsAdmin || user.IsGlobalAdmin {
+			return i18n.Translate(lang, "impersonation:Only global admins can impersonate admins")
+		}
+	}
+
+	if user.GetId() == impersonator.GetId() {
+		return i18n.Translate(lang, "impersonation:You can't impersonate yourself")
+	}
+	if user.IsDeleted || user.IsForbidden {
+		return i18n.Translate(lang, "check:The user is forbidden to sign in, please contact the administrator")
+	}
+	if user.IsServiceAccount() {
+		return i18n.Translate(lang, "check:Service accounts can only authenticate with API keys")
+	}
+
+	return ""
+}
+
+func getImpersonation(owner string, name string) *Impersonation {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	impersonation := Impersonation{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&impersonation)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		impersonation.State = impersonation.getState(time.Now())
+		return &impersonation
+	}
+	return nil
+}
+
+func GetImpersonation(id string) *Impersonation {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getImpersonation(owner, name)
+}
+
+// GetImpersonations returns the impersonations of the users of the organization, or only of the user if given
+func GetImpersonations(owner string, user string) []*Impersonation {
+	impersonations := []*Impersonation{}
+	err := adapter.Engine.Desc("created_time").Find(&impersonations, &Impersonation{Owner: owner, User: user})
+	if err != nil {
+		panic(err)
+	}
+
+	now := time.Now()
+	for _, impersonation := range impersonations {
+		impersonation.State = impersonation.getState(now)
+	}
+	return impersonations
+}
+
+func addImpersonationRecord(impersonation *Impersonation, clientIp string, action string) {
+	record := &Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: impersonation.Owner,
+		ClientIp:     clientIp,
+		User:         impersonation.User,
+		Impersonator: impersonation.Impersonator,
+		Method:       "POST",
+		Action:       action,
+	}
+	util.SafeGoroutine(func() { AddRecord(record) })
+}
+
+func notifyImpersonatedUser(impersonation *Impersonation, user *User, lang string) {
+	organization := getOrganization("admin", user.Owner)
+	if organization == nil || user.Email == "" {
+		return
+	}
+
+	application, err := GetDefaultApplication(util.GetId("admin", organization.Name))
+	if err != nil {
+		logs.Error(fmt.Sprintf("failed to notify the impersonated user: %s, error: %s", user.GetId(), err.Error()))
+		return
+	}
+	provider := application.GetEmailProvider()
+	if provider == nil {
+		return
+	}
+
+	title := fmt.Sprintf(i18n.Translate(lang, "impersonation:Your account of %s is being accessed by an administrator"), organization.DisplayName)
+	content := fmt.Sprintf(i18n.Translate(lang, "impersonation:The administrator: %s has signed in as you until %s, reason: %s"), impersonation.Impersonator, impersonation.ExpireTime, impersonation.Reason)
+	err = SendEmail(provider, title, content, user.Email, organization.DisplayName)
+	if err != nil {
+		logs.Error(fmt.Sprintf("failed to notify the impersonated user: %s, error: %s", user.GetId(), err.Error()))
+	}
+}
+
+// StartImpersonation starts the impersonation of the user by the admin, for the minutes limited by the policy of
+// the organization of the user
+func StartImpersonation(impersonator *User, user *User, reason string, minutes int, clientIp string, lang string) (*Impersonation, error) {
+	if msg := CheckImpersonation(impersonator, user, lang); msg != "" {
+		return nil, errors.New(msg)
+	}
+
+	var policy *ImpersonationPolicy
+	if organization := getOrganization("admin", user.Owner); organization != nil {
+		policy = organization.ImpersonationPolicy
+	}
+
+	now := time.Now()
+	impersonation := &Impersonation{
+		Owner:        user.Owner,
+		Name:         util.GenerateId(),
+		CreatedTime:  now.Format(time.RFC3339),
+		Impersonator: impersonator.GetId(),
+		User:         user.Name,
+		Reason:       reason,
+		ExpireTime:   now.Add(policy.getDuration(minutes)).Format(time.RFC3339),
+		State:        ImpersonationStateActive,
+	}
+	_, err := adapter.Engine.Insert(impersonation)
+	if err != nil {
+		return nil, err
+	}
+
+	addImpersonationRecord(impersonation, clientIp, "start-impersonation")
+	if policy != nil && policy.NotifyUser {
+		util.SafeGoroutine(func() { notifyImpersonatedUser(impersonation, user, lang) })
+	}
+
+	return impersonation, nil
+}
+
+// EndImpersonation ends the impersonation, and revokes the tokens minted during it
+func EndImpersonation(impersonation *Impersonation, endedBy string, clientIp string) bool {
+	if impersonation.EndTime != "" {
+		return false
+	}
+
+	impersonation.EndTime = util.GetCurrentTime()
+	impersonation.EndedBy = endedBy
+	impersonation.State = ImpersonationStateEnded
+	affected, err := adapter.Engine.ID(core.PK{impersonation.Owner, impersonation.Name}).Cols("end_time", "ended_by").Update(impersonation)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = adapter.Engine.Where("impersonation = ?", impersonation.GetId()).Cols("expires_in").Update(&Token{ExpiresIn: 0})
+	if err != nil {
+		panic(err)
+	}
+
+	addImpersonationRecord(impersonation, clientIp, "end-impersonation")
+	return affected != 0
+}
+
+// isImpersonationActive returns whether the impersonation a token is minted in is still active
+func isImpersonationActive(id string) bool {
+	impersonation := GetImpersonation(id)
+	return impersonation != nil && impersonation.IsActive()
+}
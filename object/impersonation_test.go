@@ -0,0 +1,87 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+)
+
+func TestImpersonationState(t *testing.T) {
+	now := time.Now()
+	impersonation := &Impersonation{ExpireTime: now.Add(time.Minute).Format(time.RFC3339)}
+	if state := impersonation.getState(now); state != ImpersonationStateActive {
+		t.Errorf("got state %s, want %s", state, ImpersonationStateActive)
+	}
+	if state := impersonation.getState(now.Add(2 * time.Minute)); state != ImpersonationStateExpired {
+		t.Errorf("got state %s, want %s", state, ImpersonationStateExpired)
+	}
+
+	impersonation.EndTime = now.Format(time.RFC3339)
+	if state := impersonation.getState(now); state != ImpersonationStateEnded {
+		t.Errorf("got state %s, want %s", state, ImpersonationStateEnded)
+	}
+}
+
+func TestImpersonationPolicy(t *testing.T) {
+	policy := &ImpersonationPolicy{
+		Users:      []string{"org/support"},
+		Roles:      []string{"org/helpdesk"},
+		MaxMinutes: 60,
+	}
+
+	if !policy.isPermitted("org/support", nil) {
+		t.Errorf("the listed admin should be permitted")
+	}
+	if !policy.isPermitted("org/alice", []string{"org/sales", "org/helpdesk"}) {
+		t.Errorf("the admin with the listed role should be permitted")
+	}
+	if policy.isPermitted("org/bob", []string{"org/sales"}) {
+		t.Errorf("the other admins should not be permitted")
+	}
+
+	cases := []struct {
+		policy   *ImpersonationPolicy
+		minutes  int
+		expected time.Duration
+	}{
+		{policy, 0, 60 * time.Minute},
+		{policy, 15, 15 * time.Minute},
+		{policy, 120, 60 * time.Minute},
+		{nil, 0, defaultImpersonationMinutes * time.Minute},
+		{nil, 45, defaultImpersonationMinutes * time.Minute},
+	}
+	for _, c := range cases {
+		if duration := c.policy.getDuration(c.minutes); duration != c.expected {
+			t.Errorf("getDuration(%d) = %s, want %s", c.minutes, duration, c.expected)
+		}
+	}
+}
+
+func TestImpersonationClampExpireTime(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	impersonation := &Impersonation{ExpireTime: now.Add(time.Hour).Format(time.RFC3339)}
+	if expireTime := impersonation.clampExpireTime(now.Add(24 * time.Hour)); !expireTime.Equal(now.Add(time.Hour)) {
+		t.Errorf("got expire time %s, want the end of the impersonation %s", expireTime, now.Add(time.Hour))
+	}
+	if expireTime := impersonation.clampExpireTime(now.Add(time.Minute)); !expireTime.Equal(now.Add(time.Minute)) {
+		t.Errorf("got expire time %s, want %s", expireTime, now.Add(time.Minute))
+	}
+
+	var noImpersonation *Impersonation
+	if expireTime := noImpersonation.clampExpireTime(now); !expireTime.Equal(now) {
+		t.Errorf("got expire time %s without impersonation, want %s", expireTime, now)
+	}
+}
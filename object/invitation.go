@@ -0,0 +1,451 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	InvitationStatusActive    = "Active"
+	InvitationStatusExpired   = "Expired"
+	InvitationStatusExhausted = "Exhausted"
+	InvitationStatusRevoked   = "Revoked"
+)
+
+// Invitation lets the invited people sign up to an application of the organization with the code of
+// the invitation link, even if the application doesn't allow to sign up. The email and phone of the
+// invitation are locked during the signup, and the new users get the roles and groups of the invitation.
+type Invitation struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	Code        string   `xorm:"varchar(100) index" json:"code"`
+	Application string   `xorm:"varchar(100)" json:"application"`
+	Email       string   `xorm:"varchar(100)" json:"email"`
+	Phone       string   `xorm:"varchar(20)" json:"phone"`
+	CountryCode string   `xorm:"varchar(6)" json:"countryCode"`
+	Roles       []string `xorm:"mediumtext" json:"roles"`
+	Groups      []string `xorm:"mediumtext" json:"groups"`
+	ExpireTime  string   `xorm:"varchar(100)" json:"expireTime"`
+	Quota       int      `json:"quota"`
+	UsedCount   int      `json:"usedCount"`
+	Users       []string `xorm:"mediumtext" json:"users"`
+	Inviter     string   `xorm:"varchar(100)" json:"inviter"`
+	SentTime    string   `xorm:"varchar(100)" json:"sentTime"`
+	IsRevoked   bool     `json:"isRevoked"`
+
+	Status string `xorm:"-" json:"status"`
+}
+
+func GetInvitationCount(owner, field, value string) int {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	count, err := session.Count(&Invitation{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetInvitations(owner string) []*Invitation {
+	invitations := []*Invitation{}
+	err := adapter.Engine.Desc("created_time").Find(&invitations, &Invitation{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return extendInvitationsWithStatus(invitations)
+}
+
+func GetPaginationInvitations(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Invitation {
+	invitations := []*Invitation{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&invitations)
+	if err != nil {
+		panic(err)
+	}
+
+	return extendInvitationsWithStatus(invitations)
+}
+
+func extendInvitationsWithStatus(invitations []*Invitation) []*Invitation {
+	for _, invitation := range invitations {
+		invitation.Status = invitation.GetStatus()
+	}
+	return invitations
+}
+
+func getInvitation(owner string, name string) *Invitation {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	invitation := Invitation{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&invitation)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		invitation.Status = invitation.GetStatus()
+		return &invitation
+	} else {
+		return nil
+	}
+}
+
+func GetInvitation(id string) *Invitation {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getInvitation(owner, name)
+}
+
+func GetInvitationByCode(code string) *Invitation {
+	if code == "" {
+		return nil
+	}
+
+	invitation := Invitation{Code: code}
+	existed, err := adapter.Engine.Get(&invitation)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		invitation.Status = invitation.GetStatus()
+		return &invitation
+	} else {
+		return nil
+	}
+}
+
+// UpdateInvitation keeps the code and the usage of the invitation, which can't be changed by admins
+func UpdateInvitation(id string, invitation *Invitation) bool {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	oldInvitation := getInvitation(owner, name)
+	if oldInvitation == nil {
+		return false
+	}
+
+	invitation.Code = oldInvitation.Code
+	invitation.UsedCount = oldInvitation.UsedCount
+	invitation.Users = oldInvitation.Users
+	invitation.Inviter = oldInvitation.Inviter
+	invitation.SentTime = oldInvitation.SentTime
+	if invitation.Quota <= 0 {
+		invitation.Quota = 1
+	}
+	invitation.UpdatedTime = util.GetCurrentTime()
+
+	affected, err := adapter.Engine.ID(core.PK{owner, name}).AllCols().Update(invitation)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func AddInvitation(invitation *Invitation) bool {
+	if invitation.CreatedTime == "" {
+		invitation.CreatedTime = util.GetCurrentTime()
+	}
+	if invitation.Quota <= 0 {
+		invitation.Quota = 1
+	}
+
+	invitation.Code = util.GenerateClientSecret()
+	invitation.UsedCount = 0
+	invitation.Users = []string{}
+	invitation.SentTime = ""
+
+	affected, err := adapter.Engine.Insert(invitation)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func DeleteInvitation(invitation *Invitation) bool {
+	affected, err := adapter.Engine.ID(core.PK{invitation.Owner, invitation.Name}).Delete(&Invitation{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func RevokeInvitation(id string) bool {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	invitation := &Invitation{IsRevoked: true, UpdatedTime: util.GetCurrentTime()}
+	affected, err := adapter.Engine.ID(core.PK{owner, name}).Cols("is_revoked", "updated_time").Update(invitation)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func (invitation *Invitation) GetId() string {
+	return fmt.Sprintf("%s/%s", invitation.Owner, invitation.Name)
+}
+
+func (invitation *Invitation) isExpired() bool {
+	if invitation.ExpireTime == "" {
+		return false
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, invitation.ExpireTime)
+	return err == nil && expireTime.Before(time.Now())
+}
+
+func (invitation *Invitation) GetStatus() string {
+	if invitation.IsRevoked {
+		return InvitationStatusRevoked
+	} else if invitation.isExpired() {
+		return InvitationStatusExpired
+	} else if invitation.UsedCount >= invitation.Quota {
+		return InvitationStatusExhausted
+	} else {
+		return InvitationStatusActive
+	}
+}
+
+// GetMaskedInvitation returns the fields of the invitation that are pre-filled in the signup page
+func GetMaskedInvitation(invitation *Invitation) *Invitation {
+	if invitation == nil {
+		return nil
+	}
+
+	return &Invitation{
+		Owner:       invitation.Owner,
+		Name:        invitation.Name,
+		DisplayName: invitation.DisplayName,
+		Application: invitation.Application,
+		Email:       invitation.Email,
+		Phone:       invitation.Phone,
+		CountryCode: invitation.CountryCode,
+		ExpireTime:  invitation.ExpireTime,
+		Status:      invitation.Status,
+	}
+}
+
+func CheckInvitation(invitation *Invitation, lang string) string {
+	if invitation.Application != "" {
+		application := getApplication("admin", invitation.Application)
+		if application == nil || application.Organization != invitation.Owner {
+			return fmt.Sprintf(i18n.Translate(lang, "invitation:The application: %s does not exist"), invitation.Application)
+		}
+	}
+
+	if invitation.Email != "" && !util.IsEmailValid(invitation.Email) {
+		return i18n.Translate(lang, "check:Email is invalid")
+	}
+	if invitation.Phone != "" {
+		if _, ok := util.GetE164Number(invitation.Phone, invitation.CountryCode); !ok {
+			return i18n.Translate(lang, "check:Phone number is invalid")
+		}
+	}
+
+	if invitation.ExpireTime != "" {
+		if _, err := time.Parse(time.RFC3339, invitation.ExpireTime); err != nil {
+			return fmt.Sprintf(i18n.Translate(lang, "invitation:The expire time: %s is invalid"), invitation.ExpireTime)
+		}
+	}
+	if invitation.Quota < 0 {
+		return i18n.Translate(lang, "invitation:The quota should not be negative")
+	}
+
+	for _, roleId := range invitation.Roles {
+		role := GetRole(roleId)
+		if role == nil || role.Owner != invitation.Owner {
+			return fmt.Sprintf(i18n.Translate(lang, "invitation:The role: %s does not exist"), roleId)
+		}
+	}
+	for _, groupId := range invitation.Groups {
+		group := GetGroup(groupId)
+		if group == nil || group.Owner != invitation.Owner {
+			return fmt.Sprintf(i18n.Translate(lang, "invitation:The group: %s does not exist"), groupId)
+		}
+	}
+
+	return ""
+}
+
+// CheckInvitationCode returns the invitation of the code if it can be used to sign up to the application
+func CheckInvitationCode(application *Application, code string, lang string) (*Invitation, string) {
+	invitation := GetInvitationByCode(code)
+	if invitation == nil || invitation.Owner != application.Organization ||
+		(invitation.Application != "" && invitation.Application != application.Name) {
+		return nil, i18n.Translate(lang, "invitation:The invitation code is invalid")
+	}
+
+	switch invitation.Status {
+	case InvitationStatusRevoked:
+		return nil, i18n.Translate(lang, "invitation:The invitation has been revoked")
+	case InvitationStatusExpired:
+		return nil, i18n.Translate(lang, "invitation:The invitation has expired")
+	case InvitationStatusExhausted:
+		return nil, i18n.Translate(lang, "invitation:The invitation has reached its usage limit")
+	}
+
+	return invitation, ""
+}
+
+// ReserveInvitation takes one use of the invitation, it fails when the usage limit has been reached
+// by concurrent signups
+func ReserveInvitation(invitation *Invitation) bool {
+	affected, err := adapter.Engine.ID(core.PK{invitation.Owner, invitation.Name}).
+		Where("used_count < quota").Incr("used_count").Update(&Invitation{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+// ReleaseInvitation gives back the use taken by ReserveInvitation when the signup fails
+func ReleaseInvitation(invitation *Invitation) {
+	_, err := adapter.Engine.ID(core.PK{invitation.Owner, invitation.Name}).
+		Where("used_count > 0").Decr("used_count").Update(&Invitation{})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetInvitationGroups returns the groups of the invitation that still exist, for the new user
+func GetInvitationGroups(invitation *Invitation) []string {
+	groups := []string{}
+	for _, groupId := range invitation.Groups {
+		if group := GetGroup(groupId); group != nil && group.Owner == invitation.Owner {
+			groups = append(groups, groupId)
+		}
+	}
+	return groups
+}
+
+// AddInvitationUser records the user signed up with the invitation and adds the user to the roles of the invitation
+func AddInvitationUser(invitation *Invitation, user *User) {
+	userId := user.GetId()
+	for _, roleId := range invitation.Roles {
+		role := GetRole(roleId)
+		if role == nil || role.Owner != invitation.Owner || util.InSlice(role.Users, userId) {
+			continue
+		}
+
+		role.Users = append(role.Users, userId)
+		UpdateRole(roleId, role)
+	}
+
+	invitation = getInvitation(invitation.Owner, invitation.Name)
+	if invitation == nil {
+		return
+	}
+
+	invitation.Users = append(invitation.Users, userId)
+	_, err := adapter.Engine.ID(core.PK{invitation.Owner, invitation.Name}).Cols("users").Update(invitation)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func getInvitationProvider(application *Application, category string) *Provider {
+	if provider := application.GetProviderByCategory(category); provider != nil {
+		return provider
+	}
+
+	for _, provider := range GetProviders(application.Organization) {
+		if provider.Category == category {
+			return provider
+		}
+	}
+	return nil
+}
+
+// GetInvitationLink returns the signup link of the invitation
+func GetInvitationLink(invitation *Invitation, application *Application, host string) string {
+	originFrontend, _ := getOriginFromHost(host)
+	return fmt.Sprintf("%s/signup/%s?invitationCode=%s", originFrontend, url.PathEscape(application.Name), url.QueryEscape(invitation.Code))
+}
+
+// SendInvitation delivers the invitation link by email, or the invitation code by SMS as the SMS
+// templates only carry a code, with the providers of the application or else of the organization
+func SendInvitation(invitation *Invitation, host string, lang string) error {
+	if invitation.GetStatus() != InvitationStatusActive {
+		return errors.New(i18n.Translate(lang, "invitation:The invitation is not active"))
+	}
+
+	organization := getOrganization("admin", invitation.Owner)
+	if organization == nil {
+		return errors.New(i18n.Translate(lang, "check:Organization does not exist"))
+	}
+
+	var application *Application
+	if invitation.Application != "" {
+		application = getApplication("admin", invitation.Application)
+	} else {
+		application = getApplication("admin", organization.DefaultApplication)
+	}
+	if application == nil {
+		return errors.New(i18n.Translate(lang, "invitation:The invitation has no application to sign up"))
+	}
+
+	if invitation.Email != "" {
+		provider := getInvitationProvider(application, "Email")
+		if provider == nil {
+			return errors.New("please set an Email provider first")
+		}
+
+		link := GetInvitationLink(invitation, application, host)
+		title := fmt.Sprintf(i18n.Translate(lang, "invitation:You are invited to join %s"), organization.DisplayName)
+		content := fmt.Sprintf(i18n.Translate(lang, "invitation:Please sign up with the link: <a href=\"%s\">%s</a>"), link, link)
+		if err := SendEmail(provider, title, content, invitation.Email, organization.DisplayName); err != nil {
+			return err
+		}
+	} else if invitation.Phone != "" {
+		provider := getInvitationProvider(application, "SMS")
+		if provider == nil {
+			return errors.New("please set a SMS provider first")
+		}
+
+		phone, ok := util.GetE164Number(invitation.Phone, invitation.CountryCode)
+		if !ok {
+			return errors.New(i18n.Translate(lang, "check:Phone number is invalid"))
+		}
+		if err := SendSms(provider, invitation.Code, phone); err != nil {
+			return err
+		}
+	} else {
+		return errors.New(i18n.Translate(lang, "invitation:The invitation has no email or phone to send to"))
+	}
+
+	invitation.SentTime = util.GetCurrentTime()
+	_, err := adapter.Engine.ID(core.PK{invitation.Owner, invitation.Name}).Cols("sent_time").Update(invitation)
+	if err != nil {
+		panic(err)
+	}
+
+	return nil
+}
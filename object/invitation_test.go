@@ -0,0 +1,42 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetInvitationStatus(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	cases := []struct {
+		invitation *Invitation
+		expected   string
+	}{
+		{&Invitation{Quota: 1}, InvitationStatusActive},
+		{&Invitation{Quota: 2, UsedCount: 1, ExpireTime: future}, InvitationStatusActive},
+		{&Invitation{Quota: 1, UsedCount: 1, ExpireTime: future}, InvitationStatusExhausted},
+		{&Invitation{Quota: 1, ExpireTime: past}, InvitationStatusExpired},
+		{&Invitation{Quota: 1, UsedCount: 1, ExpireTime: past, IsRevoked: true}, InvitationStatusRevoked},
+	}
+
+	for i, c := range cases {
+		if status := c.invitation.GetStatus(); status != c.expected {
+			t.Errorf("case %d: GetStatus() = %s, want %s", i, status, c.expected)
+		}
+	}
+}
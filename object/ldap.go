@@ -15,6 +15,8 @@
 package object
 
 import (
+	"reflect"
+
 	"github.com/casdoor/casdoor/util"
 )
 
@@ -33,8 +35,23 @@ type Ldap struct {
 	Filter       string   `xorm:"varchar(200)" json:"filter"`
 	FilterFields []string `xorm:"varchar(100)" json:"filterFields"`
 
-	AutoSync int    `json:"autoSync"`
-	LastSync string `xorm:"varchar(100)" json:"lastSync"`
+	// EnableGroupSync syncs the groups under GroupBaseDn matching GroupFilter to roles,
+	// the base DN and a filter of the common group object classes are used by default
+	EnableGroupSync bool   `json:"enableGroupSync"`
+	GroupBaseDn     string `xorm:"varchar(100)" json:"groupBaseDn"`
+	GroupFilter     string `xorm:"varchar(200)" json:"groupFilter"`
+
+	// AttributeMapping maps LDAP attributes to user fields like "DisplayName", or to user properties for the other names
+	AttributeMapping   map[string]string `xorm:"mediumtext" json:"attributeMapping"`
+	DepartedUserPolicy string            `xorm:"varchar(100)" json:"departedUserPolicy"`
+
+	AutoSync       int             `json:"autoSync"`
+	LastSync       string          `xorm:"varchar(100)" json:"lastSync"`
+	SyncCursor     string          `xorm:"varchar(100)" json:"syncCursor"`
+	LastSyncReport *LdapSyncReport `xorm:"json" json:"lastSyncReport"`
+
+	LeaseHolder  string `xorm:"-" json:"leaseHolder"`
+	NextSyncTime string `xorm:"-" json:"nextSyncTime"`
 }
 
 func AddLdap(ldap *Ldap) bool {
@@ -104,12 +121,24 @@ func GetLdap(id string) *Ldap {
 }
 
 func UpdateLdap(ldap *Ldap) bool {
-	if GetLdap(ldap.Id) == nil {
+	oldLdap := GetLdap(ldap.Id)
+	if oldLdap == nil {
 		return false
 	}
 
-	affected, err := adapter.Engine.ID(ldap.Id).Cols("owner", "server_name", "host",
-		"port", "enable_ssl", "username", "password", "base_dn", "filter", "filter_fields", "auto_sync").Update(ldap)
+	columns := []string{
+		"owner", "server_name", "host", "port", "enable_ssl", "username", "password", "base_dn", "filter", "filter_fields",
+		"enable_group_sync", "group_base_dn", "group_filter", "attribute_mapping", "departed_user_policy", "auto_sync",
+	}
+
+	// the next sync is a full sync when the synced users or attributes change
+	if ldap.Host != oldLdap.Host || ldap.BaseDn != oldLdap.BaseDn || ldap.Filter != oldLdap.Filter ||
+		!reflect.DeepEqual(ldap.AttributeMapping, oldLdap.AttributeMapping) {
+		ldap.SyncCursor = ""
+		columns = append(columns, "sync_cursor")
+	}
+
+	affected, err := adapter.Engine.ID(ldap.Id).Cols(columns...).Update(ldap)
 	if err != nil {
 		panic(err)
 	}
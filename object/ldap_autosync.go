@@ -2,6 +2,7 @@ package object
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -74,28 +75,65 @@ func (l *LdapAutoSynchronizer) syncRoutine(ldap *Ldap, stopChan chan struct{}) {
 		case <-ticker.C:
 		}
 
-		UpdateLdapSyncTime(ldap.Id)
-		// fetch all users
-		conn, err := ldap.GetLdapConn()
-		if err != nil {
-			logs.Warning(fmt.Sprintf("autoSync failed for %s, error %s", ldap.Id, err))
-			continue
-		}
+		// only one instance syncs the LDAP at a time, and the others skip the sync it has just done
+		runWithLease(getLdapLeaseName(ldap.Id), func() {
+			// reload the LDAP for its latest sync cursor
+			latestLdap := GetLdap(ldap.Id)
+			if latestLdap == nil || !latestLdap.isAutoSyncDue(time.Now()) {
+				return
+			}
+			ldap = latestLdap
 
-		users, err := conn.GetLdapUsers(ldap)
-		if err != nil {
-			logs.Warning(fmt.Sprintf("autoSync failed for %s, error %s", ldap.Id, err))
-			continue
-		}
+			report := SyncLdap(ldap)
+			if len(report.Errors) != 0 {
+				logs.Warning(fmt.Sprintf("autoSync for %s: %s, errors: %s", ldap.Id, report, strings.Join(report.Errors, "; ")))
+			} else {
+				logs.Info(fmt.Sprintf("autoSync for %s: %s", ldap.Id, report))
+			}
+		})
+	}
+}
 
-		existed, failed, err := SyncLdapUsers(ldap.Owner, AutoAdjustLdapUser(users), ldap.Id)
-		if len(failed) != 0 {
-			logs.Warning(fmt.Sprintf("ldap autosync,%d new users,but %d user failed during :", len(users)-len(existed)-len(failed), len(failed)), failed)
-			logs.Warning(err.Error())
-		} else {
-			logs.Info(fmt.Sprintf("ldap autosync success, %d new users, %d existing users", len(users)-len(existed), len(existed)))
-		}
+func getLdapLeaseName(ldapId string) string {
+	return fmt.Sprintf("ldap/%s", ldapId)
+}
+
+func (ldap *Ldap) getNextSyncTime() (time.Time, bool) {
+	lastSync, err := time.Parse(time.RFC3339, ldap.LastSync)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return lastSync.Add(time.Duration(ldap.AutoSync) * time.Minute), true
+}
+
+// isAutoSyncDue returns whether the auto sync interval has passed since the last sync, within the leeway
+// of the syncers as the instances tick at different times
+func (ldap *Ldap) isAutoSyncDue(now time.Time) bool {
+	nextSyncTime, ok := ldap.getNextSyncTime()
+	return !ok || !nextSyncTime.After(now.Add(syncerScheduleLeeway))
+}
+
+// SetScheduleInfo sets the instance syncing the LDAP and the next auto sync time
+func (ldap *Ldap) SetScheduleInfo() {
+	ldap.LeaseHolder = GetLeaseHolder(getLdapLeaseName(ldap.Id))
+	if ldap.AutoSync == 0 {
+		return
+	}
+
+	nextSyncTime, ok := ldap.getNextSyncTime()
+	if !ok || nextSyncTime.Before(time.Now()) {
+		nextSyncTime = time.Now().Add(time.Duration(ldap.AutoSync) * time.Minute)
+	}
+	ldap.NextSyncTime = nextSyncTime.Format(time.RFC3339)
+}
+
+// RunLdapSync syncs the LDAP now, it fails if another instance is syncing the LDAP
+func RunLdapSync(ldap *Ldap) (*LdapSyncReport, error) {
+	var report *LdapSyncReport
+	if !runWithLease(getLdapLeaseName(ldap.Id), func() { report = SyncLdap(ldap) }) {
+		return nil, fmt.Errorf("the LDAP: %s is being synced on %s", ldap.Id, GetLeaseHolder(getLdapLeaseName(ldap.Id)))
 	}
+	return report, nil
 }
 
 // LdapAutoSynchronizerStartUpAll
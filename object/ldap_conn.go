@@ -29,10 +29,15 @@ type LdapConn struct {
 	IsAD bool
 }
 
-//type ldapGroup struct {
-//	GidNumber string
-//	Cn        string
-//}
+type LdapGroup struct {
+	Dn          string   `json:"dn"`
+	Cn          string   `json:"cn"`
+	GidNumber   string   `json:"gidNumber"`
+	Description string   `json:"description"`
+	Members     []string `json:"members"`
+	MemberUids  []string `json:"memberUids"`
+	MemberOf    []string `json:"memberOf"`
+}
 
 type LdapUser struct {
 	UidNumber string `json:"uidNumber"`
@@ -54,6 +59,10 @@ type LdapUser struct {
 	GroupId string `json:"groupId"`
 	Phone   string `json:"phone"`
 	Address string `json:"address"`
+
+	Dn         string            `json:"dn"`
+	MemberOf   []string          `json:"memberOf"`
+	Attributes map[string]string `json:"attributes"`
 }
 
 func (ldap *Ldap) GetLdapConn() (c *LdapConn, err error) {
@@ -127,32 +136,63 @@ func isMicrosoftAD(Conn *goldap.Conn) (bool, error) {
 }
 
 func (l *LdapConn) GetLdapUsers(ldapServer *Ldap) ([]LdapUser, error) {
-	SearchAttributes := []string{
-		"uidNumber", "cn", "sn", "gidNumber", "entryUUID", "displayName", "mail", "email",
+	ldapUsers, err := l.searchLdapUsers(ldapServer, ldapServer.Filter, l.getUserAttributes(ldapServer))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ldapUsers) == 0 {
+		return nil, errors.New("no result")
+	}
+
+	return ldapUsers, nil
+}
+
+// getUserIdentityAttributes returns the attributes identifying the users, their groups and whether they are disabled
+func (l *LdapConn) getUserIdentityAttributes() []string {
+	attributes := []string{"cn", "entryUUID", "memberOf", l.getSyncCursorAttribute()}
+	if l.IsAD {
+		return append(attributes, "sAMAccountName", "userAccountControl")
+	}
+	return append(attributes, "uid")
+}
+
+func (l *LdapConn) getUserAttributes(ldapServer *Ldap) []string {
+	attributes := []string{
+		"uidNumber", "sn", "gidNumber", "displayName", "mail", "email",
 		"emailAddress", "telephoneNumber", "mobile", "mobileTelephoneNumber", "registeredAddress", "postalAddress",
 	}
+	for attribute := range ldapServer.AttributeMapping {
+		attributes = append(attributes, attribute)
+	}
+	return util.UniqueStrings(append(attributes, l.getUserIdentityAttributes()...))
+}
+
+// getSyncCursorAttribute returns the attribute increasing on every change of an entry, used by the incremental sync
+func (l *LdapConn) getSyncCursorAttribute() string {
 	if l.IsAD {
-		SearchAttributes = append(SearchAttributes, "sAMAccountName")
-	} else {
-		SearchAttributes = append(SearchAttributes, "uid")
+		return "uSNChanged"
 	}
+	return "modifyTimestamp"
+}
 
+func (l *LdapConn) searchLdapUsers(ldapServer *Ldap, filter string, attributes []string) ([]LdapUser, error) {
 	searchReq := goldap.NewSearchRequest(ldapServer.BaseDn, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases,
 		0, 0, false,
-		ldapServer.Filter, SearchAttributes, nil)
+		filter, attributes, nil)
 	searchResult, err := l.Conn.SearchWithPaging(searchReq, 100)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(searchResult.Entries) == 0 {
-		return nil, errors.New("no result")
-	}
-
-	var ldapUsers []LdapUser
+	ldapUsers := []LdapUser{}
 	for _, entry := range searchResult.Entries {
-		var user LdapUser
+		user := LdapUser{Dn: entry.DN, Attributes: map[string]string{}}
 		for _, attribute := range entry.Attributes {
+			if len(attribute.Values) > 0 {
+				user.Attributes[attribute.Name] = attribute.Values[0]
+			}
+
 			switch attribute.Name {
 			case "uidNumber":
 				user.UidNumber = attribute.Values[0]
@@ -186,6 +226,8 @@ func (l *LdapConn) GetLdapUsers(ldapServer *Ldap) ([]LdapUser, error) {
 				user.RegisteredAddress = attribute.Values[0]
 			case "postalAddress":
 				user.PostalAddress = attribute.Values[0]
+			case "memberOf":
+				user.MemberOf = attribute.Values
 			}
 		}
 		ldapUsers = append(ldapUsers, user)
@@ -194,42 +236,43 @@ func (l *LdapConn) GetLdapUsers(ldapServer *Ldap) ([]LdapUser, error) {
 	return ldapUsers, nil
 }
 
-// FIXME: The Base DN does not necessarily contain the Group
-//
-//	func (l *ldapConn) GetLdapGroups(baseDn string) (map[string]ldapGroup, error) {
-//		SearchFilter := "(objectClass=posixGroup)"
-//		SearchAttributes := []string{"cn", "gidNumber"}
-//		groupMap := make(map[string]ldapGroup)
-//
-//		searchReq := goldap.NewSearchRequest(baseDn,
-//			goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
-//			SearchFilter, SearchAttributes, nil)
-//		searchResult, err := l.Conn.Search(searchReq)
-//		if err != nil {
-//			return nil, err
-//		}
-//
-//		if len(searchResult.Entries) == 0 {
-//			return nil, errors.New("no result")
-//		}
-//
-//		for _, entry := range searchResult.Entries {
-//			var ldapGroupItem ldapGroup
-//			for _, attribute := range entry.Attributes {
-//				switch attribute.Name {
-//				case "gidNumber":
-//					ldapGroupItem.GidNumber = attribute.Values[0]
-//					break
-//				case "cn":
-//					ldapGroupItem.Cn = attribute.Values[0]
-//					break
-//				}
-//			}
-//			groupMap[ldapGroupItem.GidNumber] = ldapGroupItem
-//		}
-//
-//		return groupMap, nil
-//	}
+const defaultLdapGroupFilter = "(|(objectClass=group)(objectClass=groupOfNames)(objectClass=groupOfUniqueNames)(objectClass=posixGroup))"
+
+func (l *LdapConn) GetLdapGroups(ldapServer *Ldap) ([]LdapGroup, error) {
+	SearchAttributes := []string{"cn", "gidNumber", "description", "member", "uniqueMember", "memberUid", "memberOf"}
+
+	searchReq := goldap.NewSearchRequest(util.ReturnAnyNotEmpty(ldapServer.GroupBaseDn, ldapServer.BaseDn),
+		goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
+		util.ReturnAnyNotEmpty(ldapServer.GroupFilter, defaultLdapGroupFilter), SearchAttributes, nil)
+	searchResult, err := l.Conn.SearchWithPaging(searchReq, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	ldapGroups := []LdapGroup{}
+	for _, entry := range searchResult.Entries {
+		group := LdapGroup{Dn: entry.DN}
+		for _, attribute := range entry.Attributes {
+			switch attribute.Name {
+			case "cn":
+				group.Cn = attribute.Values[0]
+			case "gidNumber":
+				group.GidNumber = attribute.Values[0]
+			case "description":
+				group.Description = attribute.Values[0]
+			case "member", "uniqueMember":
+				group.Members = append(group.Members, attribute.Values...)
+			case "memberUid":
+				group.MemberUids = attribute.Values
+			case "memberOf":
+				group.MemberOf = attribute.Values
+			}
+		}
+		ldapGroups = append(ldapGroups, group)
+	}
+
+	return ldapGroups, nil
+}
 
 func AutoAdjustLdapUser(users []LdapUser) []LdapUser {
 	res := make([]LdapUser, len(users))
@@ -244,6 +287,9 @@ func AutoAdjustLdapUser(users []LdapUser) []LdapUser {
 			Email:             util.ReturnAnyNotEmpty(user.Email, user.EmailAddress, user.Mail),
 			Mobile:            util.ReturnAnyNotEmpty(user.Mobile, user.MobileTelephoneNumber, user.TelephoneNumber),
 			RegisteredAddress: util.ReturnAnyNotEmpty(user.PostalAddress, user.RegisteredAddress),
+			Dn:                user.Dn,
+			MemberOf:          user.MemberOf,
+			Attributes:        user.Attributes,
 		}
 	}
 	return res
@@ -258,22 +304,6 @@ func SyncLdapUsers(owner string, syncUsers []LdapUser, ldapId string) (existUser
 	organization := getOrganization("admin", owner)
 	ldap := GetLdap(ldapId)
 
-	var dc []string
-	for _, basedn := range strings.Split(ldap.BaseDn, ",") {
-		if strings.Contains(basedn, "dc=") {
-			dc = append(dc, basedn[3:])
-		}
-	}
-	affiliation := strings.Join(dc, ".")
-
-	var ou []string
-	for _, admin := range strings.Split(ldap.Username, ",") {
-		if strings.Contains(admin, "ou=") {
-			ou = append(ou, admin[3:])
-		}
-	}
-	tag := strings.Join(ou, ".")
-
 	for _, syncUser := range syncUsers {
 		existUuids := GetExistUuids(owner, uuids)
 		found := false
@@ -287,23 +317,7 @@ func SyncLdapUsers(owner string, syncUsers []LdapUser, ldapId string) (existUser
 		}
 
 		if !found {
-			score, _ := organization.GetInitScore()
-			newUser := &User{
-				Owner:       owner,
-				Name:        syncUser.buildLdapUserName(),
-				CreatedTime: util.GetCurrentTime(),
-				DisplayName: syncUser.buildLdapDisplayName(),
-				Avatar:      organization.DefaultAvatar,
-				Email:       syncUser.Email,
-				Phone:       syncUser.Phone,
-				Address:     []string{syncUser.Address},
-				Affiliation: affiliation,
-				Tag:         tag,
-				Score:       score,
-				Ldap:        syncUser.Uuid,
-			}
-
-			affected := AddUser(newUser)
+			affected := AddUser(ldap.newUser(organization, syncUser))
 			if !affected {
 				failedUsers = append(failedUsers, syncUser)
 				continue
@@ -314,6 +328,46 @@ func SyncLdapUsers(owner string, syncUsers []LdapUser, ldapId string) (existUser
 	return existUsers, failedUsers, err
 }
 
+func (ldap *Ldap) getAffiliation() string {
+	var dc []string
+	for _, basedn := range strings.Split(ldap.BaseDn, ",") {
+		if strings.Contains(basedn, "dc=") {
+			dc = append(dc, basedn[3:])
+		}
+	}
+	return strings.Join(dc, ".")
+}
+
+func (ldap *Ldap) getTag() string {
+	var ou []string
+	for _, admin := range strings.Split(ldap.Username, ",") {
+		if strings.Contains(admin, "ou=") {
+			ou = append(ou, admin[3:])
+		}
+	}
+	return strings.Join(ou, ".")
+}
+
+func (ldap *Ldap) newUser(organization *Organization, syncUser LdapUser) *User {
+	score, _ := organization.GetInitScore()
+	user := &User{
+		Owner:       organization.Name,
+		Name:        syncUser.buildLdapUserName(),
+		CreatedTime: util.GetCurrentTime(),
+		DisplayName: syncUser.buildLdapDisplayName(),
+		Avatar:      organization.DefaultAvatar,
+		Email:       syncUser.Email,
+		Phone:       syncUser.Phone,
+		Address:     []string{syncUser.Address},
+		Affiliation: ldap.getAffiliation(),
+		Tag:         ldap.getTag(),
+		Score:       score,
+		Ldap:        syncUser.Uuid,
+	}
+	ldap.setMappedUserFields(user, syncUser)
+	return user
+}
+
 func GetExistUuids(owner string, uuids []string) []string {
 	var existUuids []string
 
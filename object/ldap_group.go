@@ -0,0 +1,204 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+type ldapGroupMembers struct {
+	Users []string
+	Roles []string
+}
+
+func normalizeLdapDn(dn string) string {
+	rdns := strings.Split(dn, ",")
+	for i, rdn := range rdns {
+		rdns[i] = strings.TrimSpace(rdn)
+	}
+	return strings.ToLower(strings.Join(rdns, ","))
+}
+
+func (group *LdapGroup) getRoleName() string {
+	name := group.Cn
+	if name == "" {
+		// the value of the first RDN, e.g. "cn=admins,ou=groups,dc=example,dc=com" -> "admins"
+		rdn := strings.Split(group.Dn, ",")[0]
+		name = strings.TrimSpace(rdn[strings.Index(rdn, "=")+1:])
+	}
+	return strings.ReplaceAll(name, "/", "_")
+}
+
+// getLdapGroupMembers resolves the members of the groups as role ids, by the ids of the Casdoor users imported from
+// the LDAP users. A member of a group is a user or group in its member, uniqueMember or memberUid attributes, or having
+// the group in its memberOf attribute, so the nested groups of Active Directory become nested roles.
+func getLdapGroupMembers(owner string, groups []LdapGroup, users []LdapUser, userIds map[string]string) map[string]*ldapGroupMembers {
+	userIdsByDn := map[string]string{}
+	userIdsByUid := map[string]string{}
+	for _, user := range users {
+		userId, ok := userIds[user.GetLdapUuid()]
+		if !ok {
+			continue
+		}
+
+		userIdsByDn[normalizeLdapDn(user.Dn)] = userId
+		if user.Uid != "" {
+			userIdsByUid[user.Uid] = userId
+		}
+	}
+
+	res := map[string]*ldapGroupMembers{}
+	roleIdsByDn := map[string]string{}
+	for _, group := range groups {
+		roleId := util.GetId(owner, group.getRoleName())
+		roleIdsByDn[normalizeLdapDn(group.Dn)] = roleId
+		res[roleId] = &ldapGroupMembers{Users: []string{}, Roles: []string{}}
+	}
+
+	addMember := func(groupDn string, memberDn string) {
+		roleId, ok := roleIdsByDn[normalizeLdapDn(groupDn)]
+		if !ok {
+			return
+		}
+
+		memberDn = normalizeLdapDn(memberDn)
+		if userId, ok := userIdsByDn[memberDn]; ok {
+			res[roleId].Users = append(res[roleId].Users, userId)
+		} else if subRoleId, ok := roleIdsByDn[memberDn]; ok && subRoleId != roleId {
+			res[roleId].Roles = append(res[roleId].Roles, subRoleId)
+		}
+	}
+
+	for _, group := range groups {
+		for _, member := range group.Members {
+			addMember(group.Dn, member)
+		}
+		for _, parentDn := range group.MemberOf {
+			addMember(parentDn, group.Dn)
+		}
+
+		roleId := roleIdsByDn[normalizeLdapDn(group.Dn)]
+		for _, uid := range group.MemberUids {
+			if userId, ok := userIdsByUid[uid]; ok {
+				res[roleId].Users = append(res[roleId].Users, userId)
+			}
+		}
+	}
+
+	for _, user := range users {
+		for _, groupDn := range user.MemberOf {
+			addMember(groupDn, user.Dn)
+		}
+	}
+
+	for _, members := range res {
+		members.Users = getSortedMembers(members.Users)
+		members.Roles = getSortedMembers(members.Roles)
+	}
+	return res
+}
+
+func getLdapImportedUserIds(owner string) map[string]string {
+	users := []*User{}
+	err := adapter.Engine.Where("owner = ? and ldap != ?", owner, "").Cols("owner", "name", "ldap").Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	res := map[string]string{}
+	for _, user := range users {
+		res[user.Ldap] = user.GetId()
+	}
+	return res
+}
+
+// mergeLdapMembers replaces the members managed by LDAP with the synced ones, keeping the members added in Casdoor
+func mergeLdapMembers(members []string, ldapMembers []string, isManagedByLdap func(string) bool) []string {
+	res := []string{}
+	for _, member := range members {
+		if !isManagedByLdap(member) {
+			res = append(res, member)
+		}
+	}
+
+	return getSortedMembers(append(res, ldapMembers...))
+}
+
+func getSortedMembers(members []string) []string {
+	res := util.UniqueStrings(members)
+	sort.Strings(res)
+	return res
+}
+
+// SyncLdapGroups syncs the LDAP groups to the roles of the same names in the LDAP's organization, the users imported
+// from LDAP and the roles of the groups are synced as members while other members are kept. It returns the numbers
+// of added and updated roles.
+func SyncLdapGroups(ldap *Ldap, groups []LdapGroup, users []LdapUser) (int, int) {
+	userIds := getLdapImportedUserIds(ldap.Owner)
+	ldapUserIds := map[string]bool{}
+	for _, userId := range userIds {
+		ldapUserIds[userId] = true
+	}
+
+	groupMembers := getLdapGroupMembers(ldap.Owner, groups, users, userIds)
+	isLdapUser := func(userId string) bool {
+		return ldapUserIds[userId]
+	}
+	isLdapRole := func(roleId string) bool {
+		_, ok := groupMembers[roleId]
+		return ok
+	}
+
+	added, updated := 0, 0
+	for _, group := range groups {
+		roleId := util.GetId(ldap.Owner, group.getRoleName())
+		members := groupMembers[roleId]
+
+		role := GetRole(roleId)
+		if role == nil {
+			role = &Role{
+				Owner:       ldap.Owner,
+				Name:        group.getRoleName(),
+				CreatedTime: util.GetCurrentTime(),
+				DisplayName: util.ReturnAnyNotEmpty(group.Description, group.Cn),
+				Users:       members.Users,
+				Roles:       members.Roles,
+				Domains:     []string{},
+				IsEnabled:   true,
+			}
+			if AddRole(role) {
+				added++
+			}
+			continue
+		}
+
+		users := mergeLdapMembers(role.Users, members.Users, isLdapUser)
+		roles := mergeLdapMembers(role.Roles, members.Roles, isLdapRole)
+		if reflect.DeepEqual(users, getSortedMembers(role.Users)) && reflect.DeepEqual(roles, getSortedMembers(role.Roles)) {
+			continue
+		}
+
+		role.Users, role.Roles = users, roles
+		if UpdateRole(roleId, role) {
+			updated++
+		}
+	}
+
+	return added, updated
+}
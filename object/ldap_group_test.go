@@ -0,0 +1,52 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetLdapGroupMembers(t *testing.T) {
+	users := []LdapUser{
+		{Uid: "alice", Uuid: "uuid-alice", Dn: "CN=Alice,OU=Users,DC=example,DC=com", MemberOf: []string{"CN=Devs,OU=Groups,DC=example,DC=com"}},
+		{Uid: "bob", Uuid: "uuid-bob", Dn: "cn=bob,ou=users,dc=example,dc=com"},
+		{Uid: "carol", Uuid: "uuid-carol", Dn: "cn=carol,ou=users,dc=example,dc=com"},
+		// not imported to Casdoor
+		{Uid: "dave", Uuid: "uuid-dave", Dn: "cn=dave,ou=users,dc=example,dc=com"},
+	}
+	userIds := map[string]string{"uuid-alice": "built-in/alice", "uuid-bob": "built-in/bob", "uuid-carol": "built-in/carol"}
+
+	groups := []LdapGroup{
+		// an AD group nested in "staff" by its memberOf
+		{Dn: "CN=Devs,OU=Groups,DC=example,DC=com", Cn: "Devs", MemberOf: []string{"cn=staff,ou=groups,dc=example,dc=com"}},
+		{Dn: "cn=staff,ou=groups,dc=example,dc=com", Cn: "staff", Members: []string{"cn=bob, ou=users, dc=example, dc=com", "cn=admins,ou=groups,dc=example,dc=com", "cn=dave,ou=users,dc=example,dc=com"}},
+		{Dn: "cn=admins,ou=groups,dc=example,dc=com", Cn: "admins", MemberUids: []string{"carol", "dave"}},
+	}
+
+	actual := getLdapGroupMembers("built-in", groups, users, userIds)
+	expected := map[string]*ldapGroupMembers{
+		"built-in/Devs":   {Users: []string{"built-in/alice"}, Roles: []string{}},
+		"built-in/staff":  {Users: []string{"built-in/bob"}, Roles: []string{"built-in/Devs", "built-in/admins"}},
+		"built-in/admins": {Users: []string{"built-in/carol"}, Roles: []string{}},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		for roleId, members := range actual {
+			t.Logf("%s: %v", roleId, *members)
+		}
+		t.Errorf("getLdapGroupMembers() returned unexpected members")
+	}
+}
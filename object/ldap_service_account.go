@@ -0,0 +1,200 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/cred"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// LdapServiceAccount is a read-only account binding to the LDAP server, such as the account of an
+// SSSD or a Gitea instance. It can read the users and roles in its scope without being an admin.
+type LdapServiceAccount struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	Password     string `xorm:"varchar(100)" json:"password"`
+	PasswordType string `xorm:"varchar(100)" json:"passwordType"`
+
+	// Organizations are the organizations the account can read, the owner's organization if empty.
+	// Only the accounts of the built-in organization can read other organizations, "*" for all of them.
+	Organizations []string `xorm:"mediumtext" json:"organizations"`
+	// Roles narrow the readable users down to the members of these roles, and the readable roles to these roles
+	Roles     []string `xorm:"mediumtext" json:"roles"`
+	IsEnabled bool     `json:"isEnabled"`
+}
+
+func GetLdapServiceAccountCount(owner, field, value string) int {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	count, err := session.Count(&LdapServiceAccount{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetLdapServiceAccounts(owner string) []*LdapServiceAccount {
+	accounts := []*LdapServiceAccount{}
+	err := adapter.Engine.Desc("created_time").Find(&accounts, &LdapServiceAccount{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return accounts
+}
+
+func GetPaginationLdapServiceAccounts(owner string, offset, limit int, field, value, sortField, sortOrder string) []*LdapServiceAccount {
+	accounts := []*LdapServiceAccount{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&accounts)
+	if err != nil {
+		panic(err)
+	}
+
+	return accounts
+}
+
+func getLdapServiceAccount(owner string, name string) *LdapServiceAccount {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	account := LdapServiceAccount{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&account)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &account
+	} else {
+		return nil
+	}
+}
+
+func GetLdapServiceAccount(id string) *LdapServiceAccount {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getLdapServiceAccount(owner, name)
+}
+
+func GetMaskedLdapServiceAccount(account *LdapServiceAccount) *LdapServiceAccount {
+	if account == nil {
+		return nil
+	}
+
+	if account.Password != "" {
+		account.Password = "***"
+	}
+	return account
+}
+
+func GetMaskedLdapServiceAccounts(accounts []*LdapServiceAccount) []*LdapServiceAccount {
+	for _, account := range accounts {
+		GetMaskedLdapServiceAccount(account)
+	}
+	return accounts
+}
+
+// hashPassword hashes the plain password of the account with the password type of its organization
+func (account *LdapServiceAccount) hashPassword() {
+	organization := getOrganization("admin", account.Owner)
+	if organization == nil {
+		return
+	}
+
+	credManager := cred.GetCredManager(organization.PasswordType)
+	if credManager != nil {
+		account.Password = credManager.GetHashedPassword(account.Password, "", organization.PasswordSalt)
+		account.PasswordType = organization.PasswordType
+	}
+}
+
+func UpdateLdapServiceAccount(id string, account *LdapServiceAccount) bool {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if getLdapServiceAccount(owner, name) == nil {
+		return false
+	}
+
+	session := adapter.Engine.ID(core.PK{owner, name}).AllCols()
+	if account.Password == "***" {
+		session.Omit("password", "password_type")
+	} else {
+		account.hashPassword()
+	}
+
+	affected, err := session.Update(account)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func AddLdapServiceAccount(account *LdapServiceAccount) bool {
+	if account.CreatedTime == "" {
+		account.CreatedTime = util.GetCurrentTime()
+	}
+	account.hashPassword()
+
+	affected, err := adapter.Engine.Insert(account)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func DeleteLdapServiceAccount(account *LdapServiceAccount) bool {
+	affected, err := adapter.Engine.ID(core.PK{account.Owner, account.Name}).Delete(&LdapServiceAccount{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func (account *LdapServiceAccount) GetId() string {
+	return fmt.Sprintf("%s/%s", account.Owner, account.Name)
+}
+
+// CheckLdapServiceAccountPassword returns the enabled service account with the given password
+func CheckLdapServiceAccountPassword(owner string, name string, password string, lang string) (*LdapServiceAccount, string) {
+	account := getLdapServiceAccount(owner, name)
+	if account == nil || !account.IsEnabled {
+		return nil, fmt.Sprintf(i18n.Translate(lang, "general:The user: %s doesn't exist"), util.GetId(owner, name))
+	}
+
+	organization := getOrganization("admin", owner)
+	if organization == nil {
+		return nil, i18n.Translate(lang, "check:Organization does not exist")
+	}
+
+	credManager := cred.GetCredManager(account.PasswordType)
+	if credManager == nil {
+		return nil, fmt.Sprintf(i18n.Translate(lang, "check:unsupported password type: %s"), account.PasswordType)
+	}
+	if !credManager.IsPasswordCorrect(password, account.Password, "", organization.PasswordSalt) {
+		return nil, i18n.Translate(lang, "check:password or code is incorrect")
+	}
+
+	return account, ""
+}
@@ -0,0 +1,350 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// the policies for the users who left the directory or are disabled in Active Directory, they are kept by default
+const (
+	LdapDepartedUserDisable    = "Disable"
+	LdapDepartedUserSoftDelete = "Soft delete"
+)
+
+// the property marking the users disabled or deleted by the sync, they are restored when back in the directory
+const ldapDepartedProperty = "ldapDeparted"
+
+type LdapSyncReport struct {
+	StartTime     string `json:"startTime"`
+	EndTime       string `json:"endTime"`
+	IsIncremental bool   `json:"isIncremental"`
+
+	AddedUsers    []string `json:"addedUsers"`
+	UpdatedUsers  []string `json:"updatedUsers"`
+	DepartedUsers []string `json:"departedUsers"`
+	RestoredUsers []string `json:"restoredUsers"`
+	FailedUsers   []string `json:"failedUsers"`
+	AddedRoles    int      `json:"addedRoles"`
+	UpdatedRoles  int      `json:"updatedRoles"`
+
+	Errors []string `json:"errors"`
+}
+
+func (report *LdapSyncReport) String() string {
+	return fmt.Sprintf("%d new users, %d updated users, %d departed users, %d restored users, %d failed users, %d new roles, %d updated roles, %d errors",
+		len(report.AddedUsers), len(report.UpdatedUsers), len(report.DepartedUsers), len(report.RestoredUsers), len(report.FailedUsers),
+		report.AddedRoles, report.UpdatedRoles, len(report.Errors))
+}
+
+func (ldapUser *LdapUser) getAttribute(name string) string {
+	for attribute, value := range ldapUser.Attributes {
+		if strings.EqualFold(attribute, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// isDisabled returns whether the ACCOUNTDISABLE flag of the Active Directory userAccountControl is set
+func (ldapUser *LdapUser) isDisabled() bool {
+	userAccountControl, err := strconv.Atoi(ldapUser.getAttribute("userAccountControl"))
+	return err == nil && userAccountControl&2 != 0
+}
+
+// getMappedUserField returns the user field and its column for the mapped field name, nil for a user property
+func getMappedUserField(user *User, field string) (*string, string) {
+	switch field {
+	case "DisplayName":
+		return &user.DisplayName, "display_name"
+	case "FirstName":
+		return &user.FirstName, "first_name"
+	case "LastName":
+		return &user.LastName, "last_name"
+	case "Email":
+		return &user.Email, "email"
+	case "Phone":
+		return &user.Phone, "phone"
+	case "CountryCode":
+		return &user.CountryCode, "country_code"
+	case "Region":
+		return &user.Region, "region"
+	case "Location":
+		return &user.Location, "location"
+	case "Affiliation":
+		return &user.Affiliation, "affiliation"
+	case "Title":
+		return &user.Title, "title"
+	case "IdCard":
+		return &user.IdCard, "id_card"
+	case "Homepage":
+		return &user.Homepage, "homepage"
+	case "Bio":
+		return &user.Bio, "bio"
+	case "Tag":
+		return &user.Tag, "tag"
+	case "Language":
+		return &user.Language, "language"
+	case "Gender":
+		return &user.Gender, "gender"
+	case "Birthday":
+		return &user.Birthday, "birthday"
+	case "Education":
+		return &user.Education, "education"
+	default:
+		return nil, "properties"
+	}
+}
+
+// setMappedUserFields sets the user fields from the mapped LDAP attributes and returns the changed columns,
+// attributes missing in LDAP don't clear the fields
+func (ldap *Ldap) setMappedUserFields(user *User, ldapUser LdapUser) []string {
+	columns := []string{}
+	for attribute, field := range ldap.AttributeMapping {
+		value := ldapUser.getAttribute(attribute)
+		if value == "" {
+			continue
+		}
+
+		p, column := getMappedUserField(user, field)
+		if p == nil {
+			if user.Properties[field] == value {
+				continue
+			}
+			setUserProperty(user, field, value)
+		} else {
+			if *p == value {
+				continue
+			}
+			*p = value
+		}
+		columns = append(columns, column)
+	}
+	return util.UniqueStrings(columns)
+}
+
+func (ldap *Ldap) updateUser(user *User, ldapUser LdapUser) []string {
+	columns := []string{}
+	if displayName := ldapUser.buildLdapDisplayName(); displayName != "" && displayName != user.DisplayName {
+		user.DisplayName = displayName
+		columns = append(columns, "display_name")
+	}
+	if ldapUser.Email != "" && ldapUser.Email != user.Email {
+		user.Email = ldapUser.Email
+		columns = append(columns, "email")
+	}
+	return util.UniqueStrings(append(columns, ldap.setMappedUserFields(user, ldapUser)...))
+}
+
+// compareSyncCursors compares the uSNChanged numbers of Active Directory, or the modifyTimestamp generalized times
+func compareSyncCursors(a string, b string) int {
+	intA, errA := strconv.ParseInt(a, 10, 64)
+	intB, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case intA < intB:
+			return -1
+		case intA > intB:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func getLdapImportedUsers(owner string) map[string]*User {
+	users := []*User{}
+	err := adapter.Engine.Where("owner = ? and ldap != ?", owner, "").Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	res := map[string]*User{}
+	for _, user := range users {
+		res[user.Ldap] = user
+	}
+	return res
+}
+
+// SyncLdap syncs the users of the LDAP server to its organization. The users changed since the last sync are added
+// or updated, the users who left the directory or are disabled in Active Directory are handled with the departed user
+// policy, and the groups are synced to roles if enabled. The report is saved as the last sync report of the LDAP.
+func SyncLdap(ldap *Ldap) *LdapSyncReport {
+	report := &LdapSyncReport{
+		StartTime:     util.GetCurrentTime(),
+		IsIncremental: ldap.SyncCursor != "",
+		AddedUsers:    []string{},
+		UpdatedUsers:  []string{},
+		DepartedUsers: []string{},
+		RestoredUsers: []string{},
+		FailedUsers:   []string{},
+		Errors:        []string{},
+	}
+
+	cursor, err := ldap.syncUsers(report)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		cursor = ldap.SyncCursor
+	}
+	report.EndTime = util.GetCurrentTime()
+
+	ldap.LastSync = report.StartTime
+	ldap.SyncCursor = cursor
+	ldap.LastSyncReport = report
+	_, err = adapter.Engine.ID(ldap.Id).Cols("last_sync", "sync_cursor", "last_sync_report").Update(ldap)
+	if err != nil {
+		panic(err)
+	}
+
+	return report
+}
+
+// syncUsers runs the sync and returns the next sync cursor
+func (ldap *Ldap) syncUsers(report *LdapSyncReport) (string, error) {
+	conn, err := ldap.GetLdapConn()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Conn.Close()
+
+	cursorAttribute := conn.getSyncCursorAttribute()
+	filter := ldap.Filter
+	if ldap.SyncCursor != "" {
+		filter = fmt.Sprintf("(&%s(%s>=%s))", ldap.Filter, cursorAttribute, ldap.SyncCursor)
+	}
+
+	changedUsers, err := conn.searchLdapUsers(ldap, filter, conn.getUserAttributes(ldap))
+	if err != nil {
+		return "", err
+	}
+
+	// all the users are listed with few attributes to find the departed users and the group members
+	allUsers, err := conn.searchLdapUsers(ldap, ldap.Filter, conn.getUserIdentityAttributes())
+	if err != nil {
+		return "", err
+	}
+
+	organization := getOrganization("admin", ldap.Owner)
+	if organization == nil {
+		return "", fmt.Errorf("the organization: %s doesn't exist", ldap.Owner)
+	}
+
+	importedUsers := getLdapImportedUsers(ldap.Owner)
+	cursor := ldap.SyncCursor
+	for _, ldapUser := range AutoAdjustLdapUser(changedUsers) {
+		if value := ldapUser.getAttribute(cursorAttribute); compareSyncCursors(value, cursor) > 0 {
+			cursor = value
+		}
+
+		user, ok := importedUsers[ldapUser.Uuid]
+		if !ok {
+			if ldapUser.isDisabled() {
+				continue
+			}
+
+			user = ldap.newUser(organization, ldapUser)
+			if AddUser(user) {
+				report.AddedUsers = append(report.AddedUsers, user.Name)
+				importedUsers[ldapUser.Uuid] = user
+			} else {
+				report.FailedUsers = append(report.FailedUsers, ldapUser.buildLdapUserName())
+			}
+			continue
+		}
+
+		columns := ldap.updateUser(user, ldapUser)
+		if len(columns) > 0 {
+			if UpdateUser(user.GetId(), user, columns, true) {
+				report.UpdatedUsers = append(report.UpdatedUsers, user.Name)
+			} else {
+				report.FailedUsers = append(report.FailedUsers, user.Name)
+			}
+		}
+	}
+
+	ldap.syncDepartedUsers(report, allUsers, importedUsers)
+
+	if ldap.EnableGroupSync {
+		groups, err := conn.GetLdapGroups(ldap)
+		if err != nil {
+			return "", err
+		}
+		report.AddedRoles, report.UpdatedRoles = SyncLdapGroups(ldap, groups, allUsers)
+	}
+
+	return cursor, nil
+}
+
+func (ldap *Ldap) syncDepartedUsers(report *LdapSyncReport, allUsers []LdapUser, importedUsers map[string]*User) {
+	if ldap.DepartedUserPolicy != LdapDepartedUserDisable && ldap.DepartedUserPolicy != LdapDepartedUserSoftDelete {
+		return
+	}
+
+	// the imported users don't record their LDAP server, the users of another one would be taken as departed
+	if len(GetLdaps(ldap.Owner)) > 1 {
+		report.Errors = append(report.Errors, "departed users are not handled for organizations with multiple LDAP servers")
+		return
+	}
+	// an empty directory is more likely a wrong filter than everyone having left
+	if len(allUsers) == 0 {
+		report.Errors = append(report.Errors, "departed users are not handled as no user is found in the directory")
+		return
+	}
+
+	activeUuids := map[string]bool{}
+	for _, ldapUser := range allUsers {
+		if !ldapUser.isDisabled() {
+			activeUuids[ldapUser.GetLdapUuid()] = true
+		}
+	}
+
+	for uuid, user := range importedUsers {
+		isDeparted := user.Properties[ldapDepartedProperty] != ""
+		if activeUuids[uuid] == !isDeparted {
+			continue
+		}
+
+		if activeUuids[uuid] {
+			// the user is back, e.g. re-enabled in Active Directory
+			user.IsForbidden, user.IsDeleted = false, false
+			setUserProperty(user, ldapDepartedProperty, "")
+		} else if ldap.DepartedUserPolicy == LdapDepartedUserDisable {
+			if user.IsForbidden {
+				continue
+			}
+			user.IsForbidden = true
+			setUserProperty(user, ldapDepartedProperty, report.StartTime)
+		} else {
+			if user.IsDeleted {
+				continue
+			}
+			user.IsDeleted = true
+			setUserProperty(user, ldapDepartedProperty, report.StartTime)
+		}
+
+		if !UpdateUser(user.GetId(), user, []string{"is_forbidden", "is_deleted", "properties"}, true) {
+			report.FailedUsers = append(report.FailedUsers, user.Name)
+		} else if activeUuids[uuid] {
+			report.RestoredUsers = append(report.RestoredUsers, user.Name)
+		} else {
+			report.DepartedUsers = append(report.DepartedUsers, user.Name)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLdapSyncUserMapping(t *testing.T) {
+	ldap := &Ldap{AttributeMapping: map[string]string{"title": "Title", "department": "Affiliation", "employeeNumber": "employeeNumber", "manager": "manager"}}
+	ldapUser := LdapUser{
+		DisplayName: "Alice",
+		Email:       "alice@example.com",
+		Attributes:  map[string]string{"Title": "Engineer", "department": "R&D", "employeeNumber": "1001", "userAccountControl": "514"},
+	}
+	user := &User{DisplayName: "Alice", Email: "old@example.com", Title: "Engineer"}
+
+	columns := ldap.updateUser(user, ldapUser)
+	sort.Strings(columns)
+	if !reflect.DeepEqual(columns, []string{"affiliation", "email", "properties"}) {
+		t.Errorf("unexpected changed columns: %v", columns)
+	}
+	if user.Email != "alice@example.com" || user.Affiliation != "R&D" || user.Properties["employeeNumber"] != "1001" {
+		t.Errorf("unexpected user fields: %s, %s, %v", user.Email, user.Affiliation, user.Properties)
+	}
+	if len(ldap.updateUser(user, ldapUser)) != 0 {
+		t.Errorf("an unchanged user is updated")
+	}
+
+	if !ldapUser.isDisabled() {
+		t.Errorf("a disabled AD user isn't detected")
+	}
+
+	if compareSyncCursors("9", "10") >= 0 || compareSyncCursors("20230102000000Z", "20230101000000Z") <= 0 || compareSyncCursors("5", "") <= 0 {
+		t.Errorf("unexpected sync cursor order")
+	}
+}
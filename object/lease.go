@@ -0,0 +1,133 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+)
+
+// a lease expires unless renewed by its holder, so a crashed instance doesn't keep it
+const (
+	leaseDuration      = 60 * time.Second
+	leaseRenewInterval = 20 * time.Second
+)
+
+// Lease is a database-backed lock held by one Casdoor instance at a time, such as for running a syncer
+type Lease struct {
+	Name         string `xorm:"varchar(200) notnull pk" json:"name"`
+	Holder       string `xorm:"varchar(200)" json:"holder"`
+	AcquiredTime string `xorm:"varchar(100)" json:"acquiredTime"`
+	ExpireTime   int64  `json:"expireTime"`
+}
+
+var leaseHolder string
+
+func init() {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	leaseHolder = fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), util.GenerateId()[:8])
+}
+
+// GetLeaseHolder returns the instance holding the lease, empty if it is not held
+func GetLeaseHolder(name string) string {
+	lease := Lease{Name: name}
+	existed, err := adapter.Engine.Get(&lease)
+	if err != nil {
+		panic(err)
+	}
+
+	if !existed || lease.ExpireTime < time.Now().Unix() {
+		return ""
+	}
+	return lease.Holder
+}
+
+// acquireLease acquires the lease for this instance, it returns false if the lease is held, even by this instance
+func acquireLease(name string) bool {
+	now := time.Now()
+	lease := &Lease{
+		Name:         name,
+		Holder:       leaseHolder,
+		AcquiredTime: util.GetCurrentTime(),
+		ExpireTime:   now.Add(leaseDuration).Unix(),
+	}
+
+	affected, err := adapter.Engine.Where("name = ? and expire_time < ?", name, now.Unix()).
+		Cols("holder", "acquired_time", "expire_time").Update(lease)
+	if err != nil {
+		panic(err)
+	}
+	if affected != 0 {
+		return true
+	}
+
+	// the insert fails on the primary key if the lease exists, or another instance inserts it at the same time
+	affected, err = adapter.Engine.Insert(lease)
+	return err == nil && affected != 0
+}
+
+func renewLease(name string) bool {
+	affected, err := adapter.Engine.Where("name = ? and holder = ?", name, leaseHolder).
+		Cols("expire_time").Update(&Lease{ExpireTime: time.Now().Add(leaseDuration).Unix()})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func releaseLease(name string) {
+	_, err := adapter.Engine.Where("name = ? and holder = ?", name, leaseHolder).Cols("expire_time").Update(&Lease{ExpireTime: 0})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// runWithLease runs the function while holding the lease, renewing it until the function returns. It returns
+// false without running the function if another instance holds the lease.
+func runWithLease(name string, f func()) bool {
+	if !acquireLease(name) {
+		return false
+	}
+	defer releaseLease(name)
+
+	done := make(chan struct{})
+	defer close(done)
+	util.SafeGoroutine(func() {
+		ticker := time.NewTicker(leaseRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if !renewLease(name) {
+					logs.Warning(fmt.Sprintf("the lease: %s is lost by %s", name, leaseHolder))
+				}
+			}
+		}
+	})
+
+	f()
+	return true
+}
@@ -0,0 +1,333 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// LockoutPolicy is the account lockout policy of an organization, which an application can override
+type LockoutPolicy struct {
+	// Threshold is the number of failed sign-ins that locks the account
+	Threshold int `json:"threshold"`
+	// WindowMinutes is the time the failed sign-ins are counted within, 0 to count them until the next success
+	WindowMinutes int `json:"windowMinutes"`
+	// LockoutMinutes is the duration of the first lockout, the successive lockouts are multiplied by the
+	// BackoffFactor until MaxLockoutMinutes, and the backoff starts over after a successful sign-in
+	LockoutMinutes    int `json:"lockoutMinutes"`
+	BackoffFactor     int `json:"backoffFactor"`
+	MaxLockoutMinutes int `json:"maxLockoutMinutes"`
+	// CountPerIp counts the failed sign-ins per user and client IP, so that others can't lock the account
+	CountPerIp bool `json:"countPerIp"`
+	// CountVerificationCodes counts the wrong verification codes as failed sign-ins
+	CountVerificationCodes bool `json:"countVerificationCodes"`
+}
+
+// SigninAttempt is where a sign-in comes from, the application is nil for the sign-ins without an application
+type SigninAttempt struct {
+	Application *Application
+	ClientIp    string
+}
+
+// SigninLockout counts the failed sign-ins of a user, or of a user from a client IP if the policy counts per IP
+type SigninLockout struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	ClientIp    string `xorm:"varchar(100) notnull pk" json:"clientIp"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	FailedCount     int    `json:"failedCount"`
+	FirstFailedTime string `xorm:"varchar(100)" json:"firstFailedTime"`
+	LastFailedTime  string `xorm:"varchar(100)" json:"lastFailedTime"`
+	LockoutCount    int    `json:"lockoutCount"`
+	LockedUntil     string `xorm:"varchar(100)" json:"lockedUntil"`
+
+	// Version makes the concurrent failed sign-ins update the counter one after another
+	Version int `xorm:"version" json:"-"`
+}
+
+// getDefaultLockoutPolicy returns the policy used when neither the organization nor the application has one
+func getDefaultLockoutPolicy() *LockoutPolicy {
+	return &LockoutPolicy{
+		Threshold:              SigninWrongTimesLimit,
+		LockoutMinutes:         int(LastSignWrongTimeDuration.Minutes()),
+		CountVerificationCodes: true,
+	}
+}
+
+func getLockoutPolicy(organization *Organization, application *Application) *LockoutPolicy {
+	var policy LockoutPolicy
+	if application != nil && application.LockoutPolicy != nil {
+		policy = *application.LockoutPolicy
+	} else if organization != nil && organization.LockoutPolicy != nil {
+		policy = *organization.LockoutPolicy
+	} else {
+		return getDefaultLockoutPolicy()
+	}
+
+	if policy.Threshold <= 0 {
+		policy.Threshold = SigninWrongTimesLimit
+	}
+	if policy.LockoutMinutes <= 0 {
+		policy.LockoutMinutes = int(LastSignWrongTimeDuration.Minutes())
+	}
+	return &policy
+}
+
+func getUserLockoutPolicy(user *User, attempt *SigninAttempt) *LockoutPolicy {
+	var application *Application
+	if attempt != nil {
+		application = attempt.Application
+	}
+	return getLockoutPolicy(GetOrganizationByUser(user), application)
+}
+
+// getLockoutDuration returns the duration of the nth successive lockout
+func (policy *LockoutPolicy) getLockoutDuration(lockoutCount int) time.Duration {
+	minutes := float64(policy.LockoutMinutes)
+	if policy.BackoffFactor > 1 && lockoutCount > 1 {
+		minutes *= math.Pow(float64(policy.BackoffFactor), float64(lockoutCount-1))
+	}
+	if policy.MaxLockoutMinutes > 0 && minutes > float64(policy.MaxLockoutMinutes) {
+		minutes = float64(policy.MaxLockoutMinutes)
+	}
+	return time.Duration(minutes * float64(time.Minute))
+}
+
+func (policy *LockoutPolicy) getClientIp(attempt *SigninAttempt) string {
+	if !policy.CountPerIp || attempt == nil {
+		return ""
+	}
+	return attempt.ClientIp
+}
+
+func getSigninLockout(owner string, name string, clientIp string) *SigninLockout {
+	lockout := SigninLockout{Owner: owner, Name: name, ClientIp: clientIp}
+	existed, err := adapter.Engine.ID(core.PK{owner, name, clientIp}).Get(&lockout)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &lockout
+	} else {
+		return nil
+	}
+}
+
+// GetSigninLockouts returns the failed sign-in counters of the user, one per client IP if the policy counts per IP
+func GetSigninLockouts(userId string) []*SigninLockout {
+	owner, name := util.GetOwnerAndNameFromId(userId)
+	lockouts := []*SigninLockout{}
+	err := adapter.Engine.Where("owner = ? and name = ?", owner, name).Find(&lockouts)
+	if err != nil {
+		panic(err)
+	}
+
+	return lockouts
+}
+
+// saveSigninLockout returns false if the counter has been saved by a concurrent sign-in since it was read
+func saveSigninLockout(lockout *SigninLockout, isNew bool) bool {
+	if isNew {
+		_, err := adapter.Engine.Insert(lockout)
+		if err != nil {
+			if getSigninLockout(lockout.Owner, lockout.Name, lockout.ClientIp) != nil {
+				return false
+			}
+			panic(err)
+		}
+		return true
+	}
+
+	affected, err := adapter.Engine.ID(core.PK{lockout.Owner, lockout.Name, lockout.ClientIp}).AllCols().Update(lockout)
+	if err != nil {
+		panic(err)
+	}
+	return affected != 0
+}
+
+// syncUserSigninWrongTimes keeps the failed sign-in fields of the user up to date with the counter
+func syncUserSigninWrongTimes(user *User, lockout *SigninLockout) {
+	user.SigninWrongTimes = 0
+	user.LastSigninWrongTime = ""
+	if lockout != nil {
+		user.SigninWrongTimes = lockout.FailedCount
+		user.LastSigninWrongTime = lockout.LastFailedTime
+	}
+
+	_, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("signin_wrong_times", "last_signin_wrong_time").Update(user)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (lockout *SigninLockout) getRemainingTime(now time.Time) time.Duration {
+	if lockout.LockedUntil == "" {
+		return 0
+	}
+
+	lockedUntil, err := time.Parse(time.RFC3339, lockout.LockedUntil)
+	if err != nil {
+		return 0
+	}
+	return lockedUntil.Sub(now)
+}
+
+// recordFailure counts the failed sign-in, and locks the account if the threshold is reached. With the captcha
+// the account is never locked, as the captcha is required instead once the threshold is reached.
+func (lockout *SigninLockout) recordFailure(policy *LockoutPolicy, now time.Time, enableCaptcha bool) bool {
+	if policy.WindowMinutes > 0 && lockout.FailedCount > 0 {
+		firstFailedTime, err := time.Parse(time.RFC3339, lockout.FirstFailedTime)
+		if err != nil || now.Sub(firstFailedTime) > time.Duration(policy.WindowMinutes)*time.Minute {
+			lockout.FailedCount = 0
+		}
+	}
+
+	if lockout.FailedCount == 0 {
+		lockout.FirstFailedTime = now.Format(time.RFC3339)
+	}
+	lockout.LastFailedTime = now.Format(time.RFC3339)
+	if lockout.FailedCount < policy.Threshold {
+		lockout.FailedCount++
+	}
+
+	if lockout.FailedCount < policy.Threshold || enableCaptcha {
+		return false
+	}
+
+	lockout.LockoutCount++
+	lockout.LockedUntil = now.Add(policy.getLockoutDuration(lockout.LockoutCount)).Format(time.RFC3339)
+	return true
+}
+
+func getLockoutMessage(remainingTime time.Duration, lang string) string {
+	minutes := int(math.Ceil(remainingTime.Minutes()))
+	return fmt.Sprintf(i18n.Translate(lang, "check:You have entered the wrong password or code too many times, please wait for %d minutes and try again"), minutes)
+}
+
+func addLockoutRecord(user *User, clientIp string, action string) {
+	record := &Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: user.Owner,
+		ClientIp:     clientIp,
+		User:         user.Name,
+		Method:       "POST",
+		Action:       action,
+	}
+	util.SafeGoroutine(func() { AddRecord(record) })
+}
+
+// checkSigninLockout returns the error message if the account is locked for the sign-in
+func checkSigninLockout(user *User, attempt *SigninAttempt, lang string) string {
+	policy := getUserLockoutPolicy(user, attempt)
+	lockout := getSigninLockout(user.Owner, user.Name, policy.getClientIp(attempt))
+	if lockout == nil || lockout.LockedUntil == "" {
+		return ""
+	}
+
+	now := time.Now()
+	if remainingTime := lockout.getRemainingTime(now); remainingTime > 0 {
+		return getLockoutMessage(remainingTime, lang)
+	}
+
+	// the lockout has expired, the failures start over while the lockout count is kept for the backoff,
+	// a concurrent sign-in that has already saved the counter has started them over too
+	lockout.FailedCount = 0
+	lockout.LockedUntil = ""
+	saveSigninLockout(lockout, false)
+	return ""
+}
+
+// recordSigninFailure counts the failed sign-in and returns the error message with the remaining chances
+func recordSigninFailure(user *User, attempt *SigninAttempt, lang string, enableCaptcha bool) string {
+	policy := getUserLockoutPolicy(user, attempt)
+	clientIp := policy.getClientIp(attempt)
+
+	// the counter is read and saved again until no concurrent sign-in has saved it in between
+	var lockout *SigninLockout
+	var now time.Time
+	isLocked := false
+	for {
+		lockout = getSigninLockout(user.Owner, user.Name, clientIp)
+		isNew := lockout == nil
+		if isNew {
+			lockout = &SigninLockout{Owner: user.Owner, Name: user.Name, ClientIp: clientIp, CreatedTime: util.GetCurrentTime()}
+		}
+
+		now = time.Now()
+		isLocked = lockout.recordFailure(policy, now, enableCaptcha)
+		if saveSigninLockout(lockout, isNew) {
+			break
+		}
+	}
+	syncUserSigninWrongTimes(user, lockout)
+
+	if isLocked {
+		if attempt != nil {
+			clientIp = attempt.ClientIp
+		}
+		addLockoutRecord(user, clientIp, "lock-user")
+		return getLockoutMessage(lockout.getRemainingTime(now), lang)
+	}
+
+	leftChances := policy.Threshold - lockout.FailedCount
+	if leftChances == 0 && enableCaptcha {
+		return i18n.Translate(lang, "check:password or code is incorrect")
+	}
+	return fmt.Sprintf(i18n.Translate(lang, "check:password or code is incorrect, you have %d remaining chances"), leftChances)
+}
+
+// resetSigninFailures forgets the failed sign-ins and the lockouts after a successful sign-in
+func resetSigninFailures(user *User, attempt *SigninAttempt) {
+	policy := getUserLockoutPolicy(user, attempt)
+	_, err := adapter.Engine.ID(core.PK{user.Owner, user.Name, policy.getClientIp(attempt)}).Delete(&SigninLockout{})
+	if err != nil {
+		panic(err)
+	}
+
+	if user.SigninWrongTimes != 0 || user.LastSigninWrongTime != "" {
+		syncUserSigninWrongTimes(user, nil)
+	}
+}
+
+// IsSigninCaptchaNeeded returns whether the failed sign-ins of the user have reached the threshold,
+// for the applications requiring the captcha dynamically
+func IsSigninCaptchaNeeded(user *User, attempt *SigninAttempt) bool {
+	policy := getUserLockoutPolicy(user, attempt)
+	lockout := getSigninLockout(user.Owner, user.Name, policy.getClientIp(attempt))
+	return lockout != nil && lockout.FailedCount >= policy.Threshold
+}
+
+// UnlockUser forgets the failed sign-ins and the lockouts of the user from all the client IPs
+func UnlockUser(user *User, clientIp string) bool {
+	affected, err := adapter.Engine.Where("owner = ? and name = ?", user.Owner, user.Name).Delete(&SigninLockout{})
+	if err != nil {
+		panic(err)
+	}
+
+	if affected != 0 {
+		syncUserSigninWrongTimes(user, nil)
+		addLockoutRecord(user, clientIp, "unlock-user")
+	}
+	return affected != 0
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetLockoutDuration(t *testing.T) {
+	policy := &LockoutPolicy{Threshold: 3, LockoutMinutes: 5, BackoffFactor: 2, MaxLockoutMinutes: 30}
+
+	cases := []struct {
+		lockoutCount int
+		expected     time.Duration
+	}{
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{3, 20 * time.Minute},
+		{4, 30 * time.Minute},
+		{10, 30 * time.Minute},
+	}
+
+	for _, c := range cases {
+		if duration := policy.getLockoutDuration(c.lockoutCount); duration != c.expected {
+			t.Errorf("lockout %d: got %s, expected %s", c.lockoutCount, duration, c.expected)
+		}
+	}
+
+	policy = getDefaultLockoutPolicy()
+	if duration := policy.getLockoutDuration(3); duration != LastSignWrongTimeDuration {
+		t.Errorf("default policy: got %s, expected %s", duration, LastSignWrongTimeDuration)
+	}
+}
+
+func TestRecordSigninFailure(t *testing.T) {
+	now := time.Date(2023, 6, 30, 12, 0, 0, 0, time.UTC)
+	policy := &LockoutPolicy{Threshold: 3, WindowMinutes: 10, LockoutMinutes: 5, BackoffFactor: 2}
+
+	lockout := &SigninLockout{}
+	for i := 1; i < policy.Threshold; i++ {
+		if lockout.recordFailure(policy, now.Add(time.Duration(i)*time.Minute), false) {
+			t.Fatalf("failure %d: locked before the threshold", i)
+		}
+	}
+
+	// the failures out of the window start over
+	if lockout.recordFailure(policy, now.Add(20*time.Minute), false) {
+		t.Fatalf("locked by the failures out of the window")
+	}
+	if lockout.FailedCount != 1 {
+		t.Fatalf("got %d failures, expected 1", lockout.FailedCount)
+	}
+
+	lockout.recordFailure(policy, now.Add(21*time.Minute), false)
+	if !lockout.recordFailure(policy, now.Add(22*time.Minute), false) {
+		t.Fatalf("not locked at the threshold")
+	}
+	if remainingTime := lockout.getRemainingTime(now.Add(22 * time.Minute)); remainingTime != 5*time.Minute {
+		t.Errorf("got %s remaining, expected 5m", remainingTime)
+	}
+
+	// the second lockout is doubled
+	lockout.FailedCount, lockout.LockedUntil = 0, ""
+	for i := 0; i < policy.Threshold; i++ {
+		lockout.recordFailure(policy, now.Add(30*time.Minute), false)
+	}
+	if remainingTime := lockout.getRemainingTime(now.Add(30 * time.Minute)); remainingTime != 10*time.Minute {
+		t.Errorf("got %s remaining, expected 10m", remainingTime)
+	}
+
+	// the captcha is required instead of locking the account
+	lockout = &SigninLockout{}
+	for i := 0; i < policy.Threshold+2; i++ {
+		if lockout.recordFailure(policy, now, true) {
+			t.Fatalf("locked with the captcha")
+		}
+	}
+	if lockout.FailedCount != policy.Threshold {
+		t.Errorf("got %d failures, expected %d", lockout.FailedCount, policy.Threshold)
+	}
+}
@@ -66,8 +66,23 @@ type Organization struct {
 	EnableSoftDeletion bool       `json:"enableSoftDeletion"`
 	IsProfilePublic    bool       `json:"isProfilePublic"`
 
+	// SoftDeletionRetentionDays is the number of days after which the soft-deleted users are purged, 0 to keep them,
+	// and ReleaseIdentifiersOnDeletion frees the username, email and phone of the soft-deleted users for new users
+	SoftDeletionRetentionDays    int  `json:"softDeletionRetentionDays"`
+	ReleaseIdentifiersOnDeletion bool `json:"releaseIdentifiersOnDeletion"`
+
+	LockoutPolicy  *LockoutPolicy  `xorm:"json" json:"lockoutPolicy"`
+	PasswordPolicy *PasswordPolicy `xorm:"json" json:"passwordPolicy"`
+	// PasswordOptions are the work factors of the password type, the passwords are rehashed at the next sign-in
+	// when the password type or the work factors change
+	PasswordOptions *cred.CredOptions `xorm:"json" json:"passwordOptions"`
+
+	ImpersonationPolicy *ImpersonationPolicy `xorm:"json" json:"impersonationPolicy"`
+
 	MfaItems     []*MfaItem     `xorm:"varchar(300)" json:"mfaItems"`
 	AccountItems []*AccountItem `xorm:"varchar(3000)" json:"accountItems"`
+
+	UserAttributes []*UserAttribute `xorm:"mediumtext" json:"userAttributes"`
 }
 
 func GetOrganizationCount(owner, field, value string) int {
@@ -177,6 +192,8 @@ func UpdateOrganization(id string, organization *Organization) bool {
 		}
 	}
 
+	syncUserAttributeAccountItems(organization)
+
 	session := adapter.Engine.ID(core.PK{owner, name}).AllCols()
 	if organization.MasterPassword == "***" {
 		session.Omit("master_password")
@@ -190,6 +207,8 @@ func UpdateOrganization(id string, organization *Organization) bool {
 }
 
 func AddOrganization(organization *Organization) bool {
+	syncUserAttributeAccountItems(organization)
+
 	affected, err := adapter.Engine.Insert(organization)
 	if err != nil {
 		panic(err)
@@ -374,6 +393,76 @@ func organizationChangeTrigger(oldName string, newName string) error {
 		return err
 	}
 
+	ldapServiceAccount := new(LdapServiceAccount)
+	ldapServiceAccount.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(ldapServiceAccount)
+	if err != nil {
+		return err
+	}
+
+	scimToken := new(ScimToken)
+	scimToken.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(scimToken)
+	if err != nil {
+		return err
+	}
+
+	scimProvisioning := new(ScimProvisioning)
+	scimProvisioning.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(scimProvisioning)
+	if err != nil {
+		return err
+	}
+
+	group := new(Group)
+	group.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(group)
+	if err != nil {
+		return err
+	}
+
+	invitation := new(Invitation)
+	invitation.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(invitation)
+	if err != nil {
+		return err
+	}
+
+	dataRequest := new(DataRequest)
+	dataRequest.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(dataRequest)
+	if err != nil {
+		return err
+	}
+
+	signinLockout := new(SigninLockout)
+	signinLockout.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(signinLockout)
+	if err != nil {
+		return err
+	}
+
+	impersonation := new(Impersonation)
+	impersonation.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(impersonation)
+	if err != nil {
+		return err
+	}
+
+	userMerge := new(UserMerge)
+	userMerge.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(userMerge)
+	if err != nil {
+		return err
+	}
+
+	apiKey := new(ApiKey)
+	apiKey.Owner = newName
+	_, err = session.Where("owner=?", oldName).Update(apiKey)
+	if err != nil {
+		return err
+	}
+
 	model := new(Model)
 	model.Owner = newName
 	_, err = session.Where("owner=?", oldName).Update(model)
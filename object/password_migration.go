@@ -0,0 +1,106 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"github.com/casdoor/casdoor/cred"
+	"github.com/xorm-io/core"
+)
+
+// PasswordMigration is the progress of rehashing the passwords of an organization with its current password type
+type PasswordMigration struct {
+	Organization  string         `json:"organization"`
+	PasswordType  string         `json:"passwordType"`
+	UserCount     int            `json:"userCount"`
+	MigratedCount int            `json:"migratedCount"`
+	PendingCount  int            `json:"pendingCount"`
+	PasswordTypes map[string]int `json:"passwordTypes"`
+}
+
+func (organization *Organization) getCredManager() cred.CredManager {
+	return cred.GetCredManagerWithOptions(organization.PasswordType, organization.PasswordOptions)
+}
+
+// needsPasswordRehash returns whether the password of the user has been hashed with another password type
+// or other work factors than the current ones of the organization
+func needsPasswordRehash(user *User, organization *Organization, credManager cred.CredManager) bool {
+	if user.Password == "" || user.Ldap != "" {
+		return false
+	}
+
+	if user.PasswordType != organization.PasswordType {
+		return true
+	}
+
+	if rehashChecker, ok := credManager.(cred.RehashChecker); ok {
+		return rehashChecker.NeedsRehash(user.Password)
+	}
+	return false
+}
+
+// rehashUserPassword rehashes the password of the user, which has just been verified, with the current
+// password type and work factors of the organization. The password history and age are left untouched.
+func rehashUserPassword(user *User, organization *Organization, password string) {
+	credManager := organization.getCredManager()
+	if credManager == nil || !needsPasswordRehash(user, organization, credManager) {
+		return
+	}
+
+	hashedPassword := credManager.GetHashedPassword(password, user.PasswordSalt, organization.PasswordSalt)
+	if hashedPassword == "" {
+		return
+	}
+
+	user.Password = hashedPassword
+	user.PasswordType = organization.PasswordType
+	user.UpdateUserHash()
+	_, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols("password", "password_type", "hash").Update(user)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetPasswordMigration counts the users of the organization whose passwords are still to be rehashed,
+// by the password types they have been hashed with
+func GetPasswordMigration(organization *Organization) *PasswordMigration {
+	users := []*User{}
+	err := adapter.Engine.Cols("password", "password_type", "ldap").Where("owner = ? and password <> ?", organization.Name, "").Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	migration := &PasswordMigration{
+		Organization:  organization.Name,
+		PasswordType:  organization.PasswordType,
+		PasswordTypes: map[string]int{},
+	}
+
+	credManager := organization.getCredManager()
+	for _, user := range users {
+		if user.Ldap != "" {
+			continue
+		}
+
+		migration.UserCount += 1
+		migration.PasswordTypes[user.PasswordType] += 1
+		if credManager != nil && !needsPasswordRehash(user, organization, credManager) {
+			migration.MigratedCount += 1
+		} else {
+			migration.PendingCount += 1
+		}
+	}
+
+	return migration
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/cred"
+)
+
+func TestNeedsPasswordRehash(t *testing.T) {
+	organization := &Organization{PasswordType: "bcrypt", PasswordOptions: &cred.CredOptions{BcryptCost: 5}}
+	credManager := organization.getCredManager()
+	hash := credManager.GetHashedPassword("123456", "", "")
+	weakHash := cred.GetCredManagerWithOptions("bcrypt", &cred.CredOptions{BcryptCost: 4}).GetHashedPassword("123456", "", "")
+
+	cases := []struct {
+		user     *User
+		expected bool
+	}{
+		{&User{Password: hash, PasswordType: "bcrypt"}, false},
+		{&User{Password: weakHash, PasswordType: "bcrypt"}, true},
+		{&User{Password: "123456", PasswordType: "plain"}, true},
+		{&User{Password: hash, PasswordType: ""}, true},
+		{&User{Password: "", PasswordType: "plain"}, false},
+		{&User{Password: "123456", PasswordType: "plain", Ldap: "uid"}, false},
+	}
+
+	for i, c := range cases {
+		if needsPasswordRehash(c.user, organization, credManager) != c.expected {
+			t.Errorf("case %d: expected rehash: %t", i, c.expected)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/cred"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	// PasswordChangeSessionUserId is the user who has signed in with an expired password, and may only change it
+	PasswordChangeSessionUserId = "PasswordChangeSessionUserId"
+	RequiredPasswordChange      = "RequiredPasswordChange"
+)
+
+// PasswordPolicy is the password policy of an organization, enforced whenever a password is set
+type PasswordPolicy struct {
+	MinLength        int  `json:"minLength"`
+	RequireUppercase bool `json:"requireUppercase"`
+	RequireLowercase bool `json:"requireLowercase"`
+	RequireDigit     bool `json:"requireDigit"`
+	RequireSymbol    bool `json:"requireSymbol"`
+	// DisallowUserInfo rejects the passwords containing the username or the local part of the email
+	DisallowUserInfo bool `json:"disallowUserInfo"`
+	// HistoryCount is the number of the latest passwords, including the current one, that can't be reused
+	HistoryCount int `json:"historyCount"`
+	// MaxAgeDays is the number of days after which the password must be changed at the next sign-in, 0 to never expire
+	MaxAgeDays int `json:"maxAgeDays"`
+	// CheckBreached rejects the passwords in the breached password list configured by "breachedPasswordFile"
+	CheckBreached bool `json:"checkBreached"`
+}
+
+type PasswordHistoryItem struct {
+	Password     string `json:"password"`
+	PasswordSalt string `json:"passwordSalt"`
+	PasswordType string `json:"passwordType"`
+	CreatedTime  string `json:"createdTime"`
+}
+
+var (
+	breachedPasswords     map[string]bool
+	breachedPasswordsOnce sync.Once
+)
+
+// loadBreachedPasswords loads the SHA-1 hashes of the breached passwords, one per line in hex, optionally followed by
+// ":count" as in the downloadable "Pwned Passwords" lists
+func loadBreachedPasswords(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hashes := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 2)[0]
+		if len(hash) == sha1.Size*2 {
+			hashes[strings.ToUpper(hash)] = true
+		}
+	}
+	return hashes, scanner.Err()
+}
+
+func getBreachedPasswords() map[string]bool {
+	breachedPasswordsOnce.Do(func() {
+		path := conf.GetConfigString("breachedPasswordFile")
+		if path == "" {
+			return
+		}
+
+		hashes, err := loadBreachedPasswords(path)
+		if err != nil {
+			logs.Error(fmt.Sprintf("failed to load the breached password file: %s, error: %s", path, err.Error()))
+			return
+		}
+		breachedPasswords = hashes
+	})
+	return breachedPasswords
+}
+
+func isPasswordBreached(breached map[string]bool, password string) bool {
+	hash := sha1.Sum([]byte(password))
+	return breached[strings.ToUpper(hex.EncodeToString(hash[:]))]
+}
+
+// checkPassword checks the password against the complexity rules of the policy for the user with the name and email
+func (policy *PasswordPolicy) checkPassword(password string, name string, email string, lang string) string {
+	if policy.MinLength > 0 && len([]rune(password)) < policy.MinLength {
+		return fmt.Sprintf(i18n.Translate(lang, "check:Password must have at least %d characters"), policy.MinLength)
+	}
+
+	hasUppercase, hasLowercase, hasDigit, hasSymbol := false, false, false, false
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUppercase = true
+		case unicode.IsLower(r):
+			hasLowercase = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUppercase && !hasUppercase {
+		return i18n.Translate(lang, "check:Password must contain an uppercase letter")
+	}
+	if policy.RequireLowercase && !hasLowercase {
+		return i18n.Translate(lang, "check:Password must contain a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		return i18n.Translate(lang, "check:Password must contain a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		return i18n.Translate(lang, "check:Password must contain a special character")
+	}
+
+	if policy.DisallowUserInfo {
+		lowerPassword := strings.ToLower(password)
+		emailName := strings.SplitN(email, "@", 2)[0]
+		for _, info := range []string{name, emailName} {
+			if len(info) >= 3 && strings.Contains(lowerPassword, strings.ToLower(info)) {
+				return i18n.Translate(lang, "check:Password cannot contain the username or email")
+			}
+		}
+	}
+
+	if policy.CheckBreached && isPasswordBreached(getBreachedPasswords(), password) {
+		return i18n.Translate(lang, "check:The password has appeared in a data breach, please choose another one")
+	}
+
+	return ""
+}
+
+// isPasswordReused returns whether the password is the current password of the user or one of the previous
+// passwords kept by the policy
+func (policy *PasswordPolicy) isPasswordReused(user *User, organization *Organization, password string) bool {
+	if policy.HistoryCount <= 0 {
+		return false
+	}
+
+	items := []*PasswordHistoryItem{{Password: user.Password, PasswordSalt: user.PasswordSalt, PasswordType: user.PasswordType}}
+	items = append(items, user.PasswordHistory...)
+	if len(items) > policy.HistoryCount {
+		items = items[:policy.HistoryCount]
+	}
+
+	for _, item := range items {
+		if item.Password == "" {
+			continue
+		}
+
+		passwordType := item.PasswordType
+		if passwordType == "" {
+			passwordType = organization.PasswordType
+		}
+		credManager := cred.GetCredManager(passwordType)
+		if credManager != nil && credManager.IsPasswordCorrect(password, item.Password, item.PasswordSalt, organization.PasswordSalt) {
+			return true
+		}
+	}
+	return false
+}
+
+// updatePasswordHistory moves the current password hash of the old user into the history of the user,
+// keeping the previous passwords required by the policy
+func (policy *PasswordPolicy) updatePasswordHistory(user *User, oldUser *User) {
+	if policy.HistoryCount <= 1 {
+		user.PasswordHistory = nil
+		return
+	}
+
+	history := oldUser.PasswordHistory
+	if oldUser.Password != "" {
+		item := &PasswordHistoryItem{
+			Password:     oldUser.Password,
+			PasswordSalt: oldUser.PasswordSalt,
+			PasswordType: oldUser.PasswordType,
+			CreatedTime:  oldUser.PasswordChangedTime,
+		}
+		history = append([]*PasswordHistoryItem{item}, history...)
+	}
+	if len(history) > policy.HistoryCount-1 {
+		history = history[:policy.HistoryCount-1]
+	}
+	user.PasswordHistory = history
+}
+
+// isPasswordExpired returns whether the password of the user is older than the maximum age of the policy,
+// the passwords set before the policy is supported are as old as the user
+func (policy *PasswordPolicy) isPasswordExpired(user *User, now time.Time) bool {
+	if policy.MaxAgeDays <= 0 || user.Password == "" || user.Ldap != "" {
+		return false
+	}
+
+	changedTime := user.PasswordChangedTime
+	if changedTime == "" {
+		changedTime = user.CreatedTime
+	}
+	passwordTime, err := time.Parse(time.RFC3339, changedTime)
+	if err != nil {
+		return false
+	}
+	return !passwordTime.Add(time.Duration(policy.MaxAgeDays) * 24 * time.Hour).After(now)
+}
+
+// CheckPasswordPolicy checks the password a new user signs up or is added with against the organization policy
+func CheckPasswordPolicy(organization *Organization, name string, email string, password string, lang string) string {
+	if organization == nil || organization.PasswordPolicy == nil {
+		return ""
+	}
+	return organization.PasswordPolicy.checkPassword(password, name, email, lang)
+}
+
+// IsPasswordExpired returns whether the user has to change the password at the next sign-in
+func IsPasswordExpired(user *User) bool {
+	organization := GetOrganizationByUser(user)
+	if organization == nil || organization.PasswordPolicy == nil {
+		return false
+	}
+	return organization.PasswordPolicy.isPasswordExpired(user, time.Now())
+}
+
+// CheckPasswordExpired returns the error message if the user has to change the password before signing in
+func CheckPasswordExpired(user *User, lang string) string {
+	if IsPasswordExpired(user) {
+		return i18n.Translate(lang, "check:Your password has expired, please change it")
+	}
+	return ""
+}
+
+// setUserPassword hashes the new password of the user, and records when it is changed with the previous password
+// in the history if the organization keeps it
+func setUserPassword(user *User, organization *Organization) {
+	oldUser := getUser(user.Owner, user.Name)
+	if oldUser != nil && organization.PasswordPolicy != nil {
+		organization.PasswordPolicy.updatePasswordHistory(user, oldUser)
+	}
+
+	user.UpdateUserPassword(organization)
+	user.PasswordChangedTime = util.GetCurrentTime()
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	policy := &PasswordPolicy{
+		MinLength:        8,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUserInfo: true,
+	}
+
+	cases := []struct {
+		password string
+		valid    bool
+	}{
+		{"Ab1!", false},
+		{"abcdefg1!", false},
+		{"ABCDEFG1!", false},
+		{"Abcdefgh!", false},
+		{"Abcdefgh1", false},
+		{"Alice2023!", false},
+		{"xJohn.Doe9!", false},
+		{"Correct1-Horse", true},
+	}
+
+	for _, c := range cases {
+		msg := policy.checkPassword(c.password, "alice", "john.doe@example.com", "en")
+		if (msg == "") != c.valid {
+			t.Errorf("password %q: got %q, expected valid: %t", c.password, msg, c.valid)
+		}
+	}
+}
+
+func TestIsPasswordBreached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	// the SHA-1 of "password" in the "Pwned Passwords" format, and of "123456" in lowercase
+	data := "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n7c4a8d09ca3762af61e59520943dc26494f8941b\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	breached, err := loadBreachedPasswords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for password, expected := range map[string]bool{"password": true, "123456": true, "Correct1-Horse": false} {
+		if isPasswordBreached(breached, password) != expected {
+			t.Errorf("password %q: expected breached: %t", password, expected)
+		}
+	}
+}
+
+func TestPasswordHistory(t *testing.T) {
+	organization := &Organization{PasswordType: "plain"}
+	policy := &PasswordPolicy{HistoryCount: 3}
+
+	user := &User{Password: "first", PasswordType: "plain"}
+	for _, password := range []string{"second", "third", "fourth"} {
+		oldUser := *user
+		policy.updatePasswordHistory(user, &oldUser)
+		user.Password = password
+	}
+
+	if len(user.PasswordHistory) != 2 {
+		t.Fatalf("got %d passwords in the history, expected 2", len(user.PasswordHistory))
+	}
+	for password, expected := range map[string]bool{"fourth": true, "third": true, "second": true, "first": false} {
+		if policy.isPasswordReused(user, organization, password) != expected {
+			t.Errorf("password %q: expected reused: %t", password, expected)
+		}
+	}
+}
+
+func TestIsPasswordExpired(t *testing.T) {
+	now := time.Date(2023, 6, 30, 12, 0, 0, 0, time.UTC)
+	policy := &PasswordPolicy{MaxAgeDays: 90}
+
+	cases := []struct {
+		user     *User
+		expected bool
+	}{
+		{&User{Password: "123", PasswordChangedTime: "2023-06-01T12:00:00Z"}, false},
+		{&User{Password: "123", PasswordChangedTime: "2023-04-01T12:00:00Z"}, true},
+		{&User{Password: "123", CreatedTime: "2023-01-01T12:00:00Z"}, true},
+		{&User{Password: "", CreatedTime: "2023-01-01T12:00:00Z"}, false},
+		{&User{Password: "123", CreatedTime: "2023-01-01T12:00:00Z", Ldap: "uid"}, false},
+	}
+
+	for i, c := range cases {
+		if policy.isPasswordExpired(c.user, now) != c.expected {
+			t.Errorf("case %d: expected expired: %t", i, c.expected)
+		}
+	}
+}
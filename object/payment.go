@@ -56,8 +56,8 @@ type Payment struct {
 	InvoiceUrl    string `xorm:"varchar(255)" json:"invoiceUrl"`
 }
 
-func GetPaymentCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetPaymentCount(owner, field, value string, filter *Filter) int {
+	session := applyFilter(GetSession(owner, -1, -1, field, value, "", ""), filter)
 	count, err := session.Count(&Payment{})
 	if err != nil {
 		panic(err)
@@ -86,9 +86,9 @@ func GetUserPayments(owner string, organization string, user string) []*Payment
 	return payments
 }
 
-func GetPaginationPayments(owner string, offset, limit int, field, value, sortField, sortOrder string) []*Payment {
+func GetPaginationPayments(owner string, offset, limit int, field, value, sortField, sortOrder string, filter *Filter) []*Payment {
 	payments := []*Payment{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := applyFilter(GetSession(owner, offset, limit, field, value, sortField, sortOrder), filter)
 	err := session.Find(&payments)
 	if err != nil {
 		panic(err)
@@ -30,6 +30,7 @@ type Permission struct {
 
 	Users   []string `xorm:"mediumtext" json:"users"`
 	Roles   []string `xorm:"mediumtext" json:"roles"`
+	Groups  []string `xorm:"mediumtext" json:"groups"`
 	Domains []string `xorm:"mediumtext" json:"domains"`
 
 	Model        string   `xorm:"varchar(100)" json:"model"`
@@ -133,6 +134,7 @@ func checkPermissionValid(permission *Permission) {
 
 	if !HasRoleDefinition(enforcer.GetModel()) {
 		permission.Roles = []string{}
+		permission.Groups = []string{}
 		return
 	}
 
@@ -286,6 +288,17 @@ func GetPermissionsByRole(roleId string) []*Permission {
 	return permissions
 }
 
+// GetPermissionsByGroup returns the permissions granted to the group path
+func GetPermissionsByGroup(groupPath string) []*Permission {
+	permissions := []*Permission{}
+	err := adapter.Engine.Where("groups like ?", "%\""+groupPath+"\"%").Find(&permissions)
+	if err != nil {
+		panic(err)
+	}
+
+	return permissions
+}
+
 func GetPermissionsByResource(resourceId string) []*Permission {
 	permissions := []*Permission{}
 	err := adapter.Engine.Where("resources like ?", "%"+resourceId+"\"%").Find(&permissions)
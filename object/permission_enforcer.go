@@ -23,6 +23,7 @@ import (
 	"github.com/casbin/casbin/v2/log"
 	"github.com/casbin/casbin/v2/model"
 	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
 	xormadapter "github.com/casdoor/xorm-adapter/v3"
 )
 
@@ -105,7 +106,11 @@ func getPolicies(permission *Permission) [][]string {
 		}
 	}
 
-	for _, role := range permission.Roles {
+	subjects := append([]string{}, permission.Roles...)
+	for _, groupPath := range permission.Groups {
+		subjects = append(subjects, getGroupSubject(groupPath))
+	}
+	for _, role := range subjects {
 		for _, resource := range permission.Resources {
 			for _, action := range permission.Actions {
 				if domainExist {
@@ -173,9 +178,112 @@ func getGroupingPolicies(permission *Permission) [][]string {
 		}
 	}
 
+	for _, link := range getGroupLinks(permission) {
+		if domainExist {
+			for _, domain := range permission.Domains {
+				groupingPolicies = append(groupingPolicies, []string{link[0], link[1], domain, "", "", permissionId})
+			}
+		} else {
+			groupingPolicies = append(groupingPolicies, []string{link[0], link[1], "", "", "", permissionId})
+		}
+	}
+
 	return groupingPolicies
 }
 
+const groupSubjectPrefix = "group:"
+
+// getGroupSubject returns the Casbin subject of the group path, which is prefixed not to be taken for the role or
+// the user with the same id as the path of a top group
+func getGroupSubject(groupPath string) string {
+	return groupSubjectPrefix + groupPath
+}
+
+// getGroupLinks returns the (member, group) links that give the users of the permission groups,
+// and of the groups inheriting the permission roles, their group paths: a user is linked to the
+// paths of its groups, a subgroup to its parent group, and a group to the roles it holds
+func getGroupLinks(permission *Permission) [][]string {
+	links := [][]string{}
+	visited := map[string]bool{}
+	addLink := func(member string, group string) {
+		key := member + "\n" + group
+		if !visited[key] {
+			visited[key] = true
+			links = append(links, []string{member, group})
+		}
+	}
+
+	trees := map[string]*GroupTree{}
+	getTree := func(owner string) *GroupTree {
+		tree, ok := trees[owner]
+		if !ok {
+			tree = NewGroupTree(getEnabledGroups(owner))
+			trees[owner] = tree
+		}
+		return tree
+	}
+
+	addGroup := func(tree *GroupTree, group *Group) {
+		descendants := tree.GetDescendants(group.Name)
+		groupIds := []string{}
+		for _, descendant := range descendants {
+			groupIds = append(groupIds, descendant.GetId())
+			if descendant.Name != group.Name {
+				addLink(getGroupSubject(tree.GetPath(descendant.Name)), getGroupSubject(tree.GetPath(descendant.ParentName)))
+			}
+		}
+
+		for _, user := range getUsersByGroupIds(group.Owner, groupIds) {
+			for _, groupId := range user.Groups {
+				if util.InSlice(groupIds, groupId) {
+					_, name := util.GetOwnerAndNameFromIdNoCheck(groupId)
+					addLink(user.GetId(), getGroupSubject(tree.GetPath(name)))
+				}
+			}
+		}
+	}
+
+	for _, groupPath := range permission.Groups {
+		owner, _ := util.GetOwnerAndNameFromIdNoCheck(groupPath)
+		tree := getTree(owner)
+		group := tree.GetGroupByPath(groupPath)
+		if group != nil {
+			addGroup(tree, group)
+		}
+	}
+
+	for _, roleId := range permission.Roles {
+		for _, role := range getRolesInRole(roleId, map[string]struct{}{}) {
+			tree := getTree(role.Owner)
+			for _, group := range tree.GetGroups() {
+				if util.InSlice(group.Roles, role.GetId()) {
+					addLink(getGroupSubject(tree.GetPath(group.Name)), role.GetId())
+					addGroup(tree, group)
+				}
+			}
+		}
+	}
+
+	return links
+}
+
+// refreshGroupPermissions rebuilds the grouping policies of the permissions of the organization
+// that depend on groups, after the groups or their users have changed
+func refreshGroupPermissions(owner string) {
+	for _, permission := range GetPermissions(owner) {
+		if len(permission.Groups) == 0 && len(permission.Roles) == 0 {
+			continue
+		}
+
+		enforcer := getEnforcer(permission)
+		_, err := enforcer.RemoveFilteredGroupingPolicy(5, permission.GetId())
+		if err != nil {
+			panic(err)
+		}
+		addGroupingPolicies(permission)
+	}
+}
+
 func addPolicies(permission *Permission) {
 	enforcer := getEnforcer(permission)
 	policies := getPolicies(permission)
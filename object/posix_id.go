@@ -0,0 +1,133 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// the POSIX numbers are allocated above the range of the system accounts
+const (
+	posixIdMin   = 10000
+	posixIdRange = 2000000000
+)
+
+const (
+	PosixIdTypeUser = "user"
+	PosixIdTypeRole = "role"
+)
+
+// PosixId is the POSIX uid or gid number of a user or a role published by the LDAP server, the numbers of the users
+// and the roles share one range so the private group of a user never has the gid of a role
+type PosixId struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	Type        string `xorm:"varchar(100) notnull pk" json:"type"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Number int64 `xorm:"notnull unique" json:"number"`
+}
+
+// getPosixIdHash returns the preferred number of the id, which is the only number used before the numbers were
+// stored, so the ids without a collision keep their numbers
+func getPosixIdHash(id string) int64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return posixIdMin + int64(h.Sum32()%posixIdRange)
+}
+
+// getFreePosixId probes the numbers from the preferred number of the id until one isn't used
+func getFreePosixId(id string, isUsed func(number int64) bool) int64 {
+	number := getPosixIdHash(id)
+	for isUsed(number) {
+		number = posixIdMin + (number-posixIdMin+1)%posixIdRange
+	}
+	return number
+}
+
+func isPosixIdUsed(number int64) bool {
+	existed, err := adapter.Engine.Exist(&PosixId{Number: number})
+	if err != nil {
+		panic(err)
+	}
+
+	return existed
+}
+
+func allocatePosixId(idType string, id string) int64 {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	for i := 0; ; i++ {
+		posixId := &PosixId{
+			Owner:       owner,
+			Name:        name,
+			Type:        idType,
+			CreatedTime: util.GetCurrentTime(),
+			Number:      getFreePosixId(id, isPosixIdUsed),
+		}
+		_, err := adapter.Engine.Insert(posixId)
+		if err == nil {
+			return posixId.Number
+		}
+
+		// the id or the number may have been allocated by another request meanwhile
+		existing := PosixId{Owner: owner, Name: name, Type: idType}
+		existed, getErr := adapter.Engine.Get(&existing)
+		if getErr != nil {
+			panic(getErr)
+		}
+		if existed {
+			return existing.Number
+		}
+		if i >= 10 {
+			panic(err)
+		}
+	}
+}
+
+// GetPosixIds returns the POSIX numbers of the ids of the users or the roles, allocating the numbers of the ids
+// which don't have one yet
+func GetPosixIds(idType string, ids []string) map[string]string {
+	res := map[string]string{}
+	if len(ids) == 0 {
+		return res
+	}
+
+	owners := []string{}
+	for _, id := range ids {
+		owner, _ := util.GetOwnerAndNameFromIdNoCheck(id)
+		if !util.InSlice(owners, owner) {
+			owners = append(owners, owner)
+		}
+	}
+
+	posixIds := []*PosixId{}
+	err := adapter.Engine.In("owner", owners).Find(&posixIds, &PosixId{Type: idType})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, posixId := range posixIds {
+		res[util.GetId(posixId.Owner, posixId.Name)] = strconv.FormatInt(posixId.Number, 10)
+	}
+	for _, id := range ids {
+		if _, ok := res[id]; !ok {
+			res[id] = strconv.FormatInt(allocatePosixId(idType, id), 10)
+		}
+	}
+	return res
+}
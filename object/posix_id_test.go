@@ -0,0 +1,42 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import "testing"
+
+func TestGetFreePosixId(t *testing.T) {
+	id := "casbin/alice"
+	number := getPosixIdHash(id)
+	if number < posixIdMin || number >= posixIdMin+posixIdRange {
+		t.Fatalf("the number: %d of %s is out of the range", number, id)
+	}
+
+	used := map[int64]bool{}
+	isUsed := func(number int64) bool {
+		return used[number]
+	}
+
+	if actual := getFreePosixId(id, isUsed); actual != number {
+		t.Errorf("got %d for %s, expected its hash: %d", actual, id, number)
+	}
+
+	// another id colliding with the hash and the next number of the id
+	used[number] = true
+	used[number+1] = true
+	if actual := getFreePosixId(id, isUsed); actual != number+2 {
+		t.Errorf("got %d for the collision of %s, expected: %d", actual, id, number+2)
+	}
+
+}
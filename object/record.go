@@ -43,6 +43,7 @@ type Record struct {
 	Organization string `xorm:"varchar(100)" json:"organization"`
 	ClientIp     string `xorm:"varchar(100)" json:"clientIp"`
 	User         string `xorm:"varchar(100)" json:"user"`
+	Impersonator string `xorm:"varchar(100)" json:"impersonator"`
 	Method       string `xorm:"varchar(100)" json:"method"`
 	RequestUri   string `xorm:"varchar(1000)" json:"requestUri"`
 	Action       string `xorm:"varchar(1000)" json:"action"`
@@ -66,16 +67,25 @@ func NewRecord(ctx *context.Context) *Record {
 		object = string(ctx.Input.RequestBody)
 	}
 
+	// the real admin while impersonating the user
+	impersonator := ""
+	if ctx.Input.CruSession != nil {
+		if value, ok := ctx.Input.Session(ImpersonatorSessionUserId).(string); ok {
+			impersonator = value
+		}
+	}
+
 	record := Record{
-		Name:        util.GenerateId(),
-		CreatedTime: util.GetCurrentTime(),
-		ClientIp:    ip,
-		User:        "",
-		Method:      ctx.Request.Method,
-		RequestUri:  requestUri,
-		Action:      action,
-		Object:      object,
-		IsTriggered: false,
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		ClientIp:     ip,
+		User:         "",
+		Impersonator: impersonator,
+		Method:       ctx.Request.Method,
+		RequestUri:   requestUri,
+		Action:       action,
+		Object:       object,
+		IsTriggered:  false,
 	}
 	return &record
 }
@@ -108,8 +118,8 @@ func AddRecord(record *Record) bool {
 	return affected != 0
 }
 
-func GetRecordCount(field, value string, filterRecord *Record) int {
-	session := GetSession("", -1, -1, field, value, "", "")
+func GetRecordCount(field, value string, filterRecord *Record, filter *Filter) int {
+	session := applyFilter(GetSession("", -1, -1, field, value, "", ""), filter)
 	count, err := session.Count(filterRecord)
 	if err != nil {
 		panic(err)
@@ -128,9 +138,9 @@ func GetRecords() []*Record {
 	return records
 }
 
-func GetPaginationRecords(offset, limit int, field, value, sortField, sortOrder string, filterRecord *Record) []*Record {
+func GetPaginationRecords(offset, limit int, field, value, sortField, sortOrder string, filterRecord *Record, filter *Filter) []*Record {
 	records := []*Record{}
-	session := GetSession("", offset, limit, field, value, sortField, sortOrder)
+	session := applyFilter(GetSession("", offset, limit, field, value, sortField, sortOrder), filter)
 	err := session.Find(&records, filterRecord)
 	if err != nil {
 		panic(err)
@@ -130,6 +130,10 @@ func UpdateRole(id string, role *Role) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionRole(id, role)
+	}
+
 	visited = map[string]struct{}{}
 	newRoleID := role.GetId()
 	permissions = GetPermissionsByRole(newRoleID)
@@ -160,6 +164,10 @@ func AddRole(role *Role) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionRole(role.GetId(), role)
+	}
+
 	return affected != 0
 }
 
@@ -173,6 +181,12 @@ func AddRoles(roles []*Role) bool {
 			panic(err)
 		}
 	}
+
+	if affected != 0 {
+		for _, role := range roles {
+			provisionRole(role.GetId(), role)
+		}
+	}
 	return affected != 0
 }
 
@@ -215,6 +229,10 @@ func DeleteRole(role *Role) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionRole(roleId, role)
+	}
+
 	return affected != 0
 }
 
@@ -0,0 +1,175 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var scimHttpClient = &http.Client{Timeout: 30 * time.Second}
+
+// scimClient pushes the users and roles to the SCIM server of an application, they are the SCIM Users and Groups
+type scimClient struct {
+	url   string
+	token string
+}
+
+// scimError is a failed request to the SCIM server, with the detail of the SCIM error response if any
+type scimError struct {
+	status int
+	detail string
+}
+
+func (e *scimError) Error() string {
+	return fmt.Sprintf("SCIM server returned %d: %s", e.status, e.detail)
+}
+
+func newScimClient(application *Application) *scimClient {
+	return &scimClient{
+		url:   strings.TrimSuffix(application.ScimUrl, "/"),
+		token: application.ScimToken,
+	}
+}
+
+func (c *scimClient) do(method string, path string, body interface{}) (map[string]interface{}, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.url+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/scim+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/scim+json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := scimHttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	if len(data) != 0 {
+		// an error response may not be JSON, such as from a proxy, its body is kept as the detail
+		if json.Unmarshal(data, &result) != nil && resp.StatusCode < http.StatusMultipleChoices {
+			return nil, fmt.Errorf("the SCIM response isn't JSON: %s", string(data))
+		}
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		detail, ok := result["detail"].(string)
+		if !ok {
+			detail = strings.TrimSpace(string(data))
+		}
+		return nil, &scimError{status: resp.StatusCode, detail: detail}
+	}
+	return result, nil
+}
+
+func isScimNotFound(err error) bool {
+	e, ok := err.(*scimError)
+	return ok && e.status == http.StatusNotFound
+}
+
+// findId returns the id of the resource whose attribute equals the value, empty if there is none
+func (c *scimClient) findId(resourceType string, attribute string, value string) (string, error) {
+	quoted, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	filter := url.QueryEscape(fmt.Sprintf("%s eq %s", attribute, string(quoted)))
+	result, err := c.do(http.MethodGet, fmt.Sprintf("/%s?filter=%s", resourceType, filter), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resources, _ := result["Resources"].([]interface{})
+	if len(resources) == 0 {
+		return "", nil
+	}
+	resource, _ := resources[0].(map[string]interface{})
+	id, _ := resource["id"].(string)
+	return id, nil
+}
+
+// save creates or replaces the resource and returns its id. Without a known id, an existing resource with the same
+// unique attribute is replaced, so the resources created before the provisioning was enabled aren't duplicated.
+func (c *scimClient) save(resourceType string, remoteId string, attribute string, resource map[string]interface{}) (string, error) {
+	if remoteId == "" {
+		var err error
+		remoteId, err = c.findId(resourceType, attribute, resource[attribute].(string))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if remoteId != "" {
+		_, err := c.do(http.MethodPut, fmt.Sprintf("/%s/%s", resourceType, url.PathEscape(remoteId)), resource)
+		if err == nil {
+			return remoteId, nil
+		}
+		if !isScimNotFound(err) {
+			return "", err
+		}
+	}
+
+	result, err := c.do(http.MethodPost, "/"+resourceType, resource)
+	if err != nil {
+		return "", err
+	}
+
+	id, _ := result["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("the SCIM server returned no id for the %s", resourceType)
+	}
+	return id, nil
+}
+
+// delete deletes the resource, a resource already deleted from the SCIM server isn't an error
+func (c *scimClient) delete(resourceType string, remoteId string) error {
+	if remoteId == "" {
+		return nil
+	}
+
+	_, err := c.do(http.MethodDelete, fmt.Sprintf("/%s/%s", resourceType, url.PathEscape(remoteId)), nil)
+	if err != nil && !isScimNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mockScimServer is a minimal SCIM server keeping the resources in memory, it supports the "eq" filter only
+type mockScimServer struct {
+	mu        sync.Mutex
+	resources map[string]map[string]map[string]interface{}
+	nextId    int
+}
+
+func newMockScimServer() *mockScimServer {
+	return &mockScimServer{resources: map[string]map[string]map[string]interface{}{"Users": {}, "Groups": {}}}
+}
+
+func (m *mockScimServer) writeError(w http.ResponseWriter, status int, detail string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": fmt.Sprint(status), "detail": detail})
+}
+
+func (m *mockScimServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		m.writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	resources, ok := m.resources[segments[0]]
+	if !ok {
+		m.writeError(w, http.StatusNotFound, "unknown endpoint")
+		return
+	}
+
+	var body map[string]interface{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	if len(segments) == 1 && r.Method == http.MethodGet {
+		found := []interface{}{}
+		tokens := strings.SplitN(r.URL.Query().Get("filter"), " eq ", 2)
+		var value string
+		if len(tokens) == 2 && json.Unmarshal([]byte(tokens[1]), &value) == nil {
+			for _, resource := range resources {
+				if resource[tokens[0]] == value {
+					found = append(found, resource)
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"totalResults": len(found), "Resources": found})
+		return
+	}
+
+	if len(segments) == 1 && r.Method == http.MethodPost {
+		m.nextId++
+		body["id"] = fmt.Sprintf("remote-%d", m.nextId)
+		resources[body["id"].(string)] = body
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(body)
+		return
+	}
+
+	id := segments[len(segments)-1]
+	if _, ok = resources[id]; !ok {
+		m.writeError(w, http.StatusNotFound, "resource not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body["id"] = id
+		resources[id] = body
+		_ = json.NewEncoder(w).Encode(body)
+	case http.MethodDelete:
+		delete(resources, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		m.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func TestScimClient(t *testing.T) {
+	mock := newMockScimServer()
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	client := newScimClient(&Application{ScimUrl: server.URL + "/", ScimToken: "secret"})
+	user := &User{Owner: "org", Name: "alice", Id: "1", DisplayName: "Alice", Email: "alice@example.com", Affiliation: "R&D"}
+
+	remoteId, err := client.save("Users", "", "userName", getScimUserResource(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the user is disabled, and found by its user name when its id isn't known
+	user.IsForbidden = true
+	remoteId2, err := client.save("Users", "", "userName", getScimUserResource(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remoteId2 != remoteId || len(mock.resources["Users"]) != 1 || mock.resources["Users"][remoteId]["active"] != false {
+		t.Errorf("unexpected users: %v", mock.resources["Users"])
+	}
+	if enterprise := mock.resources["Users"][remoteId][scimEnterpriseUserSchema].(map[string]interface{}); enterprise["department"] != "R&D" {
+		t.Errorf("unexpected enterprise attributes: %v", enterprise)
+	}
+
+	// the user deleted from the SCIM server is created again
+	delete(mock.resources["Users"], remoteId)
+	remoteId, err = client.save("Users", remoteId, "userName", getScimUserResource(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mock.resources["Users"][remoteId]; !ok {
+		t.Errorf("the user isn't created again: %v", mock.resources["Users"])
+	}
+
+	role := &Role{Owner: "org", Name: "admins", DisplayName: "Admins"}
+	groupId, err := client.save("Groups", "", "displayName", getScimGroupResource(role, []string{remoteId}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := mock.resources["Groups"][groupId]["members"].([]interface{})
+	if len(members) != 1 || members[0].(map[string]interface{})["value"] != remoteId {
+		t.Errorf("unexpected group members: %v", members)
+	}
+
+	err = client.delete("Users", remoteId)
+	if err != nil || len(mock.resources["Users"]) != 0 {
+		t.Errorf("the user isn't deleted: %v, %v", err, mock.resources["Users"])
+	}
+	if err = client.delete("Users", remoteId); err != nil {
+		t.Errorf("deleting a deleted user fails: %v", err)
+	}
+
+	client.token = "wrong"
+	_, err = client.save("Users", "", "userName", getScimUserResource(user))
+	if e, ok := err.(*scimError); !ok || e.status != http.StatusUnauthorized || e.detail != "invalid token" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
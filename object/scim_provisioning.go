@@ -0,0 +1,454 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// the statuses of a user or a role provisioned to an application
+const (
+	ScimProvisioningPending       = "Pending"
+	ScimProvisioningProvisioned   = "Provisioned"
+	ScimProvisioningDeprovisioned = "Deprovisioned"
+	ScimProvisioningFailed        = "Failed"
+)
+
+// the types of the provisioned objects, the users are the SCIM Users and the roles the SCIM Groups
+const (
+	ScimProvisioningUser  = "User"
+	ScimProvisioningGroup = "Group"
+)
+
+const (
+	scimUserSchema           = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimEnterpriseUserSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	scimGroupSchema          = "urn:ietf:params:scim:schemas:core:2.0:Group"
+)
+
+// a failed push is retried with an exponential backoff until the attempts are used up
+var (
+	scimProvisioningMaxAttempts = 5
+	scimProvisioningRetryDelay  = 2 * time.Second
+)
+
+// the pushes of the same object are serialized, each push sends the latest state of the object, the lock of an
+// object is removed when no push of it is running or waiting
+var (
+	scimProvisioningLocks      = map[string]*scimProvisioningLock{}
+	scimProvisioningLocksMutex sync.Mutex
+)
+
+type scimProvisioningLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lockScimProvisioning locks the pushes of the object, and returns the function to unlock them
+func lockScimProvisioning(key string) func() {
+	scimProvisioningLocksMutex.Lock()
+	lock, ok := scimProvisioningLocks[key]
+	if !ok {
+		lock = &scimProvisioningLock{}
+		scimProvisioningLocks[key] = lock
+	}
+	lock.refs++
+	scimProvisioningLocksMutex.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		scimProvisioningLocksMutex.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(scimProvisioningLocks, key)
+		}
+		scimProvisioningLocksMutex.Unlock()
+	}
+}
+
+// ScimProvisioning is the status of a user or a role provisioned to an application's SCIM server, the owner is the
+// organization of the user or the role
+type ScimProvisioning struct {
+	Owner       string `xorm:"varchar(100) index" json:"owner"`
+	Application string `xorm:"varchar(100) notnull pk" json:"application"`
+	Type        string `xorm:"varchar(100) notnull pk" json:"type"`
+	ObjectId    string `xorm:"varchar(100) notnull pk" json:"objectId"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	ObjectName string `xorm:"varchar(200)" json:"objectName"`
+	RemoteId   string `xorm:"varchar(200)" json:"remoteId"`
+	Status     string `xorm:"varchar(100)" json:"status"`
+	Attempts   int    `json:"attempts"`
+	Error      string `xorm:"mediumtext" json:"error"`
+}
+
+func GetScimProvisioningCount(application, field, value string) int {
+	session := GetSession("", -1, -1, field, value, "", "")
+	count, err := session.Where("application = ?", application).Count(&ScimProvisioning{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetScimProvisionings(application string) []*ScimProvisioning {
+	provisionings := []*ScimProvisioning{}
+	err := adapter.Engine.Desc("updated_time").Find(&provisionings, &ScimProvisioning{Application: application})
+	if err != nil {
+		panic(err)
+	}
+
+	return provisionings
+}
+
+func GetPaginationScimProvisionings(application string, offset, limit int, field, value, sortField, sortOrder string) []*ScimProvisioning {
+	provisionings := []*ScimProvisioning{}
+	session := GetSession("", offset, limit, field, value, sortField, sortOrder)
+	err := session.Where("application = ?", application).Find(&provisionings)
+	if err != nil {
+		panic(err)
+	}
+
+	return provisionings
+}
+
+func GetScimProvisioning(application string, typ string, objectId string) *ScimProvisioning {
+	provisioning := ScimProvisioning{Application: application, Type: typ, ObjectId: objectId}
+	existed, err := adapter.Engine.Get(&provisioning)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &provisioning
+	} else {
+		return nil
+	}
+}
+
+func saveScimProvisioning(provisioning *ScimProvisioning) {
+	provisioning.UpdatedTime = util.GetCurrentTime()
+	affected, err := adapter.Engine.ID(core.PK{provisioning.Application, provisioning.Type, provisioning.ObjectId}).AllCols().Update(provisioning)
+	if err != nil {
+		panic(err)
+	}
+
+	if affected == 0 {
+		_, err = adapter.Engine.Insert(provisioning)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func deleteScimProvisionings(application string) {
+	_, err := adapter.Engine.Where("application = ?", application).Delete(&ScimProvisioning{})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// getScimApplications returns the applications of the organization with SCIM provisioning enabled
+func getScimApplications(organization string) []*Application {
+	applications := []*Application{}
+	err := adapter.Engine.Where("organization = ? and enable_scim_provisioning = ?", organization, true).Find(&applications)
+	if err != nil {
+		panic(err)
+	}
+
+	result := []*Application{}
+	for _, application := range applications {
+		if application.ScimUrl != "" {
+			result = append(result, application)
+		}
+	}
+	return result
+}
+
+func getScimUserResource(user *User) map[string]interface{} {
+	resource := map[string]interface{}{
+		"schemas":     []string{scimUserSchema, scimEnterpriseUserSchema},
+		"userName":    user.Name,
+		"externalId":  user.Id,
+		"displayName": user.DisplayName,
+		"active":      !user.IsForbidden && !user.IsDeleted,
+		"name": map[string]interface{}{
+			"givenName":  user.FirstName,
+			"familyName": user.LastName,
+		},
+	}
+	if user.Email != "" {
+		resource["emails"] = []interface{}{map[string]interface{}{"value": user.Email, "type": "work", "primary": true}}
+	}
+	if user.Phone != "" {
+		resource["phoneNumbers"] = []interface{}{map[string]interface{}{"value": user.Phone, "type": "work", "primary": true}}
+	}
+	if user.Title != "" {
+		resource["title"] = user.Title
+	}
+	if user.Language != "" {
+		resource["preferredLanguage"] = user.Language
+	}
+	if user.Affiliation != "" {
+		resource[scimEnterpriseUserSchema] = map[string]interface{}{"department": user.Affiliation}
+	}
+	return resource
+}
+
+// getScimGroupResource returns the group of the role, the members are the ids of the users in the SCIM server
+func getScimGroupResource(role *Role, memberIds []string) map[string]interface{} {
+	displayName := role.DisplayName
+	if displayName == "" {
+		displayName = role.Name
+	}
+
+	members := []interface{}{}
+	for _, memberId := range memberIds {
+		members = append(members, map[string]interface{}{"value": memberId})
+	}
+
+	return map[string]interface{}{
+		"schemas":     []string{scimGroupSchema},
+		"displayName": displayName,
+		"externalId":  role.GetId(),
+		"members":     members,
+	}
+}
+
+// provisionUser pushes the user to the SCIM servers of the organization's applications in the background, a deleted
+// user is deleted from them
+func provisionUser(user *User) {
+	applications := getScimApplications(user.Owner)
+	if len(applications) == 0 {
+		return
+	}
+
+	if user.Id == "" {
+		if u := getUser(user.Owner, user.Name); u != nil {
+			user.Id = u.Id
+		}
+	}
+	for _, application := range applications {
+		provisionScimUser(application, user)
+	}
+}
+
+func provisionUsers(users []*User) {
+	applicationMap := map[string][]*Application{}
+	for _, user := range users {
+		applications, ok := applicationMap[user.Owner]
+		if !ok {
+			applications = getScimApplications(user.Owner)
+			applicationMap[user.Owner] = applications
+		}
+
+		for _, application := range applications {
+			provisionScimUser(application, user)
+		}
+	}
+}
+
+// provisionScimUser pushes the user to the application if the user has access to it, or has been provisioned to it
+// and is deprovisioned when the access is revoked
+func provisionScimUser(application *Application, user *User) {
+	allowed, err := CheckAccessPermission(user.GetId(), application)
+	if err == nil && !allowed && GetScimProvisioning(application.Name, ScimProvisioningUser, user.Id) == nil {
+		return
+	}
+
+	startScimProvisioning(application, ScimProvisioningUser, user.Id, user.GetId(), user.Owner)
+}
+
+// provisionRole pushes the role to the SCIM servers as a group in the background, a deleted role is deleted from them
+func provisionRole(oldId string, role *Role) {
+	applications := getScimApplications(role.Owner)
+	for _, application := range applications {
+		if oldId != role.GetId() {
+			renameScimProvisioning(application.Name, ScimProvisioningGroup, oldId, role.GetId())
+		}
+		startScimProvisioning(application, ScimProvisioningGroup, role.GetId(), role.GetId(), role.Owner)
+	}
+}
+
+func renameScimProvisioning(application string, typ string, oldObjectId string, newObjectId string) {
+	_, err := adapter.Engine.Where("application = ? and type = ? and object_id = ?", application, typ, oldObjectId).
+		Cols("object_id", "object_name").Update(&ScimProvisioning{ObjectId: newObjectId, ObjectName: newObjectId})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// RetryScimProvisioning pushes the object again, such as after its provisioning failed
+func RetryScimProvisioning(provisioning *ScimProvisioning) bool {
+	application := getApplication("admin", provisioning.Application)
+	if application == nil || !application.EnableScimProvisioning || application.ScimUrl == "" {
+		return false
+	}
+
+	provisioning = GetScimProvisioning(provisioning.Application, provisioning.Type, provisioning.ObjectId)
+	if provisioning == nil {
+		return false
+	}
+
+	startScimProvisioning(application, provisioning.Type, provisioning.ObjectId, provisioning.ObjectName, provisioning.Owner)
+	return true
+}
+
+func startScimProvisioning(application *Application, typ string, objectId string, objectName string, owner string) {
+	if objectId == "" {
+		return
+	}
+
+	util.SafeGoroutine(func() {
+		runScimProvisioning(application, typ, objectId, objectName, owner)
+	})
+}
+
+// runScimProvisioning pushes the latest state of the object with retries and saves its provisioning status
+func runScimProvisioning(application *Application, typ string, objectId string, objectName string, owner string) {
+	unlock := lockScimProvisioning(fmt.Sprintf("%s/%s/%s", application.Name, typ, objectId))
+	defer unlock()
+
+	provisioning := GetScimProvisioning(application.Name, typ, objectId)
+	if provisioning == nil {
+		provisioning = &ScimProvisioning{
+			Owner:       owner,
+			Application: application.Name,
+			Type:        typ,
+			ObjectId:    objectId,
+			CreatedTime: util.GetCurrentTime(),
+		}
+	}
+	provisioning.ObjectName = objectName
+	provisioning.Status = ScimProvisioningPending
+	provisioning.Attempts = 0
+
+	client := newScimClient(application)
+	delay := scimProvisioningRetryDelay
+	for {
+		provisioning.Attempts++
+		oldRemoteId := provisioning.RemoteId
+		err := pushScimObject(application, client, provisioning)
+		if err == nil {
+			provisioning.Error = ""
+			saveScimProvisioning(provisioning)
+
+			// the groups of a newly provisioned or deprovisioned user are pushed again with its membership changed
+			if typ == ScimProvisioningUser && (oldRemoteId == "") != (provisioning.RemoteId == "") {
+				for _, role := range GetRolesByUser(objectName) {
+					if role.Owner == owner {
+						startScimProvisioning(application, ScimProvisioningGroup, role.GetId(), role.GetId(), role.Owner)
+					}
+				}
+			}
+			return
+		}
+
+		provisioning.Error = err.Error()
+		if provisioning.Attempts >= scimProvisioningMaxAttempts {
+			provisioning.Status = ScimProvisioningFailed
+			saveScimProvisioning(provisioning)
+			logs.Warning(fmt.Sprintf("SCIM provisioning of %s: %s to application: %s failed: %s", typ, objectName, application.Name, err.Error()))
+			return
+		}
+
+		saveScimProvisioning(provisioning)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// pushScimObject sends the object's current state to the SCIM server, the object is deleted from it if it no longer
+// exists in Casdoor, or the user no longer has access to the application
+func pushScimObject(application *Application, client *scimClient, provisioning *ScimProvisioning) error {
+	if provisioning.Type == ScimProvisioningUser {
+		user := GetUserByUserId(provisioning.Owner, provisioning.ObjectId)
+		if user == nil {
+			return deprovisionScimObject(client, provisioning, "Users")
+		}
+
+		allowed, err := CheckAccessPermission(user.GetId(), application)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return deprovisionScimObject(client, provisioning, "Users")
+		}
+
+		provisioning.ObjectName = user.GetId()
+		remoteId, err := client.save("Users", provisioning.RemoteId, "userName", getScimUserResource(user))
+		if err != nil {
+			return err
+		}
+		provisioning.RemoteId = remoteId
+	} else {
+		role := GetRole(provisioning.ObjectId)
+		if role == nil {
+			return deprovisionScimObject(client, provisioning, "Groups")
+		}
+
+		remoteId, err := client.save("Groups", provisioning.RemoteId, "displayName", getScimGroupResource(role, getScimMemberIds(provisioning.Application, role)))
+		if err != nil {
+			return err
+		}
+		provisioning.RemoteId = remoteId
+	}
+
+	provisioning.Status = ScimProvisioningProvisioned
+	return nil
+}
+
+func deprovisionScimObject(client *scimClient, provisioning *ScimProvisioning, resourceType string) error {
+	err := client.delete(resourceType, provisioning.RemoteId)
+	if err != nil {
+		return err
+	}
+
+	provisioning.RemoteId = ""
+	provisioning.Status = ScimProvisioningDeprovisioned
+	return nil
+}
+
+// getScimMemberIds returns the SCIM ids of the role's users, the users not provisioned yet are added when they are
+func getScimMemberIds(application string, role *Role) []string {
+	memberIds := []string{}
+	for _, userId := range role.Users {
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(userId)
+		if owner != role.Owner {
+			continue
+		}
+
+		user := getUser(owner, name)
+		if user == nil {
+			continue
+		}
+
+		provisioning := GetScimProvisioning(application, ScimProvisioningUser, user.Id)
+		if provisioning != nil && provisioning.RemoteId != "" {
+			memberIds = append(memberIds, provisioning.RemoteId)
+		}
+	}
+	return memberIds
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLockScimProvisioning(t *testing.T) {
+	count, maxCount := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := lockScimProvisioning("app/User/1")
+			defer unlock()
+
+			count++
+			if count > maxCount {
+				maxCount = count
+			}
+			count--
+		}()
+	}
+	wg.Wait()
+
+	if maxCount != 1 {
+		t.Errorf("the pushes of the same object ran concurrently")
+	}
+	if len(scimProvisioningLocks) != 0 {
+		t.Errorf("got %d locks left, expected none", len(scimProvisioningLocks))
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// ScimToken is a bearer token of the SCIM server of its organization, only its hash is stored so the token is
+// shown once when added
+type ScimToken struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	TokenHash    string `xorm:"varchar(100) index" json:"-"`
+	TokenPrefix  string `xorm:"varchar(100)" json:"tokenPrefix"`
+	ExpireTime   string `xorm:"varchar(100)" json:"expireTime"`
+	LastUsedTime string `xorm:"varchar(100)" json:"lastUsedTime"`
+	IsEnabled    bool   `json:"isEnabled"`
+
+	Token string `xorm:"-" json:"token,omitempty"`
+}
+
+func GetScimTokenCount(owner, field, value string) int {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	count, err := session.Count(&ScimToken{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetScimTokens(owner string) []*ScimToken {
+	tokens := []*ScimToken{}
+	err := adapter.Engine.Desc("created_time").Find(&tokens, &ScimToken{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return tokens
+}
+
+func GetPaginationScimTokens(owner string, offset, limit int, field, value, sortField, sortOrder string) []*ScimToken {
+	tokens := []*ScimToken{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&tokens)
+	if err != nil {
+		panic(err)
+	}
+
+	return tokens
+}
+
+func getScimToken(owner string, name string) *ScimToken {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	token := ScimToken{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&token)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &token
+	} else {
+		return nil
+	}
+}
+
+func GetScimToken(id string) *ScimToken {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getScimToken(owner, name)
+}
+
+// CheckScimTokenExpireTime returns the error message if the expire time isn't empty or an RFC 3339 time
+func CheckScimTokenExpireTime(expireTime string, lang string) string {
+	if expireTime == "" {
+		return ""
+	}
+
+	if _, err := time.Parse(time.RFC3339, expireTime); err != nil {
+		return fmt.Sprintf(i18n.Translate(lang, "scim:The expire time: %s is invalid"), expireTime)
+	}
+	return ""
+}
+
+// UpdateScimToken updates the token's display name, expire time and status, the token itself and the organization
+// it belongs to can't be changed
+func UpdateScimToken(id string, token *ScimToken) bool {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if getScimToken(owner, name) == nil {
+		return false
+	}
+
+	affected, err := adapter.Engine.ID(core.PK{owner, name}).Cols("display_name", "expire_time", "is_enabled").Update(token)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+// AddScimToken generates the token, which is returned in the Token field only this time
+func AddScimToken(token *ScimToken) bool {
+	if token.CreatedTime == "" {
+		token.CreatedTime = util.GetCurrentTime()
+	}
+
+	token.Token = fmt.Sprintf("scim_%s", util.GenerateClientSecret())
+	token.TokenHash = util.GetSha256Hash(token.Token)
+	token.TokenPrefix = token.Token[:9]
+
+	affected, err := adapter.Engine.Insert(token)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func DeleteScimToken(token *ScimToken) bool {
+	affected, err := adapter.Engine.ID(core.PK{token.Owner, token.Name}).Delete(&ScimToken{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func (token *ScimToken) GetId() string {
+	return fmt.Sprintf("%s/%s", token.Owner, token.Name)
+}
+
+// isExpired returns whether the token has expired, the tokens with an invalid expire time are treated as expired
+func (token *ScimToken) isExpired(now time.Time) bool {
+	if token.ExpireTime == "" {
+		return false
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, token.ExpireTime)
+	return err != nil || expireTime.Before(now)
+}
+
+// CheckScimToken returns whether the bearer token is an enabled and unexpired token of the organization
+func CheckScimToken(organization string, bearerToken string) bool {
+	if bearerToken == "" {
+		return false
+	}
+
+	tokenHash := util.GetSha256Hash(bearerToken)
+	token := ScimToken{Owner: organization, TokenHash: tokenHash}
+	existed, err := adapter.Engine.Get(&token)
+	if err != nil {
+		panic(err)
+	}
+
+	if !existed || !token.IsEnabled {
+		return false
+	}
+
+	if token.isExpired(time.Now()) {
+		return false
+	}
+
+	token.LastUsedTime = util.GetCurrentTime()
+	_, err = adapter.Engine.ID(core.PK{token.Owner, token.Name}).Cols("last_used_time").Update(&token)
+	if err != nil {
+		panic(err)
+	}
+
+	return true
+}
+
+// GetScimBaseUrl returns the url of the SCIM endpoints of the organization
+func GetScimBaseUrl(host string, organization string) string {
+	_, originBackend := getOriginFromHost(host)
+	return fmt.Sprintf("%s/scim/v2/%s", originBackend, organization)
+}
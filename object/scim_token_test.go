@@ -0,0 +1,43 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScimTokenIsExpired(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		expireTime string
+		expected   bool
+	}{
+		{"", false},
+		{now.Add(time.Hour).Format(time.RFC3339), false},
+		{now.Add(-time.Hour).Format(time.RFC3339), true},
+		{"2023-01-01 00:00:00", true},
+	}
+
+	for _, c := range cases {
+		token := &ScimToken{ExpireTime: c.expireTime}
+		if isExpired := token.isExpired(now); isExpired != c.expected {
+			t.Errorf("got expired %v for the expire time %q, want %v", isExpired, c.expireTime, c.expected)
+		}
+		if msg := CheckScimTokenExpireTime(c.expireTime, "en"); (msg != "") != (c.expireTime == "2023-01-01 00:00:00") {
+			t.Errorf("got message %q for the expire time %q", msg, c.expireTime)
+		}
+	}
+}
@@ -27,6 +27,8 @@ type TableColumn struct {
 	CasdoorName string   `json:"casdoorName"`
 	IsHashed    bool     `json:"isHashed"`
 	Values      []string `json:"values"`
+	// Owner is the side owning the column in the column owner conflict policy, "Source" or "Casdoor"
+	Owner string `json:"owner"`
 }
 
 type Syncer struct {
@@ -48,10 +50,23 @@ type Syncer struct {
 	TableColumns     []*TableColumn `xorm:"mediumtext" json:"tableColumns"`
 	AffiliationTable string         `xorm:"varchar(100)" json:"affiliationTable"`
 	AvatarBaseUrl    string         `xorm:"varchar(100)" json:"avatarBaseUrl"`
+	Url              string         `xorm:"varchar(200)" json:"url"`
+	Provider         string         `xorm:"varchar(100)" json:"provider"`
+	ItemsPath        string         `xorm:"varchar(100)" json:"itemsPath"`
+	NextPagePath     string         `xorm:"varchar(100)" json:"nextPagePath"`
+	PageSize         int            `json:"pageSize"`
 	ErrorText        string         `xorm:"mediumtext" json:"errorText"`
 	SyncInterval     int            `json:"syncInterval"`
+	CronExpression   string         `xorm:"varchar(100)" json:"cronExpression"`
+	MisfirePolicy    string         `xorm:"varchar(100)" json:"misfirePolicy"`
+	LastRunTime      string         `xorm:"varchar(100)" json:"lastRunTime"`
+	DeletionPolicy   string         `xorm:"varchar(100)" json:"deletionPolicy"`
+	ConflictPolicy   string         `xorm:"varchar(100)" json:"conflictPolicy"`
 	IsEnabled        bool           `json:"isEnabled"`
 
+	LeaseHolder string `xorm:"-" json:"leaseHolder"`
+	NextRunTime string `xorm:"-" json:"nextRunTime"`
+
 	Adapter *Adapter `xorm:"-" json:"-"`
 }
 
@@ -143,7 +158,7 @@ func UpdateSyncer(id string, syncer *Syncer) bool {
 		return false
 	}
 
-	session := adapter.Engine.ID(core.PK{owner, name}).AllCols()
+	session := adapter.Engine.ID(core.PK{owner, name}).AllCols().Omit("last_run_time")
 	if syncer.Password == "***" {
 		session.Omit("password")
 	}
@@ -196,6 +211,7 @@ func DeleteSyncer(syncer *Syncer) bool {
 
 	if affected == 1 {
 		deleteSyncerJob(syncer)
+		deleteSyncerRuns(syncer)
 	}
 
 	return affected != 0
@@ -221,7 +237,16 @@ func (syncer *Syncer) getTable() string {
 	}
 }
 
-func RunSyncer(syncer *Syncer) {
+// RunSyncer runs the syncer now, it fails if another instance is running the syncer unless it is a dry run
+func RunSyncer(syncer *Syncer, isDryRun bool) (*SyncerRun, error) {
 	syncer.initAdapter()
-	syncer.syncUsers()
+	if isDryRun {
+		return syncer.sync(true), nil
+	}
+
+	var run *SyncerRun
+	if !runWithLease(syncer.getLeaseName(), func() { run = syncer.sync(false) }) {
+		return nil, fmt.Errorf("the syncer: %s is running on %s", syncer.GetId(), GetLeaseHolder(syncer.getLeaseName()))
+	}
+	return run, nil
 }
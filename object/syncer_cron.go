@@ -16,11 +16,31 @@ package object
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/casdoor/casdoor/util"
 	"github.com/robfig/cron/v3"
+	"github.com/xorm-io/core"
 )
 
-var cronMap map[string]*cron.Cron
+// the policies for the runs missed while no instance was running the syncer, they are made up at the start by default
+const (
+	SyncerMisfireBackfill = "Backfill"
+	SyncerMisfireSkip     = "Skip"
+)
+
+// a run is due if the next scheduled time since the last run is within the leeway, as the instances tick at
+// slightly different times
+const syncerScheduleLeeway = 5 * time.Second
+
+// the cron expressions have an optional seconds field, and the descriptors like "@hourly" or "@every 1h" are supported
+var syncerCronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+var (
+	cronMap  map[string]*cron.Cron
+	cronLock sync.Mutex
+)
 
 func init() {
 	cronMap = map[string]*cron.Cron{}
@@ -43,6 +63,77 @@ func clearCron(name string) {
 	}
 }
 
+// GetSchedule returns the schedule of the cron expression, or of the sync interval in seconds if there is no expression
+func (syncer *Syncer) GetSchedule() (cron.Schedule, error) {
+	if syncer.CronExpression != "" {
+		return syncerCronParser.Parse(syncer.CronExpression)
+	}
+	return syncerCronParser.Parse(fmt.Sprintf("@every %ds", syncer.SyncInterval))
+}
+
+func (syncer *Syncer) getLeaseName() string {
+	return fmt.Sprintf("syncer/%s", syncer.GetId())
+}
+
+// getConfigHash returns the hash of the syncer's config, without the fields updated by the runs
+func (syncer *Syncer) getConfigHash() string {
+	s := *syncer
+	s.ErrorText, s.LastRunTime, s.LeaseHolder, s.NextRunTime = "", "", "", ""
+	return util.GetMd5Hash(util.StructToJson(s))
+}
+
+// SetScheduleInfo sets the instance running the syncer and the next scheduled run time
+func (syncer *Syncer) SetScheduleInfo() {
+	syncer.LeaseHolder = GetLeaseHolder(syncer.getLeaseName())
+	if !syncer.IsEnabled {
+		return
+	}
+
+	schedule, err := syncer.GetSchedule()
+	if err == nil {
+		syncer.NextRunTime = schedule.Next(time.Now()).Format(time.RFC3339)
+	}
+}
+
+func isSyncerRunDue(schedule cron.Schedule, lastRunTime string, now time.Time) bool {
+	t, err := time.Parse(time.RFC3339, lastRunTime)
+	if err != nil {
+		return true
+	}
+	return !schedule.Next(t).After(now.Add(syncerScheduleLeeway))
+}
+
+// runScheduled runs the scheduled syncer if a run is due and no other instance is running it
+func (syncer *Syncer) runScheduled(schedule cron.Schedule) {
+	latestSyncer := getSyncer(syncer.Owner, syncer.Name)
+	if latestSyncer == nil || !latestSyncer.IsEnabled {
+		deleteSyncerJob(syncer)
+		return
+	}
+	// the syncer is changed by another instance
+	if latestSyncer.getConfigHash() != syncer.getConfigHash() {
+		addSyncerJob(latestSyncer)
+		return
+	}
+
+	now := time.Now()
+	runWithLease(syncer.getLeaseName(), func() {
+		// the last run time is read with the lease held, as another instance may have just run the syncer
+		latestSyncer = getSyncer(syncer.Owner, syncer.Name)
+		if latestSyncer == nil || !isSyncerRunDue(schedule, latestSyncer.LastRunTime, now) {
+			return
+		}
+
+		syncer.LastRunTime = now.Format(time.RFC3339)
+		_, err := adapter.Engine.ID(core.PK{syncer.Owner, syncer.Name}).Cols("last_run_time").Update(syncer)
+		if err != nil {
+			panic(err)
+		}
+
+		syncer.syncUsers()
+	})
+}
+
 func addSyncerJob(syncer *Syncer) {
 	deleteSyncerJob(syncer)
 
@@ -50,20 +141,28 @@ func addSyncerJob(syncer *Syncer) {
 		return
 	}
 
+	schedule, err := syncer.GetSchedule()
+	if err != nil {
+		updateSyncerErrorText(syncer, fmt.Sprintf("[%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), err.Error()))
+		return
+	}
+
 	syncer.initAdapter()
 
-	syncer.syncUsers()
+	cronLock.Lock()
+	syncerCron := getCronMap(syncer.Name)
+	syncerCron.Schedule(schedule, cron.FuncJob(func() { syncer.runScheduled(schedule) }))
+	syncerCron.Start()
+	cronLock.Unlock()
 
-	schedule := fmt.Sprintf("@every %ds", syncer.SyncInterval)
-	cron := getCronMap(syncer.Name)
-	_, err := cron.AddFunc(schedule, syncer.syncUsers)
-	if err != nil {
-		panic(err)
+	if syncer.MisfirePolicy != SyncerMisfireSkip {
+		util.SafeGoroutine(func() { syncer.runScheduled(schedule) })
 	}
-
-	cron.Start()
 }
 
 func deleteSyncerJob(syncer *Syncer) {
+	cronLock.Lock()
+	defer cronLock.Unlock()
+
 	clearCron(syncer.Name)
 }
@@ -0,0 +1,65 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncerSchedule(t *testing.T) {
+	for _, syncer := range []*Syncer{{CronExpression: "0 2 * * *"}, {CronExpression: "30 0 2 * * *"}, {CronExpression: "@hourly"}, {SyncInterval: 60}} {
+		if _, err := syncer.GetSchedule(); err != nil {
+			t.Errorf("failed to parse the schedule of %v: %s", syncer, err.Error())
+		}
+	}
+	if _, err := (&Syncer{CronExpression: "every day"}).GetSchedule(); err == nil {
+		t.Errorf("the invalid cron expression is parsed")
+	}
+
+	schedule, _ := (&Syncer{CronExpression: "0 2 * * *"}).GetSchedule()
+	now := time.Date(2023, 6, 2, 2, 0, 1, 0, time.Local)
+	scenarios := []struct {
+		lastRunTime string
+		expected    bool
+	}{
+		{"", true},
+		{time.Date(2023, 6, 1, 2, 0, 0, 0, time.Local).Format(time.RFC3339), true},
+		// run by another instance which ticked a moment earlier
+		{time.Date(2023, 6, 2, 2, 0, 0, 0, time.Local).Format(time.RFC3339), false},
+		// a missed run
+		{time.Date(2023, 5, 20, 2, 0, 0, 0, time.Local).Format(time.RFC3339), true},
+	}
+	for _, scenery := range scenarios {
+		if isSyncerRunDue(schedule, scenery.lastRunTime, now) != scenery.expected {
+			t.Errorf("unexpected due of the last run time: %s", scenery.lastRunTime)
+		}
+	}
+	// the instance ticking slightly before the scheduled time
+	if !isSyncerRunDue(schedule, time.Date(2023, 6, 1, 2, 0, 0, 0, time.Local).Format(time.RFC3339), now.Add(-2*time.Second)) {
+		t.Errorf("the run within the leeway is not due")
+	}
+
+	syncer := &Syncer{Name: "syncer", CronExpression: "@hourly"}
+	hash := syncer.getConfigHash()
+	syncer.LastRunTime, syncer.ErrorText = "2023-06-02T02:00:00Z", "error"
+	if syncer.getConfigHash() != hash {
+		t.Errorf("the config hash changes with the run fields")
+	}
+	syncer.CronExpression = "@daily"
+	if syncer.getConfigHash() == hash {
+		t.Errorf("the config hash doesn't change with the schedule")
+	}
+}
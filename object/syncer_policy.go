@@ -0,0 +1,200 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/beego/beego/logs"
+	"github.com/xorm-io/core"
+)
+
+// the policies for the users whose rows are deleted from the syncer's table, they are ignored by default
+const (
+	SyncerDeletionIgnore     = "Ignore"
+	SyncerDeletionDisable    = "Disable"
+	SyncerDeletionSoftDelete = "Soft delete"
+	SyncerDeletionHardDelete = "Hard delete"
+)
+
+// the policies for the users changed on both sides since the last sync, the source wins by default
+const (
+	SyncerConflictSourceWins  = "Source wins"
+	SyncerConflictCasdoorWins = "Casdoor wins"
+	SyncerConflictColumnOwner = "Column owner"
+)
+
+// the owners of a table column in the column owner conflict policy, the source by default
+const (
+	SyncerColumnOwnerSource  = "Source"
+	SyncerColumnOwnerCasdoor = "Casdoor"
+)
+
+// the property marking the users linked to a row of the syncer's table, only they are deleted when the row is gone
+const syncerLinkedProperty = "syncer"
+
+// the property marking the users disabled or soft-deleted by the syncer, they are restored when the row is back
+const syncerDeletedProperty = "syncerDeleted"
+
+func (syncer *Syncer) isLinkedUser(user *User) bool {
+	return user.Properties[syncerLinkedProperty] == syncer.Name
+}
+
+func (syncer *Syncer) setUserColumns(user *User, columns ...string) error {
+	_, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols(columns...).Update(user)
+	return err
+}
+
+// linkUser marks the user as linked to its row, and restores the user if it was disabled or soft-deleted by the syncer
+func (syncer *Syncer) linkUser(run *SyncerRun, user *User) {
+	deletionPolicy := user.Properties[syncerDeletedProperty]
+	if syncer.isLinkedUser(user) && deletionPolicy == "" {
+		return
+	}
+
+	if deletionPolicy == SyncerDeletionDisable {
+		user.IsForbidden = false
+	} else if deletionPolicy == SyncerDeletionSoftDelete {
+		user.IsDeleted = false
+	}
+	if deletionPolicy != "" {
+		run.addDiff(user.Name, SyncerActionRestore, deletionPolicy, nil)
+	}
+
+	if run.IsDryRun {
+		return
+	}
+
+	setUserProperty(user, syncerLinkedProperty, syncer.Name)
+	setUserProperty(user, syncerDeletedProperty, "")
+	err := syncer.setUserColumns(user, "is_forbidden", "is_deleted", "properties")
+	if err != nil {
+		run.addError(err)
+	}
+}
+
+// deleteUser applies the deletion policy to the linked user whose row is deleted from the table
+func (syncer *Syncer) deleteUser(run *SyncerRun, user *User) {
+	if user.Properties[syncerDeletedProperty] != "" {
+		return
+	}
+
+	switch syncer.DeletionPolicy {
+	case SyncerDeletionDisable:
+		// the users disabled in Casdoor are left as they are, so they are not enabled again when restored
+		if user.IsForbidden {
+			return
+		}
+		user.IsForbidden = true
+	case SyncerDeletionSoftDelete:
+		if user.IsDeleted {
+			return
+		}
+		user.IsDeleted = true
+	case SyncerDeletionHardDelete:
+	default:
+		return
+	}
+
+	run.addDiff(user.Name, SyncerActionDelete, syncer.DeletionPolicy, nil)
+	if run.IsDryRun {
+		return
+	}
+
+	if syncer.DeletionPolicy == SyncerDeletionHardDelete {
+		if _, err := DeleteUser(user, "en"); err != nil {
+			run.addError(err)
+		}
+		return
+	}
+
+	setUserProperty(user, syncerDeletedProperty, syncer.DeletionPolicy)
+	err := syncer.setUserColumns(user, "is_forbidden", "is_deleted", "properties")
+	if err != nil {
+		run.addError(err)
+	}
+}
+
+// getMergedUser returns the original user with the values of the columns owned by Casdoor taken from the user
+func (syncer *Syncer) getMergedUser(user *User, oUser *OriginalUser) *OriginalUser {
+	mergedUser := *oUser
+	mergedUser.Properties = map[string]string{}
+	for k, v := range oUser.Properties {
+		mergedUser.Properties[k] = v
+	}
+
+	m := syncer.getMapFromOriginalUser(user)
+	for _, tableColumn := range syncer.TableColumns {
+		if tableColumn.Owner == SyncerColumnOwnerCasdoor {
+			syncer.setUserByKeyValue(&mergedUser, tableColumn.CasdoorName, m[tableColumn.Name])
+		}
+	}
+	return &mergedUser
+}
+
+// resolveConflict resolves the user changed on both sides with the conflict policy, the conflicting fields are logged
+func (syncer *Syncer) resolveConflict(run *SyncerRun, user *User, oUser *OriginalUser, affiliationMap map[int]string) {
+	conflictPolicy := syncer.ConflictPolicy
+	if conflictPolicy == "" {
+		conflictPolicy = SyncerConflictSourceWins
+	}
+
+	// the old values are the ones in Casdoor and the new values the ones in the table
+	fields := syncer.getUserDiff(user, oUser)
+	for _, field := range fields {
+		logs.Warning(fmt.Sprintf("syncer %s: conflict on the field %s of user %s, Casdoor: %s, source: %s, resolved by %s",
+			syncer.GetId(), field.Field, user.Name, field.OldValue, field.NewValue, conflictPolicy))
+	}
+	run.addDiff(user.Name, SyncerActionConflict, conflictPolicy, fields)
+
+	var updatedUser *User
+	if conflictPolicy == SyncerConflictCasdoorWins {
+		casdoorUser := *user
+		updatedUser = &casdoorUser
+	} else if conflictPolicy == SyncerConflictColumnOwner {
+		updatedUser = syncer.createUserFromOriginalUser(syncer.getMergedUser(user, oUser), affiliationMap)
+	} else {
+		updatedUser = syncer.createUserFromOriginalUser(oUser, affiliationMap)
+	}
+	updatedUser.Hash = syncer.calculateHash(updatedUser)
+	updatedUser.PreHash = updatedUser.Hash
+
+	if diff := syncer.getUserDiff(user, updatedUser); len(diff) != 0 {
+		run.addDiff(user.Name, SyncerActionUpdate, conflictPolicy, diff)
+		if !run.IsDryRun {
+			_, err := syncer.updateUserForOriginalFields(updatedUser)
+			if err != nil {
+				run.addError(err)
+			}
+		}
+	} else if !run.IsDryRun {
+		SetUserField(user, "pre_hash", updatedUser.PreHash)
+	}
+
+	if !syncer.isWritableSource() {
+		return
+	}
+
+	updatedOUser := syncer.createOriginalUserFromUser(updatedUser)
+	if diff := syncer.getUserDiff(oUser, updatedOUser); len(diff) != 0 {
+		run.addDiff(user.Name, SyncerActionUpdateOriginal, conflictPolicy, diff)
+		if !run.IsDryRun {
+			_, err := syncer.updateUser(updatedOUser)
+			if err != nil {
+				run.addError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// the actions of a syncer run on a user, the "original" ones are on the syncer's table
+const (
+	SyncerActionAdd            = "Add"
+	SyncerActionUpdate         = "Update"
+	SyncerActionAddOriginal    = "Add original"
+	SyncerActionUpdateOriginal = "Update original"
+	SyncerActionSkip           = "Skip"
+	SyncerActionDelete         = "Delete"
+	SyncerActionRestore        = "Restore"
+	SyncerActionConflict       = "Conflict"
+)
+
+// at most this many user diffs are kept in a run, the counts still cover all the users
+const maxSyncerRunDiffs = 1000
+
+type SyncerFieldDiff struct {
+	Field    string `json:"field"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+}
+
+type SyncerUserDiff struct {
+	User   string             `json:"user"`
+	Action string             `json:"action"`
+	Reason string             `json:"reason"`
+	Fields []*SyncerFieldDiff `json:"fields"`
+}
+
+type SyncerRun struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Syncer    string `xorm:"varchar(100) index" json:"syncer"`
+	StartTime string `xorm:"varchar(100)" json:"startTime"`
+	EndTime   string `xorm:"varchar(100)" json:"endTime"`
+	IsDryRun  bool   `json:"isDryRun"`
+
+	AddedCount           int `json:"addedCount"`
+	UpdatedCount         int `json:"updatedCount"`
+	AddedOriginalCount   int `json:"addedOriginalCount"`
+	UpdatedOriginalCount int `json:"updatedOriginalCount"`
+	SkippedCount         int `json:"skippedCount"`
+	DeletedCount         int `json:"deletedCount"`
+	RestoredCount        int `json:"restoredCount"`
+	ConflictCount        int `json:"conflictCount"`
+
+	Diffs            []*SyncerUserDiff `xorm:"mediumtext" json:"diffs"`
+	IsDiffsTruncated bool              `json:"isDiffsTruncated"`
+	Errors           []string          `xorm:"mediumtext" json:"errors"`
+}
+
+func GetSyncerRunCount(owner, syncer, field, value string) int {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	count, err := session.Count(&SyncerRun{Syncer: syncer})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetSyncerRuns(owner, syncer string) []*SyncerRun {
+	runs := []*SyncerRun{}
+	err := adapter.Engine.Desc("created_time").Omit("diffs").Find(&runs, &SyncerRun{Owner: owner, Syncer: syncer})
+	if err != nil {
+		panic(err)
+	}
+
+	return runs
+}
+
+func GetPaginationSyncerRuns(owner, syncer string, offset, limit int, field, value, sortField, sortOrder string) []*SyncerRun {
+	runs := []*SyncerRun{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Omit("diffs").Find(&runs, &SyncerRun{Syncer: syncer})
+	if err != nil {
+		panic(err)
+	}
+
+	return runs
+}
+
+func getSyncerRun(owner string, name string) *SyncerRun {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	run := SyncerRun{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&run)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &run
+	} else {
+		return nil
+	}
+}
+
+func GetSyncerRun(id string) *SyncerRun {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getSyncerRun(owner, name)
+}
+
+func AddSyncerRun(run *SyncerRun) bool {
+	affected, err := adapter.Engine.Insert(run)
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func DeleteSyncerRun(run *SyncerRun) bool {
+	affected, err := adapter.Engine.ID(core.PK{run.Owner, run.Name}).Delete(&SyncerRun{})
+	if err != nil {
+		panic(err)
+	}
+
+	return affected != 0
+}
+
+func deleteSyncerRuns(syncer *Syncer) {
+	_, err := adapter.Engine.Delete(&SyncerRun{Owner: syncer.Owner, Syncer: syncer.Name})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (run *SyncerRun) GetId() string {
+	return fmt.Sprintf("%s/%s", run.Owner, run.Name)
+}
+
+func newSyncerRun(syncer *Syncer, isDryRun bool) *SyncerRun {
+	currentTime := util.GetCurrentTime()
+	return &SyncerRun{
+		Owner:       syncer.Owner,
+		Name:        util.GenerateId(),
+		CreatedTime: currentTime,
+		Syncer:      syncer.Name,
+		StartTime:   currentTime,
+		IsDryRun:    isDryRun,
+		Diffs:       []*SyncerUserDiff{},
+		Errors:      []string{},
+	}
+}
+
+func (run *SyncerRun) addDiff(user string, action string, reason string, fields []*SyncerFieldDiff) {
+	switch action {
+	case SyncerActionAdd:
+		run.AddedCount++
+	case SyncerActionUpdate:
+		run.UpdatedCount++
+	case SyncerActionAddOriginal:
+		run.AddedOriginalCount++
+	case SyncerActionUpdateOriginal:
+		run.UpdatedOriginalCount++
+	case SyncerActionSkip:
+		run.SkippedCount++
+	case SyncerActionDelete:
+		run.DeletedCount++
+	case SyncerActionRestore:
+		run.RestoredCount++
+	case SyncerActionConflict:
+		run.ConflictCount++
+	}
+
+	if len(run.Diffs) >= maxSyncerRunDiffs {
+		run.IsDiffsTruncated = true
+		return
+	}
+	run.Diffs = append(run.Diffs, &SyncerUserDiff{User: user, Action: action, Reason: reason, Fields: fields})
+}
+
+func (run *SyncerRun) addError(err error) {
+	run.Errors = append(run.Errors, err.Error())
+}
+
+// getUserDiff returns the differences of the synced columns between the users, a nil old user for a new user.
+// The password columns are masked.
+func (syncer *Syncer) getUserDiff(oldUser *User, newUser *User) []*SyncerFieldDiff {
+	oldMap := map[string]string{}
+	if oldUser != nil {
+		oldMap = syncer.getMapFromOriginalUser(oldUser)
+	}
+	newMap := syncer.getMapFromOriginalUser(newUser)
+
+	res := []*SyncerFieldDiff{}
+	for _, tableColumn := range syncer.TableColumns {
+		oldValue, newValue := oldMap[tableColumn.Name], newMap[tableColumn.Name]
+		if oldValue == newValue {
+			continue
+		}
+
+		if tableColumn.CasdoorName == "Password" || tableColumn.CasdoorName == "PasswordSalt" {
+			oldValue, newValue = maskSyncerValue(oldValue), maskSyncerValue(newValue)
+		}
+		res = append(res, &SyncerFieldDiff{Field: tableColumn.CasdoorName, OldValue: oldValue, NewValue: newValue})
+	}
+	return res
+}
+
+func maskSyncerValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "***"
+}
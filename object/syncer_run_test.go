@@ -0,0 +1,100 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetSyncerUserDiff(t *testing.T) {
+	syncer := &Syncer{
+		TableColumns: []*TableColumn{
+			{Name: "user_name", CasdoorName: "Name"},
+			{Name: "mail", CasdoorName: "Email"},
+			{Name: "pwd", CasdoorName: "Password"},
+		},
+	}
+
+	oldUser := &User{Name: "alice", Email: "alice@example.com", Password: "old"}
+	newUser := &User{Name: "alice", Email: "alice@example.org", Password: "new"}
+	expected := []*SyncerFieldDiff{
+		{Field: "Email", OldValue: "alice@example.com", NewValue: "alice@example.org"},
+		{Field: "Password", OldValue: "***", NewValue: "***"},
+	}
+	if diff := syncer.getUserDiff(oldUser, newUser); !reflect.DeepEqual(diff, expected) {
+		t.Errorf("unexpected diff: %v", diff)
+	}
+
+	if diff := syncer.getUserDiff(nil, newUser); len(diff) != 3 || diff[0].OldValue != "" || diff[0].NewValue != "alice" {
+		t.Errorf("unexpected diff of a new user: %v", diff)
+	}
+}
+
+func TestSyncerRunAddDiff(t *testing.T) {
+	run := newSyncerRun(&Syncer{Owner: "admin", Name: "syncer"}, true)
+	for i := 0; i < maxSyncerRunDiffs+1; i++ {
+		run.addDiff("user", SyncerActionAdd, "", nil)
+	}
+	run.addDiff("user", SyncerActionSkip, "conflict", nil)
+
+	if run.AddedCount != maxSyncerRunDiffs+1 || run.SkippedCount != 1 {
+		t.Errorf("unexpected counts: %d added, %d skipped", run.AddedCount, run.SkippedCount)
+	}
+	if len(run.Diffs) != maxSyncerRunDiffs || !run.IsDiffsTruncated {
+		t.Errorf("the diffs are not truncated: %d", len(run.Diffs))
+	}
+}
+
+func TestSyncerConflictColumnOwner(t *testing.T) {
+	syncer := &Syncer{
+		ConflictPolicy: SyncerConflictColumnOwner,
+		TableColumns: []*TableColumn{
+			{Name: "id", CasdoorName: "Id"},
+			{Name: "mail", CasdoorName: "Email", Owner: SyncerColumnOwnerCasdoor},
+			{Name: "phone", CasdoorName: "Phone", Owner: SyncerColumnOwnerSource},
+		},
+	}
+
+	user := &User{Id: "1", Email: "alice@casdoor.org", Phone: "111"}
+	oUser := &OriginalUser{Id: "1", Email: "alice@source.org", Phone: "222"}
+	mergedUser := syncer.getMergedUser(user, oUser)
+	if mergedUser.Email != "alice@casdoor.org" || mergedUser.Phone != "222" || oUser.Email != "alice@source.org" {
+		t.Errorf("unexpected merged user: %s, %s", mergedUser.Email, mergedUser.Phone)
+	}
+}
+
+func TestSyncerDeletionDryRun(t *testing.T) {
+	syncer := &Syncer{Owner: "admin", Name: "syncer", DeletionPolicy: SyncerDeletionDisable}
+	run := newSyncerRun(syncer, true)
+
+	user := &User{Name: "alice", Properties: map[string]string{syncerLinkedProperty: "syncer"}}
+	syncer.deleteUser(run, user)
+	if run.DeletedCount != 1 || !user.IsForbidden {
+		t.Errorf("the user is not disabled: %d deleted", run.DeletedCount)
+	}
+
+	forbiddenUser := &User{Name: "bob", IsForbidden: true, Properties: map[string]string{syncerLinkedProperty: "syncer"}}
+	syncer.deleteUser(run, forbiddenUser)
+	if run.DeletedCount != 1 {
+		t.Errorf("the user disabled in Casdoor is deleted again")
+	}
+
+	deletedUser := &User{Name: "carol", IsForbidden: true, Properties: map[string]string{syncerLinkedProperty: "syncer", syncerDeletedProperty: SyncerDeletionDisable}}
+	syncer.linkUser(run, deletedUser)
+	if run.RestoredCount != 1 || deletedUser.IsForbidden {
+		t.Errorf("the user is not restored: %d restored", run.RestoredCount)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyncerSource is where a syncer reads the original users from. A row maps the table column names of the syncer
+// to their values, the name being a column of a database table, a JSONPath of an HTTP JSON item or a file header.
+type SyncerSource interface {
+	GetRows() ([]map[string]string, error)
+}
+
+// WritableSyncerSource is a source the users added or changed in Casdoor are written back to
+type WritableSyncerSource interface {
+	SyncerSource
+	AddRow(row map[string]string) (bool, error)
+	UpdateRow(row map[string]string) (bool, error)
+}
+
+func (syncer *Syncer) getSource() SyncerSource {
+	switch syncer.Type {
+	case "HTTP JSON":
+		return &HttpSyncerSource{Syncer: syncer}
+	case "File":
+		return &FileSyncerSource{Syncer: syncer}
+	default:
+		return &DatabaseSyncerSource{Syncer: syncer}
+	}
+}
+
+func (syncer *Syncer) isDatabaseSource() bool {
+	_, ok := syncer.getSource().(*DatabaseSyncerSource)
+	return ok
+}
+
+func (syncer *Syncer) isWritableSource() bool {
+	_, ok := syncer.getSource().(WritableSyncerSource)
+	return ok
+}
+
+func (syncer *Syncer) getWritableSource() (WritableSyncerSource, error) {
+	source, ok := syncer.getSource().(WritableSyncerSource)
+	if !ok {
+		return nil, fmt.Errorf("the source of the syncer: %s is read-only", syncer.GetId())
+	}
+	return source, nil
+}
+
+// getColumnNames returns the names a table column reads from the rows, a column like "first_name+last_name" joins
+// the values of several names
+func (tableColumn *TableColumn) getColumnNames() []string {
+	res := []string{}
+	for _, name := range strings.Split(tableColumn.Name, "+") {
+		res = append(res, strings.Trim(name, " "))
+	}
+	return res
+}
+
+// DatabaseSyncerSource is the table of a database, the default source
+type DatabaseSyncerSource struct {
+	Syncer *Syncer
+}
+
+func (source *DatabaseSyncerSource) GetRows() ([]map[string]string, error) {
+	sql := fmt.Sprintf("select * from %s", source.Syncer.getTable())
+	return source.Syncer.Adapter.Engine.QueryString(sql)
+}
+
+func (source *DatabaseSyncerSource) AddRow(row map[string]string) (bool, error) {
+	keyString, valueString := source.Syncer.getSqlKeyValueStringFromMap(row)
+
+	sql := fmt.Sprintf("insert into %s (%s) values (%s)", source.Syncer.getTable(), keyString, valueString)
+	res, err := source.Syncer.Adapter.Engine.Exec(sql)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func (source *DatabaseSyncerSource) UpdateRow(row map[string]string) (bool, error) {
+	pkValue := row[source.Syncer.TablePrimaryKey]
+	delete(row, source.Syncer.TablePrimaryKey)
+	setString := source.Syncer.getSqlSetStringFromMap(row)
+
+	sql := fmt.Sprintf("update %s set %s where %s = %s", source.Syncer.getTable(), setString, source.Syncer.TablePrimaryKey, pkValue)
+	res, err := source.Syncer.Adapter.Engine.Exec(sql)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/casdoor/casdoor/storage"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casdoor/casdoor/xlsx"
+)
+
+// FileSyncerSource is a read-only CSV or XLSX file in a storage provider, the syncer's url being the object key. The
+// first row of the file is the header, and the table column names are the header names.
+type FileSyncerSource struct {
+	Syncer *Syncer
+}
+
+func (source *FileSyncerSource) readFile() ([]byte, error) {
+	// the same check as the uploaded files
+	if strings.Contains(source.Syncer.Url, "..") {
+		return nil, fmt.Errorf("the objectKey: %s is not allowed", source.Syncer.Url)
+	}
+
+	provider := getProvider("admin", source.Syncer.Provider)
+	if provider == nil || provider.Category != "Storage" {
+		return nil, fmt.Errorf("the storage provider: %s doesn't exist", source.Syncer.Provider)
+	}
+
+	storageProvider := storage.GetStorageProvider(provider.Type, provider.ClientId, provider.ClientSecret, provider.RegionId, provider.Bucket, getProviderEndpoint(provider))
+	if storageProvider == nil {
+		return nil, fmt.Errorf("the provider type: %s is not supported", provider.Type)
+	}
+
+	reader, err := storageProvider.GetStream(source.Syncer.Url)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+func (source *FileSyncerSource) GetRows() ([]map[string]string, error) {
+	data, err := source.readFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return getSyncerFileRows(source.Syncer.Url, data)
+}
+
+// getSyncerFileRows parses the CSV or XLSX file by its extension
+func getSyncerFileRows(path string, data []byte) ([]map[string]string, error) {
+	var table [][]string
+	var err error
+	if strings.HasSuffix(strings.ToLower(path), ".xlsx") {
+		table, err = xlsx.ReadXlsxBytes(data)
+	} else {
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		table, err = reader.ReadAll()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return getRowsFromTable(table), nil
+}
+
+// getRowsFromTable maps the rows after the header by the header names, skipping the empty rows
+func getRowsFromTable(table [][]string) []map[string]string {
+	rows := []map[string]string{}
+	if len(table) == 0 {
+		return rows
+	}
+
+	header := table[0]
+	for _, line := range table[1:] {
+		row := map[string]string{}
+		for i, name := range header {
+			if i < len(line) {
+				row[strings.TrimSpace(name)] = strings.TrimSpace(line[i])
+			}
+		}
+
+		if util.ReturnAnyNotEmpty(line...) != "" {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// stops a source whose pages never end, such as one ignoring the page parameter
+const maxSyncerSourcePages = 10000
+
+var syncerHttpClient = &http.Client{Timeout: 60 * time.Second}
+
+// HttpSyncerSource is a read-only HTTP endpoint returning the users as JSON. The syncer's url can contain "{page}"
+// and "{pageSize}" for the page number pagination, or "{cursor}" for the cursor one where the next page path is
+// the JSONPath of the next cursor. Without "{cursor}", the next page path is the JSONPath of the next page's URL.
+// The user list is at the items path, and the table column names are the JSONPaths of the user fields in an item.
+type HttpSyncerSource struct {
+	Syncer *Syncer
+}
+
+func (source *HttpSyncerSource) getJson(pageUrl string) (interface{}, error) {
+	req, err := http.NewRequest("GET", pageUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if source.Syncer.User != "" {
+		req.SetBasicAuth(source.Syncer.User, source.Syncer.Password)
+	} else if source.Syncer.Password != "" {
+		req.Header.Set("Authorization", "Bearer "+source.Syncer.Password)
+	}
+
+	resp, err := syncerHttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the syncer source: %s returns the status: %d", pageUrl, resp.StatusCode)
+	}
+
+	var data interface{}
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (source *HttpSyncerSource) getItems(data interface{}) ([]interface{}, error) {
+	value, ok := util.GetJsonPathValue(data, source.Syncer.ItemsPath)
+	if !ok || value == nil {
+		return []interface{}{}, nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the items path: %s is not an array", source.Syncer.ItemsPath)
+	}
+	return items, nil
+}
+
+func (source *HttpSyncerSource) getRow(item interface{}) map[string]string {
+	row := map[string]string{}
+	for _, tableColumn := range source.Syncer.TableColumns {
+		for _, name := range tableColumn.getColumnNames() {
+			value, _ := util.GetJsonPathValue(item, name)
+			row[name] = util.JsonValueToString(value)
+		}
+	}
+	return row
+}
+
+func (source *HttpSyncerSource) getPageUrl(page int, cursor string) string {
+	res := strings.ReplaceAll(source.Syncer.Url, "{page}", strconv.Itoa(page))
+	res = strings.ReplaceAll(res, "{pageSize}", strconv.Itoa(source.Syncer.PageSize))
+	return strings.ReplaceAll(res, "{cursor}", url.QueryEscape(cursor))
+}
+
+func (source *HttpSyncerSource) GetRows() ([]map[string]string, error) {
+	isPaged := strings.Contains(source.Syncer.Url, "{page}")
+	isCursor := strings.Contains(source.Syncer.Url, "{cursor}")
+
+	rows := []map[string]string{}
+	pageUrl := source.getPageUrl(1, "")
+	for page := 1; page <= maxSyncerSourcePages; page++ {
+		data, err := source.getJson(pageUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := source.getItems(data)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			rows = append(rows, source.getRow(item))
+		}
+
+		if source.Syncer.NextPagePath != "" {
+			value, _ := util.GetJsonPathValue(data, source.Syncer.NextPagePath)
+			next := util.JsonValueToString(value)
+			if next == "" || len(items) == 0 {
+				return rows, nil
+			}
+
+			if isCursor {
+				pageUrl = source.getPageUrl(page+1, next)
+			} else {
+				pageUrl, err = getNextPageUrl(pageUrl, next)
+				if err != nil {
+					return nil, err
+				}
+			}
+		} else if isPaged {
+			if len(items) == 0 || len(items) < source.Syncer.PageSize {
+				return rows, nil
+			}
+			pageUrl = source.getPageUrl(page+1, "")
+		} else {
+			return rows, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the syncer source: %s has more than %d pages", source.Syncer.Url, maxSyncerSourcePages)
+}
+
+// getNextPageUrl resolves the next page's URL, which can be relative to the current one
+func getNextPageUrl(pageUrl string, next string) (string, error) {
+	base, err := url.Parse(pageUrl)
+	if err != nil {
+		return "", err
+	}
+
+	ref, err := url.Parse(next)
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(ref).String(), nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+// newSyncerSourceTestServer serves the user fixture with the page number, next URL and cursor paginations
+func newSyncerSourceTestServer(t *testing.T) *httptest.Server {
+	data, err := os.ReadFile("syncer_source_test_users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users []interface{}
+	err = json.Unmarshal(data, &users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// the page starts from 1, and the cursor is the index of the first user
+		start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		if page, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil {
+			start = (page - 1) * 2
+		}
+		end := start + 2
+		if start > len(users) {
+			start = len(users)
+		}
+		if end > len(users) {
+			end = len(users)
+		}
+
+		resp := map[string]interface{}{"data": map[string]interface{}{"users": users[start:end]}}
+		if end < len(users) {
+			resp["next"] = fmt.Sprintf("?mode=next&cursor=%d", end)
+			resp["cursor"] = strconv.Itoa(end)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func getSyncerSourceTestColumns() []*TableColumn {
+	return []*TableColumn{
+		{Name: "$.id", CasdoorName: "Id"},
+		{Name: "login", CasdoorName: "Name"},
+		{Name: "$.profile.name", CasdoorName: "DisplayName"},
+		{Name: "$.profile['email']", CasdoorName: "Email"},
+	}
+}
+
+func TestHttpSyncerSource(t *testing.T) {
+	server := newSyncerSourceTestServer(t)
+	defer server.Close()
+
+	scenarios := []struct {
+		description  string
+		url          string
+		nextPagePath string
+	}{
+		{"page number", server.URL + "/users?page={page}&pageSize={pageSize}", ""},
+		{"next URL", server.URL + "/users?mode=next", "$.next"},
+		{"cursor", server.URL + "/users?cursor={cursor}", "$.cursor"},
+	}
+	for _, scenery := range scenarios {
+		t.Run(scenery.description, func(t *testing.T) {
+			syncer := &Syncer{
+				Type:         "HTTP JSON",
+				Password:     "token",
+				Url:          scenery.url,
+				ItemsPath:    "$.data.users",
+				NextPagePath: scenery.nextPagePath,
+				PageSize:     2,
+				TableColumns: getSyncerSourceTestColumns(),
+			}
+			if syncer.isWritableSource() {
+				t.Errorf("the HTTP JSON source is writable")
+			}
+
+			rows, err := syncer.getSource().GetRows()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			users := syncer.getOriginalUsersFromMap(rows)
+			if len(users) != 3 {
+				t.Fatalf("unexpected user count: %d", len(users))
+			}
+			if users[0].Id != "1001" || users[0].Name != "alice" || users[0].DisplayName != "Alice Liddell" || users[0].Email != "alice@example.com" {
+				t.Errorf("unexpected user: %v", users[0])
+			}
+			if users[2].Name != "carol" || users[2].Email != "" {
+				t.Errorf("unexpected user: %v", users[2])
+			}
+		})
+	}
+
+	syncer := &Syncer{Type: "HTTP JSON", Url: server.URL, TableColumns: getSyncerSourceTestColumns()}
+	_, err := syncer.getSource().GetRows()
+	if err == nil {
+		t.Errorf("the unauthorized request doesn't fail")
+	}
+}
+
+func TestFileSyncerSource(t *testing.T) {
+	syncer := &Syncer{
+		Type: "File",
+		TableColumns: []*TableColumn{
+			{Name: "id", CasdoorName: "Id"},
+			{Name: "login", CasdoorName: "Name"},
+			{Name: "name", CasdoorName: "DisplayName"},
+			{Name: "email", CasdoorName: "Email"},
+		},
+	}
+	if syncer.isWritableSource() {
+		t.Errorf("the file source is writable")
+	}
+
+	csvData, err := os.ReadFile("syncer_source_test_users.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range [][]string{{"id", "login", "name", "email"}, {"1001", "alice", "Alice Liddell", "alice@example.com"}, {"1002", "bob", "Builder, Bob", "bob@example.com"}, {"1003", "carol", "Carol Danvers"}} {
+		sheet.AddRow().WriteSlice(&line, -1)
+	}
+	var xlsxData bytes.Buffer
+	err = file.Write(&xlsxData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, data := range map[string][]byte{"users.csv": csvData, "users.xlsx": xlsxData.Bytes()} {
+		rows, err := getSyncerFileRows(path, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		users := syncer.getOriginalUsersFromMap(rows)
+		if len(users) != 3 {
+			t.Fatalf("unexpected user count of %s: %d", path, len(users))
+		}
+		if users[0].Id != "1001" || users[0].Email != "alice@example.com" || users[1].DisplayName != "Builder, Bob" {
+			t.Errorf("unexpected users of %s: %v, %v", path, users[0], users[1])
+		}
+		if users[2].Name != "carol" || users[2].Email != "" {
+			t.Errorf("unexpected user of %s: %v", path, users[2])
+		}
+	}
+}
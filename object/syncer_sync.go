@@ -17,83 +17,165 @@ package object
 import (
 	"fmt"
 	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
 )
 
 func (syncer *Syncer) syncUsers() {
-	fmt.Printf("Running syncUsers()..\n")
+	syncer.sync(false)
+}
+
+// sync syncs the users between Casdoor and the syncer's table and records the run, a dry run computes
+// the changes without writing them
+func (syncer *Syncer) sync(isDryRun bool) *SyncerRun {
+	run := newSyncerRun(syncer, isDryRun)
+	syncer.syncWithRun(run)
+	run.EndTime = util.GetCurrentTime()
+
+	logs.Info(fmt.Sprintf("syncer %s: %d added, %d updated, %d original added, %d original updated, %d skipped, %d deleted, %d restored, %d conflicts, %d errors, dry run: %v",
+		syncer.GetId(), run.AddedCount, run.UpdatedCount, run.AddedOriginalCount, run.UpdatedOriginalCount, run.SkippedCount,
+		run.DeletedCount, run.RestoredCount, run.ConflictCount, len(run.Errors), isDryRun))
+
+	if !isDryRun && len(run.Errors) != 0 {
+		timestamp := time.Now().Format("2006-01-02 15:04:05")
+		for _, e := range run.Errors {
+			updateSyncerErrorText(syncer, fmt.Sprintf("[%s] %s\n", timestamp, e))
+		}
+	}
+
+	AddSyncerRun(run)
+	return run
+}
 
+func (syncer *Syncer) syncWithRun(run *SyncerRun) {
 	users, userMap, userNameMap := syncer.getUserMap()
 	oUsers, oUserMap, err := syncer.getOriginalUserMap()
 	if err != nil {
-		fmt.Printf(err.Error())
-
-		timestamp := time.Now().Format("2006-01-02 15:04:05")
-		line := fmt.Sprintf("[%s] %s\n", timestamp, err.Error())
-		updateSyncerErrorText(syncer, line)
+		run.addError(err)
 		return
 	}
 
-	fmt.Printf("Users: %d, oUsers: %d\n", len(users), len(oUsers))
+	isWritable := syncer.isWritableSource()
 
 	var affiliationMap map[int]string
-	if syncer.AffiliationTable != "" {
+	if syncer.AffiliationTable != "" && syncer.isDatabaseSource() {
 		_, affiliationMap = syncer.getAffiliationMap()
 	}
 
+	// updateUserFromOriginal updates the Casdoor user from the original user
+	updateUserFromOriginal := func(user *User, oUser *OriginalUser, oHash string) {
+		updatedUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
+		updatedUser.Hash = oHash
+		updatedUser.PreHash = oHash
+		run.addDiff(user.Name, SyncerActionUpdate, "", syncer.getUserDiff(user, updatedUser))
+		if run.IsDryRun {
+			return
+		}
+
+		_, err := syncer.updateUserForOriginalFields(updatedUser)
+		if err != nil {
+			run.addError(err)
+		}
+	}
+
+	updatePreHash := func(user *User) {
+		if run.IsDryRun {
+			return
+		}
+
+		user.PreHash = user.Hash
+		SetUserField(user, "pre_hash", user.PreHash)
+	}
+
 	newUsers := []*User{}
 	for _, oUser := range oUsers {
 		id := oUser.Id
 		if _, ok := userMap[id]; !ok {
 			if _, ok := userNameMap[oUser.Name]; !ok {
 				newUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
-				fmt.Printf("New user: %v\n", newUser)
+				setUserProperty(newUser, syncerLinkedProperty, syncer.Name)
+				run.addDiff(newUser.Name, SyncerActionAdd, "", syncer.getUserDiff(nil, newUser))
 				newUsers = append(newUsers, newUser)
+			} else {
+				run.addDiff(oUser.Name, SyncerActionSkip, "a user with the same name but a different id exists", nil)
 			}
 		} else {
 			user := userMap[id]
+			syncer.linkUser(run, user)
 			oHash := syncer.calculateHash(oUser)
 
 			if user.Hash == user.PreHash {
 				if user.Hash != oHash {
-					updatedUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
-					updatedUser.Hash = oHash
-					updatedUser.PreHash = oHash
-					syncer.updateUserForOriginalFields(updatedUser)
-					fmt.Printf("Update from oUser to user: %v\n", updatedUser)
+					updateUserFromOriginal(user, oUser, oHash)
+				} else {
+					run.SkippedCount++
 				}
 			} else {
-				if user.PreHash == oHash {
+				if user.PreHash == oHash && !isWritable {
+					// the change in Casdoor is kept as it can't be written back to a read-only source
+					run.SkippedCount++
+				} else if user.PreHash == oHash {
 					updatedOUser := syncer.createOriginalUserFromUser(user)
-					syncer.updateUser(updatedOUser)
-					fmt.Printf("Update from user to oUser: %v\n", updatedOUser)
+					run.addDiff(user.Name, SyncerActionUpdateOriginal, "", syncer.getUserDiff(oUser, updatedOUser))
+					if !run.IsDryRun {
+						_, err := syncer.updateUser(updatedOUser)
+						if err != nil {
+							run.addError(err)
+						}
+					}
 
-					// update preHash
-					user.PreHash = user.Hash
-					SetUserField(user, "pre_hash", user.PreHash)
+					updatePreHash(user)
 				} else {
 					if user.Hash == oHash {
-						// update preHash
-						user.PreHash = user.Hash
-						SetUserField(user, "pre_hash", user.PreHash)
+						run.SkippedCount++
+						updatePreHash(user)
 					} else {
-						updatedUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
-						updatedUser.Hash = oHash
-						updatedUser.PreHash = oHash
-						syncer.updateUserForOriginalFields(updatedUser)
-						fmt.Printf("Update from oUser to user (2nd condition): %v\n", updatedUser)
+						syncer.resolveConflict(run, user, oUser, affiliationMap)
 					}
 				}
 			}
 		}
 	}
-	AddUsersInBatch(newUsers)
+
+	if !run.IsDryRun {
+		AddUsersInBatch(newUsers)
+	}
+
+	// an empty table is more likely a wrong configuration than every row being deleted
+	isDeletionEnabled := syncer.DeletionPolicy != "" && syncer.DeletionPolicy != SyncerDeletionIgnore
+	if isDeletionEnabled && len(oUsers) == 0 {
+		run.addError(fmt.Errorf("the deleted users are not handled as no user is found in the table"))
+		isDeletionEnabled = false
+	}
 
 	for _, user := range users {
 		id := user.Id
 		if _, ok := oUserMap[id]; !ok {
+			// the linked users were synced with a row, which is deleted from the table since then
+			if syncer.isLinkedUser(user) {
+				if isDeletionEnabled {
+					syncer.deleteUser(run, user)
+				}
+				continue
+			}
+
+			if !isWritable {
+				continue
+			}
+
 			newOUser := syncer.createOriginalUserFromUser(user)
-			syncer.addUser(newOUser)
-			fmt.Printf("New oUser: %v\n", newOUser)
+			run.addDiff(user.Name, SyncerActionAddOriginal, "", syncer.getUserDiff(nil, newOUser))
+			if run.IsDryRun {
+				continue
+			}
+
+			_, err := syncer.addUser(newOUser)
+			if err != nil {
+				run.addError(err)
+				continue
+			}
+			syncer.linkUser(run, user)
 		}
 	}
 }
@@ -31,8 +31,7 @@ type Credential struct {
 }
 
 func (syncer *Syncer) getOriginalUsers() ([]*OriginalUser, error) {
-	sql := fmt.Sprintf("select * from %s", syncer.getTable())
-	results, err := syncer.Adapter.Engine.QueryString(sql)
+	results, err := syncer.getSource().GetRows()
 	if err != nil {
 		return nil, err
 	}
@@ -63,21 +62,12 @@ func (syncer *Syncer) getOriginalUserMap() ([]*OriginalUser, map[string]*Origina
 }
 
 func (syncer *Syncer) addUser(user *OriginalUser) (bool, error) {
-	m := syncer.getMapFromOriginalUser(user)
-	keyString, valueString := syncer.getSqlKeyValueStringFromMap(m)
-
-	sql := fmt.Sprintf("insert into %s (%s) values (%s)", syncer.getTable(), keyString, valueString)
-	res, err := syncer.Adapter.Engine.Exec(sql)
+	source, err := syncer.getWritableSource()
 	if err != nil {
 		return false, err
 	}
 
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return false, err
-	}
-
-	return affected != 0, nil
+	return source.AddRow(syncer.getMapFromOriginalUser(user))
 }
 
 /*func (syncer *Syncer) getOriginalColumns() []string {
@@ -92,9 +82,17 @@ func (syncer *Syncer) addUser(user *OriginalUser) (bool, error) {
 
 func (syncer *Syncer) getCasdoorColumns() []string {
 	res := []string{}
+	hasProperties := false
 	for _, tableColumn := range syncer.TableColumns {
 		if tableColumn.CasdoorName != "Id" {
 			v := util.CamelToSnakeCase(tableColumn.CasdoorName)
+			if strings.HasPrefix(tableColumn.CasdoorName, "Properties.") {
+				if hasProperties {
+					continue
+				}
+				v = "properties"
+				hasProperties = true
+			}
 			res = append(res, v)
 		}
 	}
@@ -102,23 +100,12 @@ func (syncer *Syncer) getCasdoorColumns() []string {
 }
 
 func (syncer *Syncer) updateUser(user *OriginalUser) (bool, error) {
-	m := syncer.getMapFromOriginalUser(user)
-	pkValue := m[syncer.TablePrimaryKey]
-	delete(m, syncer.TablePrimaryKey)
-	setString := syncer.getSqlSetStringFromMap(m)
-
-	sql := fmt.Sprintf("update %s set %s where %s = %s", syncer.getTable(), setString, syncer.TablePrimaryKey, pkValue)
-	res, err := syncer.Adapter.Engine.Exec(sql)
+	source, err := syncer.getWritableSource()
 	if err != nil {
 		return false, err
 	}
 
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return false, err
-	}
-
-	return affected != 0, nil
+	return source.UpdateRow(syncer.getMapFromOriginalUser(user))
 }
 
 func (syncer *Syncer) updateUserForOriginalFields(user *User) (bool, error) {
@@ -132,6 +119,18 @@ func (syncer *Syncer) updateUserForOriginalFields(user *User) (bool, error) {
 		user.PermanentAvatar = getPermanentAvatarUrl(user.Owner, user.Name, user.Avatar, true)
 	}
 
+	// the synced custom attributes are merged into the properties kept by Casdoor
+	if len(user.Properties) != 0 {
+		properties := map[string]string{}
+		for k, v := range oldUser.Properties {
+			properties[k] = v
+		}
+		for k, v := range user.Properties {
+			properties[k] = v
+		}
+		user.Properties = properties
+	}
+
 	columns := syncer.getCasdoorColumns()
 	columns = append(columns, "affiliation", "hash", "pre_hash")
 	affected, err := adapter.Engine.ID(core.PK{oldUser.Owner, oldUser.Name}).Cols(columns...).Update(user)
@@ -156,7 +155,7 @@ func (syncer *Syncer) calculateHash(user *OriginalUser) string {
 }
 
 func (syncer *Syncer) initAdapter() {
-	if syncer.Adapter == nil {
+	if syncer.Adapter == nil && syncer.isDatabaseSource() {
 		var dataSourceName string
 		if syncer.DatabaseType == "mssql" {
 			dataSourceName = fmt.Sprintf("sqlserver://%s:%s@%s:%d?database=%s", syncer.User, syncer.Password, syncer.Host, syncer.Port, syncer.Database)
@@ -161,6 +161,14 @@ func (syncer *Syncer) setUserByKeyValue(user *User, key string, value string) {
 		user.IsDeleted = util.ParseBool(value)
 	case "CreatedIp":
 		user.CreatedIp = value
+	default:
+		// custom user attributes are mapped as "Properties.<attribute name>"
+		if strings.HasPrefix(key, "Properties.") {
+			if user.Properties == nil {
+				user.Properties = map[string]string{}
+			}
+			user.Properties[strings.TrimPrefix(key, "Properties.")] = value
+		}
 	}
 }
 
@@ -264,6 +272,9 @@ func (syncer *Syncer) getMapFromOriginalUser(user *OriginalUser) map[string]stri
 	m["IsForbidden"] = util.BoolToString(user.IsForbidden)
 	m["IsDeleted"] = util.BoolToString(user.IsDeleted)
 	m["CreatedIp"] = user.CreatedIp
+	for k, v := range user.Properties {
+		m["Properties."+k] = v
+	}
 
 	m2 := map[string]string{}
 	for _, tableColumn := range syncer.TableColumns {
@@ -60,6 +60,9 @@ type Token struct {
 	CodeChallenge string `xorm:"varchar(100)" json:"codeChallenge"`
 	CodeIsUsed    bool   `json:"codeIsUsed"`
 	CodeExpireIn  int64  `json:"codeExpireIn"`
+
+	// Impersonation is the impersonation the token is minted in, the token is revoked when it ends
+	Impersonation string `xorm:"varchar(100) index" json:"impersonation"`
 }
 
 type TokenWrapper struct {
@@ -91,8 +94,8 @@ type IntrospectionResponse struct {
 	Jti       string   `json:"jti,omitempty"`
 }
 
-func GetTokenCount(owner, organization, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetTokenCount(owner, organization, field, value string, filter *Filter) int {
+	session := applyFilter(GetSession(owner, -1, -1, field, value, "", ""), filter)
 	count, err := session.Count(&Token{Organization: organization})
 	if err != nil {
 		panic(err)
@@ -111,9 +114,9 @@ func GetTokens(owner string, organization string) []*Token {
 	return tokens
 }
 
-func GetPaginationTokens(owner, organization string, offset, limit int, field, value, sortField, sortOrder string) []*Token {
+func GetPaginationTokens(owner, organization string, offset, limit int, field, value, sortField, sortOrder string, filter *Filter) []*Token {
 	tokens := []*Token{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := applyFilter(GetSession(owner, offset, limit, field, value, sortField, sortOrder), filter)
 	err := session.Find(&tokens, &Token{Organization: organization})
 	if err != nil {
 		panic(err)
@@ -263,7 +266,7 @@ func CheckOAuthLogin(clientId string, responseType string, redirectUri string, s
 	return "", application
 }
 
-func GetOAuthCode(userId string, clientId string, responseType string, redirectUri string, scope string, state string, nonce string, challenge string, host string, lang string) *Code {
+func GetOAuthCode(userId string, impersonation *Impersonation, clientId string, responseType string, redirectUri string, scope string, state string, nonce string, challenge string, host string, lang string) *Code {
 	user := GetUser(userId)
 	if user == nil {
 		return &Code{
@@ -287,7 +290,7 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 	}
 
 	ExtendUserWithRolesAndPermissions(user)
-	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, nonce, scope, host)
+	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, impersonation, nonce, scope, host)
 	if err != nil {
 		panic(err)
 	}
@@ -306,13 +309,16 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 		Code:          util.GenerateClientId(),
 		AccessToken:   accessToken,
 		RefreshToken:  refreshToken,
-		ExpiresIn:     application.ExpireInHours * hourSeconds,
+		ExpiresIn:     getTokenExpiresIn(application, impersonation),
 		Scope:         scope,
 		TokenType:     "Bearer",
 		CodeChallenge: challenge,
 		CodeIsUsed:    false,
 		CodeExpireIn:  time.Now().Add(time.Minute * 5).Unix(),
 	}
+	if impersonation != nil {
+		token.Impersonation = impersonation.GetId()
+	}
 	AddToken(token)
 
 	return &Code{
@@ -321,7 +327,7 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 	}
 }
 
-func GetOAuthToken(grantType string, clientId string, clientSecret string, code string, verifier string, scope string, username string, password string, host string, refreshToken string, tag string, avatar string, lang string) interface{} {
+func GetOAuthToken(grantType string, clientId string, clientSecret string, code string, verifier string, scope string, username string, password string, host string, clientIp string, refreshToken string, tag string, avatar string, lang string) interface{} {
 	application := GetApplicationByClientId(clientId)
 	if application == nil {
 		return &TokenError{
@@ -345,7 +351,7 @@ func GetOAuthToken(grantType string, clientId string, clientSecret string, code
 	case "authorization_code": // Authorization Code Grant
 		token, tokenError = GetAuthorizationCodeToken(application, clientSecret, code, verifier)
 	case "password": //	Resource Owner Password Credentials Grant
-		token, tokenError = GetPasswordToken(application, username, password, scope, host)
+		token, tokenError = GetPasswordToken(application, username, password, scope, host, clientIp)
 	case "client_credentials": // Client Credentials Grant
 		token, tokenError = GetClientCredentialsToken(application, clientSecret, scope, host)
 	case "refresh_token":
@@ -415,6 +421,18 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 			ErrorDescription: fmt.Sprintf("parse refresh token error: %s", err.Error()),
 		}
 	}
+	// the tokens minted while impersonating the user can't be refreshed after the impersonation ends
+	var impersonation *Impersonation
+	if token.Impersonation != "" {
+		impersonation = GetImpersonation(token.Impersonation)
+		if impersonation == nil || !impersonation.IsActive() {
+			return &TokenError{
+				Error:            InvalidGrant,
+				ErrorDescription: "the impersonation has ended",
+			}
+		}
+	}
+
 	// generate a new token
 	user := getUser(application.Organization, token.User)
 	if user.IsForbidden {
@@ -425,7 +443,7 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 	}
 
 	ExtendUserWithRolesAndPermissions(user)
-	newAccessToken, newRefreshToken, tokenName, err := generateJwtToken(application, user, "", scope, host)
+	newAccessToken, newRefreshToken, tokenName, err := generateJwtToken(application, user, impersonation, "", scope, host)
 	if err != nil {
 		return &TokenError{
 			Error:            EndpointError,
@@ -443,10 +461,11 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 		Code:         util.GenerateClientId(),
 		AccessToken:  newAccessToken,
 		RefreshToken: newRefreshToken,
-		ExpiresIn:    application.ExpireInHours * hourSeconds,
+		ExpiresIn:    getTokenExpiresIn(application, impersonation),
 		Scope:        scope,
 		TokenType:    "Bearer",
 	}
+	newToken.Impersonation = token.Impersonation
 	AddToken(newToken)
 	DeleteToken(&token)
 
@@ -509,6 +528,13 @@ func GetAuthorizationCodeToken(application *Application, clientSecret string, co
 		}
 	}
 
+	if token.Impersonation != "" && !isImpersonationActive(token.Impersonation) {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the impersonation has ended",
+		}
+	}
+
 	if token.CodeChallenge != "" && pkceChallenge(verifier) != token.CodeChallenge {
 		return nil, &TokenError{
 			Error:            InvalidGrant,
@@ -553,7 +579,7 @@ func GetAuthorizationCodeToken(application *Application, clientSecret string, co
 
 // GetPasswordToken
 // Resource Owner Password Credentials flow
-func GetPasswordToken(application *Application, username string, password string, scope string, host string) (*Token, *TokenError) {
+func GetPasswordToken(application *Application, username string, password string, scope string, host string, clientIp string) (*Token, *TokenError) {
 	user := getUser(application.Organization, username)
 	if user == nil {
 		return nil, &TokenError{
@@ -561,7 +587,7 @@ func GetPasswordToken(application *Application, username string, password string
 			ErrorDescription: "the user does not exist",
 		}
 	}
-	msg := CheckPassword(user, password, "en")
+	msg := CheckPassword(user, password, "en", &SigninAttempt{Application: application, ClientIp: clientIp})
 	if msg != "" {
 		return nil, &TokenError{
 			Error:            InvalidGrant,
@@ -574,9 +600,15 @@ func GetPasswordToken(application *Application, username string, password string
 			ErrorDescription: "the user is forbidden to sign in, please contact the administrator",
 		}
 	}
+	if msg = CheckPasswordExpired(user, "en"); msg != "" {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: msg,
+		}
+	}
 
 	ExtendUserWithRolesAndPermissions(user)
-	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, "", scope, host)
+	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, nil, "", scope, host)
 	if err != nil {
 		return nil, &TokenError{
 			Error:            EndpointError,
@@ -618,7 +650,7 @@ func GetClientCredentialsToken(application *Application, clientSecret string, sc
 		Type:  "application",
 	}
 
-	accessToken, _, tokenName, err := generateJwtToken(application, nullUser, "", scope, host)
+	accessToken, _, tokenName, err := generateJwtToken(application, nullUser, nil, "", scope, host)
 	if err != nil {
 		return nil, &TokenError{
 			Error:            EndpointError,
@@ -643,11 +675,21 @@ func GetClientCredentialsToken(application *Application, clientSecret string, sc
 	return token, nil
 }
 
+// getTokenExpiresIn returns the lifetime in seconds of the access token, shortened to the end of the impersonation
+func getTokenExpiresIn(application *Application, impersonation *Impersonation) int {
+	now := time.Now()
+	expireTime := impersonation.clampExpireTime(now.Add(time.Duration(application.ExpireInHours) * time.Hour))
+	if !expireTime.After(now) {
+		return 0
+	}
+	return int(expireTime.Sub(now).Seconds())
+}
+
 // GetTokenByUser
 // Implicit flow
-func GetTokenByUser(application *Application, user *User, scope string, host string) (*Token, error) {
+func GetTokenByUser(application *Application, user *User, impersonation *Impersonation, scope string, host string) (*Token, error) {
 	ExtendUserWithRolesAndPermissions(user)
-	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, "", scope, host)
+	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, impersonation, "", scope, host)
 	if err != nil {
 		return nil, err
 	}
@@ -661,11 +703,14 @@ func GetTokenByUser(application *Application, user *User, scope string, host str
 		Code:         util.GenerateClientId(),
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-		ExpiresIn:    application.ExpireInHours * hourSeconds,
+		ExpiresIn:    getTokenExpiresIn(application, impersonation),
 		Scope:        scope,
 		TokenType:    "Bearer",
 		CodeIsUsed:   true,
 	}
+	if impersonation != nil {
+		token.Impersonation = impersonation.GetId()
+	}
 	AddToken(token)
 	return token, nil
 }
@@ -734,7 +779,7 @@ func GetWechatMiniProgramToken(application *Application, code string, host strin
 	}
 
 	ExtendUserWithRolesAndPermissions(user)
-	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, "", "", host)
+	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, nil, "", "", host)
 	if err != nil {
 		return nil, &TokenError{
 			Error:            EndpointError,
@@ -24,10 +24,14 @@ import (
 
 type Claims struct {
 	*User
-	TokenType string `json:"tokenType,omitempty"`
-	Nonce     string `json:"nonce,omitempty"`
-	Tag       string `json:"tag,omitempty"`
-	Scope     string `json:"scope,omitempty"`
+	TokenType        string                 `json:"tokenType,omitempty"`
+	Nonce            string                 `json:"nonce,omitempty"`
+	Tag              string                 `json:"tag,omitempty"`
+	Scope            string                 `json:"scope,omitempty"`
+	CustomAttributes map[string]interface{} `json:"customAttributes,omitempty"`
+	Groups           []string               `json:"groups,omitempty"`
+	// Act is the real admin while impersonating the user
+	Act *ActClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -95,15 +99,21 @@ type ClaimsShort struct {
 	TokenType string `json:"tokenType,omitempty"`
 	Nonce     string `json:"nonce,omitempty"`
 	Scope     string `json:"scope,omitempty"`
+	// Act is the real admin while impersonating the user
+	Act *ActClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type ClaimsWithoutThirdIdp struct {
 	*UserWithoutThirdIdp
-	TokenType string `json:"tokenType,omitempty"`
-	Nonce     string `json:"nonce,omitempty"`
-	Tag       string `json:"tag,omitempty"`
-	Scope     string `json:"scope,omitempty"`
+	TokenType        string                 `json:"tokenType,omitempty"`
+	Nonce            string                 `json:"nonce,omitempty"`
+	Tag              string                 `json:"tag,omitempty"`
+	Scope            string                 `json:"scope,omitempty"`
+	CustomAttributes map[string]interface{} `json:"customAttributes,omitempty"`
+	Groups           []string               `json:"groups,omitempty"`
+	// Act is the real admin while impersonating the user
+	Act *ActClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -184,6 +194,7 @@ func getShortClaims(claims Claims) ClaimsShort {
 		TokenType:        claims.TokenType,
 		Nonce:            claims.Nonce,
 		Scope:            claims.Scope,
+		Act:              claims.Act,
 		RegisteredClaims: claims.RegisteredClaims,
 	}
 	return res
@@ -196,6 +207,9 @@ func getClaimsWithoutThirdIdp(claims Claims) ClaimsWithoutThirdIdp {
 		Nonce:               claims.Nonce,
 		Tag:                 claims.Tag,
 		Scope:               claims.Scope,
+		CustomAttributes:    claims.CustomAttributes,
+		Groups:              claims.Groups,
+		Act:                 claims.Act,
 		RegisteredClaims:    claims.RegisteredClaims,
 	}
 	return res
@@ -220,13 +234,15 @@ func refineUser(user *User) *User {
 	return user
 }
 
-func generateJwtToken(application *Application, user *User, nonce string, scope string, host string) (string, string, string, error) {
+func generateJwtToken(application *Application, user *User, impersonation *Impersonation, nonce string, scope string, host string) (string, string, string, error) {
 	nowTime := time.Now()
 	expireTime := nowTime.Add(time.Duration(application.ExpireInHours) * time.Hour)
 	refreshExpireTime := nowTime.Add(time.Duration(application.RefreshExpireInHours) * time.Hour)
 	if application.RefreshExpireInHours == 0 {
 		refreshExpireTime = expireTime
 	}
+	expireTime = impersonation.clampExpireTime(expireTime)
+	refreshExpireTime = impersonation.clampExpireTime(refreshExpireTime)
 
 	user = refineUser(user)
 
@@ -240,8 +256,11 @@ func generateJwtToken(application *Application, user *User, nonce string, scope
 		TokenType: "access-token",
 		Nonce:     nonce,
 		// FIXME: A workaround for custom claim by reusing `tag` in user info
-		Tag:   user.Tag,
-		Scope: scope,
+		Tag:              user.Tag,
+		Scope:            scope,
+		CustomAttributes: GetUserAttributeClaims(GetOrganizationByUser(user), user),
+		// the paths of the groups of the user, e.g. "org/engineering/backend"
+		Groups: GetUserGroupPaths(user),
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    originBackend,
 			Subject:   user.Id,
@@ -253,6 +272,10 @@ func generateJwtToken(application *Application, user *User, nonce string, scope
 		},
 	}
 
+	if impersonation != nil {
+		claims.Act = impersonation.getActClaim()
+	}
+
 	var token *jwt.Token
 	var refreshToken *jwt.Token
 
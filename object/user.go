@@ -74,6 +74,8 @@ type User struct {
 	IsGlobalAdmin     bool     `json:"isGlobalAdmin"`
 	IsForbidden       bool     `json:"isForbidden"`
 	IsDeleted         bool     `json:"isDeleted"`
+	DeletedTime       string   `xorm:"varchar(100)" json:"deletedTime"`
+	IsLegalHold       bool     `json:"isLegalHold"`
 	SignupApplication string   `xorm:"varchar(100)" json:"signupApplication"`
 	Hash              string   `xorm:"varchar(100)" json:"hash"`
 	PreHash           string   `xorm:"varchar(100)" json:"preHash"`
@@ -82,6 +84,9 @@ type User struct {
 	LastSigninTime string `xorm:"varchar(100)" json:"lastSigninTime"`
 	LastSigninIp   string `xorm:"varchar(100)" json:"lastSigninIp"`
 
+	// PasswordChangedTime is when the password was last set, for the maximum password age of the organization
+	PasswordChangedTime string `xorm:"varchar(100)" json:"passwordChangedTime"`
+
 	GitHub          string `xorm:"github varchar(100)" json:"github"`
 	Google          string `xorm:"varchar(100)" json:"google"`
 	QQ              string `xorm:"qq varchar(100)" json:"qq"`
@@ -162,6 +167,14 @@ type User struct {
 
 	Ldap       string            `xorm:"ldap varchar(100)" json:"ldap"`
 	Properties map[string]string `json:"properties"`
+	Groups     []string          `xorm:"mediumtext" json:"groups"`
+
+	// ReleasedIdentifiers are the username, email and phone given up by the soft-deleted user, to restore the user
+	ReleasedIdentifiers map[string]string `xorm:"mediumtext" json:"releasedIdentifiers"`
+	// MergedInto is the surviving user the user has been merged into, the sign-ins of the user are redirected to it
+	MergedInto string `xorm:"varchar(100)" json:"mergedInto"`
+	// PasswordHistory is the previous password hashes of the user, which are never sent to the clients
+	PasswordHistory []*PasswordHistoryItem `xorm:"mediumtext" json:"-"`
 
 	Roles       []*Role       `json:"roles"`
 	Permissions []*Permission `json:"permissions"`
@@ -191,8 +204,8 @@ type ManagedAccount struct {
 	SigninUrl   string `xorm:"varchar(200)" json:"signinUrl"`
 }
 
-func GetGlobalUserCount(field, value string) int {
-	session := GetSession("", -1, -1, field, value, "", "")
+func GetGlobalUserCount(field, value string, filter *Filter) int {
+	session := applyFilter(GetSession("", -1, -1, field, value, "", ""), filter)
 	count, err := session.Count(&User{})
 	if err != nil {
 		panic(err)
@@ -211,9 +224,9 @@ func GetGlobalUsers() []*User {
 	return users
 }
 
-func GetPaginationGlobalUsers(offset, limit int, field, value, sortField, sortOrder string) []*User {
+func GetPaginationGlobalUsers(offset, limit int, field, value, sortField, sortOrder string, filter *Filter) []*User {
 	users := []*User{}
-	session := GetSession("", offset, limit, field, value, sortField, sortOrder)
+	session := applyFilter(GetSession("", offset, limit, field, value, sortField, sortOrder), filter)
 	err := session.Find(&users)
 	if err != nil {
 		panic(err)
@@ -222,8 +235,8 @@ func GetPaginationGlobalUsers(offset, limit int, field, value, sortField, sortOr
 	return users
 }
 
-func GetUserCount(owner, field, value string) int {
-	session := GetSession(owner, -1, -1, field, value, "", "")
+func GetUserCount(owner, field, value string, filter *Filter) int {
+	session := applyFilter(GetSession(owner, -1, -1, field, value, "", ""), filter)
 	count, err := session.Count(&User{})
 	if err != nil {
 		panic(err)
@@ -271,9 +284,9 @@ func GetSortedUsers(owner string, sorter string, limit int) []*User {
 	return users
 }
 
-func GetPaginationUsers(owner string, offset, limit int, field, value, sortField, sortOrder string) []*User {
+func GetPaginationUsers(owner string, offset, limit int, field, value, sortField, sortOrder string, filter *Filter) []*User {
 	users := []*User{}
-	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	session := applyFilter(GetSession(owner, offset, limit, field, value, sortField, sortOrder), filter)
 	err := session.Find(&users)
 	if err != nil {
 		panic(err)
@@ -451,7 +464,7 @@ func UpdateUser(id string, user *User, columns []string, isAdmin bool) bool {
 	}
 
 	if name != user.Name {
-		err := userChangeTrigger(name, user.Name)
+		err := userChangeTrigger(owner, name, user.Name)
 		if err != nil {
 			return false
 		}
@@ -482,7 +495,19 @@ func UpdateUser(id string, user *User, columns []string, isAdmin bool) bool {
 		}
 	}
 	if isAdmin {
-		columns = append(columns, "name", "email", "phone", "country_code")
+		columns = append(columns, "name", "email", "phone", "country_code", "groups", "is_legal_hold")
+	}
+	if !isAdmin {
+		columns = util.DeleteVal(columns, "groups")
+		columns = util.DeleteVal(columns, "is_legal_hold")
+	}
+	if util.InSlice(columns, "is_deleted") && user.IsDeleted != oldUser.IsDeleted {
+		if user.IsDeleted {
+			user.DeletedTime = util.GetCurrentTime()
+		} else {
+			user.DeletedTime = ""
+		}
+		columns = append(columns, "deleted_time")
 	}
 
 	affected, err := adapter.Engine.ID(core.PK{owner, name}).Cols(columns...).Update(user)
@@ -490,6 +515,13 @@ func UpdateUser(id string, user *User, columns []string, isAdmin bool) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionUser(user)
+		if util.InSlice(columns, "groups") && !util.StringSlicesEqual(oldUser.Groups, user.Groups) {
+			refreshGroupPermissions(user.Owner)
+		}
+	}
+
 	return affected != 0
 }
 
@@ -501,7 +533,7 @@ func UpdateUserForAllFields(id string, user *User) bool {
 	}
 
 	if name != user.Name {
-		err := userChangeTrigger(name, user.Name)
+		err := userChangeTrigger(owner, name, user.Name)
 		if err != nil {
 			return false
 		}
@@ -518,6 +550,10 @@ func UpdateUserForAllFields(id string, user *User) bool {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionUser(user)
+	}
+
 	return affected != 0
 }
 
@@ -536,6 +572,10 @@ func AddUser(user *User) bool {
 	}
 
 	user.UpdateUserPassword(organization)
+	if user.Password != "" {
+		user.PasswordChangedTime = util.GetCurrentTime()
+	}
+	setUserAttributeDefaults(organization, user)
 
 	user.UpdateUserHash()
 	user.PreHash = user.Hash
@@ -545,13 +585,20 @@ func AddUser(user *User) bool {
 		user.PermanentAvatar = getPermanentAvatarUrl(user.Owner, user.Name, user.Avatar, false)
 	}
 
-	user.Ranking = GetUserCount(user.Owner, "", "") + 1
+	user.Ranking = GetUserCount(user.Owner, "", "", nil) + 1
 
 	affected, err := adapter.Engine.Insert(user)
 	if err != nil {
 		panic(err)
 	}
 
+	if affected != 0 {
+		provisionUser(user)
+		if len(user.Groups) != 0 {
+			refreshGroupPermissions(user.Owner)
+		}
+	}
+
 	return affected != 0
 }
 
@@ -578,6 +625,10 @@ func AddUsers(users []*User) bool {
 		}
 	}
 
+	if affected != 0 {
+		provisionUsers(users)
+	}
+
 	return affected != 0
 }
 
@@ -607,7 +658,14 @@ func AddUsersInBatch(users []*User) bool {
 	return affected
 }
 
-func DeleteUser(user *User) bool {
+// DeleteUser deletes the user for good, unless the stored user is under legal hold
+func DeleteUser(user *User, lang string) (bool, error) {
+	if oldUser := getUser(user.Owner, user.Name); oldUser != nil {
+		if err := CheckUserLegalHold(oldUser, lang); err != nil {
+			return false, err
+		}
+	}
+
 	// Forced offline the user first
 	DeleteSession(util.GetSessionId(user.Owner, user.Name, CasdoorApplication))
 
@@ -616,7 +674,15 @@ func DeleteUser(user *User) bool {
 		panic(err)
 	}
 
-	return affected != 0
+	if affected != 0 {
+		deleteUserApiKeys(user)
+		provisionUser(user)
+		if len(user.Groups) != 0 {
+			refreshGroupPermissions(user.Owner)
+		}
+	}
+
+	return affected != 0, nil
 }
 
 func GetUserInfo(user *User, scope string, aud string, host string) *Userinfo {
@@ -663,9 +729,45 @@ func ExtendUserWithRolesAndPermissions(user *User) {
 
 	user.Roles = GetRolesByUser(user.GetId())
 	user.Permissions = GetPermissionsByUser(user.GetId())
+
+	// the roles and permissions inherited from the groups of the user
+	for _, role := range getGroupRolesByUser(user) {
+		if !containsRoleId(user.Roles, role.GetId()) {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+	if len(user.Groups) != 0 {
+		tree := NewGroupTree(getEnabledGroups(user.Owner))
+		for _, group := range tree.GetUserGroups(user) {
+			for _, permission := range GetPermissionsByGroup(tree.GetPath(group.Name)) {
+				if !containsPermissionId(user.Permissions, permission.GetId()) {
+					permission.Users = nil
+					user.Permissions = append(user.Permissions, permission)
+				}
+			}
+		}
+	}
+}
+
+func containsRoleId(roles []*Role, roleId string) bool {
+	for _, role := range roles {
+		if role.GetId() == roleId {
+			return true
+		}
+	}
+	return false
 }
 
-func userChangeTrigger(oldName string, newName string) error {
+func containsPermissionId(permissions []*Permission, permissionId string) bool {
+	for _, permission := range permissions {
+		if permission.GetId() == permissionId {
+			return true
+		}
+	}
+	return false
+}
+
+func userChangeTrigger(owner string, oldName string, newName string) error {
 	session := adapter.Engine.NewSession()
 	defer session.Close()
 
@@ -712,6 +814,29 @@ func userChangeTrigger(oldName string, newName string) error {
 		}
 	}
 
+	var groups []*Group
+	err = adapter.Engine.Find(&groups)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		isChanged := false
+		for j, u := range group.Admins {
+			// u = organization/username
+			adminOwner, name := util.GetOwnerAndNameFromId(u)
+			if adminOwner == owner && name == oldName {
+				group.Admins[j] = util.GetId(owner, newName)
+				isChanged = true
+			}
+		}
+		if isChanged {
+			_, err = session.Where("name=?", group.Name).And("owner=?", group.Owner).Cols("admins").Update(group)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	resource := new(Resource)
 	resource.User = newName
 	_, err = session.Where("user=?", oldName).Update(resource)
@@ -719,6 +844,12 @@ func userChangeTrigger(oldName string, newName string) error {
 		return err
 	}
 
+	// the merged users keep signing in as the renamed user
+	_, err = session.Where("merged_into=?", util.GetId(owner, oldName)).Cols("merged_into").Update(&User{MergedInto: util.GetId(owner, newName)})
+	if err != nil {
+		return err
+	}
+
 	return session.Commit()
 }
 
@@ -0,0 +1,395 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	UserAttributeTypeString = "String"
+	UserAttributeTypeNumber = "Number"
+	UserAttributeTypeBool   = "Bool"
+	UserAttributeTypeDate   = "Date"
+	UserAttributeTypeEnum   = "Enum"
+	UserAttributeTypeList   = "List"
+)
+
+// UserAttribute is a typed custom attribute of the users in an organization. The values
+// are kept in user.Properties under the attribute name, and who can view or modify them
+// is decided by the account item with the same name.
+type UserAttribute struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Type        string   `json:"type"`
+	Options     []string `json:"options"`
+	Required    bool     `json:"required"`
+	Regex       string   `json:"regex"`
+	IsUnique    bool     `json:"isUnique"`
+	Default     string   `json:"default"`
+}
+
+func GetUserAttributeByName(name string, organization *Organization) *UserAttribute {
+	if organization == nil {
+		return nil
+	}
+	for _, attribute := range organization.UserAttributes {
+		if attribute.Name == name {
+			return attribute
+		}
+	}
+	return nil
+}
+
+func (attribute *UserAttribute) hasOption(option string) bool {
+	if len(attribute.Options) == 0 {
+		return attribute.Type != UserAttributeTypeEnum
+	}
+
+	for _, o := range attribute.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func (attribute *UserAttribute) matchRegex(value string) bool {
+	if attribute.Regex == "" {
+		return true
+	}
+
+	re, err := regexp.Compile(attribute.Regex)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(value)
+}
+
+// parseValue converts the stored string value into its typed form: float64 for numbers,
+// bool for booleans, []string for lists and string otherwise.
+func (attribute *UserAttribute) parseValue(value string) (interface{}, error) {
+	switch attribute.Type {
+	case UserAttributeTypeString, "":
+		if !attribute.matchRegex(value) {
+			return nil, fmt.Errorf("%s doesn't match %s", value, attribute.Regex)
+		}
+		return value, nil
+	case UserAttributeTypeNumber:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a number", value)
+		}
+		return number, nil
+	case UserAttributeTypeBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a boolean", value)
+		}
+		return b, nil
+	case UserAttributeTypeDate:
+		value = strings.TrimSpace(value)
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			date, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a date", value)
+			}
+		}
+		return date.Format("2006-01-02"), nil
+	case UserAttributeTypeEnum:
+		if !attribute.hasOption(value) {
+			return nil, fmt.Errorf("%s is not one of %s", value, strings.Join(attribute.Options, ", "))
+		}
+		return value, nil
+	case UserAttributeTypeList:
+		items := []string{}
+		if strings.HasPrefix(strings.TrimSpace(value), "[") {
+			err := json.Unmarshal([]byte(value), &items)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a list", value)
+			}
+		} else if value != "" {
+			for _, item := range strings.Split(value, ",") {
+				items = append(items, strings.TrimSpace(item))
+			}
+		}
+
+		for _, item := range items {
+			if !attribute.hasOption(item) {
+				return nil, fmt.Errorf("%s is not one of %s", item, strings.Join(attribute.Options, ", "))
+			}
+			if !attribute.matchRegex(item) {
+				return nil, fmt.Errorf("%s doesn't match %s", item, attribute.Regex)
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown attribute type: %s", attribute.Type)
+	}
+}
+
+// normalizeValue validates the value and returns it in the canonical form it is stored in.
+func (attribute *UserAttribute) normalizeValue(value string) (string, error) {
+	typedValue, err := attribute.parseValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := typedValue.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []string:
+		if len(v) == 0 {
+			return "", nil
+		}
+		return util.StructToJson(v), nil
+	default:
+		return typedValue.(string), nil
+	}
+}
+
+func (attribute *UserAttribute) getAccountItem(organization *Organization) *AccountItem {
+	accountItem := GetAccountItemByName(attribute.Name, organization)
+	if accountItem == nil {
+		accountItem = &AccountItem{Name: attribute.Name, Visible: true, ViewRule: "Public", ModifyRule: "Self"}
+	}
+	return accountItem
+}
+
+// CheckUserAttributeSchema validates the custom user attributes defined by an organization.
+func CheckUserAttributeSchema(organization *Organization, lang string) string {
+	builtInNames := map[string]bool{}
+	for _, accountItem := range getBuiltInAccountItems() {
+		builtInNames[accountItem.Name] = true
+	}
+
+	names := map[string]bool{}
+	for _, attribute := range organization.UserAttributes {
+		if attribute.Name == "" {
+			return i18n.Translate(lang, "organization:The name of a user attribute cannot be empty")
+		}
+		if names[attribute.Name] || builtInNames[attribute.Name] {
+			return fmt.Sprintf(i18n.Translate(lang, "organization:The user attribute %s is duplicated"), attribute.Name)
+		}
+		names[attribute.Name] = true
+
+		switch attribute.Type {
+		case UserAttributeTypeString, UserAttributeTypeNumber, UserAttributeTypeBool, UserAttributeTypeDate, UserAttributeTypeList:
+		case UserAttributeTypeEnum:
+			if len(attribute.Options) == 0 {
+				return fmt.Sprintf(i18n.Translate(lang, "organization:The user attribute %s needs options"), attribute.Name)
+			}
+		default:
+			return fmt.Sprintf(i18n.Translate(lang, "organization:Unknown type %s of user attribute %s"), attribute.Type, attribute.Name)
+		}
+
+		if attribute.Regex != "" {
+			if _, err := regexp.Compile(attribute.Regex); err != nil {
+				return fmt.Sprintf(i18n.Translate(lang, "organization:The regex of user attribute %s is invalid: %s"), attribute.Name, err.Error())
+			}
+		}
+
+		if attribute.Default != "" {
+			if _, err := attribute.normalizeValue(attribute.Default); err != nil {
+				return fmt.Sprintf(i18n.Translate(lang, "organization:The default value of user attribute %s is invalid: %s"), attribute.Name, err.Error())
+			}
+		}
+	}
+
+	return ""
+}
+
+// syncUserAttributeAccountItems makes sure every custom user attribute shows up in the
+// account items, so that its view and modify rules can be configured there.
+func syncUserAttributeAccountItems(organization *Organization) {
+	for _, attribute := range organization.UserAttributes {
+		if GetAccountItemByName(attribute.Name, organization) == nil {
+			organization.AccountItems = append(organization.AccountItems, attribute.getAccountItem(organization))
+		}
+	}
+}
+
+func hasUserByAttribute(user *User, name string, value string) bool {
+	pattern := fmt.Sprintf("%%%s:%s%%", util.StructToJson(name), util.StructToJson(value))
+
+	users := []*User{}
+	err := adapter.Engine.Where("owner = ? and name != ? and properties like ?", user.Owner, user.Name, pattern).Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, u := range users {
+		if u.Properties[name] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func setUserAttributeDefaults(organization *Organization, user *User) {
+	for _, attribute := range organization.UserAttributes {
+		if attribute.Default == "" || user.Properties[attribute.Name] != "" {
+			continue
+		}
+
+		value, err := attribute.normalizeValue(attribute.Default)
+		if err != nil {
+			continue
+		}
+
+		if user.Properties == nil {
+			user.Properties = map[string]string{}
+		}
+		user.Properties[attribute.Name] = value
+	}
+}
+
+// CheckUserAttributes validates the custom attribute values of the user against the
+// organization schema, filling in defaults and storing every value in canonical form.
+func CheckUserAttributes(organization *Organization, user *User, lang string) string {
+	if organization == nil || len(organization.UserAttributes) == 0 {
+		return ""
+	}
+
+	if user.Properties == nil {
+		user.Properties = map[string]string{}
+	}
+
+	for _, attribute := range organization.UserAttributes {
+		value := user.Properties[attribute.Name]
+		if value == "" {
+			value = attribute.Default
+		}
+
+		if value != "" {
+			normalizedValue, err := attribute.normalizeValue(value)
+			if err != nil {
+				return fmt.Sprintf(i18n.Translate(lang, "user:The value of %s is invalid: %s"), attribute.Name, err.Error())
+			}
+			value = normalizedValue
+		}
+
+		if value == "" {
+			if attribute.Required {
+				return fmt.Sprintf(i18n.Translate(lang, "user:%s cannot be empty"), attribute.Name)
+			}
+			delete(user.Properties, attribute.Name)
+			continue
+		}
+		user.Properties[attribute.Name] = value
+
+		if attribute.IsUnique && hasUserByAttribute(user, attribute.Name, value) {
+			return fmt.Sprintf(i18n.Translate(lang, "user:%s already exists"), attribute.Name)
+		}
+	}
+
+	return ""
+}
+
+// getUserAttributeChanges returns the account items of the custom attributes whose values
+// differ between the two users, and whether any other property has changed.
+func getUserAttributeChanges(organization *Organization, oldUser, newUser *User) ([]*AccountItem, bool) {
+	itemsChanged := []*AccountItem{}
+	oldProperties := map[string]string{}
+	newProperties := map[string]string{}
+	for k, v := range oldUser.Properties {
+		oldProperties[k] = v
+	}
+	for k, v := range newUser.Properties {
+		newProperties[k] = v
+	}
+
+	if organization != nil {
+		for _, attribute := range organization.UserAttributes {
+			if oldProperties[attribute.Name] != newProperties[attribute.Name] {
+				itemsChanged = append(itemsChanged, attribute.getAccountItem(organization))
+			}
+			delete(oldProperties, attribute.Name)
+			delete(newProperties, attribute.Name)
+		}
+	}
+
+	oldPropertiesJson, _ := json.Marshal(oldProperties)
+	newPropertiesJson, _ := json.Marshal(newProperties)
+	return itemsChanged, string(oldPropertiesJson) != string(newPropertiesJson)
+}
+
+func canViewUserAttribute(accountItem *AccountItem, isSelf bool, isAdmin bool) bool {
+	switch accountItem.ViewRule {
+	case "Admin":
+		return isAdmin
+	case "Self":
+		return isSelf || isAdmin
+	default:
+		return true
+	}
+}
+
+// MaskUserAttributes removes the custom attribute values that the requester is not
+// allowed to view according to the account item view rules.
+func MaskUserAttributes(user *User, isSelf bool, isAdmin bool) *User {
+	if user == nil || len(user.Properties) == 0 {
+		return user
+	}
+
+	organization := GetOrganizationByUser(user)
+	if organization == nil {
+		return user
+	}
+
+	for _, attribute := range organization.UserAttributes {
+		if !canViewUserAttribute(attribute.getAccountItem(organization), isSelf, isAdmin) {
+			delete(user.Properties, attribute.Name)
+		}
+	}
+	return user
+}
+
+// GetUserAttributeClaims returns the typed custom attribute values of the user that are
+// visible to the user, for use as token claims.
+func GetUserAttributeClaims(organization *Organization, user *User) map[string]interface{} {
+	if organization == nil || len(organization.UserAttributes) == 0 {
+		return nil
+	}
+
+	res := map[string]interface{}{}
+	for _, attribute := range organization.UserAttributes {
+		value, ok := user.Properties[attribute.Name]
+		if !ok || value == "" {
+			continue
+		}
+		if !canViewUserAttribute(attribute.getAccountItem(organization), true, false) {
+			continue
+		}
+
+		typedValue, err := attribute.parseValue(value)
+		if err != nil {
+			continue
+		}
+		res[attribute.Name] = typedValue
+	}
+	return res
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeUserAttributeValue(t *testing.T) {
+	cases := []struct {
+		attribute *UserAttribute
+		value     string
+		expected  string
+		isValid   bool
+	}{
+		{&UserAttribute{Type: UserAttributeTypeString, Regex: "^E[0-9]+$"}, "E123", "E123", true},
+		{&UserAttribute{Type: UserAttributeTypeString, Regex: "^E[0-9]+$"}, "123", "", false},
+		{&UserAttribute{Type: UserAttributeTypeNumber}, " 1.50 ", "1.5", true},
+		{&UserAttribute{Type: UserAttributeTypeNumber}, "abc", "", false},
+		{&UserAttribute{Type: UserAttributeTypeBool}, "1", "true", true},
+		{&UserAttribute{Type: UserAttributeTypeBool}, "yes", "", false},
+		{&UserAttribute{Type: UserAttributeTypeDate}, "2023-05-01T10:00:00Z", "2023-05-01", true},
+		{&UserAttribute{Type: UserAttributeTypeDate}, "05/01/2023", "", false},
+		{&UserAttribute{Type: UserAttributeTypeEnum, Options: []string{"Sales", "R&D"}}, "R&D", "R&D", true},
+		{&UserAttribute{Type: UserAttributeTypeEnum, Options: []string{"Sales", "R&D"}}, "HR", "", false},
+		{&UserAttribute{Type: UserAttributeTypeList}, "a, b", `["a","b"]`, true},
+		{&UserAttribute{Type: UserAttributeTypeList, Options: []string{"a", "b"}}, `["b"]`, `["b"]`, true},
+		{&UserAttribute{Type: UserAttributeTypeList, Options: []string{"a", "b"}}, "a,c", "", false},
+		{&UserAttribute{Type: UserAttributeTypeList}, "[]", "", true},
+	}
+
+	for _, c := range cases {
+		value, err := c.attribute.normalizeValue(c.value)
+		if (err == nil) != c.isValid {
+			t.Errorf("normalizeValue(%s, %q) error = %v, want valid = %v", c.attribute.Type, c.value, err, c.isValid)
+			continue
+		}
+		if value != c.expected {
+			t.Errorf("normalizeValue(%s, %q) = %q, want %q", c.attribute.Type, c.value, value, c.expected)
+		}
+	}
+}
+
+func TestCheckUserAttributeSchema(t *testing.T) {
+	cases := []struct {
+		attributes []*UserAttribute
+		isValid    bool
+	}{
+		{[]*UserAttribute{{Name: "Department", Type: UserAttributeTypeEnum, Options: []string{"Sales"}, Default: "Sales"}}, true},
+		{[]*UserAttribute{{Name: "Department", Type: UserAttributeTypeEnum}}, false},
+		{[]*UserAttribute{{Name: "Department", Type: UserAttributeTypeEnum, Options: []string{"Sales"}, Default: "HR"}}, false},
+		{[]*UserAttribute{{Name: "Email", Type: UserAttributeTypeString}}, false},
+		{[]*UserAttribute{{Name: "Level", Type: UserAttributeTypeNumber}, {Name: "Level", Type: UserAttributeTypeNumber}}, false},
+		{[]*UserAttribute{{Name: "Code", Type: UserAttributeTypeString, Regex: "("}}, false},
+		{[]*UserAttribute{{Name: "Code", Type: "Binary"}}, false},
+	}
+
+	for i, c := range cases {
+		msg := CheckUserAttributeSchema(&Organization{UserAttributes: c.attributes}, "en")
+		if (msg == "") != c.isValid {
+			t.Errorf("case %d: CheckUserAttributeSchema() = %q, want valid = %v", i, msg, c.isValid)
+		}
+	}
+}
+
+func TestGetUserAttributeChanges(t *testing.T) {
+	organization := &Organization{
+		AccountItems: []*AccountItem{{Name: "Level", Visible: true, ViewRule: "Admin", ModifyRule: "Admin"}},
+		UserAttributes: []*UserAttribute{
+			{Name: "Level", Type: UserAttributeTypeNumber},
+			{Name: "Nickname", Type: UserAttributeTypeString},
+		},
+	}
+
+	oldUser := &User{Properties: map[string]string{"Level": "1", "Nickname": "a", "oauth_GitHub_id": "1"}}
+	newUser := &User{Properties: map[string]string{"Level": "2", "Nickname": "a", "oauth_GitHub_id": "1"}}
+	items, propertiesChanged := getUserAttributeChanges(organization, oldUser, newUser)
+	if propertiesChanged {
+		t.Errorf("propertiesChanged = true, want false")
+	}
+	if len(items) != 1 || items[0].Name != "Level" || items[0].ModifyRule != "Admin" {
+		t.Errorf("items = %v, want the Level account item", items)
+	}
+
+	newUser = &User{Properties: map[string]string{"Level": "1", "Nickname": "b"}}
+	items, propertiesChanged = getUserAttributeChanges(organization, oldUser, newUser)
+	if !propertiesChanged {
+		t.Errorf("propertiesChanged = false, want true")
+	}
+	if len(items) != 1 || items[0].Name != "Nickname" || items[0].ModifyRule != "Self" {
+		t.Errorf("items = %v, want the default Nickname account item", items)
+	}
+}
+
+func TestGetUserAttributeClaims(t *testing.T) {
+	organization := &Organization{
+		AccountItems: []*AccountItem{{Name: "Salary", Visible: true, ViewRule: "Admin", ModifyRule: "Admin"}},
+		UserAttributes: []*UserAttribute{
+			{Name: "Level", Type: UserAttributeTypeNumber},
+			{Name: "Active", Type: UserAttributeTypeBool},
+			{Name: "Skills", Type: UserAttributeTypeList},
+			{Name: "Salary", Type: UserAttributeTypeNumber},
+		},
+	}
+	user := &User{Properties: map[string]string{"Level": "3", "Active": "true", "Skills": `["go","sql"]`, "Salary": "100"}}
+
+	claims := GetUserAttributeClaims(organization, user)
+	expected := map[string]interface{}{"Level": float64(3), "Active": true, "Skills": []string{"go", "sql"}}
+	if !reflect.DeepEqual(claims, expected) {
+		t.Errorf("GetUserAttributeClaims() = %v, want %v", claims, expected)
+	}
+}
@@ -14,8 +14,6 @@
 
 package object
 
-import "github.com/casdoor/casdoor/cred"
-
 func calculateHash(user *User) string {
 	syncer := getDbSyncerForUser(user)
 	if syncer == nil {
@@ -31,7 +29,7 @@ func (user *User) UpdateUserHash() {
 }
 
 func (user *User) UpdateUserPassword(organization *Organization) {
-	credManager := cred.GetCredManager(organization.PasswordType)
+	credManager := organization.getCredManager()
 	if credManager != nil {
 		hashedPassword := credManager.GetHashedPassword(user.Password, user.PasswordSalt, organization.PasswordSalt)
 		user.Password = hashedPassword
@@ -0,0 +1,469 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	DataRequestTypeExport  = "Export"
+	DataRequestTypeErasure = "Erasure"
+
+	// ErasureModeAnonymize keeps the data related to the user under a pseudonym, ErasureModeDelete deletes it
+	ErasureModeAnonymize = "Anonymize"
+	ErasureModeDelete    = "Delete"
+
+	DataRequestStateCompleted = "Completed"
+	DataRequestStateRejected  = "Rejected"
+
+	DataActionExported    = "Exported"
+	DataActionDeleted     = "Deleted"
+	DataActionAnonymized  = "Anonymized"
+	DataActionSoftDeleted = "Soft deleted"
+)
+
+type DataRequestAction struct {
+	Table   string `json:"table"`
+	Action  string `json:"action"`
+	Count   int    `json:"count"`
+	Message string `json:"message,omitempty"`
+}
+
+// DataRequest is the audit record of a data subject request, i.e. an export or an erasure of the data
+// related to a user. The user is referred to by its immutable id as the user name may be anonymized.
+type DataRequest struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	UserId    string               `xorm:"varchar(100) index" json:"userId"`
+	Type      string               `xorm:"varchar(100)" json:"type"`
+	Mode      string               `xorm:"varchar(100)" json:"mode"`
+	Requester string               `xorm:"varchar(100)" json:"requester"`
+	Reason    string               `xorm:"varchar(1000)" json:"reason"`
+	State     string               `xorm:"varchar(100)" json:"state"`
+	Message   string               `xorm:"varchar(1000)" json:"message"`
+	Actions   []*DataRequestAction `xorm:"mediumtext" json:"actions"`
+}
+
+// UserData bundles all the data related to a user, the credentials like passwords and tokens are left out
+type UserData struct {
+	User                *User                 `json:"user"`
+	Tokens              []*Token              `json:"tokens"`
+	Records             []*Record             `json:"records"`
+	Resources           []*Resource           `json:"resources"`
+	Payments            []*Payment            `json:"payments"`
+	Subscriptions       []*Subscription       `json:"subscriptions"`
+	Sessions            []*Session            `json:"sessions"`
+	VerificationRecords []*VerificationRecord `json:"verificationRecords"`
+}
+
+func GetDataRequestCount(owner, field, value string) int {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	count, err := session.Count(&DataRequest{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetDataRequests(owner string) []*DataRequest {
+	dataRequests := []*DataRequest{}
+	err := adapter.Engine.Desc("created_time").Find(&dataRequests, &DataRequest{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return dataRequests
+}
+
+func GetPaginationDataRequests(owner string, offset, limit int, field, value, sortField, sortOrder string) []*DataRequest {
+	dataRequests := []*DataRequest{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&dataRequests)
+	if err != nil {
+		panic(err)
+	}
+
+	return dataRequests
+}
+
+func getDataRequest(owner string, name string) *DataRequest {
+	if owner == "" || name == "" {
+		return nil
+	}
+
+	dataRequest := DataRequest{Owner: owner, Name: name}
+	existed, err := adapter.Engine.Get(&dataRequest)
+	if err != nil {
+		panic(err)
+	}
+
+	if existed {
+		return &dataRequest
+	} else {
+		return nil
+	}
+}
+
+func GetDataRequest(id string) *DataRequest {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getDataRequest(owner, name)
+}
+
+func addDataRequest(dataRequest *DataRequest) {
+	_, err := adapter.Engine.Insert(dataRequest)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newDataRequest(user *User, requestType string, requester string, reason string) *DataRequest {
+	return &DataRequest{
+		Owner:       user.Owner,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		UserId:      user.Id,
+		Type:        requestType,
+		Requester:   requester,
+		Reason:      reason,
+		State:       DataRequestStateCompleted,
+		Actions:     []*DataRequestAction{},
+	}
+}
+
+func (dataRequest *DataRequest) GetId() string {
+	return fmt.Sprintf("%s/%s", dataRequest.Owner, dataRequest.Name)
+}
+
+func (dataRequest *DataRequest) addAction(table string, action string, count int, err error) {
+	if count == 0 && err == nil {
+		return
+	}
+
+	item := &DataRequestAction{Table: table, Action: action, Count: count}
+	if err != nil {
+		item.Message = err.Error()
+	}
+	dataRequest.Actions = append(dataRequest.Actions, item)
+}
+
+// GetUserData returns all the data related to the user
+func GetUserData(user *User) *UserData {
+	userId := user.GetId()
+	data := &UserData{
+		Tokens:              []*Token{},
+		Records:             []*Record{},
+		Resources:           []*Resource{},
+		Payments:            []*Payment{},
+		Subscriptions:       []*Subscription{},
+		Sessions:            []*Session{},
+		VerificationRecords: []*VerificationRecord{},
+	}
+
+	exportedUser := *user
+	exportedUser.Password = ""
+	exportedUser.PasswordSalt = ""
+	exportedUser.Hash = ""
+	exportedUser.PreHash = ""
+	data.User = &exportedUser
+
+	err := adapter.Engine.Find(&data.Tokens, &Token{Organization: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+	for _, token := range data.Tokens {
+		token.Code = ""
+		token.AccessToken = ""
+		token.RefreshToken = ""
+	}
+
+	err = adapter.Engine.Find(&data.Records, &Record{Organization: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	err = adapter.Engine.Find(&data.Resources, &Resource{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	err = adapter.Engine.Find(&data.Payments, &Payment{Organization: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	err = adapter.Engine.Find(&data.Subscriptions, &Subscription{Owner: user.Owner, User: userId})
+	if err != nil {
+		panic(err)
+	}
+
+	err = adapter.Engine.Find(&data.Sessions, &Session{Owner: user.Owner, Name: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	err = adapter.Engine.Find(&data.VerificationRecords, &VerificationRecord{User: userId})
+	if err != nil {
+		panic(err)
+	}
+	for _, record := range data.VerificationRecords {
+		record.Code = ""
+	}
+
+	return data
+}
+
+// WriteUserDataArchive writes the data as a zip archive with a JSON file per table
+func WriteUserDataArchive(w io.Writer, data *UserData) error {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"user.json", data.User},
+		{"webauthn_credentials.json", data.User.WebauthnCredentials},
+		{"tokens.json", data.Tokens},
+		{"records.json", data.Records},
+		{"resources.json", data.Resources},
+		{"payments.json", data.Payments},
+		{"subscriptions.json", data.Subscriptions},
+		{"sessions.json", data.Sessions},
+		{"verification_records.json", data.VerificationRecords},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fw, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err = fw.Write(content); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// ExportUserData returns the zip archive of the data related to the user, and records the export
+func ExportUserData(user *User, requester string) ([]byte, error) {
+	data := GetUserData(user)
+
+	var buf bytes.Buffer
+	if err := WriteUserDataArchive(&buf, data); err != nil {
+		return nil, err
+	}
+
+	dataRequest := newDataRequest(user, DataRequestTypeExport, requester, "")
+	dataRequest.addAction("user", DataActionExported, 1, nil)
+	dataRequest.addAction("token", DataActionExported, len(data.Tokens), nil)
+	dataRequest.addAction("record", DataActionExported, len(data.Records), nil)
+	dataRequest.addAction("resource", DataActionExported, len(data.Resources), nil)
+	dataRequest.addAction("payment", DataActionExported, len(data.Payments), nil)
+	dataRequest.addAction("subscription", DataActionExported, len(data.Subscriptions), nil)
+	dataRequest.addAction("session", DataActionExported, len(data.Sessions), nil)
+	dataRequest.addAction("verification_record", DataActionExported, len(data.VerificationRecords), nil)
+	addDataRequest(dataRequest)
+
+	return buf.Bytes(), nil
+}
+
+// getErasedUserName returns the pseudonym of the erased user, derived from its immutable id
+func getErasedUserName(user *User) string {
+	return fmt.Sprintf("erased-%s", util.GetSha256Hash(user.Id)[:16])
+}
+
+// getErasedUser returns the user without any personal data, which is forbidden to sign in
+func getErasedUser(user *User, name string) *User {
+	return &User{
+		Owner:             user.Owner,
+		Name:              name,
+		CreatedTime:       user.CreatedTime,
+		UpdatedTime:       util.GetCurrentTime(),
+		Id:                user.Id,
+		Type:              user.Type,
+		DisplayName:       name,
+		Address:           []string{},
+		Properties:        map[string]string{},
+		IsForbidden:       true,
+		IsDeleted:         true,
+		DeletedTime:       util.GetCurrentTime(),
+		SignupApplication: user.SignupApplication,
+	}
+}
+
+// deleteUserSessions forces the user offline and deletes all the sessions of the user
+func deleteUserSessions(user *User) int {
+	DeleteSession(util.GetSessionId(user.Owner, user.Name, CasdoorApplication))
+
+	affected, err := adapter.Engine.Delete(&Session{Owner: user.Owner, Name: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(affected)
+}
+
+func deleteUserTokens(user *User) int {
+	affected, err := adapter.Engine.Delete(&Token{Organization: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(affected)
+}
+
+func deleteUserVerificationRecords(user *User) int {
+	affected, err := adapter.Engine.Delete(&VerificationRecord{User: user.GetId()})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(affected)
+}
+
+// deleteUserResources deletes the resources of the user with their files, the files failed to be deleted
+// don't stop the deletion of the other resources and the last error is returned
+func deleteUserResources(user *User, lang string) (int, error) {
+	resources := []*Resource{}
+	err := adapter.Engine.Find(&resources, &Resource{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		panic(err)
+	}
+
+	var fileErr error
+	for _, resource := range resources {
+		if err = deleteUserResourceFile(resource, lang); err != nil {
+			fileErr = err
+		}
+		DeleteResource(resource)
+	}
+
+	return len(resources), fileErr
+}
+
+func deleteUserResourceFile(resource *Resource, lang string) error {
+	provider := GetProvider(util.GetId("admin", resource.Provider))
+	if provider == nil {
+		provider = GetProvider(util.GetId(resource.Owner, resource.Provider))
+	}
+	if provider == nil {
+		return fmt.Errorf(i18n.Translate(lang, "general:The provider: %s does not exist"), resource.Provider)
+	}
+
+	return DeleteFile(provider, resource.Name, lang)
+}
+
+// EraseUserData anonymizes or deletes the data related to the user, unless the user is under legal hold.
+// The tokens, sessions and verification codes are always deleted, the payments and subscriptions are kept
+// for accounting under the pseudonym of the user. The user itself is deleted in the delete mode if the
+// organization doesn't enable soft deletion, otherwise it is kept without any personal data under the pseudonym.
+func EraseUserData(user *User, mode string, requester string, reason string, lang string) (*DataRequest, error) {
+	if mode != ErasureModeAnonymize && mode != ErasureModeDelete {
+		return nil, fmt.Errorf(i18n.Translate(lang, "user:The erasure mode: %s is not supported"), mode)
+	}
+
+	dataRequest := newDataRequest(user, DataRequestTypeErasure, requester, reason)
+	dataRequest.Mode = mode
+
+	if err := CheckUserLegalHold(user, lang); err != nil {
+		dataRequest.State = DataRequestStateRejected
+		dataRequest.Message = err.Error()
+		addDataRequest(dataRequest)
+		return dataRequest, err
+	}
+
+	organization := GetOrganizationByUser(user)
+	isSoftDeletion := organization != nil && organization.EnableSoftDeletion
+	userId := user.GetId()
+	erasedName := getErasedUserName(user)
+
+	dataRequest.addAction("session", DataActionDeleted, deleteUserSessions(user), nil)
+	dataRequest.addAction("token", DataActionDeleted, deleteUserTokens(user), nil)
+	dataRequest.addAction("api_key", DataActionDeleted, deleteUserApiKeys(user), nil)
+	dataRequest.addAction("verification_record", DataActionDeleted, deleteUserVerificationRecords(user), nil)
+
+	if mode == ErasureModeDelete {
+		affected, err := adapter.Engine.Delete(&Record{Organization: user.Owner, User: user.Name})
+		if err != nil {
+			panic(err)
+		}
+		dataRequest.addAction("record", DataActionDeleted, int(affected), nil)
+
+		count, err := deleteUserResources(user, lang)
+		dataRequest.addAction("resource", DataActionDeleted, count, err)
+	} else {
+		affected, err := adapter.Engine.Where("organization = ? and user = ?", user.Owner, user.Name).
+			Cols("user", "client_ip").Update(&Record{User: erasedName})
+		if err != nil {
+			panic(err)
+		}
+		dataRequest.addAction("record", DataActionAnonymized, int(affected), nil)
+
+		affected, err = adapter.Engine.Where("owner = ? and user = ?", user.Owner, user.Name).
+			Cols("user").Update(&Resource{User: erasedName})
+		if err != nil {
+			panic(err)
+		}
+		dataRequest.addAction("resource", DataActionAnonymized, int(affected), nil)
+	}
+
+	affected, err := adapter.Engine.Where("organization = ? and user = ?", user.Owner, user.Name).
+		Cols("user", "person_name", "person_id_card", "person_email", "person_phone").Update(&Payment{User: erasedName})
+	if err != nil {
+		panic(err)
+	}
+	dataRequest.addAction("payment", DataActionAnonymized, int(affected), nil)
+
+	affected, err = adapter.Engine.Where("owner = ? and user = ?", user.Owner, userId).
+		Cols("user").Update(&Subscription{User: util.GetId(user.Owner, erasedName)})
+	if err != nil {
+		panic(err)
+	}
+	dataRequest.addAction("subscription", DataActionAnonymized, int(affected), nil)
+
+	if mode == ErasureModeDelete && !isSoftDeletion {
+		affected, err := DeleteUser(user, lang)
+		count := 0
+		if affected {
+			count = 1
+		}
+		dataRequest.addAction("user", DataActionDeleted, count, err)
+	} else {
+		UpdateUserForAllFields(userId, getErasedUser(user, erasedName))
+		if mode == ErasureModeDelete {
+			dataRequest.addAction("user", DataActionSoftDeleted, 1, nil)
+		} else {
+			dataRequest.addAction("user", DataActionAnonymized, 1, nil)
+		}
+	}
+
+	addDataRequest(dataRequest)
+	return dataRequest, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestWriteUserDataArchive(t *testing.T) {
+	data := &UserData{
+		User:    &User{Owner: "casbin", Name: "alice", Email: "alice@example.com"},
+		Tokens:  []*Token{{Owner: "admin", Name: "token", User: "alice"}},
+		Records: []*Record{{Organization: "casbin", User: "alice", Action: "login"}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteUserDataArchive(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{}
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = content
+	}
+
+	if len(files) != 9 {
+		t.Errorf("the archive has %d files, want 9", len(files))
+	}
+
+	var user User
+	if err = json.Unmarshal(files["user.json"], &user); err != nil || user.Email != "alice@example.com" {
+		t.Errorf("user.json = %s, error = %v", files["user.json"], err)
+	}
+
+	var records []*Record
+	if err = json.Unmarshal(files["records.json"], &records); err != nil || len(records) != 1 || records[0].Action != "login" {
+		t.Errorf("records.json = %s, error = %v", files["records.json"], err)
+	}
+}
+
+func TestGetErasedUser(t *testing.T) {
+	user := &User{
+		Owner: "casbin", Name: "alice", Id: "f5a3c1f2", Type: "normal-user", DisplayName: "Alice",
+		Email: "alice@example.com", Phone: "12345678", Password: "123", Properties: map[string]string{"Level": "1"},
+	}
+
+	name := getErasedUserName(user)
+	if name != getErasedUserName(&User{Id: user.Id}) || name == "erased-" {
+		t.Errorf("getErasedUserName() = %s", name)
+	}
+
+	erased := getErasedUser(user, name)
+	if erased.Name != name || erased.Id != user.Id || erased.Owner != user.Owner {
+		t.Errorf("getErasedUser() doesn't keep the identifiers: %+v", erased)
+	}
+	if erased.Email != "" || erased.Phone != "" || erased.Password != "" || len(erased.Properties) != 0 || erased.DisplayName == user.DisplayName {
+		t.Errorf("getErasedUser() keeps personal data: %+v", erased)
+	}
+	if !erased.IsForbidden || !erased.IsDeleted {
+		t.Errorf("getErasedUser() should be forbidden and deleted: %+v", erased)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	purgeDeletedUsersLeaseName = "purge-deleted-users"
+	purgeDeletedUsersInterval  = time.Hour
+)
+
+func GetDeletedUserCount(owner, field, value string) int {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	count, err := session.And("is_deleted = ?", true).Count(&User{})
+	if err != nil {
+		panic(err)
+	}
+
+	return int(count)
+}
+
+func GetDeletedUsers(owner string) []*User {
+	users := []*User{}
+	err := adapter.Engine.Desc("deleted_time").Where("owner = ? and is_deleted = ?", owner, true).Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	return users
+}
+
+func GetPaginationDeletedUsers(owner string, offset, limit int, field, value, sortField, sortOrder string) []*User {
+	if sortField == "" || sortOrder == "" {
+		sortField, sortOrder = "deletedTime", "descend"
+	}
+
+	users := []*User{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.And("is_deleted = ?", true).Find(&users)
+	if err != nil {
+		panic(err)
+	}
+
+	return users
+}
+
+// getDeletedUserName returns the name that frees the username of the soft-deleted user, derived from its immutable id
+func getDeletedUserName(user *User) string {
+	if user.Id == "" {
+		return fmt.Sprintf("deleted-%s", util.GenerateId())
+	}
+	return fmt.Sprintf("deleted-%s", user.Id)
+}
+
+// SoftDeleteUser marks the user as deleted and forces it offline, the username, email and phone are released
+// if the organization enables it, so that they can be used by new users until the user is restored.
+func SoftDeleteUser(user *User) bool {
+	if user.IsDeleted {
+		return false
+	}
+
+	id := user.GetId()
+	deleteUserSessions(user)
+
+	organization := GetOrganizationByUser(user)
+	if organization != nil && organization.ReleaseIdentifiersOnDeletion {
+		// the tokens and verification codes refer to the username, which may be taken by a new user
+		deleteUserTokens(user)
+		deleteUserVerificationRecords(user)
+
+		user.ReleasedIdentifiers = map[string]string{"name": user.Name, "email": user.Email, "phone": user.Phone}
+		user.Name = getDeletedUserName(user)
+		user.Email = ""
+		user.Phone = ""
+	}
+
+	user.IsDeleted = true
+	return UpdateUser(id, user, []string{"is_deleted", "released_identifiers"}, true)
+}
+
+// RestoreUser restores the soft-deleted user with the identifiers it has released, unless they have been
+// taken by other users in the meantime
+func RestoreUser(user *User, lang string) error {
+	if !user.IsDeleted {
+		return fmt.Errorf(i18n.Translate(lang, "user:The user: %s is not deleted"), user.GetId())
+	}
+	if user.MergedInto != "" {
+		return fmt.Errorf(i18n.Translate(lang, "user:The user: %s has been merged into %s"), user.GetId(), user.MergedInto)
+	}
+
+	id := user.GetId()
+	if len(user.ReleasedIdentifiers) != 0 {
+		name, email, phone := user.ReleasedIdentifiers["name"], user.ReleasedIdentifiers["email"], user.ReleasedIdentifiers["phone"]
+		if name != user.Name && HasUserByField(user.Owner, "name", name) {
+			return errors.New(i18n.Translate(lang, "check:Username already exists"))
+		}
+		if HasUserByField(user.Owner, "email", email) {
+			return errors.New(i18n.Translate(lang, "check:Email already exists"))
+		}
+		if HasUserByField(user.Owner, "phone", phone) {
+			return errors.New(i18n.Translate(lang, "check:Phone already exists"))
+		}
+
+		if name != "" {
+			user.Name = name
+		}
+		user.Email = email
+		user.Phone = phone
+		user.ReleasedIdentifiers = map[string]string{}
+	}
+
+	user.IsDeleted = false
+	if !UpdateUser(id, user, []string{"is_deleted", "released_identifiers"}, true) {
+		return fmt.Errorf(i18n.Translate(lang, "user:Failed to restore the user: %s"), id)
+	}
+	return nil
+}
+
+// CheckUserLegalHold returns the error if the user is under legal hold, whose data can't be deleted or erased
+func CheckUserLegalHold(user *User, lang string) error {
+	if user.IsLegalHold {
+		return errors.New(i18n.Translate(lang, "user:The user is under legal hold"))
+	}
+	return nil
+}
+
+// PurgeUser deletes the user with its tokens, sessions, verification codes and resources, the users under legal hold
+// are kept, and so are the merged users as the aliases of the users they have been merged into
+func PurgeUser(user *User, lang string) (bool, error) {
+	if err := CheckUserLegalHold(user, lang); err != nil {
+		return false, err
+	}
+	if user.MergedInto != "" {
+		return false, nil
+	}
+
+	deleteUserSessions(user)
+	deleteUserTokens(user)
+	deleteUserVerificationRecords(user)
+	if _, err := deleteUserResources(user, "en"); err != nil {
+		logs.Warning(fmt.Sprintf("failed to delete the resource files of the user: %s, error: %s", user.GetId(), err.Error()))
+	}
+
+	return DeleteUser(user, lang)
+}
+
+// isUserRetentionExpired returns whether the soft-deleted user has been kept for the retention days
+func isUserRetentionExpired(user *User, retentionDays int, now time.Time) bool {
+	if !user.IsDeleted || retentionDays <= 0 {
+		return false
+	}
+
+	deletedTime, err := time.Parse(time.RFC3339, user.DeletedTime)
+	if err != nil {
+		return false
+	}
+	return !deletedTime.Add(time.Duration(retentionDays) * 24 * time.Hour).After(now)
+}
+
+// PurgeDeletedUsers purges the soft-deleted users kept for longer than the retention of their organizations.
+// The users deleted without a deleted time, e.g. before the retention is supported, start their retention now.
+func PurgeDeletedUsers() int {
+	count := 0
+	now := time.Now()
+	for _, organization := range GetOrganizations("admin") {
+		if organization.SoftDeletionRetentionDays <= 0 {
+			continue
+		}
+
+		for _, user := range GetDeletedUsers(organization.Name) {
+			if user.DeletedTime == "" {
+				user.DeletedTime = util.GetCurrentTime()
+				UpdateUser(user.GetId(), user, []string{"deleted_time"}, false)
+				continue
+			}
+
+			if !isUserRetentionExpired(user, organization.SoftDeletionRetentionDays, now) {
+				continue
+			}
+
+			// the users under legal hold and the merged users are kept
+			if purged, _ := PurgeUser(user, "en"); purged {
+				count += 1
+			}
+		}
+	}
+
+	return count
+}
+
+// RunPurgeDeletedUsersJob purges the expired soft-deleted users periodically, by one instance at a time
+func RunPurgeDeletedUsersJob() {
+	ticker := time.NewTicker(purgeDeletedUsersInterval)
+	defer ticker.Stop()
+
+	for {
+		runWithLease(purgeDeletedUsersLeaseName, func() {
+			count := PurgeDeletedUsers()
+			if count != 0 {
+				logs.Info(fmt.Sprintf("purged %d soft-deleted users", count))
+			}
+		})
+
+		<-ticker.C
+	}
+}
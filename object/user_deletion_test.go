@@ -0,0 +1,52 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsUserRetentionExpired(t *testing.T) {
+	now := time.Date(2023, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		user          *User
+		retentionDays int
+		expected      bool
+	}{
+		{&User{IsDeleted: true, DeletedTime: "2023-06-01T12:00:00Z"}, 30, false},
+		{&User{IsDeleted: true, DeletedTime: "2023-05-31T12:00:00Z"}, 30, true},
+		{&User{IsDeleted: true, DeletedTime: "2023-05-31T20:00:00+08:00"}, 30, true},
+		{&User{IsDeleted: true, DeletedTime: "2023-01-01T00:00:00Z"}, 0, false},
+		{&User{IsDeleted: false, DeletedTime: "2023-01-01T00:00:00Z"}, 30, false},
+		{&User{IsDeleted: true, DeletedTime: ""}, 30, false},
+	}
+
+	for i, c := range cases {
+		if actual := isUserRetentionExpired(c.user, c.retentionDays, now); actual != c.expected {
+			t.Errorf("case %d: isUserRetentionExpired() = %v, want %v", i, actual, c.expected)
+		}
+	}
+}
+
+func TestCheckUserLegalHold(t *testing.T) {
+	if err := CheckUserLegalHold(&User{IsLegalHold: true}, "en"); err == nil {
+		t.Errorf("the user under legal hold should be rejected")
+	}
+	if err := CheckUserLegalHold(&User{}, "en"); err != nil {
+		t.Errorf("the user without legal hold should be accepted: %s", err)
+	}
+}
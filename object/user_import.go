@@ -0,0 +1,660 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/casdoor/casdoor/cred"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casdoor/casdoor/xlsx"
+	"github.com/xorm-io/core"
+)
+
+const (
+	UserImportModeCreate = "create"
+	UserImportModeUpsert = "upsert"
+	UserImportModeUpdate = "update"
+
+	UserImportActionCreate = "create"
+	UserImportActionUpdate = "update"
+	UserImportActionSkip   = "skip"
+	UserImportActionError  = "error"
+)
+
+// userImportFields are the JSON names of the user fields that can be imported and exported, in the order of
+// the exported columns. The properties can also be imported one by one by the "properties.<key>" columns.
+var userImportFields = []string{
+	"name", "id", "type", "createdTime", "password", "passwordSalt", "passwordType",
+	"displayName", "firstName", "lastName", "avatar", "email", "emailVerified", "phone", "countryCode",
+	"region", "location", "affiliation", "title", "idCardType", "idCard", "homepage", "bio", "tag",
+	"language", "gender", "birthday", "education", "score", "karma", "ranking",
+	"isAdmin", "isForbidden", "signupApplication", "groups", "properties",
+}
+
+// the fields written by the password logic instead of the generic one
+var userImportPasswordFields = []string{"password", "passwordSalt", "passwordType"}
+
+// userImportFieldIndexes are the indexes of the importable fields in the User struct by their JSON names
+var userImportFieldIndexes = map[string]int{}
+
+func init() {
+	userType := reflect.TypeOf(User{})
+	for i := 0; i < userType.NumField(); i++ {
+		name := strings.Split(userType.Field(i).Tag.Get("json"), ",")[0]
+		if util.InSlice(userImportFields, name) {
+			userImportFieldIndexes[name] = i
+		}
+	}
+}
+
+type UserImportOptions struct {
+	Format   string `json:"format"`
+	Mode     string `json:"mode"`
+	IsDryRun bool   `json:"isDryRun"`
+	// Mapping maps the column headers of the file to the user fields, the other headers are matched to the fields by name
+	Mapping map[string]string `json:"mapping"`
+}
+
+type UserImportRow struct {
+	Line    int    `json:"line"`
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	Message string `json:"message"`
+}
+
+type UserImportReport struct {
+	Organization   string            `json:"organization"`
+	Mode           string            `json:"mode"`
+	IsDryRun       bool              `json:"isDryRun"`
+	Columns        map[string]string `json:"columns"`
+	IgnoredColumns []string          `json:"ignoredColumns"`
+
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Skipped int              `json:"skipped"`
+	Failed  int              `json:"failed"`
+	Rows    []*UserImportRow `json:"rows"`
+}
+
+// userImportRecord is a row of the imported file, with the line number in the file for the report
+type userImportRecord struct {
+	line   int
+	values map[string]string
+}
+
+// GetUserImportFormat returns the format of the imported or exported file, guessed from its name if not given
+func GetUserImportFormat(format string, fileName string) string {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(fileName), ".")
+	}
+
+	format = strings.ToLower(format)
+	if format == "csv" || format == "json" || format == "xlsx" {
+		return format
+	}
+	return ""
+}
+
+// parseUserImportFile returns the headers and the non-empty rows of the CSV, XLSX or JSON file. The JSON file is
+// an array of objects whose keys are the headers, and the nested values are kept as JSON strings.
+func parseUserImportFile(format string, data []byte) ([]string, []*userImportRecord, error) {
+	records := []*userImportRecord{}
+	if format == "json" {
+		objects := []map[string]interface{}{}
+		err := json.Unmarshal(data, &objects)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		headers := []string{}
+		for i, object := range objects {
+			keys := make([]string, 0, len(object))
+			for key := range object {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			values := map[string]string{}
+			for _, key := range keys {
+				if !util.InSlice(headers, key) {
+					headers = append(headers, key)
+				}
+				values[key] = strings.TrimSpace(util.JsonValueToString(object[key]))
+			}
+			records = append(records, &userImportRecord{line: i + 1, values: values})
+		}
+		return headers, records, nil
+	}
+
+	var table [][]string
+	var err error
+	if format == "xlsx" {
+		table, err = xlsx.ReadXlsxBytes(data)
+	} else {
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		table, err = reader.ReadAll()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(table) == 0 {
+		return []string{}, records, nil
+	}
+
+	headers := make([]string, len(table[0]))
+	for i, header := range table[0] {
+		headers[i] = strings.TrimSpace(header)
+	}
+
+	for i, line := range table[1:] {
+		if util.ReturnAnyNotEmpty(line...) == "" {
+			continue
+		}
+
+		values := map[string]string{}
+		for j, header := range headers {
+			if j < len(line) && header != "" {
+				values[header] = strings.TrimSpace(line[j])
+			}
+		}
+		// the header is the first line of the file
+		records = append(records, &userImportRecord{line: i + 2, values: values})
+	}
+	return headers, records, nil
+}
+
+func normalizeUserImportField(name string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name))
+}
+
+// getUserImportField returns the user field of the column header or the mapped name, e.g. "Display Name" and
+// "display_name" for "displayName", or an empty string for an unknown field
+func getUserImportField(name string) string {
+	if strings.HasPrefix(strings.ToLower(name), "properties.") {
+		key := name[len("properties."):]
+		if key == "" {
+			return ""
+		}
+		return "properties." + key
+	}
+
+	normalizedName := normalizeUserImportField(name)
+	for _, field := range userImportFields {
+		if normalizeUserImportField(field) == normalizedName {
+			return field
+		}
+	}
+	return ""
+}
+
+// getUserImportColumns maps the headers to the user fields, returning the mapped and the ignored headers
+func getUserImportColumns(headers []string, mapping map[string]string) (map[string]string, []string) {
+	columns := map[string]string{}
+	ignoredColumns := []string{}
+	for _, header := range headers {
+		name := header
+		if mapping[header] != "" {
+			name = mapping[header]
+		}
+
+		field := getUserImportField(name)
+		if field == "" {
+			ignoredColumns = append(ignoredColumns, header)
+		} else {
+			columns[header] = field
+		}
+	}
+	return columns, ignoredColumns
+}
+
+func parseUserImportList(value string) ([]string, error) {
+	items := []string{}
+	if strings.HasPrefix(value, "[") {
+		err := json.Unmarshal([]byte(value), &items)
+		return items, err
+	}
+
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// setUserImportValues sets the imported values of the user other than the name and the password,
+// returning the updated columns
+func setUserImportValues(user *User, values map[string]string, lang string) ([]string, error) {
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	columns := []string{}
+	userValue := reflect.ValueOf(user).Elem()
+	for _, field := range fields {
+		value := values[field]
+		if field == "name" || util.InSlice(userImportPasswordFields, field) {
+			continue
+		}
+
+		if strings.HasPrefix(field, "properties.") {
+			setUserProperty(user, strings.TrimPrefix(field, "properties."), value)
+			if !util.InSlice(columns, "properties") {
+				columns = append(columns, "properties")
+			}
+			continue
+		}
+
+		var err error
+		fieldValue := userValue.Field(userImportFieldIndexes[field])
+		switch fieldValue.Kind() {
+		case reflect.String:
+			fieldValue.SetString(value)
+		case reflect.Int:
+			var i int
+			if value != "" {
+				i, err = strconv.Atoi(value)
+			}
+			fieldValue.SetInt(int64(i))
+		case reflect.Bool:
+			var b bool
+			if value != "" {
+				b, err = strconv.ParseBool(value)
+			}
+			fieldValue.SetBool(b)
+		case reflect.Slice:
+			var items []string
+			items, err = parseUserImportList(value)
+			fieldValue.Set(reflect.ValueOf(items))
+		case reflect.Map:
+			m := map[string]string{}
+			if value != "" {
+				err = json.Unmarshal([]byte(value), &m)
+			}
+			fieldValue.Set(reflect.ValueOf(m))
+		}
+		if err != nil {
+			return nil, fmt.Errorf(i18n.Translate(lang, "user_upload:The value: %s of the field: %s is invalid"), value, field)
+		}
+
+		if !util.InSlice(columns, util.SnakeString(field)) {
+			columns = append(columns, util.SnakeString(field))
+		}
+	}
+	return columns, nil
+}
+
+// checkUserImport checks the user to be imported, which is a new user if oldUser is nil
+func checkUserImport(user *User, oldUser *User, password string, passwordType string, lang string) string {
+	if oldUser == nil {
+		if msg := CheckUsername(user.Name, lang); msg != "" {
+			return msg
+		}
+	}
+
+	if user.Email != "" {
+		if !util.IsEmailValid(user.Email) {
+			return i18n.Translate(lang, "check:Email is invalid")
+		}
+		if other := GetUserByField(user.Owner, "email", user.Email); other != nil && other.Name != user.Name {
+			return i18n.Translate(lang, "check:Email already exists")
+		}
+	}
+	if user.Phone != "" {
+		if user.CountryCode != "" && !util.IsPhoneValid(user.Phone, user.CountryCode) {
+			return i18n.Translate(lang, "check:Phone number is invalid")
+		}
+		if other := GetUserByField(user.Owner, "phone", user.Phone); other != nil && other.Name != user.Name {
+			return i18n.Translate(lang, "check:Phone already exists")
+		}
+	}
+
+	for i, groupId := range user.Groups {
+		if !strings.Contains(groupId, "/") {
+			groupId = util.GetId(user.Owner, groupId)
+			user.Groups[i] = groupId
+		}
+		// the group must be one of the organization of the user, given by its id and not by its path
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(groupId)
+		if owner != user.Owner || name == "" || strings.Contains(name, "/") || GetGroup(groupId) == nil {
+			return fmt.Sprintf(i18n.Translate(lang, "group:The group: %s doesn't exist"), groupId)
+		}
+	}
+
+	if password != "" {
+		if passwordType != "" {
+			// the pre-hashed passwords, e.g. exported from another organization or system
+			if cred.GetCredManager(passwordType) == nil {
+				return fmt.Sprintf(i18n.Translate(lang, "check:unsupported password type: %s"), passwordType)
+			}
+		} else {
+			checkedUser := *user
+			if oldUser != nil {
+				checkedUser = *oldUser
+			}
+			if msg := CheckNewPassword(&checkedUser, password, lang); msg != "" {
+				return msg
+			}
+		}
+	}
+
+	return ""
+}
+
+// setImportedPasswordHash stores the pre-hashed password as is
+func setImportedPasswordHash(user *User, password string, passwordSalt string, passwordType string) {
+	user.Password = password
+	user.PasswordSalt = passwordSalt
+	user.PasswordType = passwordType
+	user.PasswordChangedTime = util.GetCurrentTime()
+	user.UpdateUserHash()
+
+	columns := []string{"password", "password_salt", "password_type", "password_changed_time", "hash"}
+	_, err := adapter.Engine.ID(core.PK{user.Owner, user.Name}).Cols(columns...).Update(user)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// importUser creates or updates the user of the record by the mode, returning the action and the error message
+func importUser(organization *Organization, record *userImportRecord, options *UserImportOptions, lang string) (string, string) {
+	values := record.values
+	name := values["name"]
+	password, passwordSalt, passwordType := values["password"], values["passwordSalt"], values["passwordType"]
+
+	oldUser := getUser(organization.Name, name)
+	if oldUser != nil && options.Mode == UserImportModeCreate {
+		return UserImportActionSkip, i18n.Translate(lang, "check:Username already exists")
+	}
+	if oldUser == nil && options.Mode == UserImportModeUpdate {
+		return UserImportActionSkip, fmt.Sprintf(i18n.Translate(lang, "general:The user: %s doesn't exist"), util.GetId(organization.Name, name))
+	}
+
+	var user *User
+	action := UserImportActionCreate
+	if oldUser == nil {
+		initScore, err := organization.GetInitScore()
+		if err != nil {
+			return UserImportActionError, err.Error()
+		}
+
+		user = &User{
+			Owner:       organization.Name,
+			Name:        name,
+			CreatedTime: util.GetCurrentTime(),
+			Id:          util.GenerateId(),
+			Type:        "normal-user",
+			Avatar:      organization.DefaultAvatar,
+			Address:     []string{},
+			Score:       initScore,
+			Properties:  map[string]string{},
+		}
+	} else {
+		action = UserImportActionUpdate
+		copiedUser := *oldUser
+		user = &copiedUser
+	}
+
+	columns, err := setUserImportValues(user, values, lang)
+	if err != nil {
+		return UserImportActionError, err.Error()
+	}
+	if oldUser != nil {
+		// the immutable id and the creation time are kept for the existing users
+		user.Id, user.CreatedTime = oldUser.Id, oldUser.CreatedTime
+		columns = util.DeleteVal(util.DeleteVal(columns, "id"), "created_time")
+	}
+
+	if msg := checkUserImport(user, oldUser, password, passwordType, lang); msg != "" {
+		return UserImportActionError, msg
+	}
+
+	if options.IsDryRun {
+		return action, ""
+	}
+
+	if oldUser == nil {
+		if passwordType == "" {
+			user.Password = password
+		}
+		if !AddUser(user) {
+			return UserImportActionError, i18n.Translate(lang, "user_upload:Failed to import users")
+		}
+	} else if len(columns) != 0 {
+		UpdateUser(user.GetId(), user, columns, true)
+	}
+
+	if password != "" {
+		if passwordType != "" {
+			setImportedPasswordHash(user, password, passwordSalt, passwordType)
+		} else if oldUser != nil {
+			user.Password = password
+			SetUserField(user, "password", password)
+		}
+	}
+
+	return action, ""
+}
+
+// ImportUsers creates or updates the users of the organization from the CSV, XLSX or JSON file, every row is
+// checked and reported on its own, and nothing is written in a dry run
+func ImportUsers(owner string, data []byte, options *UserImportOptions, lang string) (*UserImportReport, error) {
+	organization := getOrganization("admin", owner)
+	if organization == nil {
+		return nil, errors.New(i18n.Translate(lang, "check:Organization does not exist"))
+	}
+
+	if options.Mode == "" {
+		options.Mode = UserImportModeCreate
+	}
+	if !util.InSlice([]string{UserImportModeCreate, UserImportModeUpsert, UserImportModeUpdate}, options.Mode) {
+		return nil, fmt.Errorf(i18n.Translate(lang, "user_upload:The import mode: %s is not supported"), options.Mode)
+	}
+	if options.Format == "" {
+		return nil, errors.New(i18n.Translate(lang, "user_upload:The file format is not supported"))
+	}
+
+	headers, records, err := parseUserImportFile(options.Format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, ignoredColumns := getUserImportColumns(headers, options.Mapping)
+	hasName := false
+	for _, field := range columns {
+		hasName = hasName || field == "name"
+	}
+	if !hasName {
+		return nil, errors.New(i18n.Translate(lang, "user_upload:The name column is missing"))
+	}
+
+	report := &UserImportReport{
+		Organization:   owner,
+		Mode:           options.Mode,
+		IsDryRun:       options.IsDryRun,
+		Columns:        columns,
+		IgnoredColumns: ignoredColumns,
+		Rows:           []*UserImportRow{},
+	}
+
+	names := map[string]bool{}
+	for _, record := range records {
+		values := map[string]string{}
+		for header, value := range record.values {
+			if field, ok := columns[header]; ok {
+				values[field] = value
+			}
+		}
+		record.values = values
+
+		row := &UserImportRow{Line: record.line, Name: values["name"]}
+		if row.Name == "" {
+			row.Action, row.Message = UserImportActionError, i18n.Translate(lang, "check:Empty username.")
+		} else if names[row.Name] {
+			row.Action, row.Message = UserImportActionError, i18n.Translate(lang, "user_upload:The user is duplicated in the file")
+		} else {
+			names[row.Name] = true
+			row.Action, row.Message = importUser(organization, record, options, lang)
+		}
+
+		switch row.Action {
+		case UserImportActionCreate:
+			report.Created += 1
+		case UserImportActionUpdate:
+			report.Updated += 1
+		case UserImportActionSkip:
+			report.Skipped += 1
+		default:
+			report.Failed += 1
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	report.Total = len(records)
+
+	return report, nil
+}
+
+// getUserExportValues returns the exported values of the user in the order of the fields, the password hashes are
+// exported with their password types only if requested and not in plain text
+func getUserExportValues(user *User, organization *Organization, withPasswords bool) []interface{} {
+	passwordType := user.PasswordType
+	if passwordType == "" && organization != nil {
+		passwordType = organization.PasswordType
+	}
+	if !withPasswords || passwordType == "" || passwordType == "plain" || user.Ldap != "" || user.Password == "" {
+		user.Password, user.PasswordSalt, passwordType = "", "", ""
+	}
+	user.PasswordType = passwordType
+
+	values := []interface{}{}
+	userValue := reflect.ValueOf(user).Elem()
+	for _, field := range userImportFields {
+		values = append(values, userValue.Field(userImportFieldIndexes[field]).Interface())
+	}
+	return values
+}
+
+func formatUserExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ";")
+	case map[string]string:
+		if len(v) == 0 {
+			return ""
+		}
+		return util.StructToJson(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ExportUsers writes the users of the organization matching the filter to the CSV, XLSX or JSON file. The CSV and
+// JSON files are written while the users are read, the XLSX file is written at the end.
+func ExportUsers(w io.Writer, owner string, format string, field string, value string, withPasswords bool) error {
+	organization := getOrganization("admin", owner)
+
+	var csvWriter *csv.Writer
+	var table [][]string
+	switch format {
+	case "csv":
+		csvWriter = csv.NewWriter(w)
+		err := csvWriter.Write(userImportFields)
+		if err != nil {
+			return err
+		}
+	case "xlsx":
+		table = [][]string{userImportFields}
+	case "json":
+		_, err := io.WriteString(w, "[")
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("the file format: %s is not supported", format)
+	}
+
+	count := 0
+	session := GetSession(owner, -1, -1, field, value, "", "").And("is_deleted = ?", false)
+	err := session.Iterate(&User{}, func(i int, bean interface{}) error {
+		values := getUserExportValues(bean.(*User), organization, withPasswords)
+		count += 1
+
+		if format == "json" {
+			var sb strings.Builder
+			if count > 1 {
+				sb.WriteString(",")
+			}
+			sb.WriteString("\n{")
+			for j, field := range userImportFields {
+				if j > 0 {
+					sb.WriteString(",")
+				}
+				sb.WriteString(fmt.Sprintf("%q:%s", field, util.StructToJson(values[j])))
+			}
+			sb.WriteString("}")
+			_, err := io.WriteString(w, sb.String())
+			return err
+		}
+
+		line := make([]string, len(values))
+		for j, value := range values {
+			line[j] = formatUserExportValue(value)
+		}
+		if format == "xlsx" {
+			table = append(table, line)
+			return nil
+		}
+
+		err := csvWriter.Write(line)
+		if err == nil && count%100 == 0 {
+			csvWriter.Flush()
+			err = csvWriter.Error()
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "csv":
+		csvWriter.Flush()
+		return csvWriter.Error()
+	case "xlsx":
+		return xlsx.WriteXlsx(w, "users", table)
+	default:
+		_, err = io.WriteString(w, "\n]\n")
+		return err
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+)
+
+func TestParseUserImportFile(t *testing.T) {
+	csvData := "\xef\xbb\xbfName,Display Name,E-mail,Unknown\nalice,Alice,alice@example.com,x\n,,,\nbob,Bob\n"
+	headers, records, err := parseUserImportFile("csv", []byte(csvData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 4 || headers[0] != "Name" {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+	if len(records) != 2 || records[0].line != 2 || records[1].line != 4 {
+		t.Fatalf("unexpected records: %v", records)
+	}
+	if records[1].values["Display Name"] != "Bob" || records[1].values["E-mail"] != "" {
+		t.Errorf("unexpected values: %v", records[1].values)
+	}
+
+	jsonData := `[{"name": "alice", "score": 10, "groups": ["g1"]}, {"name": "bob", "isAdmin": true}]`
+	headers, records, err = parseUserImportFile("json", []byte(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 4 || len(records) != 2 {
+		t.Fatalf("unexpected headers: %v, records: %v", headers, records)
+	}
+	if records[0].values["score"] != "10" || records[0].values["groups"] != `["g1"]` || records[1].values["isAdmin"] != "true" {
+		t.Errorf("unexpected values: %v, %v", records[0].values, records[1].values)
+	}
+
+	_, _, err = parseUserImportFile("json", []byte(`{"name": "alice"}`))
+	if err == nil {
+		t.Errorf("expected an error for the invalid JSON file")
+	}
+}
+
+func TestGetUserImportColumns(t *testing.T) {
+	headers := []string{"Name", "display_name", "E-mail", "Mail", "properties.dept", "created_ip"}
+	columns, ignoredColumns := getUserImportColumns(headers, map[string]string{"Mail": "email", "E-mail": "phone"})
+
+	expected := map[string]string{
+		"Name":            "name",
+		"display_name":    "displayName",
+		"E-mail":          "phone",
+		"Mail":            "email",
+		"properties.dept": "properties.dept",
+	}
+	for header, field := range expected {
+		if columns[header] != field {
+			t.Errorf("column %s: got %s, want %s", header, columns[header], field)
+		}
+	}
+	if len(ignoredColumns) != 1 || ignoredColumns[0] != "created_ip" {
+		t.Errorf("unexpected ignored columns: %v", ignoredColumns)
+	}
+}
+
+func TestSetUserImportValues(t *testing.T) {
+	user := &User{Properties: map[string]string{"old": "1"}}
+	values := map[string]string{
+		"name":            "alice",
+		"password":        "secret",
+		"displayName":     "Alice",
+		"score":           "42",
+		"isAdmin":         "1",
+		"groups":          "g1; g2",
+		"properties.dept": "R&D",
+	}
+
+	columns, err := setUserImportValues(user, values, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "" || user.Password != "" {
+		t.Errorf("the name and password should be left to the import")
+	}
+	if user.DisplayName != "Alice" || user.Score != 42 || !user.IsAdmin || len(user.Groups) != 2 || user.Groups[1] != "g2" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	if user.Properties["dept"] != "R&D" || user.Properties["old"] != "1" {
+		t.Errorf("unexpected properties: %v", user.Properties)
+	}
+	if len(columns) != 5 {
+		t.Errorf("unexpected columns: %v", columns)
+	}
+
+	_, err = setUserImportValues(user, map[string]string{"karma": "many"}, "en")
+	if err == nil {
+		t.Errorf("expected an error for the invalid number")
+	}
+}
+
+func TestGetUserImportFormat(t *testing.T) {
+	cases := []struct {
+		format   string
+		fileName string
+		expected string
+	}{
+		{"", "users.CSV", "csv"},
+		{"", "users.xlsx", "xlsx"},
+		{"JSON", "users.txt", "json"},
+		{"", "users.txt", ""},
+	}
+	for _, c := range cases {
+		if format := GetUserImportFormat(c.format, c.fileName); format != c.expected {
+			t.Errorf("GetUserImportFormat(%q, %q) = %q, want %q", c.format, c.fileName, format, c.expected)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
+)
+
+// maxMergedUserRedirects limits the chain of the merged users followed to the surviving user
+const maxMergedUserRedirects = 10
+
+type UserMergeItem struct {
+	Table string `json:"table"`
+	Count int    `json:"count"`
+}
+
+// UserMerge is the audit record of merging a duplicate user into the surviving user. The merged user is
+// referred to by its immutable id as well, as its name is only kept as an alias of the surviving user.
+type UserMerge struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User         string           `xorm:"varchar(100) index" json:"user"`
+	MergedUser   string           `xorm:"varchar(100) index" json:"mergedUser"`
+	MergedUserId string           `xorm:"varchar(100)" json:"mergedUserId"`
+	Operator     string           `xorm:"varchar(100)" json:"operator"`
+	Items        []*UserMergeItem `xorm:"mediumtext" json:"items"`
+	// Conflicts are the fields set differently for both users, which keep the values of the surviving user
+	Conflicts []string `xorm:"mediumtext" json:"conflicts"`
+}
+
+func (userMerge *UserMerge) addItem(table string, count int) {
+	if count == 0 {
+		return
+	}
+	userMerge.Items = append(userMerge.Items, &UserMergeItem{Table: table, Count: count})
+}
+
+func GetUserMerges(owner string) []*UserMerge {
+	userMerges := []*UserMerge{}
+	err := adapter.Engine.Desc("created_time").Find(&userMerges, &UserMerge{Owner: owner})
+	if err != nil {
+		panic(err)
+	}
+
+	return userMerges
+}
+
+// getUserProviderFieldIndexes returns the indexes of the fields of the identity provider links in the User struct,
+// i.e. the fields from "GitHub" to "Custom"
+func getUserProviderFieldIndexes() []int {
+	userType := reflect.TypeOf(User{})
+	first, _ := userType.FieldByName("GitHub")
+	last, _ := userType.FieldByName("Custom")
+
+	indexes := []int{}
+	for i := first.Index[0]; i <= last.Index[0]; i++ {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// getUserProviderColumns returns the columns of the identity provider links, which are named after their JSON keys
+func getUserProviderColumns() []string {
+	userType := reflect.TypeOf(User{})
+	columns := []string{}
+	for _, i := range getUserProviderFieldIndexes() {
+		columns = append(columns, userType.Field(i).Tag.Get("json"))
+	}
+	return columns
+}
+
+// mergeUserFields moves the identity provider links, properties, WebAuthn credentials, groups, email and phone
+// of the merged user into the surviving user, the conflicting fields keep the values of the surviving user
+func mergeUserFields(user *User, mergedUser *User, userMerge *UserMerge) {
+	userValue := reflect.ValueOf(user).Elem()
+	mergedValue := reflect.ValueOf(mergedUser).Elem()
+	count := 0
+	for _, i := range getUserProviderFieldIndexes() {
+		value, mergedLink := userValue.Field(i), mergedValue.Field(i).String()
+		if mergedLink == "" {
+			continue
+		}
+
+		if value.String() == "" {
+			value.SetString(mergedLink)
+			count += 1
+		} else if value.String() != mergedLink {
+			userMerge.Conflicts = append(userMerge.Conflicts, reflect.TypeOf(User{}).Field(i).Tag.Get("json"))
+		}
+		mergedValue.Field(i).SetString("")
+	}
+	userMerge.addItem("provider", count)
+
+	count = 0
+	for key, value := range mergedUser.Properties {
+		if oldValue, ok := user.Properties[key]; !ok {
+			setUserProperty(user, key, value)
+			count += 1
+		} else if oldValue != value {
+			userMerge.Conflicts = append(userMerge.Conflicts, "properties."+key)
+		}
+	}
+	userMerge.addItem("property", count)
+
+	count = 0
+	for _, mergedCredential := range mergedUser.WebauthnCredentials {
+		isExisting := false
+		for _, credential := range user.WebauthnCredentials {
+			isExisting = isExisting || bytes.Equal(credential.ID, mergedCredential.ID)
+		}
+		if !isExisting {
+			user.WebauthnCredentials = append(user.WebauthnCredentials, mergedCredential)
+			count += 1
+		}
+	}
+	mergedUser.WebauthnCredentials = nil
+	userMerge.addItem("webauthn", count)
+
+	count = 0
+	for _, group := range mergedUser.Groups {
+		if !util.InSlice(user.Groups, group) {
+			user.Groups = append(user.Groups, group)
+			count += 1
+		}
+	}
+	userMerge.addItem("group", count)
+
+	if user.Email == "" && mergedUser.Email != "" {
+		user.Email, user.EmailVerified = mergedUser.Email, mergedUser.EmailVerified
+		mergedUser.Email = ""
+	}
+	if user.Phone == "" && mergedUser.Phone != "" {
+		user.Phone, user.CountryCode = mergedUser.Phone, mergedUser.CountryCode
+		mergedUser.Phone = ""
+	}
+}
+
+// replaceUserId replaces the merged user with the surviving user in the users of a role or a permission
+func replaceUserId(users []string, mergedUserId string, userId string) []string {
+	res := []string{}
+	for _, u := range users {
+		if u == mergedUserId {
+			u = userId
+		}
+		if !util.InSlice(res, u) {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+func mergeUserRoles(user *User, mergedUser *User) int {
+	userId, mergedUserId := user.GetId(), mergedUser.GetId()
+	count := 0
+	for _, role := range GetRolesByUser(mergedUserId) {
+		role = GetRole(role.GetId())
+		if role == nil || !util.InSlice(role.Users, mergedUserId) {
+			continue
+		}
+
+		role.Users = replaceUserId(role.Users, mergedUserId, userId)
+		UpdateRole(role.GetId(), role)
+		count += 1
+	}
+	return count
+}
+
+func mergeUserPermissions(user *User, mergedUser *User) int {
+	userId, mergedUserId := user.GetId(), mergedUser.GetId()
+	count := 0
+	for _, permission := range GetPermissionsByUser(mergedUserId) {
+		permission = GetPermission(permission.GetId())
+		if permission == nil || !util.InSlice(permission.Users, mergedUserId) {
+			continue
+		}
+
+		permission.Users = replaceUserId(permission.Users, mergedUserId, userId)
+		UpdatePermission(permission.GetId(), permission)
+		count += 1
+	}
+	return count
+}
+
+// moveUserData moves the resources, payments and subscriptions of the merged user to the surviving user
+func moveUserData(session *xorm.Session, user *User, mergedUser *User, userMerge *UserMerge) error {
+	affected, err := session.Where("owner = ? and user = ?", mergedUser.Owner, mergedUser.Name).
+		Cols("user").Update(&Resource{User: user.Name})
+	if err != nil {
+		return err
+	}
+	userMerge.addItem("resource", int(affected))
+
+	affected, err = session.Where("organization = ? and user = ?", mergedUser.Owner, mergedUser.Name).
+		Cols("user").Update(&Payment{User: user.Name})
+	if err != nil {
+		return err
+	}
+	userMerge.addItem("payment", int(affected))
+
+	affected, err = session.Where("owner = ? and user = ?", mergedUser.Owner, mergedUser.GetId()).
+		Cols("user").Update(&Subscription{User: user.GetId()})
+	if err != nil {
+		return err
+	}
+	userMerge.addItem("subscription", int(affected))
+	return nil
+}
+
+// saveUserMerge saves both users, the moved data and the audit record together, so that a failed merge
+// leaves both users as they were
+func saveUserMerge(user *User, mergedUser *User, userMerge *UserMerge) error {
+	session := adapter.Engine.NewSession()
+	defer session.Close()
+
+	err := session.Begin()
+	if err != nil {
+		return err
+	}
+
+	// the merged user gives up its unique links and identifiers before the surviving user takes them
+	columns := append(getUserProviderColumns(), "properties", "webauthnCredentials", "groups", "email", "email_verified", "phone", "country_code", "hash")
+	_, err = session.ID(core.PK{mergedUser.Owner, mergedUser.Name}).Cols(append(columns, "is_deleted", "deleted_time", "merged_into")...).Update(mergedUser)
+	if err != nil {
+		return err
+	}
+
+	_, err = session.ID(core.PK{user.Owner, user.Name}).Cols(columns...).Update(user)
+	if err != nil {
+		return err
+	}
+
+	err = moveUserData(session, user, mergedUser, userMerge)
+	if err != nil {
+		return err
+	}
+
+	_, err = session.Insert(userMerge)
+	if err != nil {
+		return err
+	}
+
+	return session.Commit()
+}
+
+// CheckUserMerge returns the error message if the merged user can't be merged into the surviving user
+func CheckUserMerge(user *User, mergedUser *User, lang string) string {
+	if user.Owner != mergedUser.Owner {
+		return i18n.Translate(lang, "user:The users to merge must be in the same organization")
+	}
+	if user.GetId() == mergedUser.GetId() {
+		return i18n.Translate(lang, "user:A user can't be merged into itself")
+	}
+	if user.IsDeleted || mergedUser.IsDeleted {
+		return i18n.Translate(lang, "user:The deleted users can't be merged")
+	}
+	if mergedUser.Ldap != "" {
+		return i18n.Translate(lang, "user:The LDAP users can't be merged into other users")
+	}
+	return ""
+}
+
+// MergeUser merges the duplicate user into the surviving user, and retires the merged user as a soft-deleted alias
+// whose name, email and phone still sign in as the surviving user
+func MergeUser(user *User, mergedUser *User, operator string, lang string) (*UserMerge, error) {
+	if msg := CheckUserMerge(user, mergedUser, lang); msg != "" {
+		return nil, errors.New(msg)
+	}
+
+	userId := user.GetId()
+	userMerge := &UserMerge{
+		Owner:        user.Owner,
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		User:         userId,
+		MergedUser:   mergedUser.GetId(),
+		MergedUserId: mergedUser.Id,
+		Operator:     operator,
+		Items:        []*UserMergeItem{},
+		Conflicts:    []string{},
+	}
+
+	groupCount := len(user.Groups)
+	mergeUserFields(user, mergedUser, userMerge)
+	mergedUser.IsDeleted = true
+	mergedUser.DeletedTime = util.GetCurrentTime()
+	mergedUser.MergedInto = userId
+	mergedUser.UpdateUserHash()
+	user.UpdateUserHash()
+
+	deleteUserSessions(mergedUser)
+	deleteUserTokens(mergedUser)
+	deleteUserApiKeys(mergedUser)
+	err := saveUserMerge(user, mergedUser, userMerge)
+	if err != nil {
+		return nil, err
+	}
+
+	// the roles and permissions update their Casbin policies as well, which are outside of the transaction
+	userMerge.addItem("role", mergeUserRoles(user, mergedUser))
+	userMerge.addItem("permission", mergeUserPermissions(user, mergedUser))
+	_, err = adapter.Engine.ID(core.PK{userMerge.Owner, userMerge.Name}).Cols("items").Update(userMerge)
+	if err != nil {
+		return nil, err
+	}
+
+	provisionUser(mergedUser)
+	provisionUser(user)
+	if len(user.Groups) != groupCount {
+		refreshGroupPermissions(user.Owner)
+	}
+	return userMerge, nil
+}
+
+// getSurvivingUser follows the merged user to the user it has been merged into
+func getSurvivingUser(user *User) *User {
+	for i := 0; i < maxMergedUserRedirects && user != nil && user.MergedInto != ""; i++ {
+		user = GetUser(user.MergedInto)
+	}
+	return user
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+func TestMergeUserFields(t *testing.T) {
+	user := &User{
+		Owner:               "org",
+		Name:                "alice",
+		GitHub:              "1",
+		Google:              "2",
+		Properties:          map[string]string{"oauth_GitHub_id": "1", "dept": "R&D"},
+		WebauthnCredentials: []webauthn.Credential{{ID: []byte{1}}},
+		Groups:              []string{"org/a"},
+		Phone:               "123",
+	}
+	mergedUser := &User{
+		Owner:               "org",
+		Name:                "alice2",
+		Google:              "3",
+		Custom:              "4",
+		Properties:          map[string]string{"oauth_Custom_id": "4", "dept": "Sales"},
+		WebauthnCredentials: []webauthn.Credential{{ID: []byte{1}}, {ID: []byte{2}}},
+		Groups:              []string{"org/a", "org/b"},
+		Email:               "alice@example.com",
+		EmailVerified:       true,
+		Phone:               "456",
+	}
+
+	userMerge := &UserMerge{}
+	mergeUserFields(user, mergedUser, userMerge)
+
+	if user.GitHub != "1" || user.Google != "2" || user.Custom != "4" {
+		t.Errorf("unexpected provider links: %s, %s, %s", user.GitHub, user.Google, user.Custom)
+	}
+	if mergedUser.Google != "" || mergedUser.Custom != "" {
+		t.Errorf("the provider links of the merged user should be cleared")
+	}
+	if user.Properties["oauth_Custom_id"] != "4" || user.Properties["dept"] != "R&D" {
+		t.Errorf("unexpected properties: %v", user.Properties)
+	}
+	if len(user.WebauthnCredentials) != 2 || mergedUser.WebauthnCredentials != nil {
+		t.Errorf("unexpected WebAuthn credentials: %v", user.WebauthnCredentials)
+	}
+	if !util.StringSlicesEqual(user.Groups, []string{"org/a", "org/b"}) {
+		t.Errorf("unexpected groups: %v", user.Groups)
+	}
+	if user.Email != "alice@example.com" || !user.EmailVerified || mergedUser.Email != "" {
+		t.Errorf("the email should be moved to the surviving user")
+	}
+	if user.Phone != "123" || mergedUser.Phone != "456" {
+		t.Errorf("the phone of the surviving user should be kept")
+	}
+	if !util.StringSlicesEqual(userMerge.Conflicts, []string{"google", "properties.dept"}) {
+		t.Errorf("unexpected conflicts: %v", userMerge.Conflicts)
+	}
+}
+
+func TestReplaceUserId(t *testing.T) {
+	users := replaceUserId([]string{"org/alice2", "org/bob", "org/alice"}, "org/alice2", "org/alice")
+	if !util.StringSlicesEqual(users, []string{"org/alice", "org/bob"}) {
+		t.Errorf("unexpected users: %v", users)
+	}
+}
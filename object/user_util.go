@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/casdoor/casdoor/idp"
+	"github.com/casdoor/casdoor/util"
 	"github.com/xorm-io/core"
 )
 
@@ -47,6 +48,10 @@ func HasUserByField(organizationName string, field string, value string) bool {
 }
 
 func GetUserByFields(organization string, field string) *User {
+	return getSurvivingUser(getUserByFields(organization, field))
+}
+
+func getUserByFields(organization string, field string) *User {
 	// check username
 	user := GetUserByField(organization, "name", field)
 	if user != nil {
@@ -80,9 +85,11 @@ func SetUserField(user *User, field string, value string) bool {
 	bean := make(map[string]interface{})
 	if field == "password" {
 		organization := GetOrganizationByUser(user)
-		user.UpdateUserPassword(organization)
+		setUserPassword(user, organization)
 		bean[strings.ToLower(field)] = user.Password
 		bean["password_type"] = user.PasswordType
+		bean["password_changed_time"] = user.PasswordChangedTime
+		bean["password_history"] = util.StructToJson(user.PasswordHistory)
 	} else {
 		bean[strings.ToLower(field)] = value
 	}
@@ -257,14 +264,19 @@ func CheckPermissionForUpdateUser(oldUser, newUser *User, isAdmin bool, lang str
 		item := GetAccountItemByName("Tag", organization)
 		itemsChanged = append(itemsChanged, item)
 	}
+	// the groups grant their roles and permissions, so only the admins can change them whatever the account items are
+	if !util.StringSlicesEqual(oldUser.Groups, newUser.Groups) {
+		item := &AccountItem{Name: "Groups", ModifyRule: "Admin"}
+		itemsChanged = append(itemsChanged, item)
+	}
 	if oldUser.SignupApplication != newUser.SignupApplication {
 		item := GetAccountItemByName("Signup application", organization)
 		itemsChanged = append(itemsChanged, item)
 	}
 
-	oldUserPropertiesJson, _ := json.Marshal(oldUser.Properties)
-	newUserPropertiesJson, _ := json.Marshal(newUser.Properties)
-	if string(oldUserPropertiesJson) != string(newUserPropertiesJson) {
+	attributeItemsChanged, propertiesChanged := getUserAttributeChanges(organization, oldUser, newUser)
+	itemsChanged = append(itemsChanged, attributeItemsChanged...)
+	if propertiesChanged {
 		item := GetAccountItemByName("Properties", organization)
 		itemsChanged = append(itemsChanged, item)
 	}
@@ -292,6 +304,10 @@ func CheckPermissionForUpdateUser(oldUser, newUser *User, isAdmin bool, lang str
 		item := GetAccountItemByName("Is deleted", organization)
 		itemsChanged = append(itemsChanged, item)
 	}
+	if oldUser.IsLegalHold != newUser.IsLegalHold {
+		item := &AccountItem{Name: "Is legal hold", ModifyRule: "Admin"}
+		itemsChanged = append(itemsChanged, item)
+	}
 
 	for i := range itemsChanged {
 		if pass, err := CheckAccountItemModifyRule(itemsChanged[i], isAdmin, lang); !pass {
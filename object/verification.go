@@ -201,19 +201,27 @@ func DisableVerificationCode(dest string) {
 	}
 }
 
-func CheckSigninCode(user *User, dest, code, lang string) string {
-	// check the login error times
-	if msg := checkSigninErrorTimes(user, lang); msg != "" {
-		return msg
+func CheckSigninCode(user *User, dest, code, lang string, attempt *SigninAttempt) string {
+	// the wrong codes lock the account only if the lockout policy counts them
+	countCodes := getUserLockoutPolicy(user, attempt).CountVerificationCodes
+	if countCodes {
+		if msg := checkSigninLockout(user, attempt, lang); msg != "" {
+			return msg
+		}
 	}
 
 	result := CheckVerificationCode(dest, code, lang)
 	switch result.Code {
 	case VerificationSuccess:
-		resetUserSigninErrorTimes(user)
+		if countCodes {
+			resetSigninFailures(user, attempt)
+		}
 		return ""
 	case wrongCodeError:
-		return recordSigninErrorInfo(user, lang)
+		if !countCodes {
+			return result.Msg
+		}
+		return recordSigninFailure(user, attempt, lang, false)
 	default:
 		return result.Msg
 	}
@@ -37,6 +37,10 @@ func getUsername(ctx *context.Context) (username string) {
 		}
 	}()
 
+	if username = getRequestUser(ctx); username != "" {
+		return
+	}
+
 	username = ctx.Input.Session("username").(string)
 
 	if username == "" {
@@ -110,6 +114,10 @@ func getUrlPath(urlPath string) string {
 		return "/cas"
 	}
 
+	if strings.HasPrefix(urlPath, "/scim/") {
+		return "/scim"
+	}
+
 	if strings.HasPrefix(urlPath, "/api/login/oauth") {
 		return "/api/login/oauth"
 	}
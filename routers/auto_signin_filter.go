@@ -16,6 +16,7 @@ package routers
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/beego/beego/context"
 	"github.com/casdoor/casdoor/object"
@@ -27,6 +28,29 @@ func AutoSigninFilter(ctx *context.Context) {
 	//	return
 	//}
 
+	// the SCIM endpoints are authenticated by their own bearer tokens, which aren't access tokens
+	if strings.HasPrefix(ctx.Request.URL.Path, "/scim/") {
+		return
+	}
+
+	// the API key of a service account like "X-Api-Key: cak_123_456" or "Authorization: Bearer cak_123_456"
+	apiKey := ctx.Request.Header.Get("X-Api-Key")
+	if bearerToken := parseBearerToken(ctx); strings.HasPrefix(bearerToken, object.ApiKeyPrefix) {
+		apiKey = bearerToken
+	}
+
+	if apiKey != "" {
+		user, msg := object.CheckApiKey(apiKey, util.GetIPFromRequest(ctx.Request), getAcceptLanguage(ctx))
+		if msg != "" {
+			responseError(ctx, msg)
+			return
+		}
+
+		// the API keys are sent with every request, a session would let the cookie outlive a revoked key
+		setRequestUser(ctx, user.GetId())
+		return
+	}
+
 	// GET parameter like "/page?access_token=123" or
 	// HTTP Bearer token like "Authorization: Bearer 123"
 	accessToken := util.GetMaxLenStr(ctx.Input.Query("accessToken"), ctx.Input.Query("access_token"), parseBearerToken(ctx))
@@ -62,7 +86,10 @@ func AutoSigninFilter(ctx *context.Context) {
 	password := ctx.Input.Query("password")
 	if userId != "" && password != "" && ctx.Input.Query("grant_type") == "" {
 		owner, name := util.GetOwnerAndNameFromId(userId)
-		_, msg := object.CheckUserPassword(owner, name, password, "en")
+		user, msg := object.CheckUserPassword(owner, name, password, "en", &object.SigninAttempt{ClientIp: util.GetIPFromRequest(ctx.Request)})
+		if msg == "" {
+			msg = object.CheckPasswordExpired(user, "en")
+		}
 		if msg != "" {
 			responseError(ctx, msg)
 			return
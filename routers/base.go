@@ -99,6 +99,20 @@ func setSessionUser(ctx *context.Context, user string) {
 	ctx.Input.CruSession.SessionRelease(ctx.ResponseWriter)
 }
 
+// getRequestUser returns the service account signed in for the request only by its API key
+func getRequestUser(ctx *context.Context) string {
+	user, ok := ctx.Input.GetData(object.ApiKeyUsername).(string)
+	if !ok {
+		return ""
+	}
+
+	return user
+}
+
+func setRequestUser(ctx *context.Context, user string) {
+	ctx.Input.SetData(object.ApiKeyUsername, user)
+}
+
 func setSessionExpire(ctx *context.Context, ExpireTime int64) {
 	SessionData := struct{ ExpireTime int64 }{ExpireTime: ExpireTime}
 	err := ctx.Input.CruSession.Set("SessionData", util.StructToJson(SessionData))
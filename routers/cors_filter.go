@@ -37,7 +37,7 @@ func CorsFilter(ctx *context.Context) {
 		if object.IsOriginAllowed(origin) {
 			ctx.Output.Header(headerAllowOrigin, origin)
 			ctx.Output.Header(headerAllowMethods, "POST, GET, OPTIONS, DELETE")
-			ctx.Output.Header(headerAllowHeaders, "Content-Type, Authorization")
+			ctx.Output.Header(headerAllowHeaders, "Content-Type, Authorization, X-Api-Key")
 		} else {
 			ctx.ResponseWriter.WriteHeader(http.StatusForbidden)
 			return
@@ -27,6 +27,10 @@ func getUser(ctx *context.Context) (username string) {
 		}
 	}()
 
+	if username = getRequestUser(ctx); username != "" {
+		return
+	}
+
 	username = ctx.Input.Session("username").(string)
 
 	if username == "" {
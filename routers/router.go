@@ -61,6 +61,7 @@ func initAPI() {
 
 	beego.Router("/api/get-organizations", &controllers.ApiController{}, "GET:GetOrganizations")
 	beego.Router("/api/get-organization", &controllers.ApiController{}, "GET:GetOrganization")
+	beego.Router("/api/get-password-migration", &controllers.ApiController{}, "GET:GetPasswordMigration")
 	beego.Router("/api/update-organization", &controllers.ApiController{}, "POST:UpdateOrganization")
 	beego.Router("/api/add-organization", &controllers.ApiController{}, "POST:AddOrganization")
 	beego.Router("/api/delete-organization", &controllers.ApiController{}, "POST:DeleteOrganization")
@@ -75,7 +76,47 @@ func initAPI() {
 	beego.Router("/api/update-user", &controllers.ApiController{}, "POST:UpdateUser")
 	beego.Router("/api/add-user", &controllers.ApiController{}, "POST:AddUser")
 	beego.Router("/api/delete-user", &controllers.ApiController{}, "POST:DeleteUser")
+	beego.Router("/api/get-deleted-users", &controllers.ApiController{}, "GET:GetDeletedUsers")
+	beego.Router("/api/restore-user", &controllers.ApiController{}, "POST:RestoreUser")
+	beego.Router("/api/get-signin-lockouts", &controllers.ApiController{}, "GET:GetSigninLockouts")
+	beego.Router("/api/unlock-user", &controllers.ApiController{}, "POST:UnlockUser")
+	beego.Router("/api/merge-users", &controllers.ApiController{}, "POST:MergeUsers")
+	beego.Router("/api/get-user-merges", &controllers.ApiController{}, "GET:GetUserMerges")
+	beego.Router("/api/get-api-keys", &controllers.ApiController{}, "GET:GetApiKeys")
+	beego.Router("/api/get-api-key", &controllers.ApiController{}, "GET:GetApiKey")
+	beego.Router("/api/add-api-key", &controllers.ApiController{}, "POST:AddApiKey")
+	beego.Router("/api/update-api-key", &controllers.ApiController{}, "POST:UpdateApiKey")
+	beego.Router("/api/rotate-api-key", &controllers.ApiController{}, "POST:RotateApiKey")
+	beego.Router("/api/delete-api-key", &controllers.ApiController{}, "POST:DeleteApiKey")
+	beego.Router("/api/start-impersonation", &controllers.ApiController{}, "POST:StartImpersonation")
+	beego.Router("/api/stop-impersonation", &controllers.ApiController{}, "POST:StopImpersonation")
+	beego.Router("/api/end-impersonation", &controllers.ApiController{}, "POST:EndImpersonation")
+	beego.Router("/api/get-impersonations", &controllers.ApiController{}, "GET:GetImpersonations")
+	beego.Router("/api/get-impersonation", &controllers.ApiController{}, "GET:GetImpersonation")
 	beego.Router("/api/upload-users", &controllers.ApiController{}, "POST:UploadUsers")
+	beego.Router("/api/import-users", &controllers.ApiController{}, "POST:ImportUsers")
+	beego.Router("/api/export-users", &controllers.ApiController{}, "GET:ExportUsers")
+	beego.Router("/api/export-user-data", &controllers.ApiController{}, "GET:ExportUserData")
+	beego.Router("/api/erase-user-data", &controllers.ApiController{}, "POST:EraseUserData")
+	beego.Router("/api/get-data-requests", &controllers.ApiController{}, "GET:GetDataRequests")
+	beego.Router("/api/get-data-request", &controllers.ApiController{}, "GET:GetDataRequest")
+
+	beego.Router("/api/get-groups", &controllers.ApiController{}, "GET:GetGroups")
+	beego.Router("/api/get-group", &controllers.ApiController{}, "GET:GetGroup")
+	beego.Router("/api/update-group", &controllers.ApiController{}, "POST:UpdateGroup")
+	beego.Router("/api/add-group", &controllers.ApiController{}, "POST:AddGroup")
+	beego.Router("/api/delete-group", &controllers.ApiController{}, "POST:DeleteGroup")
+	beego.Router("/api/add-group-user", &controllers.ApiController{}, "POST:AddGroupUser")
+	beego.Router("/api/remove-group-user", &controllers.ApiController{}, "POST:RemoveGroupUser")
+
+	beego.Router("/api/get-invitations", &controllers.ApiController{}, "GET:GetInvitations")
+	beego.Router("/api/get-invitation", &controllers.ApiController{}, "GET:GetInvitation")
+	beego.Router("/api/get-invitation-info", &controllers.ApiController{}, "GET:GetInvitationInfo")
+	beego.Router("/api/update-invitation", &controllers.ApiController{}, "POST:UpdateInvitation")
+	beego.Router("/api/add-invitation", &controllers.ApiController{}, "POST:AddInvitation")
+	beego.Router("/api/delete-invitation", &controllers.ApiController{}, "POST:DeleteInvitation")
+	beego.Router("/api/revoke-invitation", &controllers.ApiController{}, "POST:RevokeInvitation")
+	beego.Router("/api/send-invitation", &controllers.ApiController{}, "POST:SendInvitation")
 
 	beego.Router("/api/get-roles", &controllers.ApiController{}, "GET:GetRoles")
 	beego.Router("/api/get-role", &controllers.ApiController{}, "GET:GetRole")
@@ -131,6 +172,20 @@ func initAPI() {
 	beego.Router("/api/update-ldap", &controllers.ApiController{}, "POST:UpdateLdap")
 	beego.Router("/api/delete-ldap", &controllers.ApiController{}, "POST:DeleteLdap")
 	beego.Router("/api/sync-ldap-users", &controllers.ApiController{}, "POST:SyncLdapUsers")
+	beego.Router("/api/run-ldap-sync", &controllers.ApiController{}, "POST:RunLdapSync")
+	beego.Router("/api/get-ldap-service-accounts", &controllers.ApiController{}, "GET:GetLdapServiceAccounts")
+	beego.Router("/api/get-ldap-service-account", &controllers.ApiController{}, "GET:GetLdapServiceAccount")
+	beego.Router("/api/add-ldap-service-account", &controllers.ApiController{}, "POST:AddLdapServiceAccount")
+	beego.Router("/api/update-ldap-service-account", &controllers.ApiController{}, "POST:UpdateLdapServiceAccount")
+	beego.Router("/api/delete-ldap-service-account", &controllers.ApiController{}, "POST:DeleteLdapServiceAccount")
+
+	beego.Router("/api/get-scim-tokens", &controllers.ApiController{}, "GET:GetScimTokens")
+	beego.Router("/api/get-scim-token", &controllers.ApiController{}, "GET:GetScimToken")
+	beego.Router("/api/update-scim-token", &controllers.ApiController{}, "POST:UpdateScimToken")
+	beego.Router("/api/add-scim-token", &controllers.ApiController{}, "POST:AddScimToken")
+	beego.Router("/api/delete-scim-token", &controllers.ApiController{}, "POST:DeleteScimToken")
+	beego.Router("/api/get-scim-provisionings", &controllers.ApiController{}, "GET:GetScimProvisionings")
+	beego.Router("/api/retry-scim-provisioning", &controllers.ApiController{}, "POST:RetryScimProvisioning")
 
 	beego.Router("/api/get-providers", &controllers.ApiController{}, "GET:GetProviders")
 	beego.Router("/api/get-provider", &controllers.ApiController{}, "GET:GetProvider")
@@ -185,6 +240,9 @@ func initAPI() {
 	beego.Router("/api/add-syncer", &controllers.ApiController{}, "POST:AddSyncer")
 	beego.Router("/api/delete-syncer", &controllers.ApiController{}, "POST:DeleteSyncer")
 	beego.Router("/api/run-syncer", &controllers.ApiController{}, "GET:RunSyncer")
+	beego.Router("/api/get-syncer-runs", &controllers.ApiController{}, "GET:GetSyncerRuns")
+	beego.Router("/api/get-syncer-run", &controllers.ApiController{}, "GET:GetSyncerRun")
+	beego.Router("/api/delete-syncer-run", &controllers.ApiController{}, "POST:DeleteSyncerRun")
 
 	beego.Router("/api/get-certs", &controllers.ApiController{}, "GET:GetCerts")
 	beego.Router("/api/get-globle-certs", &controllers.ApiController{}, "GET:GetGlobleCerts")
@@ -255,6 +313,8 @@ func initAPI() {
 	beego.Router("/cas/:organization/:application/p3/proxyValidate", &controllers.RootController{}, "GET:CasP3ServiceAndProxyValidate")
 	beego.Router("/cas/:organization/:application/samlValidate", &controllers.RootController{}, "POST:SamlValidate")
 
+	beego.Router("/scim/v2/:organization/*", &controllers.RootController{}, "GET,POST,PUT,PATCH,DELETE:HandleScim")
+
 	beego.Router("/api/webauthn/signup/begin", &controllers.ApiController{}, "Get:WebAuthnSignupBegin")
 	beego.Router("/api/webauthn/signup/finish", &controllers.ApiController{}, "Post:WebAuthnSignupFinish")
 	beego.Router("/api/webauthn/signin/begin", &controllers.ApiController{}, "Get:WebAuthnSigninBegin")
@@ -0,0 +1,157 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type BulkOperation struct {
+	Method  string          `json:"method"`
+	BulkId  string          `json:"bulkId,omitempty"`
+	Version string          `json:"version,omitempty"`
+	Path    string          `json:"path"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type BulkRequest struct {
+	Schemas      []string         `json:"schemas"`
+	FailOnErrors int              `json:"failOnErrors"`
+	Operations   []*BulkOperation `json:"Operations"`
+}
+
+type BulkOperationResult struct {
+	Method   string      `json:"method"`
+	BulkId   string      `json:"bulkId,omitempty"`
+	Version  string      `json:"version,omitempty"`
+	Location string      `json:"location,omitempty"`
+	Status   string      `json:"status"`
+	Response interface{} `json:"response,omitempty"`
+}
+
+type BulkResponse struct {
+	Schemas    []string               `json:"schemas"`
+	Operations []*BulkOperationResult `json:"Operations"`
+}
+
+// the prefix of the references to the resources created earlier in the same bulk request
+const bulkIdPrefix = "bulkId:"
+
+// handleBulk runs the operations in order, the "bulkId:" references are replaced by the ids of the created resources
+func (s *Server) handleBulk(r *Request) *Response {
+	if len(r.Body) > maxPayloadSize {
+		return errorResponse(NewError(http.StatusRequestEntityTooLarge, "", "the payload is larger than %d bytes", maxPayloadSize))
+	}
+
+	var bulk BulkRequest
+	err := json.Unmarshal(r.Body, &bulk)
+	if err != nil {
+		return errorResponse(badRequest(ErrorInvalidSyntax, "%s", err.Error()))
+	}
+	if len(bulk.Operations) > maxOperations {
+		return errorResponse(NewError(http.StatusRequestEntityTooLarge, ErrorTooMany, "the operations are more than %d", maxOperations))
+	}
+
+	ids := map[string]string{}
+	errorCount := 0
+	results := []*BulkOperationResult{}
+	for _, operation := range bulk.Operations {
+		if bulk.FailOnErrors > 0 && errorCount >= bulk.FailOnErrors {
+			break
+		}
+
+		result := s.runBulkOperation(operation, ids)
+		if status, _ := strconv.Atoi(result.Status); status >= http.StatusBadRequest {
+			errorCount++
+		}
+		results = append(results, result)
+	}
+
+	return okResponse(http.StatusOK, &BulkResponse{
+		Schemas:    []string{BulkResponseSchema},
+		Operations: results,
+	})
+}
+
+func (s *Server) runBulkOperation(operation *BulkOperation, ids map[string]string) *BulkOperationResult {
+	result := &BulkOperationResult{
+		Method: operation.Method,
+		BulkId: operation.BulkId,
+	}
+	setResponse := func(response *Response) *BulkOperationResult {
+		result.Status = strconv.Itoa(response.Status)
+		result.Location = response.Location
+		result.Version = response.ETag
+		if response.Status >= http.StatusBadRequest {
+			result.Response = response.Body
+		}
+		return result
+	}
+
+	method := strings.ToUpper(operation.Method)
+	if method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch && method != http.MethodDelete {
+		return setResponse(errorResponse(badRequest(ErrorInvalidSyntax, "the method: %s is not supported in bulk", operation.Method)))
+	}
+	if method == http.MethodPost && operation.BulkId == "" {
+		return setResponse(errorResponse(badRequest(ErrorInvalidSyntax, "the bulkId is required for POST")))
+	}
+
+	opPath := replaceBulkIds(operation.Path, ids)
+	data := replaceBulkIds(string(operation.Data), ids)
+	if strings.Contains(opPath, bulkIdPrefix) || strings.Contains(data, bulkIdPrefix) {
+		return setResponse(errorResponse(NewError(http.StatusConflict, ErrorInvalidValue, "the operation references an unknown bulkId")))
+	}
+
+	segments := strings.Split(strings.Trim(opPath, "/"), "/")
+	if segments[0] != "Users" && segments[0] != "Groups" {
+		return setResponse(errorResponse(badRequest(ErrorInvalidPath, "the path: %s is invalid in bulk", operation.Path)))
+	}
+
+	response := s.Handle(&Request{
+		Method:  method,
+		Path:    opPath,
+		Body:    []byte(data),
+		IfMatch: operation.Version,
+	})
+	if method == http.MethodPost && response.Status == http.StatusCreated {
+		id, err := url.PathUnescape(path.Base(response.Location))
+		if err == nil {
+			ids[operation.BulkId] = id
+		}
+	}
+	return setResponse(response)
+}
+
+func replaceBulkIds(s string, ids map[string]string) string {
+	// the longer bulk ids are replaced first, so "bulkId:ab" isn't replaced as "bulkId:a"
+	bulkIds := []string{}
+	for bulkId := range ids {
+		bulkIds = append(bulkIds, bulkId)
+	}
+	sort.Slice(bulkIds, func(i, j int) bool {
+		return len(bulkIds[i]) > len(bulkIds[j])
+	})
+
+	for _, bulkId := range bulkIds {
+		s = strings.ReplaceAll(s, bulkIdPrefix+bulkId, ids[bulkId])
+	}
+	return s
+}
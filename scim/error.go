@@ -0,0 +1,74 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// the scimType values of RFC 7644 section 3.12
+const (
+	ErrorInvalidFilter = "invalidFilter"
+	ErrorTooMany       = "tooMany"
+	ErrorUniqueness    = "uniqueness"
+	ErrorMutability    = "mutability"
+	ErrorInvalidSyntax = "invalidSyntax"
+	ErrorInvalidPath   = "invalidPath"
+	ErrorNoTarget      = "noTarget"
+	ErrorInvalidValue  = "invalidValue"
+	ErrorInvalidVers   = "invalidVers"
+)
+
+// Error is a SCIM error response, it is also returned as an error by the resource handlers
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func NewError(status int, scimType string, format string, a ...interface{}) *Error {
+	return &Error{
+		Schemas:  []string{ErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   fmt.Sprintf(format, a...),
+	}
+}
+
+func (e *Error) Error() string {
+	if e.ScimType == "" {
+		return e.Detail
+	}
+	return fmt.Sprintf("%s: %s", e.ScimType, e.Detail)
+}
+
+func (e *Error) GetStatus() int {
+	status, err := strconv.Atoi(e.Status)
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+func badRequest(scimType string, format string, a ...interface{}) *Error {
+	return NewError(http.StatusBadRequest, scimType, format, a...)
+}
+
+func notFound(format string, a ...interface{}) *Error {
+	return NewError(http.StatusNotFound, "", format, a...)
+}
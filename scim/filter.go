@@ -0,0 +1,536 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// Filter is a parsed filter of RFC 7644 section 3.4.2.2, it is matched against a resource or, in a value path,
+// against an element of a multi-valued attribute
+type Filter interface {
+	Matches(resource map[string]interface{}) bool
+}
+
+var compareOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true, "gt": true, "ge": true, "lt": true, "le": true,
+}
+
+// the schemas whose attributes can be written without their URN prefix
+var coreSchemas = []string{UserSchema, GroupSchema}
+
+var extensionSchemas = []string{EnterpriseUserSchema}
+
+// attrPath is an attribute path, such as "name.givenName" or "urn:...:enterprise:2.0:User:manager.value"
+type attrPath struct {
+	schema string
+	names  []string
+}
+
+type compareFilter struct {
+	path  *attrPath
+	op    string
+	value interface{}
+}
+
+type logicalFilter struct {
+	op    string
+	left  Filter
+	right Filter
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+type valuePathFilter struct {
+	path   *attrPath
+	filter Filter
+}
+
+func parseAttrPath(text string) (*attrPath, error) {
+	path := &attrPath{}
+	if strings.HasPrefix(strings.ToLower(text), "urn:") {
+		for _, schema := range extensionSchemas {
+			if strings.EqualFold(text, schema) {
+				path.schema = schema
+				return path, nil
+			}
+		}
+
+		i := strings.LastIndex(text, ":")
+		path.schema, text = text[:i], text[i+1:]
+		for _, schema := range coreSchemas {
+			if strings.EqualFold(path.schema, schema) {
+				path.schema = ""
+			}
+		}
+	}
+
+	path.names = strings.Split(text, ".")
+	if len(path.names) > 2 {
+		return nil, badRequest(ErrorInvalidPath, "the attribute path: %s is too deep", text)
+	}
+	for _, name := range path.names {
+		if name == "" {
+			return nil, badRequest(ErrorInvalidPath, "the attribute path: %s is invalid", text)
+		}
+	}
+	return path, nil
+}
+
+func (path *attrPath) String() string {
+	name := strings.Join(path.names, ".")
+	if path.schema == "" {
+		return name
+	}
+	if name == "" {
+		return path.schema
+	}
+	return path.schema + ":" + name
+}
+
+func (path *attrPath) getValues(resource map[string]interface{}) []interface{} {
+	var node interface{} = resource
+	if path.schema != "" {
+		node = getAttribute(resource, path.schema)
+	}
+	return collectValues(node, path.names)
+}
+
+// collectValues returns the values at the names, the elements of the multi-valued attributes are flattened
+func collectValues(node interface{}, names []string) []interface{} {
+	switch v := node.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		values := []interface{}{}
+		for _, element := range v {
+			values = append(values, collectValues(element, names)...)
+		}
+		return values
+	case map[string]interface{}:
+		if len(names) == 0 {
+			return []interface{}{v}
+		}
+		return collectValues(getAttribute(v, names[0]), names[1:])
+	default:
+		if len(names) == 0 {
+			return []interface{}{v}
+		}
+		return nil
+	}
+}
+
+// getAttributeName returns the key of the attribute in the map, attribute names are case-insensitive
+func getAttributeName(m map[string]interface{}, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return name, false
+}
+
+func getAttribute(m map[string]interface{}, name string) interface{} {
+	key, ok := getAttributeName(m, name)
+	if !ok {
+		return nil
+	}
+	return m[key]
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+func (f *compareFilter) Matches(resource map[string]interface{}) bool {
+	values := f.path.getValues(resource)
+	present := false
+	for _, value := range values {
+		if !isEmptyValue(value) {
+			present = true
+		}
+	}
+
+	if f.op == "pr" {
+		return present
+	}
+	if f.value == nil {
+		return (f.op == "eq") != present
+	}
+
+	caseExact := caseExactAttributes[strings.ToLower(f.path.names[len(f.path.names)-1])]
+	if f.op == "ne" {
+		for _, value := range values {
+			if compareValue(value, "eq", f.value, caseExact) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, value := range values {
+		if compareValue(value, f.op, f.value, caseExact) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareValue(value interface{}, op string, expected interface{}, caseExact bool) bool {
+	// a complex value is compared by its "value" sub-attribute, such as "emails eq ..."
+	if m, ok := value.(map[string]interface{}); ok {
+		value = getAttribute(m, "value")
+	}
+
+	switch v := value.(type) {
+	case string:
+		s, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		if !caseExact {
+			v, s = strings.ToLower(v), strings.ToLower(s)
+		}
+
+		switch op {
+		case "eq":
+			return v == s
+		case "co":
+			return strings.Contains(v, s)
+		case "sw":
+			return strings.HasPrefix(v, s)
+		case "ew":
+			return strings.HasSuffix(v, s)
+		case "gt":
+			return v > s
+		case "ge":
+			return v >= s
+		case "lt":
+			return v < s
+		case "le":
+			return v <= s
+		}
+	case bool:
+		b, ok := expected.(bool)
+		return ok && op == "eq" && v == b
+	case float64:
+		n, ok := expected.(float64)
+		if !ok {
+			return false
+		}
+
+		switch op {
+		case "eq":
+			return v == n
+		case "gt":
+			return v > n
+		case "ge":
+			return v >= n
+		case "lt":
+			return v < n
+		case "le":
+			return v <= n
+		}
+	}
+	return false
+}
+
+func (f *logicalFilter) Matches(resource map[string]interface{}) bool {
+	if f.op == "and" {
+		return f.left.Matches(resource) && f.right.Matches(resource)
+	}
+	return f.left.Matches(resource) || f.right.Matches(resource)
+}
+
+func (f *notFilter) Matches(resource map[string]interface{}) bool {
+	return !f.filter.Matches(resource)
+}
+
+func (f *valuePathFilter) Matches(resource map[string]interface{}) bool {
+	for _, value := range f.path.getValues(resource) {
+		if element, ok := value.(map[string]interface{}); ok && f.filter.Matches(element) {
+			return true
+		}
+	}
+	return false
+}
+
+// getEqualValue returns the value if the filter is "<name> eq <string value>", which can be looked up directly
+func getEqualValue(filter Filter, name string) (string, bool) {
+	f, ok := filter.(*compareFilter)
+	if !ok || f.op != "eq" || f.path.schema != "" || len(f.path.names) != 1 || !strings.EqualFold(f.path.names[0], name) {
+		return "", false
+	}
+
+	value, ok := f.value.(string)
+	return value, ok
+}
+
+type filterToken struct {
+	text     string
+	value    interface{}
+	isString bool
+}
+
+type filterParser struct {
+	tokens []*filterToken
+	pos    int
+}
+
+func tokenizeFilter(s string) ([]*filterToken, error) {
+	tokens := []*filterToken{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, &filterToken{text: string(c)})
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return nil, badRequest(ErrorInvalidFilter, "the string starting at %d is not terminated", i)
+			}
+
+			var value string
+			err := json.Unmarshal([]byte(s[i:j+1]), &value)
+			if err != nil {
+				return nil, badRequest(ErrorInvalidFilter, "the string: %s is invalid", s[i:j+1])
+			}
+			tokens = append(tokens, &filterToken{text: s[i : j+1], value: value, isString: true})
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(s) && !unicode.IsSpace(rune(s[j])) && !strings.ContainsRune("()[]\"", rune(s[j])); j++ {
+			}
+			tokens = append(tokens, &filterToken{text: s[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// ParseFilter parses a filter such as `userName eq "alice" and emails[type eq "work" and value co "@example.com"]`
+func ParseFilter(s string) (Filter, error) {
+	tokens, err := tokenizeFilter(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, badRequest(ErrorInvalidFilter, "unexpected: %s", p.tokens[p.pos].text)
+	}
+	return filter, nil
+}
+
+func (p *filterParser) peek() *filterToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) isKeyword(keyword string) bool {
+	token := p.peek()
+	return token != nil && !token.isString && strings.EqualFold(token.text, keyword)
+}
+
+func (p *filterParser) next() (*filterToken, error) {
+	token := p.peek()
+	if token == nil {
+		return nil, badRequest(ErrorInvalidFilter, "the filter ends unexpectedly")
+	}
+	p.pos++
+	return token, nil
+}
+
+func (p *filterParser) expect(text string) error {
+	token, err := p.next()
+	if err != nil {
+		return err
+	}
+	if token.isString || token.text != text {
+		return badRequest(ErrorInvalidFilter, "expected %s but got: %s", text, token.text)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("and") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (Filter, error) {
+	if !p.isKeyword("not") {
+		return p.parseAtom()
+	}
+
+	p.pos++
+	err := p.expect("(")
+	if err != nil {
+		return nil, err
+	}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	err = p.expect(")")
+	if err != nil {
+		return nil, err
+	}
+	return &notFilter{filter: filter}, nil
+}
+
+func (p *filterParser) parseAtom() (Filter, error) {
+	if p.isKeyword("(") {
+		p.pos++
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		err = p.expect(")")
+		if err != nil {
+			return nil, err
+		}
+		return filter, nil
+	}
+
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if token.isString || strings.ContainsAny(token.text, "()[]") {
+		return nil, badRequest(ErrorInvalidFilter, "expected an attribute but got: %s", token.text)
+	}
+	path, err := parseAttrPath(token.text)
+	if err != nil {
+		return nil, badRequest(ErrorInvalidFilter, "%s", err.(*Error).Detail)
+	}
+
+	if p.isKeyword("[") {
+		p.pos++
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		err = p.expect("]")
+		if err != nil {
+			return nil, err
+		}
+		return &valuePathFilter{path: path, filter: filter}, nil
+	}
+
+	token, err = p.next()
+	if err != nil {
+		return nil, err
+	}
+	op := strings.ToLower(token.text)
+	if op == "pr" && !token.isString {
+		return &compareFilter{path: path, op: op}, nil
+	}
+	if token.isString || !compareOperators[op] {
+		return nil, badRequest(ErrorInvalidFilter, "unknown operator: %s", token.text)
+	}
+
+	token, err = p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseFilterValue(token)
+	if err != nil {
+		return nil, err
+	}
+	return &compareFilter{path: path, op: op, value: value}, nil
+}
+
+func parseFilterValue(token *filterToken) (interface{}, error) {
+	if token.isString {
+		return token.value, nil
+	}
+
+	switch strings.ToLower(token.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	var value float64
+	err := json.Unmarshal([]byte(token.text), &value)
+	if err != nil {
+		return nil, badRequest(ErrorInvalidFilter, "the value: %s is invalid", token.text)
+	}
+	return value, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testUserResource = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	"id": "2819c223",
+	"externalId": "Bjensen",
+	"userName": "bjensen",
+	"name": {"givenName": "Barbara", "familyName": "Jensen"},
+	"active": true,
+	"emails": [
+		{"value": "bjensen@example.com", "type": "work", "primary": true},
+		{"value": "babs@jensen.org", "type": "home"}
+	],
+	"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"department": "Tour Operations", "employeeNumber": "701984"}
+}`
+
+func getTestResource(t *testing.T) map[string]interface{} {
+	resource := map[string]interface{}{}
+	err := json.Unmarshal([]byte(testUserResource), &resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resource
+}
+
+func TestFilter(t *testing.T) {
+	resource := getTestResource(t)
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`userName eq "BJensen"`, true},
+		{`externalId eq "bjensen"`, false},
+		{`name.familyName co "ens"`, true},
+		{`userName sw "bj" and active eq true`, true},
+		{`userName sw "x" or name.givenName ew "ara"`, true},
+		{`not (userName eq "bjensen")`, false},
+		{`emails co "jensen.org"`, true},
+		{`emails.value ew "@example.com"`, true},
+		{`emails[type eq "work" and value co "@example.com"]`, true},
+		{`emails[type eq "home" and value co "@example.com"]`, false},
+		{`title pr`, false},
+		{`title eq null`, true},
+		{`title ne "x"`, true},
+		{`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department eq "tour operations"`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bjensen"`, true},
+		{`(userName eq "x" or userName eq "bjensen") and not (active eq false)`, true},
+		{`id gt "2819c000" and id le "2819c223"`, true},
+	}
+	for _, test := range tests {
+		filter, err := ParseFilter(test.filter)
+		if err != nil {
+			t.Errorf("%s: %v", test.filter, err)
+			continue
+		}
+		if got := filter.Matches(resource); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.filter, got, test.want)
+		}
+	}
+
+	for _, s := range []string{`userName`, `userName eq`, `userName xx "a"`, `(userName eq "a"`, `userName eq "a`, `emails[type eq "work"`} {
+		_, err := ParseFilter(s)
+		if err == nil {
+			t.Errorf("%s: the invalid filter is parsed", s)
+		} else if e, ok := err.(*Error); !ok || e.ScimType != ErrorInvalidFilter {
+			t.Errorf("%s: unexpected error: %v", s, err)
+		}
+	}
+
+	filter, _ := ParseFilter(`userName eq "bjensen"`)
+	if name, ok := getEqualValue(filter, "username"); !ok || name != "bjensen" {
+		t.Errorf("the user name isn't looked up directly: %s", name)
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// userDirectory indexes the users of the organization for the group members, which reference the users by id
+type userDirectory struct {
+	byId   map[string]*object.User
+	byName map[string]*object.User
+}
+
+func newUserDirectory(users []*object.User) *userDirectory {
+	directory := &userDirectory{
+		byId:   map[string]*object.User{},
+		byName: map[string]*object.User{},
+	}
+	for _, user := range users {
+		directory.byId[user.Id] = user
+		directory.byName[user.GetId()] = user
+	}
+	return directory
+}
+
+func getRoleDisplayName(role *object.Role) string {
+	if role.DisplayName != "" {
+		return role.DisplayName
+	}
+	return role.Name
+}
+
+// getGroupResource returns the SCIM resource of the role without its meta, the nested roles are members of type Group
+func (s *Server) getGroupResource(role *object.Role, directory *userDirectory) map[string]interface{} {
+	members := []interface{}{}
+	for _, userId := range role.Users {
+		user, ok := directory.byName[userId]
+		if !ok {
+			continue
+		}
+
+		display := user.DisplayName
+		if display == "" {
+			display = user.Name
+		}
+		members = append(members, map[string]interface{}{
+			"value":   user.Id,
+			"display": display,
+			"type":    "User",
+			"$ref":    s.getLocation("Users", user.Id),
+		})
+	}
+	for _, roleId := range role.Roles {
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(roleId)
+		if owner != s.Organization {
+			continue
+		}
+
+		members = append(members, map[string]interface{}{
+			"value": name,
+			"type":  "Group",
+			"$ref":  s.getLocation("Groups", name),
+		})
+	}
+
+	resource := map[string]interface{}{
+		"schemas":     []interface{}{GroupSchema},
+		"id":          role.Name,
+		"displayName": getRoleDisplayName(role),
+	}
+	if len(members) != 0 {
+		resource["members"] = members
+	}
+	return resource
+}
+
+// applyGroupResource sets the role's display name and members from the resource, the members are users or, with
+// the type Group, roles of the organization
+func (s *Server) applyGroupResource(role *object.Role, resource map[string]interface{}, directory *userDirectory) error {
+	displayName := getString(resource, "displayName")
+	if displayName == "" {
+		return badRequest(ErrorInvalidValue, "the displayName is required")
+	}
+	role.DisplayName = displayName
+
+	// the members which can't be referenced by the client, such as the users of other organizations, are kept
+	users := []string{}
+	for _, userId := range role.Users {
+		if _, ok := directory.byName[userId]; !ok {
+			users = append(users, userId)
+		}
+	}
+	roles := []string{}
+	for _, roleId := range role.Roles {
+		if owner, _ := util.GetOwnerAndNameFromIdNoCheck(roleId); owner != s.Organization {
+			roles = append(roles, roleId)
+		}
+	}
+	role.Users, role.Roles = users, roles
+
+	userSet := map[string]bool{}
+	roleSet := map[string]bool{}
+	members, _ := getAttribute(resource, "members").([]interface{})
+	for _, member := range members {
+		m, ok := member.(map[string]interface{})
+		if !ok {
+			return badRequest(ErrorInvalidValue, "the member must be an object")
+		}
+
+		value := getString(m, "value")
+		isGroup := strings.EqualFold(getString(m, "type"), "Group")
+		if user, ok := directory.byId[value]; ok && !isGroup {
+			if !userSet[user.GetId()] {
+				userSet[user.GetId()] = true
+				role.Users = append(role.Users, user.GetId())
+			}
+			continue
+		}
+
+		roleId := util.GetId(s.Organization, value)
+		if value == role.Name || object.GetRole(roleId) == nil {
+			return badRequest(ErrorInvalidValue, "the member: %s doesn't exist", value)
+		}
+		if !roleSet[roleId] {
+			roleSet[roleId] = true
+			role.Roles = append(role.Roles, roleId)
+		}
+	}
+	return nil
+}
+
+type groupHandler struct {
+	s *Server
+}
+
+func (h *groupHandler) getDirectory() *userDirectory {
+	return newUserDirectory((&userHandler{s: h.s}).getUsers())
+}
+
+func (h *groupHandler) getRole(id string) (*object.Role, error) {
+	role := object.GetRole(util.GetId(h.s.Organization, id))
+	if role == nil {
+		return nil, notFound("the group: %s doesn't exist", id)
+	}
+	return role, nil
+}
+
+func (h *groupHandler) newEntry(role *object.Role, directory *userDirectory) *resourceEntry {
+	return &resourceEntry{
+		id:       role.Name,
+		resource: h.s.getGroupResource(role, directory),
+		meta: &Meta{
+			ResourceType: "Group",
+			Created:      role.CreatedTime,
+			LastModified: role.CreatedTime,
+			Location:     h.s.getLocation("Groups", role.Name),
+		},
+	}
+}
+
+func (h *groupHandler) list(filter Filter) ([]*resourceEntry, error) {
+	directory := h.getDirectory()
+
+	entries := []*resourceEntry{}
+	for _, role := range object.GetRoles(h.s.Organization) {
+		entry := h.newEntry(role, directory)
+		if filter == nil || filter.Matches(entry.resource) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (h *groupHandler) get(id string) (*resourceEntry, error) {
+	role, err := h.getRole(id)
+	if err != nil {
+		return nil, err
+	}
+	return h.newEntry(role, h.getDirectory()), nil
+}
+
+// create adds a role named by the display name, the name is the id of the group and isn't changed by later renames
+func (h *groupHandler) create(resource map[string]interface{}) (*resourceEntry, error) {
+	name := getString(resource, "displayName")
+	if strings.Contains(name, "/") {
+		return nil, badRequest(ErrorInvalidValue, "the displayName: %s can't contain \"/\"", name)
+	}
+	if name != "" && object.GetRole(util.GetId(h.s.Organization, name)) != nil {
+		return nil, NewError(http.StatusConflict, ErrorUniqueness, "the group: %s already exists", name)
+	}
+
+	role := &object.Role{
+		Owner:       h.s.Organization,
+		Name:        name,
+		CreatedTime: util.GetCurrentTime(),
+		Users:       []string{},
+		Roles:       []string{},
+		Domains:     []string{},
+		IsEnabled:   true,
+	}
+	directory := h.getDirectory()
+	err := h.s.applyGroupResource(role, resource, directory)
+	if err != nil {
+		return nil, err
+	}
+
+	if !object.AddRole(role) {
+		return nil, NewError(http.StatusInternalServerError, "", "failed to add the group: %s", role.Name)
+	}
+	return h.newEntry(role, directory), nil
+}
+
+func (h *groupHandler) replace(entry *resourceEntry, resource map[string]interface{}) (*resourceEntry, error) {
+	role, err := h.getRole(entry.id)
+	if err != nil {
+		return nil, err
+	}
+
+	directory := h.getDirectory()
+	err = h.s.applyGroupResource(role, resource, directory)
+	if err != nil {
+		return nil, err
+	}
+
+	object.UpdateRole(role.GetId(), role)
+	return h.newEntry(role, directory), nil
+}
+
+func (h *groupHandler) delete(entry *resourceEntry) error {
+	role, err := h.getRole(entry.id)
+	if err != nil {
+		return err
+	}
+
+	object.DeleteRole(role)
+	return nil
+}
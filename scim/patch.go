@@ -0,0 +1,360 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"strings"
+)
+
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+type PatchRequest struct {
+	Schemas    []string          `json:"schemas"`
+	Operations []*PatchOperation `json:"Operations"`
+}
+
+// the attributes managed by the server, they can't be patched
+var readOnlyAttributes = map[string]bool{
+	"id":      true,
+	"meta":    true,
+	"groups":  true,
+	"schemas": true,
+}
+
+// patchPath is a path of a PATCH operation, such as `members[value eq "..."]` or `emails[type eq "work"].value`
+type patchPath struct {
+	path    *attrPath
+	filter  Filter
+	subAttr string
+}
+
+func parsePatchPath(s string) (*patchPath, error) {
+	result := &patchPath{}
+	pathText := s
+	if i := strings.Index(s, "["); i >= 0 {
+		j := strings.LastIndex(s, "]")
+		if j < i {
+			return nil, badRequest(ErrorInvalidPath, "the path: %s is invalid", s)
+		}
+
+		filter, err := ParseFilter(s[i+1 : j])
+		if err != nil {
+			return nil, badRequest(ErrorInvalidPath, "the filter of the path: %s is invalid", s)
+		}
+		result.filter = filter
+
+		rest := s[j+1:]
+		if rest != "" {
+			if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+				return nil, badRequest(ErrorInvalidPath, "the path: %s is invalid", s)
+			}
+			result.subAttr = rest[1:]
+		}
+		pathText = s[:i]
+	}
+
+	path, err := parseAttrPath(pathText)
+	if err != nil {
+		return nil, err
+	}
+	if result.filter != nil && len(path.names) != 1 {
+		return nil, badRequest(ErrorInvalidPath, "the path: %s is invalid", s)
+	}
+	if len(path.names) != 0 && path.schema == "" && readOnlyAttributes[strings.ToLower(path.names[0])] {
+		return nil, badRequest(ErrorMutability, "the attribute: %s is read-only", path.names[0])
+	}
+
+	result.path = path
+	return result, nil
+}
+
+// ApplyPatch applies the operations to the resource in place, the operations are applied in order
+func ApplyPatch(resource map[string]interface{}, operations []*PatchOperation) error {
+	for _, operation := range operations {
+		err := applyOperation(resource, operation)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyOperation(resource map[string]interface{}, operation *PatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return badRequest(ErrorInvalidSyntax, "unknown operation: %s", operation.Op)
+	}
+
+	if operation.Path == "" {
+		if op == "remove" {
+			return badRequest(ErrorNoTarget, "the path is required for the remove operation")
+		}
+
+		// the value is a set of attributes, their names can be paths such as "name.givenName"
+		values, ok := operation.Value.(map[string]interface{})
+		if !ok {
+			return badRequest(ErrorInvalidValue, "the value must be an object when the path is empty")
+		}
+		for name, value := range values {
+			if strings.EqualFold(name, "schemas") {
+				continue
+			}
+
+			err := applyOperation(resource, &PatchOperation{Op: op, Path: name, Value: value})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path, err := parsePatchPath(operation.Path)
+	if err != nil {
+		return err
+	}
+	if op != "remove" && operation.Value == nil {
+		return badRequest(ErrorInvalidValue, "the value of the path: %s is missing", operation.Path)
+	}
+
+	container := getContainer(resource, path.path.schema, op != "remove")
+	if container == nil {
+		return nil
+	}
+
+	names := path.path.names
+	if len(names) == 0 {
+		// the path is an extension schema
+		if op == "remove" {
+			deleteAttribute(resource, path.path.schema)
+			return nil
+		}
+		return setValue(resource, path.path.schema, operation.Value, op)
+	}
+
+	if path.filter != nil {
+		return applyFilteredOperation(container, names[0], path, op, operation.Value)
+	}
+
+	if len(names) == 1 {
+		if op == "remove" {
+			return removeValue(container, names[0], operation.Value)
+		}
+		return setValue(container, names[0], operation.Value, op)
+	}
+
+	// a sub-attribute, such as "name.givenName", it is applied to all the elements of a multi-valued attribute
+	parent := getAttribute(container, names[0])
+	switch v := parent.(type) {
+	case []interface{}:
+		for _, element := range v {
+			if m, ok := element.(map[string]interface{}); ok {
+				err = applySubAttribute(m, names[1], op, operation.Value)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		return applySubAttribute(v, names[1], op, operation.Value)
+	default:
+		if op != "remove" {
+			container[names[0]] = map[string]interface{}{names[1]: operation.Value}
+		}
+	}
+	return nil
+}
+
+func applySubAttribute(m map[string]interface{}, name string, op string, value interface{}) error {
+	if op == "remove" {
+		deleteAttribute(m, name)
+		return nil
+	}
+	return setValue(m, name, value, op)
+}
+
+func applyFilteredOperation(container map[string]interface{}, name string, path *patchPath, op string, value interface{}) error {
+	key, _ := getAttributeName(container, name)
+	elements, _ := container[key].([]interface{})
+
+	matched := false
+	result := []interface{}{}
+	for _, element := range elements {
+		m, ok := element.(map[string]interface{})
+		if !ok || !path.filter.Matches(m) {
+			result = append(result, element)
+			continue
+		}
+
+		matched = true
+		if op == "remove" && path.subAttr == "" {
+			continue
+		}
+
+		var err error
+		if path.subAttr != "" {
+			err = applySubAttribute(m, path.subAttr, op, value)
+		} else {
+			err = mergeValue(m, value)
+		}
+		if err != nil {
+			return err
+		}
+		result = append(result, m)
+	}
+
+	if !matched {
+		if op == "remove" {
+			return badRequest(ErrorNoTarget, "no value matches the path: %s", path.path.String())
+		}
+
+		// the element is created from the filter, such as `emails[type eq "work"].value`
+		element := getFilterAttributes(path.filter)
+		var err error
+		if path.subAttr != "" {
+			err = setValue(element, path.subAttr, value, op)
+		} else {
+			err = mergeValue(element, value)
+		}
+		if err != nil {
+			return err
+		}
+		result = append(result, element)
+	}
+
+	container[key] = result
+	return nil
+}
+
+// getFilterAttributes returns the attributes set by the equality comparisons of the filter
+func getFilterAttributes(filter Filter) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	switch f := filter.(type) {
+	case *compareFilter:
+		if f.op == "eq" && f.path.schema == "" && len(f.path.names) == 1 && f.value != nil {
+			attributes[f.path.names[0]] = f.value
+		}
+	case *logicalFilter:
+		if f.op == "and" {
+			for k, v := range getFilterAttributes(f.left) {
+				attributes[k] = v
+			}
+			for k, v := range getFilterAttributes(f.right) {
+				attributes[k] = v
+			}
+		}
+	}
+	return attributes
+}
+
+func getContainer(resource map[string]interface{}, schema string, create bool) map[string]interface{} {
+	if schema == "" {
+		return resource
+	}
+
+	key, _ := getAttributeName(resource, schema)
+	container, ok := resource[key].(map[string]interface{})
+	if !ok && create {
+		container = map[string]interface{}{}
+		resource[key] = container
+	}
+	return container
+}
+
+func deleteAttribute(m map[string]interface{}, name string) {
+	key, ok := getAttributeName(m, name)
+	if ok {
+		delete(m, key)
+	}
+}
+
+func mergeValue(m map[string]interface{}, value interface{}) error {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return badRequest(ErrorInvalidValue, "the value must be an object")
+	}
+
+	for k, v := range values {
+		key, _ := getAttributeName(m, k)
+		m[key] = v
+	}
+	return nil
+}
+
+// setValue adds or replaces the attribute, an added value is appended to a multi-valued attribute and the
+// sub-attributes of a complex attribute are merged
+func setValue(m map[string]interface{}, name string, value interface{}, op string) error {
+	key, _ := getAttributeName(m, name)
+	switch existing := m[key].(type) {
+	case []interface{}:
+		if op == "add" {
+			if values, ok := value.([]interface{}); ok {
+				m[key] = append(existing, values...)
+			} else {
+				m[key] = append(existing, value)
+			}
+			return nil
+		}
+	case map[string]interface{}:
+		if _, ok := value.(map[string]interface{}); ok {
+			return mergeValue(existing, value)
+		}
+	}
+
+	m[key] = value
+	return nil
+}
+
+// removeValue removes the attribute, or only the given elements of a multi-valued attribute, matched by "value"
+func removeValue(m map[string]interface{}, name string, value interface{}) error {
+	key, ok := getAttributeName(m, name)
+	if !ok {
+		return nil
+	}
+
+	elements, isMultiValued := m[key].([]interface{})
+	if value == nil || !isMultiValued {
+		delete(m, key)
+		return nil
+	}
+
+	removed := map[string]bool{}
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	for _, v := range values {
+		if element, ok := v.(map[string]interface{}); ok {
+			if s, ok := getAttribute(element, "value").(string); ok {
+				removed[s] = true
+			}
+		}
+	}
+
+	result := []interface{}{}
+	for _, element := range elements {
+		if e, ok := element.(map[string]interface{}); ok {
+			if s, ok := getAttribute(e, "value").(string); ok && removed[s] {
+				continue
+			}
+		}
+		result = append(result, element)
+	}
+	m[key] = result
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func applyTestPatch(t *testing.T, resource map[string]interface{}, operations string) error {
+	var patch PatchRequest
+	err := json.Unmarshal([]byte(operations), &patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ApplyPatch(resource, patch.Operations)
+}
+
+func TestApplyPatch(t *testing.T) {
+	resource := getTestResource(t)
+	err := applyTestPatch(t, resource, `{"Operations": [
+		{"op": "Replace", "path": "name.givenName", "value": "Babs"},
+		{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "babs@example.com"},
+		{"op": "add", "path": "phoneNumbers[type eq \"work\"].value", "value": "555-1234"},
+		{"op": "remove", "path": "emails[type eq \"home\"]"},
+		{"op": "replace", "value": {"active": "False", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department": "Sales", "title": "Tour Guide"}},
+		{"op": "add", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User", "value": {"costCenter": "4130"}}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := newTestUser(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.FirstName != "Babs" || user.Email != "babs@example.com" || user.Phone != "555-1234" || !user.IsForbidden || user.Title != "Tour Guide" {
+		t.Errorf("unexpected patched user: %s, %s, %s, %v, %s", user.FirstName, user.Email, user.Phone, user.IsForbidden, user.Title)
+	}
+	if user.Affiliation != "Sales" || user.Properties["costCenter"] != "4130" || user.Properties["employeeNumber"] != "701984" {
+		t.Errorf("unexpected patched extension: %s, %v", user.Affiliation, user.Properties)
+	}
+	if emails := resource["emails"].([]interface{}); len(emails) != 1 {
+		t.Errorf("the home email isn't removed: %v", emails)
+	}
+
+	group := map[string]interface{}{"displayName": "Admins", "members": []interface{}{
+		map[string]interface{}{"value": "1"}, map[string]interface{}{"value": "2"},
+	}}
+	err = applyTestPatch(t, group, `{"Operations": [
+		{"op": "add", "path": "members", "value": [{"value": "3"}]},
+		{"op": "remove", "path": "members", "value": [{"value": "1"}]},
+		{"op": "remove", "path": "members[value eq \"2\"]"}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if members := group["members"].([]interface{}); len(members) != 1 || members[0].(map[string]interface{})["value"] != "3" {
+		t.Errorf("unexpected patched members: %v", members)
+	}
+
+	for _, operations := range []string{
+		`{"Operations": [{"op": "remove", "path": "members[value eq \"9\"]"}]}`,
+		`{"Operations": [{"op": "replace", "path": "id", "value": "1"}]}`,
+		`{"Operations": [{"op": "move", "path": "displayName", "value": "x"}]}`,
+		`{"Operations": [{"op": "remove"}]}`,
+	} {
+		if err = applyTestPatch(t, group, operations); err == nil {
+			t.Errorf("the invalid patch is applied: %s", operations)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import "strings"
+
+const (
+	UserSchema           = "urn:ietf:params:scim:schemas:core:2.0:User"
+	EnterpriseUserSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	GroupSchema          = "urn:ietf:params:scim:schemas:core:2.0:Group"
+
+	ListResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SearchRequestSchema         = "urn:ietf:params:scim:api:messages:2.0:SearchRequest"
+	PatchOpSchema               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	BulkRequestSchema           = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	BulkResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+	ErrorSchema                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ServiceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ResourceTypeSchema          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+// the limits advertised in the service provider config
+const (
+	maxResults        = 1000
+	maxOperations     = 1000
+	maxPayloadSize    = 1048576
+	defaultStartIndex = 1
+)
+
+// the attributes compared case-sensitively in filters, the other string attributes are compared case-insensitively
+var caseExactAttributes = map[string]bool{
+	"id":         true,
+	"externalid": true,
+	"password":   true,
+	"version":    true,
+	"$ref":       true,
+}
+
+// Attribute is an attribute definition of a schema, as returned by the /Schemas endpoint
+type Attribute struct {
+	Name          string       `json:"name"`
+	Type          string       `json:"type"`
+	MultiValued   bool         `json:"multiValued"`
+	Description   string       `json:"description,omitempty"`
+	Required      bool         `json:"required"`
+	CaseExact     bool         `json:"caseExact"`
+	Mutability    string       `json:"mutability"`
+	Returned      string       `json:"returned"`
+	Uniqueness    string       `json:"uniqueness"`
+	SubAttributes []*Attribute `json:"subAttributes,omitempty"`
+}
+
+type Schema struct {
+	Schemas     []string     `json:"schemas"`
+	Id          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Attributes  []*Attribute `json:"attributes"`
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+type SchemaExtension struct {
+	Schema   string `json:"schema"`
+	Required bool   `json:"required"`
+}
+
+type ResourceType struct {
+	Schemas          []string           `json:"schemas"`
+	Id               string             `json:"id"`
+	Name             string             `json:"name"`
+	Endpoint         string             `json:"endpoint"`
+	Description      string             `json:"description"`
+	Schema           string             `json:"schema"`
+	SchemaExtensions []*SchemaExtension `json:"schemaExtensions,omitempty"`
+	Meta             *Meta              `json:"meta,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+type Supported struct {
+	Supported bool `json:"supported"`
+}
+
+type BulkSupported struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type FilterSupported struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type AuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+}
+
+type ServiceProviderConfig struct {
+	Schemas               []string                `json:"schemas"`
+	DocumentationUri      string                  `json:"documentationUri,omitempty"`
+	Patch                 Supported               `json:"patch"`
+	Bulk                  BulkSupported           `json:"bulk"`
+	Filter                FilterSupported         `json:"filter"`
+	ChangePassword        Supported               `json:"changePassword"`
+	Sort                  Supported               `json:"sort"`
+	Etag                  Supported               `json:"etag"`
+	AuthenticationSchemes []*AuthenticationScheme `json:"authenticationSchemes"`
+	Meta                  *Meta                   `json:"meta,omitempty"`
+}
+
+func newAttribute(name string, typ string, subAttributes ...*Attribute) *Attribute {
+	attribute := &Attribute{
+		Name:          name,
+		Type:          typ,
+		CaseExact:     caseExactAttributes[strings.ToLower(name)],
+		Mutability:    "readWrite",
+		Returned:      "default",
+		Uniqueness:    "none",
+		SubAttributes: subAttributes,
+	}
+	if len(subAttributes) != 0 {
+		attribute.Type = "complex"
+	}
+	return attribute
+}
+
+func (attribute *Attribute) multiValued() *Attribute {
+	attribute.MultiValued = true
+	return attribute
+}
+
+func (attribute *Attribute) readOnly() *Attribute {
+	attribute.Mutability = "readOnly"
+	return attribute
+}
+
+func multiValuedAttribute(name string) *Attribute {
+	return newAttribute(name, "complex",
+		newAttribute("value", "string"),
+		newAttribute("display", "string"),
+		newAttribute("type", "string"),
+		newAttribute("primary", "boolean"),
+	).multiValued()
+}
+
+func getUserAttributes() []*Attribute {
+	userName := newAttribute("userName", "string")
+	userName.Required = true
+	userName.Uniqueness = "server"
+
+	password := newAttribute("password", "string")
+	password.Mutability = "writeOnly"
+	password.Returned = "never"
+
+	return []*Attribute{
+		userName,
+		newAttribute("name", "complex",
+			newAttribute("formatted", "string"),
+			newAttribute("familyName", "string"),
+			newAttribute("givenName", "string"),
+		),
+		newAttribute("displayName", "string"),
+		newAttribute("profileUrl", "reference"),
+		newAttribute("title", "string"),
+		newAttribute("userType", "string"),
+		newAttribute("preferredLanguage", "string"),
+		newAttribute("active", "boolean"),
+		password,
+		multiValuedAttribute("emails"),
+		multiValuedAttribute("phoneNumbers"),
+		multiValuedAttribute("photos"),
+		newAttribute("addresses", "complex",
+			newAttribute("formatted", "string"),
+			newAttribute("locality", "string"),
+			newAttribute("region", "string"),
+			newAttribute("country", "string"),
+			newAttribute("type", "string"),
+			newAttribute("primary", "boolean"),
+		).multiValued(),
+		newAttribute("groups", "complex",
+			newAttribute("value", "string").readOnly(),
+			newAttribute("$ref", "reference").readOnly(),
+			newAttribute("display", "string").readOnly(),
+		).multiValued().readOnly(),
+	}
+}
+
+func getEnterpriseUserAttributes() []*Attribute {
+	return []*Attribute{
+		newAttribute("employeeNumber", "string"),
+		newAttribute("costCenter", "string"),
+		newAttribute("organization", "string"),
+		newAttribute("division", "string"),
+		newAttribute("department", "string"),
+		newAttribute("manager", "complex",
+			newAttribute("value", "string"),
+			newAttribute("displayName", "string").readOnly(),
+		),
+	}
+}
+
+func getGroupAttributes() []*Attribute {
+	displayName := newAttribute("displayName", "string")
+	displayName.Required = true
+
+	return []*Attribute{
+		displayName,
+		newAttribute("members", "complex",
+			newAttribute("value", "string"),
+			newAttribute("$ref", "reference"),
+			newAttribute("display", "string").readOnly(),
+			newAttribute("type", "string"),
+		).multiValued(),
+	}
+}
+
+func (s *Server) getSchemas() []*Schema {
+	schemas := []*Schema{
+		{Id: UserSchema, Name: "User", Description: "User Account", Attributes: getUserAttributes()},
+		{Id: EnterpriseUserSchema, Name: "EnterpriseUser", Description: "Enterprise User", Attributes: getEnterpriseUserAttributes()},
+		{Id: GroupSchema, Name: "Group", Description: "Group, a role of the organization", Attributes: getGroupAttributes()},
+	}
+	for _, schema := range schemas {
+		schema.Schemas = []string{SchemaSchema}
+		schema.Meta = &Meta{ResourceType: "Schema", Location: s.getLocation("Schemas", schema.Id)}
+	}
+	return schemas
+}
+
+func (s *Server) getResourceTypes() []*ResourceType {
+	resourceTypes := []*ResourceType{
+		{
+			Id:               "User",
+			Name:             "User",
+			Endpoint:         "/Users",
+			Description:      "User Account",
+			Schema:           UserSchema,
+			SchemaExtensions: []*SchemaExtension{{Schema: EnterpriseUserSchema, Required: false}},
+		},
+		{
+			Id:          "Group",
+			Name:        "Group",
+			Endpoint:    "/Groups",
+			Description: "Group, a role of the organization",
+			Schema:      GroupSchema,
+		},
+	}
+	for _, resourceType := range resourceTypes {
+		resourceType.Schemas = []string{ResourceTypeSchema}
+		resourceType.Meta = &Meta{ResourceType: "ResourceType", Location: s.getLocation("ResourceTypes", resourceType.Id)}
+	}
+	return resourceTypes
+}
+
+func (s *Server) getServiceProviderConfig() *ServiceProviderConfig {
+	return &ServiceProviderConfig{
+		Schemas:          []string{ServiceProviderConfigSchema},
+		DocumentationUri: "https://casdoor.org/docs/overview",
+		Patch:            Supported{Supported: true},
+		Bulk:             BulkSupported{Supported: true, MaxOperations: maxOperations, MaxPayloadSize: maxPayloadSize},
+		Filter:           FilterSupported{Supported: true, MaxResults: maxResults},
+		ChangePassword:   Supported{Supported: true},
+		Sort:             Supported{Supported: false},
+		Etag:             Supported{Supported: true},
+		AuthenticationSchemes: []*AuthenticationScheme{
+			{
+				Type:        "oauthbearertoken",
+				Name:        "OAuth Bearer Token",
+				Description: "Authentication with a SCIM token of the organization",
+				Primary:     true,
+			},
+		},
+		Meta: &Meta{ResourceType: "ServiceProviderConfig", Location: s.getLocation("ServiceProviderConfig", "")},
+	}
+}
+
+// IsDiscoveryPath returns whether the path, relative to the SCIM base url, is a discovery endpoint, which is
+// served without authentication
+func IsDiscoveryPath(path string) bool {
+	path = strings.Trim(path, "/")
+	for _, endpoint := range []string{"ServiceProviderConfig", "Schemas", "ResourceTypes"} {
+		if path == endpoint || strings.HasPrefix(path, endpoint+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,484 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// Server is the SCIM 2.0 service provider of an organization, its users are the SCIM Users and its roles the
+// SCIM Groups
+type Server struct {
+	Organization string
+	// BaseUrl is the url of the SCIM endpoints of the organization, such as "https://door.example.com/scim/v2/my-org"
+	BaseUrl string
+}
+
+// Request is a SCIM request, the path is relative to the base url, such as "/Users/123"
+type Request struct {
+	Method      string
+	Path        string
+	Query       url.Values
+	Body        []byte
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// Response is a SCIM response, the body is written as JSON if it isn't nil
+type Response struct {
+	Status   int
+	Body     interface{}
+	ETag     string
+	Location string
+}
+
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type SearchRequest struct {
+	Schemas            []string `json:"schemas"`
+	Attributes         []string `json:"attributes"`
+	ExcludedAttributes []string `json:"excludedAttributes"`
+	Filter             string   `json:"filter"`
+	StartIndex         int      `json:"startIndex"`
+	Count              *int     `json:"count"`
+}
+
+func NewServer(organization string, baseUrl string) *Server {
+	return &Server{
+		Organization: organization,
+		BaseUrl:      strings.TrimSuffix(baseUrl, "/"),
+	}
+}
+
+func (s *Server) getLocation(resourceType string, id string) string {
+	if id == "" {
+		return fmt.Sprintf("%s/%s", s.BaseUrl, resourceType)
+	}
+	return fmt.Sprintf("%s/%s/%s", s.BaseUrl, resourceType, url.PathEscape(id))
+}
+
+func errorResponse(err error) *Response {
+	e, ok := err.(*Error)
+	if !ok {
+		e = NewError(http.StatusInternalServerError, "", "%s", err.Error())
+	}
+	return &Response{Status: e.GetStatus(), Body: e}
+}
+
+func okResponse(status int, body interface{}) *Response {
+	return &Response{Status: status, Body: body}
+}
+
+// Handle serves the SCIM request, the caller is responsible for the authentication
+func (s *Server) Handle(r *Request) *Response {
+	path := strings.Trim(r.Path, "/")
+	segments := strings.SplitN(path, "/", 2)
+	endpoint, id := segments[0], ""
+	if len(segments) == 2 {
+		var err error
+		id, err = url.PathUnescape(segments[1])
+		if err != nil {
+			return errorResponse(badRequest(ErrorInvalidPath, "the path: %s is invalid", r.Path))
+		}
+	}
+
+	switch endpoint {
+	case "Users", "Groups":
+		return s.handleResources(r, endpoint, id)
+	case "Bulk":
+		if r.Method != http.MethodPost || id != "" {
+			return errorResponse(NewError(http.StatusMethodNotAllowed, "", "the method: %s is not allowed", r.Method))
+		}
+		return s.handleBulk(r)
+	case "ServiceProviderConfig":
+		return okResponse(http.StatusOK, s.getServiceProviderConfig())
+	case "Schemas":
+		schemas := []interface{}{}
+		for _, schema := range s.getSchemas() {
+			if schema.Id == id {
+				return okResponse(http.StatusOK, schema)
+			}
+			schemas = append(schemas, schema)
+		}
+		if id != "" {
+			return errorResponse(notFound("the schema: %s doesn't exist", id))
+		}
+		return okResponse(http.StatusOK, newListResponse(schemas, len(schemas), defaultStartIndex))
+	case "ResourceTypes":
+		resourceTypes := []interface{}{}
+		for _, resourceType := range s.getResourceTypes() {
+			if resourceType.Id == id {
+				return okResponse(http.StatusOK, resourceType)
+			}
+			resourceTypes = append(resourceTypes, resourceType)
+		}
+		if id != "" {
+			return errorResponse(notFound("the resource type: %s doesn't exist", id))
+		}
+		return okResponse(http.StatusOK, newListResponse(resourceTypes, len(resourceTypes), defaultStartIndex))
+	case "Me":
+		return errorResponse(NewError(http.StatusNotImplemented, "", "the /Me endpoint is not supported"))
+	default:
+		return errorResponse(notFound("the endpoint: %s doesn't exist", r.Path))
+	}
+}
+
+func newListResponse(resources []interface{}, totalResults int, startIndex int) *ListResponse {
+	return &ListResponse{
+		Schemas:      []string{ListResponseSchema},
+		TotalResults: totalResults,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// resourceHandler reads and writes the resources of a type, it is implemented for the Users and the Groups
+type resourceHandler interface {
+	list(filter Filter) ([]*resourceEntry, error)
+	get(id string) (*resourceEntry, error)
+	create(resource map[string]interface{}) (*resourceEntry, error)
+	replace(entry *resourceEntry, resource map[string]interface{}) (*resourceEntry, error)
+	delete(entry *resourceEntry) error
+}
+
+// resourceEntry is a resource with its meta, the meta isn't patched or filtered by its version
+type resourceEntry struct {
+	id       string
+	resource map[string]interface{}
+	meta     *Meta
+}
+
+func (entry *resourceEntry) getVersion() string {
+	return fmt.Sprintf("W/\"%s\"", util.GetMd5Hash(util.StructToJson(entry.resource)))
+}
+
+func (entry *resourceEntry) getResource() map[string]interface{} {
+	resource := copyMap(entry.resource)
+	entry.meta.Version = entry.getVersion()
+	resource["meta"] = entry.meta
+	return resource
+}
+
+// matchesETag returns whether the If-Match or If-None-Match header matches the version, weak tags are compared weakly
+func matchesETag(header string, version string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(version, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) getResourceHandler(endpoint string) resourceHandler {
+	if endpoint == "Users" {
+		return &userHandler{s: s}
+	}
+	return &groupHandler{s: s}
+}
+
+func (s *Server) handleResources(r *Request, endpoint string, id string) *Response {
+	handler := s.getResourceHandler(endpoint)
+
+	if id == "" || id == ".search" {
+		switch {
+		case id == "" && r.Method == http.MethodGet:
+			return s.handleList(handler, getSearchRequest(r.Query))
+		case id == "" && r.Method == http.MethodPost:
+			return s.handleCreate(handler, r)
+		case id == ".search" && r.Method == http.MethodPost:
+			var search SearchRequest
+			err := json.Unmarshal(r.Body, &search)
+			if err != nil {
+				return errorResponse(badRequest(ErrorInvalidSyntax, "%s", err.Error()))
+			}
+			return s.handleList(handler, &search)
+		default:
+			return errorResponse(NewError(http.StatusMethodNotAllowed, "", "the method: %s is not allowed", r.Method))
+		}
+	}
+
+	entry, err := handler.get(id)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	version := entry.getVersion()
+	if r.Method == http.MethodGet {
+		if r.IfNoneMatch != "" && matchesETag(r.IfNoneMatch, version) {
+			return &Response{Status: http.StatusNotModified, ETag: version}
+		}
+
+		search := getSearchRequest(r.Query)
+		resource := projectResource(entry.getResource(), search.Attributes, search.ExcludedAttributes)
+		return &Response{Status: http.StatusOK, Body: resource, ETag: version, Location: entry.meta.Location}
+	}
+
+	if r.IfMatch != "" && !matchesETag(r.IfMatch, version) {
+		return errorResponse(NewError(http.StatusPreconditionFailed, "", "the version of %s doesn't match: %s", id, r.IfMatch))
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		resource, err := decodeResource(r.Body)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return s.handleReplace(handler, entry, resource)
+	case http.MethodPatch:
+		var patch PatchRequest
+		err = json.Unmarshal(r.Body, &patch)
+		if err != nil {
+			return errorResponse(badRequest(ErrorInvalidSyntax, "%s", err.Error()))
+		}
+
+		// the resource is patched as a copy through its JSON, so the original entry isn't changed
+		resource, _ := decodeResource([]byte(util.StructToJson(entry.resource)))
+		err = ApplyPatch(resource, patch.Operations)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return s.handleReplace(handler, entry, resource)
+	case http.MethodDelete:
+		err = handler.delete(entry)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return &Response{Status: http.StatusNoContent}
+	default:
+		return errorResponse(NewError(http.StatusMethodNotAllowed, "", "the method: %s is not allowed", r.Method))
+	}
+}
+
+func decodeResource(body []byte) (map[string]interface{}, error) {
+	resource := map[string]interface{}{}
+	err := json.Unmarshal(body, &resource)
+	if err != nil {
+		return nil, badRequest(ErrorInvalidSyntax, "%s", err.Error())
+	}
+	return resource, nil
+}
+
+func (s *Server) handleCreate(handler resourceHandler, r *Request) *Response {
+	resource, err := decodeResource(r.Body)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	entry, err := handler.create(resource)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return &Response{Status: http.StatusCreated, Body: entry.getResource(), ETag: entry.getVersion(), Location: entry.meta.Location}
+}
+
+func (s *Server) handleReplace(handler resourceHandler, entry *resourceEntry, resource map[string]interface{}) *Response {
+	entry, err := handler.replace(entry, resource)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return &Response{Status: http.StatusOK, Body: entry.getResource(), ETag: entry.getVersion(), Location: entry.meta.Location}
+}
+
+func getSearchRequest(query url.Values) *SearchRequest {
+	search := &SearchRequest{
+		Filter:     query.Get("filter"),
+		StartIndex: util.ParseInt(query.Get("startIndex")),
+	}
+	if count := query.Get("count"); count != "" {
+		n := util.ParseInt(count)
+		search.Count = &n
+	}
+	if attributes := query.Get("attributes"); attributes != "" {
+		search.Attributes = strings.Split(attributes, ",")
+	}
+	if excludedAttributes := query.Get("excludedAttributes"); excludedAttributes != "" {
+		search.ExcludedAttributes = strings.Split(excludedAttributes, ",")
+	}
+	return search
+}
+
+func (s *Server) handleList(handler resourceHandler, search *SearchRequest) *Response {
+	var filter Filter
+	if search.Filter != "" {
+		var err error
+		filter, err = ParseFilter(search.Filter)
+		if err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	entries, err := handler.list(filter)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	startIndex := search.StartIndex
+	if startIndex < 1 {
+		startIndex = defaultStartIndex
+	}
+	count := maxResults
+	if search.Count != nil && *search.Count >= 0 && *search.Count < maxResults {
+		count = *search.Count
+	}
+
+	resources := []interface{}{}
+	for i := startIndex - 1; i < len(entries) && len(resources) < count; i++ {
+		resources = append(resources, projectResource(entries[i].getResource(), search.Attributes, search.ExcludedAttributes))
+	}
+	return okResponse(http.StatusOK, newListResponse(resources, len(entries), startIndex))
+}
+
+// projectResource returns the resource with only the requested attributes, or without the excluded ones. The id
+// and the schemas are always returned.
+func projectResource(resource map[string]interface{}, attributes []string, excludedAttributes []string) map[string]interface{} {
+	if len(attributes) != 0 {
+		result := map[string]interface{}{
+			"schemas": resource["schemas"],
+			"id":      resource["id"],
+		}
+		for _, attribute := range attributes {
+			path, err := parseAttrPath(strings.TrimSpace(attribute))
+			if err == nil {
+				copyAttribute(resource, result, path)
+			}
+		}
+		resource = result
+	}
+
+	if len(excludedAttributes) == 0 {
+		return resource
+	}
+
+	// the maps are copied before their attributes are excluded, they are shared with the resource
+	result := copyMap(resource)
+	for _, attribute := range excludedAttributes {
+		path, err := parseAttrPath(strings.TrimSpace(attribute))
+		if err != nil || len(path.names) == 0 {
+			continue
+		}
+		if path.schema == "" && (strings.EqualFold(path.names[0], "id") || strings.EqualFold(path.names[0], "schemas")) {
+			continue
+		}
+
+		container := result
+		if path.schema != "" {
+			key, ok := getAttributeName(result, path.schema)
+			m, isMap := result[key].(map[string]interface{})
+			if !ok || !isMap {
+				continue
+			}
+			container = copyMap(m)
+			result[key] = container
+		}
+
+		if len(path.names) == 1 {
+			deleteAttribute(container, path.names[0])
+		} else if parent, ok := getAttribute(container, path.names[0]).(map[string]interface{}); ok {
+			child := copyMap(parent)
+			deleteAttribute(child, path.names[1])
+			key, _ := getAttributeName(container, path.names[0])
+			container[key] = child
+		}
+	}
+	return result
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+func copyAttribute(src map[string]interface{}, dst map[string]interface{}, path *attrPath) {
+	if path.schema != "" {
+		key, ok := getAttributeName(src, path.schema)
+		if !ok {
+			return
+		}
+		if len(path.names) == 0 {
+			dst[key] = src[key]
+			return
+		}
+
+		srcContainer, ok := src[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		dstContainer, ok := dst[key].(map[string]interface{})
+		if !ok {
+			dstContainer = map[string]interface{}{}
+			dst[key] = dstContainer
+		}
+		src, dst = srcContainer, dstContainer
+	}
+
+	key, ok := getAttributeName(src, path.names[0])
+	if !ok {
+		return
+	}
+	if len(path.names) == 1 {
+		dst[key] = src[key]
+		return
+	}
+
+	// a sub-attribute is copied from the complex attribute or from each element of the multi-valued attribute
+	switch v := src[key].(type) {
+	case map[string]interface{}:
+		child, ok := dst[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			dst[key] = child
+		}
+		if subKey, ok := getAttributeName(v, path.names[1]); ok {
+			child[subKey] = v[subKey]
+		}
+	case []interface{}:
+		// the elements copied by an earlier sub-attribute, such as "emails.type" before "emails.value", are reused
+		elements, ok := dst[key].([]interface{})
+		if !ok || len(elements) != len(v) {
+			elements = make([]interface{}, len(v))
+			for i := range elements {
+				elements[i] = map[string]interface{}{}
+			}
+		}
+		for i, element := range v {
+			m, ok := element.(map[string]interface{})
+			child, ok2 := elements[i].(map[string]interface{})
+			if !ok || !ok2 {
+				continue
+			}
+			if subKey, ok := getAttributeName(m, path.names[1]); ok {
+				child[subKey] = m[subKey]
+			}
+		}
+		dst[key] = elements
+	}
+}
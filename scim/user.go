@@ -0,0 +1,445 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// the user property storing the id of the user in the SCIM client
+const externalIdProperty = "scimExternalId"
+
+// the attributes of the enterprise extension stored in the user properties, the department is the affiliation
+var enterpriseProperties = []string{"employeeNumber", "costCenter", "organization", "division"}
+
+const managerProperty = "manager"
+
+// the columns written when a user is replaced or patched
+var userColumns = []string{
+	"display_name", "first_name", "last_name", "avatar", "address", "location", "region", "language", "affiliation", "title", "homepage", "type", "is_forbidden", "properties", "hash",
+}
+
+func setString(m map[string]interface{}, key string, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+func getString(m map[string]interface{}, name string) string {
+	if m == nil {
+		return ""
+	}
+
+	value, _ := getAttribute(m, name).(string)
+	return value
+}
+
+func getMap(m map[string]interface{}, name string) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	value, _ := getAttribute(m, name).(map[string]interface{})
+	return value
+}
+
+// getBool returns the boolean attribute, some clients send booleans as strings such as "False"
+func getBool(m map[string]interface{}, name string, defaultValue bool) bool {
+	switch v := getAttribute(m, name).(type) {
+	case bool:
+		return v
+	case string:
+		if strings.EqualFold(v, "true") {
+			return true
+		} else if strings.EqualFold(v, "false") {
+			return false
+		}
+	}
+	return defaultValue
+}
+
+// getPrimaryElement returns the primary element of the multi-valued attribute, or the first one if none is primary
+func getPrimaryElement(m map[string]interface{}, name string) map[string]interface{} {
+	elements, _ := getAttribute(m, name).([]interface{})
+
+	var result map[string]interface{}
+	for _, element := range elements {
+		e, ok := element.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if getBool(e, "primary", false) {
+			return e
+		}
+		if result == nil {
+			result = e
+		}
+	}
+	return result
+}
+
+func newMultiValue(value string, typ string) []interface{} {
+	return []interface{}{
+		map[string]interface{}{"value": value, "type": typ, "primary": true},
+	}
+}
+
+// getUserResource returns the SCIM resource of the user without its meta, the roles are the user's groups
+func (s *Server) getUserResource(user *object.User, roles []*object.Role) map[string]interface{} {
+	resource := map[string]interface{}{
+		"schemas":  []interface{}{UserSchema, EnterpriseUserSchema},
+		"id":       user.Id,
+		"userName": user.Name,
+		"active":   !user.IsForbidden,
+	}
+	setString(resource, "externalId", user.Properties[externalIdProperty])
+	setString(resource, "displayName", user.DisplayName)
+	setString(resource, "title", user.Title)
+	setString(resource, "userType", user.Type)
+	setString(resource, "preferredLanguage", user.Language)
+	setString(resource, "profileUrl", user.Homepage)
+
+	name := map[string]interface{}{}
+	setString(name, "givenName", user.FirstName)
+	setString(name, "familyName", user.LastName)
+	setString(name, "formatted", strings.TrimSpace(user.FirstName+" "+user.LastName))
+	if len(name) != 0 {
+		resource["name"] = name
+	}
+
+	if user.Email != "" {
+		resource["emails"] = newMultiValue(user.Email, "work")
+	}
+	if user.Phone != "" {
+		resource["phoneNumbers"] = newMultiValue(user.Phone, "work")
+	}
+	if user.Avatar != "" {
+		resource["photos"] = newMultiValue(user.Avatar, "photo")
+	}
+
+	address := map[string]interface{}{}
+	setString(address, "formatted", strings.Join(user.Address, "\n"))
+	setString(address, "locality", user.Location)
+	setString(address, "region", user.Region)
+	setString(address, "country", user.CountryCode)
+	if len(address) != 0 {
+		address["type"] = "work"
+		address["primary"] = true
+		resource["addresses"] = []interface{}{address}
+	}
+
+	groups := []interface{}{}
+	for _, role := range roles {
+		groups = append(groups, map[string]interface{}{
+			"value":   role.Name,
+			"display": getRoleDisplayName(role),
+			"$ref":    s.getLocation("Groups", role.Name),
+		})
+	}
+	if len(groups) != 0 {
+		resource["groups"] = groups
+	}
+
+	enterprise := map[string]interface{}{}
+	setString(enterprise, "department", user.Affiliation)
+	for _, property := range enterpriseProperties {
+		setString(enterprise, property, user.Properties[property])
+	}
+	if manager := user.Properties[managerProperty]; manager != "" {
+		enterprise["manager"] = map[string]interface{}{"value": manager}
+	}
+	if len(enterprise) != 0 {
+		resource[EnterpriseUserSchema] = enterprise
+	}
+
+	return resource
+}
+
+// applyUserResource sets the user fields from the resource, the fields missing from the resource are cleared,
+// except the avatar and the user type. The password is returned to be checked and hashed by the caller.
+func applyUserResource(user *object.User, resource map[string]interface{}) (string, error) {
+	userName := getString(resource, "userName")
+	if userName == "" {
+		return "", badRequest(ErrorInvalidValue, "the userName is required")
+	}
+	if strings.Contains(userName, "/") {
+		return "", badRequest(ErrorInvalidValue, "the userName: %s can't contain \"/\"", userName)
+	}
+	user.Name = userName
+
+	name := getMap(resource, "name")
+	user.FirstName = getString(name, "givenName")
+	user.LastName = getString(name, "familyName")
+
+	user.DisplayName = getString(resource, "displayName")
+	if user.DisplayName == "" {
+		user.DisplayName = getString(name, "formatted")
+	}
+	if user.DisplayName == "" {
+		user.DisplayName = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+	if user.DisplayName == "" {
+		user.DisplayName = userName
+	}
+
+	user.Email = getString(getPrimaryElement(resource, "emails"), "value")
+	user.Phone = getString(getPrimaryElement(resource, "phoneNumbers"), "value")
+	if photo := getString(getPrimaryElement(resource, "photos"), "value"); photo != "" {
+		user.Avatar = photo
+	}
+
+	address := getPrimaryElement(resource, "addresses")
+	user.Address = []string{}
+	if formatted := getString(address, "formatted"); formatted != "" {
+		user.Address = strings.Split(formatted, "\n")
+	}
+	user.Location = getString(address, "locality")
+	user.Region = getString(address, "region")
+	user.CountryCode = getString(address, "country")
+
+	user.Title = getString(resource, "title")
+	user.Language = getString(resource, "preferredLanguage")
+	user.Homepage = getString(resource, "profileUrl")
+	if userType := getString(resource, "userType"); userType != "" {
+		user.Type = userType
+	}
+	user.IsForbidden = !getBool(resource, "active", true)
+
+	if user.Properties == nil {
+		user.Properties = map[string]string{}
+	}
+	setProperty(user, externalIdProperty, getString(resource, "externalId"))
+
+	enterprise := getMap(resource, EnterpriseUserSchema)
+	user.Affiliation = getString(enterprise, "department")
+	for _, property := range enterpriseProperties {
+		setProperty(user, property, getString(enterprise, property))
+	}
+	setProperty(user, managerProperty, getString(getMap(enterprise, "manager"), "value"))
+
+	return getString(resource, "password"), nil
+}
+
+func setProperty(user *object.User, key string, value string) {
+	if value == "" {
+		delete(user.Properties, key)
+	} else {
+		user.Properties[key] = value
+	}
+}
+
+type userHandler struct {
+	s *Server
+}
+
+func (h *userHandler) getUsers() []*object.User {
+	users := []*object.User{}
+	for _, user := range object.GetUsers(h.s.Organization) {
+		if !user.IsDeleted {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+func (h *userHandler) getUser(id string) (*object.User, error) {
+	user := object.GetUserByUserId(h.s.Organization, id)
+	if user == nil || user.IsDeleted {
+		return nil, notFound("the user: %s doesn't exist", id)
+	}
+	return user, nil
+}
+
+func (h *userHandler) getUserRoles(user *object.User) []*object.Role {
+	roles := []*object.Role{}
+	for _, role := range object.GetRolesByUser(user.GetId()) {
+		if role.Owner == h.s.Organization {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+func (h *userHandler) newEntry(user *object.User, roles []*object.Role) *resourceEntry {
+	lastModified := user.UpdatedTime
+	if lastModified == "" {
+		lastModified = user.CreatedTime
+	}
+
+	return &resourceEntry{
+		id:       user.Id,
+		resource: h.s.getUserResource(user, roles),
+		meta: &Meta{
+			ResourceType: "User",
+			Created:      user.CreatedTime,
+			LastModified: lastModified,
+			Location:     h.s.getLocation("Users", user.Id),
+		},
+	}
+}
+
+func (h *userHandler) list(filter Filter) ([]*resourceEntry, error) {
+	// the user name is looked up directly, the other filters are matched against all the users
+	var users []*object.User
+	if name, ok := getEqualValue(filter, "userName"); ok {
+		user := object.GetUser(util.GetId(h.s.Organization, name))
+		if user != nil && !user.IsDeleted {
+			users = []*object.User{user}
+		}
+	}
+	if users == nil {
+		users = h.getUsers()
+	}
+
+	userRoles := map[string][]*object.Role{}
+	for _, role := range object.GetRoles(h.s.Organization) {
+		for _, userId := range role.Users {
+			userRoles[userId] = append(userRoles[userId], role)
+		}
+	}
+
+	entries := []*resourceEntry{}
+	for _, user := range users {
+		entry := h.newEntry(user, userRoles[user.GetId()])
+		if filter == nil || filter.Matches(entry.resource) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (h *userHandler) get(id string) (*resourceEntry, error) {
+	user, err := h.getUser(id)
+	if err != nil {
+		return nil, err
+	}
+	return h.newEntry(user, h.getUserRoles(user)), nil
+}
+
+func (h *userHandler) checkPassword(user *object.User, password string) error {
+	if password == "" {
+		return nil
+	}
+
+	msg := object.CheckNewPassword(user, password, "en")
+	if msg != "" {
+		return badRequest(ErrorInvalidValue, "%s", msg)
+	}
+	return nil
+}
+
+func (h *userHandler) create(resource map[string]interface{}) (*resourceEntry, error) {
+	organization := object.GetOrganization(util.GetId("admin", h.s.Organization))
+	if organization == nil {
+		return nil, notFound("the organization: %s doesn't exist", h.s.Organization)
+	}
+
+	initScore, err := organization.GetInitScore()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &object.User{
+		Owner:       h.s.Organization,
+		CreatedTime: util.GetCurrentTime(),
+		Id:          util.GenerateId(),
+		Type:        "normal-user",
+		Avatar:      organization.DefaultAvatar,
+		Address:     []string{},
+		Score:       initScore,
+		Properties:  map[string]string{},
+	}
+	password, err := applyUserResource(user, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if object.GetUser(user.GetId()) != nil {
+		return nil, NewError(http.StatusConflict, ErrorUniqueness, "the userName: %s already exists", user.Name)
+	}
+	err = h.checkPassword(user, password)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = password
+
+	if !object.AddUser(user) {
+		return nil, NewError(http.StatusInternalServerError, "", "failed to add the user: %s", user.Name)
+	}
+	return h.newEntry(user, nil), nil
+}
+
+func (h *userHandler) replace(entry *resourceEntry, resource map[string]interface{}) (*resourceEntry, error) {
+	user, err := h.getUser(entry.id)
+	if err != nil {
+		return nil, err
+	}
+
+	oldId := user.GetId()
+	password, err := applyUserResource(user, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.GetId() != oldId && object.GetUser(user.GetId()) != nil {
+		return nil, NewError(http.StatusConflict, ErrorUniqueness, "the userName: %s already exists", user.Name)
+	}
+	err = h.checkPassword(user, password)
+	if err != nil {
+		return nil, err
+	}
+
+	user.UpdatedTime = util.GetCurrentTime()
+	object.UpdateUser(oldId, user, append(userColumns, "updated_time"), true)
+	if password != "" {
+		user.Password = password
+		object.SetUserField(user, "password", password)
+	}
+
+	return h.get(user.Id)
+}
+
+// delete soft-deletes the user if the organization enables soft deletion
+func (h *userHandler) delete(entry *resourceEntry) error {
+	user, err := h.getUser(entry.id)
+	if err != nil {
+		return err
+	}
+
+	return deleteUser(user)
+}
+
+// deleteUser soft-deletes the user if the organization enables it, or deletes it unless it is under legal hold
+func deleteUser(user *object.User) error {
+	if err := object.CheckUserLegalHold(user, "en"); err != nil {
+		return NewError(http.StatusConflict, "", "%s", err.Error())
+	}
+
+	organization := object.GetOrganizationByUser(user)
+	if organization != nil && organization.EnableSoftDeletion {
+		object.SoftDeleteUser(user)
+		return nil
+	}
+
+	if _, err := object.DeleteUser(user, "en"); err != nil {
+		return NewError(http.StatusConflict, "", "%s", err.Error())
+	}
+	return nil
+}
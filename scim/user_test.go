@@ -0,0 +1,115 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/casdoor/casdoor/object"
+)
+
+func newTestUser(resource map[string]interface{}) (*object.User, error) {
+	user := &object.User{Owner: "org", Type: "normal-user", Avatar: "default.png", Properties: map[string]string{"other": "1"}}
+	_, err := applyUserResource(user, resource)
+	return user, err
+}
+
+func TestUserResource(t *testing.T) {
+	s := NewServer("org", "https://door.example.com/scim/v2/org/")
+	user, err := newTestUser(getTestResource(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Id = "2819c223"
+	if user.Name != "bjensen" || user.DisplayName != "Barbara Jensen" || user.Email != "bjensen@example.com" || user.Avatar != "default.png" {
+		t.Errorf("unexpected user: %s, %s, %s, %s", user.Name, user.DisplayName, user.Email, user.Avatar)
+	}
+	if user.Properties[externalIdProperty] != "Bjensen" || user.Properties["other"] != "1" || user.Affiliation != "Tour Operations" {
+		t.Errorf("unexpected user properties: %v, %s", user.Properties, user.Affiliation)
+	}
+
+	roles := []*object.Role{{Owner: "org", Name: "admins", DisplayName: "Admins"}}
+	resource := s.getUserResource(user, roles)
+	groups := resource["groups"].([]interface{})
+	if ref := groups[0].(map[string]interface{})["$ref"]; ref != "https://door.example.com/scim/v2/org/Groups/admins" {
+		t.Errorf("unexpected group reference: %s", ref)
+	}
+
+	// the resource is read back into the same user, as a PUT of the returned resource does
+	var decoded map[string]interface{}
+	err = json.Unmarshal([]byte(marshal(t, resource)), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user2, err := newTestUser(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user2.Id = user.Id
+	if !reflect.DeepEqual(user, user2) {
+		t.Errorf("the user changes through its resource: %+v, %+v", user, user2)
+	}
+
+	projected := projectResource(resource, []string{"userName", "emails.value", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department"}, nil)
+	want := `{"emails":[{"value":"bjensen@example.com"}],"id":"2819c223","schemas":["urn:ietf:params:scim:schemas:core:2.0:User","urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"],"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User":{"department":"Tour Operations"},"userName":"bjensen"}`
+	if got := marshal(t, projected); got != want {
+		t.Errorf("unexpected projected resource: %s", got)
+	}
+
+	excluded := projectResource(resource, nil, []string{"groups", "name.givenName", "id"})
+	if _, ok := excluded["groups"]; ok || excluded["id"] != "2819c223" || excluded["name"].(map[string]interface{})["givenName"] != nil {
+		t.Errorf("unexpected excluded resource: %s", marshal(t, excluded))
+	}
+	if resource["name"].(map[string]interface{})["givenName"] != "Barbara" {
+		t.Errorf("the resource is changed by its projection")
+	}
+
+	if _, err = newTestUser(map[string]interface{}{"userName": "a/b"}); err == nil {
+		t.Errorf("the invalid user name is accepted")
+	}
+}
+
+func TestBulkIds(t *testing.T) {
+	ids := map[string]string{"a": "1", "ab": "2"}
+	if got := replaceBulkIds(`{"members": [{"value": "bulkId:ab"}, {"value": "bulkId:a"}]}`, ids); got != `{"members": [{"value": "2"}, {"value": "1"}]}` {
+		t.Errorf("unexpected replaced bulk ids: %s", got)
+	}
+
+	if !matchesETag(`W/"1", "2"`, `W/"2"`) || matchesETag(`W/"1"`, `W/"2"`) || !IsDiscoveryPath("/Schemas/urn:x") || IsDiscoveryPath("Users") {
+		t.Errorf("unexpected ETag or discovery path match")
+	}
+}
+
+func marshal(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDeleteUserUnderLegalHold(t *testing.T) {
+	user := &object.User{Owner: "org", Name: "bjensen", IsLegalHold: true}
+	err := deleteUser(user)
+	if err == nil {
+		t.Fatal("the user under legal hold should not be deleted")
+	}
+	if status := err.(*Error).GetStatus(); status != http.StatusConflict {
+		t.Errorf("got status %d, want %d", status, http.StatusConflict)
+	}
+}
@@ -37,3 +37,9 @@ func GetHmacSha256(key string, data string) string {
 
 	return hex.EncodeToString(mac.Sum(nil))
 }
+
+func GetSha256Hash(data string) string {
+	hash := sha256.Sum256([]byte(data))
+
+	return hex.EncodeToString(hash[:])
+}
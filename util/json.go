@@ -14,7 +14,11 @@
 
 package util
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
 
 func StructToJson(v interface{}) string {
 	data, err := json.Marshal(v)
@@ -37,3 +41,80 @@ func StructToJsonFormatted(v interface{}) string {
 func JsonToStruct(data string, v interface{}) error {
 	return json.Unmarshal([]byte(data), v)
 }
+
+// GetJsonPathValue returns the value at the JSONPath in the unmarshalled JSON data, supporting the root "$",
+// the children ".name" or "['name']" and the array elements "[0]", e.g. "$.data.users[0]['first name']"
+func GetJsonPathValue(data interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	for path != "" {
+		var key string
+		index := -1
+		if strings.HasPrefix(path, ".") {
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			key, path = path[:end], path[end:]
+		} else if strings.HasPrefix(path, "['") {
+			end := strings.Index(path, "']")
+			if end == -1 {
+				return nil, false
+			}
+			key, path = path[2:end], path[end+2:]
+		} else if strings.HasPrefix(path, "[") {
+			end := strings.Index(path, "]")
+			if end == -1 {
+				return nil, false
+			}
+			i, err := strconv.Atoi(path[1:end])
+			if err != nil {
+				return nil, false
+			}
+			index, path = i, path[end+1:]
+		} else {
+			// a path without the root, e.g. "profile.email"
+			path = "." + path
+			continue
+		}
+
+		if index == -1 {
+			m, ok := data.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			data, ok = m[key]
+			if !ok {
+				return nil, false
+			}
+		} else {
+			a, ok := data.([]interface{})
+			if !ok || index < 0 || index >= len(a) {
+				return nil, false
+			}
+			data = a[index]
+		}
+	}
+
+	return data, true
+}
+
+// JsonValueToString returns the string of the unmarshalled JSON value, an empty string for null
+func JsonValueToString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJsonPathValue(t *testing.T) {
+	var data interface{}
+	err := json.Unmarshal([]byte(`{"data": {"users": [{"id": 1001, "profile": {"first name": "Alice", "active": true}}]}, "next": null}`), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		description string
+		path        string
+		expected    string
+		ok          bool
+	}{
+		{"Should be return the number", "$.data.users[0].id", "1001", true},
+		{"Should be return the bracket child", "$.data.users[0].profile['first name']", "Alice", true},
+		{"Should be return the bool", "data.users[0].profile.active", "true", true},
+		{"Should be return the null", "$.next", "", true},
+		{"Should be return false for a missing child", "$.data.users[0].profile['missing']", "", false},
+		{"Should be return false for an out of range index", "$.data.users[1]", "", false},
+		{"Should be return false for a child of an array", "$.data.users.id", "", false},
+	}
+	for _, scenery := range scenarios {
+		t.Run(scenery.description, func(t *testing.T) {
+			value, ok := GetJsonPathValue(data, scenery.path)
+			assert.Equal(t, scenery.ok, ok)
+			assert.Equal(t, scenery.expected, JsonValueToString(value))
+		})
+	}
+}
@@ -35,6 +35,7 @@ import {CaptchaModal} from "../common/modal/CaptchaModal";
 import {CaptchaRule} from "../common/modal/CaptchaModal";
 import RedirectForm from "../common/RedirectForm";
 import {MfaAuthVerifyForm, NextMfa, RequiredMfa} from "./MfaAuthVerifyForm";
+import {PasswordModal, RequiredPasswordChange} from "../common/modal/PasswordModal";
 
 class LoginPage extends React.Component {
   constructor(props) {
@@ -387,6 +388,11 @@ class LoginPage extends React.Component {
                   />);
               },
             });
+          } else if (res.status === RequiredPasswordChange) {
+            Setting.showMessage("warning", res.msg);
+            this.setState({
+              passwordChangeUserId: res.data,
+            });
           } else {
             Setting.showMessage("error", `${i18next.t("application:Failed to sign in")}: ${res.msg}`);
           }
@@ -824,7 +830,9 @@ class LoginPage extends React.Component {
       return this.renderOrganizationChoiceBox(orgChoiceMode);
     }
 
-    if (this.state.getVerifyTotp !== undefined) {
+    if (this.state.passwordChangeUserId !== undefined) {
+      return this.renderPasswordChange();
+    } else if (this.state.getVerifyTotp !== undefined) {
       return this.state.getVerifyTotp();
     } else {
       return (
@@ -836,6 +844,20 @@ class LoginPage extends React.Component {
     }
   }
 
+  renderPasswordChange() {
+    // the expired password has to be changed with the old one, and then the user signs in with the new one
+    const [owner, name] = this.state.passwordChangeUserId.split("/");
+    return (
+      <div style={{marginTop: "20px"}}>
+        <PasswordModal user={{owner: owner, name: name, password: "***"}} account={null} onSuccess={() => {
+          this.setState({
+            passwordChangeUserId: undefined,
+          });
+        }} />
+      </div>
+    );
+  }
+
   renderOrganizationChoiceBox(orgChoiceMode) {
     const renderChoiceBox = () => {
       switch (orgChoiceMode) {
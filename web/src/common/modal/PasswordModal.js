@@ -18,6 +18,8 @@ import React from "react";
 import * as UserBackend from "../../backend/UserBackend";
 import * as Setting from "../../Setting";
 
+export const RequiredPasswordChange = "RequiredPasswordChange";
+
 export const PasswordModal = (props) => {
   const [visible, setVisible] = React.useState(false);
   const [confirmLoading, setConfirmLoading] = React.useState(false);
@@ -50,6 +52,7 @@ export const PasswordModal = (props) => {
       if (res.status === "ok") {
         Setting.showMessage("success", i18next.t("user:Password set successfully"));
         setVisible(false);
+        props.onSuccess?.();
       } else {Setting.showMessage("error", i18next.t(`user:${res.msg}`));}
     });
   };
@@ -15,6 +15,8 @@
 package xlsx
 
 import (
+	"io"
+
 	"github.com/casdoor/casdoor/util"
 	"github.com/tealeg/xlsx"
 )
@@ -26,6 +28,20 @@ func ReadXlsxFile(fileId string) [][]string {
 		panic(err)
 	}
 
+	return readFirstSheet(file)
+}
+
+// ReadXlsxBytes reads the first sheet of the xlsx file content, such as a file in a storage provider
+func ReadXlsxBytes(data []byte) ([][]string, error) {
+	file, err := xlsx.OpenBinary(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return readFirstSheet(file), nil
+}
+
+func readFirstSheet(file *xlsx.File) [][]string {
 	res := [][]string{}
 	for _, sheet := range file.Sheets {
 		for _, row := range sheet.Rows {
@@ -41,3 +57,21 @@ func ReadXlsxFile(fileId string) [][]string {
 
 	return res
 }
+
+// WriteXlsx writes the table as the only sheet of a xlsx file
+func WriteXlsx(w io.Writer, sheetName string, table [][]string) error {
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet(sheetName)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range table {
+		row := sheet.AddRow()
+		for _, text := range line {
+			row.AddCell().SetString(text)
+		}
+	}
+
+	return file.Write(w)
+}